@@ -18,6 +18,7 @@ package caldav
 
 import (
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +31,10 @@ import (
 // DateFormat is the caldav date format
 const DateFormat = `20060102T150405`
 
+// CustomFieldPropertyPrefix is the prefix of the X- properties holding custom field values, followed by the
+// id of the custom field.
+const CustomFieldPropertyPrefix = `X-VIKUNJA-CUSTOM-FIELD-`
+
 // Todo holds a single VTODO
 type Todo struct {
 	// Required
@@ -51,8 +56,11 @@ type Todo struct {
 	Duration    time.Duration
 	RepeatAfter int64
 	RepeatMode  models.TaskRepeatMode
+	RepeatRule  string
 	Alarms      []Alarm
 
+	CustomFields map[int64]interface{}
+
 	Created time.Time
 	Updated time.Time // last-mod
 }
@@ -128,6 +136,16 @@ func getRruleFromInterval(interval int64) (freq string, newInterval int64) {
 
 // ParseTodos returns a caldav vcalendar string with todos.
 func ParseTodos(config *Config, todos []*Todo) (caldavtodos string) {
+	return parseCalendar(config, todos, false)
+}
+
+// ParseFeed returns a vcalendar string to subscribe to in calendar apps. Many of them, like Google Calendar and
+// Outlook, ignore todos, so every todo with a date is exported as an event instead.
+func ParseFeed(config *Config, todos []*Todo) (caldavfeed string) {
+	return parseCalendar(config, todos, true)
+}
+
+func parseCalendar(config *Config, todos []*Todo, datedAsEvents bool) (caldavtodos string) {
 	caldavtodos = `BEGIN:VCALENDAR
 VERSION:2.0
 X-PUBLISHED-TTL:PT4H
@@ -139,85 +157,171 @@ PRODID:-//` + config.ProdID + `//EN` + getCaldavColor(config.Color)
 			t.UID = makeCalDavTimeFromTimeStamp(t.Timestamp) + utils.Sha256(t.Summary)
 		}
 
-		caldavtodos += `
+		if datedAsEvents && t.hasDate() {
+			caldavtodos += parseEvent(t)
+			continue
+		}
+
+		caldavtodos += parseTodo(t)
+	}
+
+	caldavtodos += `
+END:VCALENDAR` // Need a line break
+
+	return
+}
+
+func parseTodo(t *Todo) (caldavtodo string) {
+	caldavtodo = `
 BEGIN:VTODO
 UID:` + t.UID + `
 DTSTAMP:` + makeCalDavTimeFromTimeStamp(t.Timestamp) + `
 SUMMARY:` + t.Summary + getCaldavColor(t.Color)
 
-		if t.Start.Unix() > 0 {
-			caldavtodos += `
+	if t.Start.Unix() > 0 {
+		caldavtodo += `
 DTSTART:` + makeCalDavTimeFromTimeStamp(t.Start)
-			if t.Duration != 0 && t.DueDate.Unix() == 0 {
-				caldavtodos += `
+		if t.Duration != 0 && t.DueDate.Unix() == 0 {
+			caldavtodo += `
 DURATION:PT` + formatDuration(t.Duration)
-			}
 		}
-		if t.End.Unix() > 0 {
-			caldavtodos += `
+	}
+	if t.End.Unix() > 0 {
+		caldavtodo += `
 DTEND:` + makeCalDavTimeFromTimeStamp(t.End)
-		}
-		if t.Description != "" {
-			re := regexp.MustCompile(`\r?\n`)
-			formattedDescription := re.ReplaceAllString(t.Description, "\\n")
-			caldavtodos += `
-DESCRIPTION:` + formattedDescription
-		}
-		if t.Completed.Unix() > 0 {
-			caldavtodos += `
+	}
+	caldavtodo += t.description()
+	if t.Completed.Unix() > 0 {
+		caldavtodo += `
 COMPLETED:` + makeCalDavTimeFromTimeStamp(t.Completed) + `
 STATUS:COMPLETED`
-		}
-		if t.Organizer != nil {
-			caldavtodos += `
+	}
+	if t.Organizer != nil {
+		caldavtodo += `
 ORGANIZER;CN=:` + t.Organizer.Username
-		}
+	}
 
-		if t.DueDate.Unix() > 0 {
-			caldavtodos += `
+	if t.DueDate.Unix() > 0 {
+		caldavtodo += `
 DUE:` + makeCalDavTimeFromTimeStamp(t.DueDate)
-		}
+	}
 
-		if t.Created.Unix() > 0 {
-			caldavtodos += `
+	if t.Created.Unix() > 0 {
+		caldavtodo += `
 CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
-		}
+	}
 
-		if t.Priority != 0 {
-			caldavtodos += `
+	if t.Priority != 0 {
+		caldavtodo += `
 PRIORITY:` + strconv.Itoa(mapPriorityToCaldav(t.Priority))
-		}
+	}
 
-		if t.RepeatAfter > 0 || t.RepeatMode == models.TaskRepeatModeMonth {
-			if t.RepeatMode == models.TaskRepeatModeMonth {
-				caldavtodos += `
-RRULE:FREQ=MONTHLY;BYMONTHDAY=` + t.DueDate.Format("02") // Day of the month
-			} else {
-				freq, interval := getRruleFromInterval(t.RepeatAfter)
-				caldavtodos += `
-RRULE:FREQ=` + freq + `;INTERVAL=` + strconv.FormatInt(interval, 10)
-			}
-		}
+	caldavtodo += t.rrule()
 
-		if len(t.Categories) > 0 {
-			caldavtodos += `
+	if len(t.Categories) > 0 {
+		caldavtodo += `
 CATEGORIES:` + strings.Join(t.Categories, ",")
-		}
+	}
 
-		caldavtodos += `
+	caldavtodo += `
 LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
-		caldavtodos += ParseAlarms(t.Alarms, t.Summary)
-		caldavtodos += ParseRelations(t.Relations)
-		caldavtodos += `
+	caldavtodo += ParseAlarms(t.Alarms, t.Summary)
+	caldavtodo += ParseRelations(t.Relations)
+	caldavtodo += ParseCustomFields(t.CustomFields)
+	caldavtodo += `
 END:VTODO`
+
+	return
+}
+
+// parseEvent returns a todo with a date as an event. The event starts at the first of the start, due or end date
+// which is set and ends at the end or due date, if one of them is after the start.
+func parseEvent(t *Todo) (caldavevent string) {
+	start := t.Start
+	if start.Unix() <= 0 {
+		start = t.DueDate
+	}
+	if start.Unix() <= 0 {
+		start = t.End
+	}
+	end := t.End
+	if end.Unix() <= 0 {
+		end = t.DueDate
 	}
 
-	caldavtodos += `
-END:VCALENDAR` // Need a line break
+	caldavevent = `
+BEGIN:VEVENT
+UID:` + t.UID + `
+DTSTAMP:` + makeCalDavTimeFromTimeStamp(t.Timestamp) + `
+SUMMARY:` + t.Summary + getCaldavColor(t.Color)
+
+	caldavevent += `
+DTSTART:` + makeCalDavTimeFromTimeStamp(start)
+	if end.After(start) {
+		caldavevent += `
+DTEND:` + makeCalDavTimeFromTimeStamp(end)
+	}
+	caldavevent += t.description()
+
+	if t.Created.Unix() > 0 {
+		caldavevent += `
+CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
+	}
+
+	if t.Priority != 0 {
+		caldavevent += `
+PRIORITY:` + strconv.Itoa(mapPriorityToCaldav(t.Priority))
+	}
+
+	caldavevent += t.rrule()
+
+	if len(t.Categories) > 0 {
+		caldavevent += `
+CATEGORIES:` + strings.Join(t.Categories, ",")
+	}
+
+	caldavevent += `
+LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
+	caldavevent += ParseAlarms(t.Alarms, t.Summary)
+	caldavevent += `
+END:VEVENT`
 
 	return
 }
 
+func (t *Todo) hasDate() bool {
+	return t.Start.Unix() > 0 || t.End.Unix() > 0 || t.DueDate.Unix() > 0
+}
+
+func (t *Todo) description() string {
+	if t.Description == "" {
+		return ""
+	}
+	re := regexp.MustCompile(`\r?\n`)
+	return `
+DESCRIPTION:` + re.ReplaceAllString(t.Description, "\\n")
+}
+
+func (t *Todo) rrule() string {
+	if t.RepeatRule != "" {
+		return `
+RRULE:` + t.RepeatRule
+	}
+
+	if t.RepeatMode == models.TaskRepeatModeMonth {
+		return `
+RRULE:FREQ=MONTHLY;BYMONTHDAY=` + t.DueDate.Format("02") // Day of the month
+	}
+
+	if t.RepeatAfter > 0 {
+		freq, interval := getRruleFromInterval(t.RepeatAfter)
+		return `
+RRULE:FREQ=` + freq + `;INTERVAL=` + strconv.FormatInt(interval, 10)
+	}
+
+	return ""
+}
+
 func ParseAlarms(alarms []Alarm, taskDescription string) (caldavalarms string) {
 	for _, a := range alarms {
 		if a.Description == "" {
@@ -286,6 +390,57 @@ RELATED-TO:`
 	return caldavrelatedtos
 }
 
+// ParseCustomFields returns the custom field values of a task as X- properties
+func ParseCustomFields(customFields map[int64]interface{}) (caldavfields string) {
+	fieldIDs := make([]int64, 0, len(customFields))
+	for fieldID := range customFields {
+		fieldIDs = append(fieldIDs, fieldID)
+	}
+	sort.Slice(fieldIDs, func(i, j int) bool {
+		return fieldIDs[i] < fieldIDs[j]
+	})
+
+	for _, fieldID := range fieldIDs {
+		property := CustomFieldPropertyPrefix + strconv.FormatInt(fieldID, 10)
+
+		var value string
+		switch v := customFields[fieldID].(type) {
+		case time.Time:
+			property += ";VALUE=DATE-TIME"
+			value = makeCalDavTimeFromTimeStamp(v)
+		case []string:
+			// Each selected option gets its own property, the same way multiple relations are exported
+			for _, option := range v {
+				caldavfields += `
+` + property + `:` + escapeCaldavText(option)
+			}
+			continue
+		case string:
+			value = escapeCaldavText(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		default:
+			continue
+		}
+
+		caldavfields += `
+` + property + `:` + value
+	}
+
+	return
+}
+
+// https://tools.ietf.org/html/rfc5545#section-3.3.11
+func escapeCaldavText(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `;`, `\;`)
+	text = strings.ReplaceAll(text, `,`, `\,`)
+	re := regexp.MustCompile(`\r?\n`)
+	return re.ReplaceAllString(text, `\n`)
+}
+
 func makeCalDavTimeFromTimeStamp(ts time.Time) (caldavtime string) {
 	return ts.In(time.UTC).Format(DateFormat) + "Z"
 }
@@ -209,6 +209,41 @@ DUE:20181201T011204Z
 RRULE:FREQ=SECONDLY;INTERVAL=435
 LAST-MODIFIED:00010101T000000Z
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with repeat rule",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:     "Todo #1",
+						Description: "Lorem Ipsum",
+						UID:         "randommduid",
+						Timestamp:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						DueDate:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						RepeatAfter: 435,
+						RepeatRule:  "FREQ=MONTHLY;BYDAY=-1FR;COUNT=5",
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+DUE:20181201T011204Z
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=5
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
 END:VCALENDAR`,
 		},
 		{
@@ -365,6 +400,47 @@ LAST-MODIFIED:00010101T000000Z
 RELATED-TO;RELTYPE=PARENT:parentuid
 RELATED-TO;RELTYPE=CHILD:subtaskuid
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "Test caldav parsing with custom fields",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary: "Todo #1",
+						UID:     "randommduid",
+						CustomFields: map[int64]interface{}{
+							5: []string{"web", "ios"},
+							1: "ACME, Inc.",
+							2: float64(2.5),
+							3: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+							6: int64(1),
+						},
+						Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+LAST-MODIFIED:00010101T000000Z
+X-VIKUNJA-CUSTOM-FIELD-1:ACME\, Inc.
+X-VIKUNJA-CUSTOM-FIELD-2:2.5
+X-VIKUNJA-CUSTOM-FIELD-3;VALUE=DATE-TIME:20181201T011204Z
+X-VIKUNJA-CUSTOM-FIELD-5:web
+X-VIKUNJA-CUSTOM-FIELD-5:ios
+X-VIKUNJA-CUSTOM-FIELD-6:1
+END:VTODO
 END:VCALENDAR`,
 		},
 	}
@@ -376,3 +452,73 @@ END:VCALENDAR`,
 		})
 	}
 }
+
+func TestParseFeed(t *testing.T) {
+	feedConfig := &Config{
+		Name:   "test",
+		ProdID: "RandomProdID which is not random",
+	}
+	todos := []*Todo{
+		{
+			Summary:   "Without date",
+			UID:       "nodate",
+			Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+		},
+		{
+			Summary:    "With due date",
+			UID:        "due",
+			Timestamp:  time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			DueDate:    time.Unix(1543630000, 0).In(config.GetTimeZone()),
+			RepeatRule: "FREQ=WEEKLY",
+		},
+		{
+			Summary:   "With start and end",
+			UID:       "startend",
+			Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			Start:     time.Unix(1543626000, 0).In(config.GetTimeZone()),
+			End:       time.Unix(1543633200, 0).In(config.GetTimeZone()),
+		},
+		{
+			Summary:   "With end only",
+			UID:       "end",
+			Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			End:       time.Unix(1543633200, 0).In(config.GetTimeZone()),
+		},
+	}
+
+	assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:nodate
+DTSTAMP:20181201T011204Z
+SUMMARY:Without date
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
+BEGIN:VEVENT
+UID:due
+DTSTAMP:20181201T011204Z
+SUMMARY:With due date
+DTSTART:20181201T020640Z
+RRULE:FREQ=WEEKLY
+LAST-MODIFIED:00010101T000000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:startend
+DTSTAMP:20181201T011204Z
+SUMMARY:With start and end
+DTSTART:20181201T010000Z
+DTEND:20181201T030000Z
+LAST-MODIFIED:00010101T000000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:end
+DTSTAMP:20181201T011204Z
+SUMMARY:With end only
+DTSTART:20181201T030000Z
+LAST-MODIFIED:00010101T000000Z
+END:VEVENT
+END:VCALENDAR`, ParseFeed(feedConfig, todos))
+}
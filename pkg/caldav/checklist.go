@@ -0,0 +1,101 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/models"
+)
+
+// checklistItemUIDInfix separates the uid of a task from the id of one of its checklist items
+const checklistItemUIDInfix = `-checklist-`
+
+// GetChecklistItemUID returns the uid under which a checklist item of a task is exposed via caldav.
+func GetChecklistItemUID(task *models.Task, item *models.TaskChecklistItem) string {
+	return task.UID + checklistItemUIDInfix + strconv.FormatInt(item.ID, 10)
+}
+
+// ParseChecklistItemUID returns the id of the checklist item a caldav uid refers to.
+// Whether the item really belongs to the task in the uid must be checked by the caller.
+func ParseChecklistItemUID(uid string) (itemID int64, is bool) {
+	i := strings.LastIndex(uid, checklistItemUIDInfix)
+	if i < 1 {
+		return 0, false
+	}
+
+	itemID, err := strconv.ParseInt(uid[i+len(checklistItemUIDInfix):], 10, 64)
+	if err != nil || itemID <= 0 {
+		return 0, false
+	}
+	return itemID, true
+}
+
+// IsChecklistItemUIDOf checks if a caldav uid refers to a checklist item of the given task.
+func IsChecklistItemUIDOf(task *models.Task, uid string) bool {
+	if task.UID == "" || !strings.HasPrefix(uid, task.UID+checklistItemUIDInfix) {
+		return false
+	}
+	_, is := ParseChecklistItemUID(uid)
+	return is
+}
+
+// GetChecklistItemTask converts a checklist item into a task which is exposed as subtask of its parent task.
+func GetChecklistItemTask(parent *models.Task, item *models.TaskChecklistItem) *models.Task {
+	return &models.Task{
+		ID:        item.ID,
+		UID:       GetChecklistItemUID(parent, item),
+		ProjectID: parent.ProjectID,
+		Title:     item.Title,
+		Done:      item.Done,
+		DoneAt:    item.DoneAt,
+		DueDate:   item.DueDate,
+		Created:   item.Created,
+		Updated:   item.Updated,
+		RelatedTasks: models.RelatedTaskMap{
+			models.RelationKindParenttask: {{UID: parent.UID}},
+		},
+	}
+}
+
+// LinkChecklistItems adds the checklist items of a task to its subtasks.
+func LinkChecklistItems(task *models.Task) {
+	if len(task.ChecklistItems) == 0 {
+		return
+	}
+	if task.RelatedTasks == nil {
+		task.RelatedTasks = models.RelatedTaskMap{}
+	}
+	for _, item := range task.ChecklistItems {
+		task.RelatedTasks[models.RelationKindSubtask] = append(task.RelatedTasks[models.RelationKindSubtask], &models.Task{UID: GetChecklistItemUID(task, item)})
+	}
+}
+
+// AddChecklistItemsAsSubtasks appends the checklist items of all tasks as subtasks and links them from their
+// parent tasks, since caldav clients only know about subtasks.
+func AddChecklistItemsAsSubtasks(tasks []*models.TaskWithComments) []*models.TaskWithComments {
+	result := make([]*models.TaskWithComments, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, t)
+		for _, item := range t.ChecklistItems {
+			result = append(result, &models.TaskWithComments{Task: *GetChecklistItemTask(&t.Task, item)})
+		}
+		LinkChecklistItems(&t.Task)
+	}
+	return result
+}
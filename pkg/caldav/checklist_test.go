@@ -0,0 +1,79 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChecklistItemUID(t *testing.T) {
+	id, is := ParseChecklistItemUID("randomuid-checklist-12")
+	assert.True(t, is)
+	assert.Equal(t, int64(12), id)
+
+	_, is = ParseChecklistItemUID("randomuid")
+	assert.False(t, is)
+	_, is = ParseChecklistItemUID("randomuid-checklist-abc")
+	assert.False(t, is)
+	_, is = ParseChecklistItemUID("-checklist-12")
+	assert.False(t, is)
+
+	parent := &models.Task{UID: "randomuid"}
+	assert.True(t, IsChecklistItemUIDOf(parent, "randomuid-checklist-12"))
+	assert.False(t, IsChecklistItemUIDOf(parent, "otheruid-checklist-12"))
+}
+
+func TestAddChecklistItemsAsSubtasks(t *testing.T) {
+	done := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tasks := []*models.TaskWithComments{
+		{Task: models.Task{
+			ID:        1,
+			UID:       "randomuid",
+			ProjectID: 1,
+			Title:     "Parent",
+			ChecklistItems: []*models.TaskChecklistItem{
+				{ID: 3, TaskID: 1, Title: "First"},
+				{ID: 4, TaskID: 1, Title: "Second", Done: true, DoneAt: done},
+			},
+		}},
+		{Task: models.Task{ID: 2, UID: "otheruid", Title: "Other"}},
+	}
+
+	result := AddChecklistItemsAsSubtasks(tasks)
+	require.Len(t, result, 4)
+	assert.Equal(t, "randomuid-checklist-3", result[1].UID)
+	assert.Equal(t, "First", result[1].Title)
+	assert.Equal(t, int64(1), result[1].ProjectID)
+	assert.Equal(t, "randomuid-checklist-4", result[2].UID)
+	assert.True(t, result[2].Done)
+	assert.Equal(t, "otheruid", result[3].UID)
+
+	require.Len(t, result[0].RelatedTasks[models.RelationKindSubtask], 2)
+	assert.Equal(t, "randomuid", result[1].RelatedTasks[models.RelationKindParenttask][0].UID)
+
+	ical := GetCaldavTodosForTasks(&models.ProjectWithTasksAndBuckets{Project: models.Project{Title: "Test"}}, result)
+	assert.Contains(t, ical, "RELATED-TO;RELTYPE=CHILD:randomuid-checklist-3")
+	assert.Contains(t, ical, "RELATED-TO;RELTYPE=PARENT:randomuid")
+	assert.Contains(t, ical, "UID:randomuid-checklist-4")
+	assert.Contains(t, ical, "STATUS:COMPLETED")
+}
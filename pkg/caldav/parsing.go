@@ -185,9 +185,26 @@ func init() {
 }
 
 func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectTasks []*models.TaskWithComments) string {
+	caldavConfig := &Config{
+		Name:   project.Title,
+		ProdID: "Vikunja Todo App",
+	}
+
+	return ParseTodos(caldavConfig, getTodosForTasks(projectTasks))
+}
+
+// GetCaldavFeedForTasks returns the tasks as a calendar to subscribe to. Tasks with a date are events in it.
+func GetCaldavFeedForTasks(project *models.ProjectWithTasksAndBuckets, projectTasks []*models.TaskWithComments) string {
+	caldavConfig := &Config{
+		Name:   project.Title,
+		ProdID: "Vikunja Todo App",
+	}
+
+	return ParseFeed(caldavConfig, getTodosForTasks(projectTasks))
+}
 
+func getTodosForTasks(projectTasks []*models.TaskWithComments) (caldavtodos []*Todo) {
 	// Make caldav todos from Vikunja todos
-	var caldavtodos []*Todo
 	for _, t := range projectTasks {
 
 		duration := t.EndDate.Sub(t.StartDate)
@@ -231,17 +248,15 @@ func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectT
 			Duration:    duration,
 			RepeatAfter: t.RepeatAfter,
 			RepeatMode:  t.RepeatMode,
+			RepeatRule:  t.RepeatRule,
 			Alarms:      alarms,
 			Relations:   relations,
-		})
-	}
 
-	caldavConfig := &Config{
-		Name:   project.Title,
-		ProdID: "Vikunja Todo App",
+			CustomFields: t.CustomFields,
+		})
 	}
 
-	return ParseTodos(caldavConfig, caldavtodos)
+	return
 }
 
 func getHexColorFromCaldavColor(caldavColor string) string {
@@ -288,12 +303,16 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 	task := make(map[string]ics.IANAProperty)
 
 	var relations []ics.IANAProperty
+	var customFields []ics.IANAProperty
 	var color string
 	for _, c := range vTodo.UnknownPropertiesIANAProperties() {
 		task[c.IANAToken] = c
 		if strings.HasPrefix(c.IANAToken, "RELATED-TO") {
 			relations = append(relations, c)
 		}
+		if strings.HasPrefix(c.IANAToken, CustomFieldPropertyPrefix) {
+			customFields = append(customFields, c)
+		}
 		if c.IANAToken == "X-APPLE-CALENDAR-COLOR" {
 			color = c.Value
 		}
@@ -405,6 +424,44 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 		})
 	}
 
+	for _, c := range customFields {
+		fieldID, err := strconv.ParseInt(strings.TrimPrefix(c.IANAToken, CustomFieldPropertyPrefix), 10, 64)
+		if err != nil {
+			log.Warningf("[CALDAV] Invalid custom field property %s", c.IANAToken)
+			continue
+		}
+
+		if vTask.CustomFields == nil {
+			vTask.CustomFields = make(map[int64]interface{})
+		}
+
+		if valueType, has := c.ICalParameters["VALUE"]; has && len(valueType) == 1 && valueType[0] == "DATE-TIME" {
+			vTask.CustomFields[fieldID] = caldavTimeToTimestamp(c)
+			continue
+		}
+
+		// Multiple properties for the same field are the selected options of a multi select field
+		switch existing := vTask.CustomFields[fieldID].(type) {
+		case string:
+			vTask.CustomFields[fieldID] = []string{existing, c.Value}
+		case []string:
+			vTask.CustomFields[fieldID] = append(existing, c.Value)
+		default:
+			vTask.CustomFields[fieldID] = c.Value
+		}
+	}
+
+	if rrule, ok := task["RRULE"]; ok {
+		rule, err := models.ParseRepeatRule(rrule.Value)
+		if err != nil {
+			log.Warningf("[CALDAV] Could not parse RRULE %q: %s", rrule.Value, err)
+		} else if interval, fixed := rule.FixedInterval(); fixed {
+			vTask.RepeatAfter = interval
+		} else {
+			vTask.RepeatRule = rule.String()
+		}
+	}
+
 	if status, ok := task["STATUS"]; ok && status.Value == "COMPLETED" {
 		vTask.Done = true
 	}
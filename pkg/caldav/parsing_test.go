@@ -486,6 +486,84 @@ END:VCALENDAR`,
 				HexColor: "7b68ee",
 			},
 		},
+		{
+			name: "with custom fields",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+SUMMARY:Todo #1
+X-VIKUNJA-CUSTOM-FIELD-1:ACME\, Inc.
+X-VIKUNJA-CUSTOM-FIELD-3;VALUE=DATE-TIME:20181201T011204
+X-VIKUNJA-CUSTOM-FIELD-5:web
+X-VIKUNJA-CUSTOM-FIELD-5:ios
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title: "Todo #1",
+				CustomFields: map[int64]interface{}{
+					1: "ACME, Inc.",
+					3: time.Date(2018, 12, 1, 1, 12, 4, 0, config.GetTimeZone()).In(config.GetTimeZone()),
+					5: []string{"web", "ios"},
+				},
+			},
+		},
+		{
+			name: "with repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+SUMMARY:Todo #1
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20191231T000000Z;X-NAME=value
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:      "Todo #1",
+				RepeatRule: "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20191231T000000Z;X-NAME=value",
+			},
+		},
+		{
+			name: "with repeat interval",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+SUMMARY:Todo #1
+RRULE:FREQ=WEEKLY;INTERVAL=2
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				RepeatAfter: 1209600,
+			},
+		},
+		{
+			name: "with invalid repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+SUMMARY:Todo #1
+RRULE:FREQ=SOMETIMES
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title: "Todo #1",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,76 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(inboundMailCmd)
+}
+
+var inboundMailCmd = &cobra.Command{
+	Use:   "inbound-mail",
+	Short: "Receive replies to notification mails and post them as task comments.",
+	Long: `Starts an LMTP server your mail server delivers replies to notification mails to. Every reply is posted as a comment on the task the notification was about, as the user who replied.
+The server listens on inboundmail.listen, which should only be reachable by your mail server. The mail server must deliver all mails to inboundmail.address, including the ones with a "+" suffix, via LMTP.`,
+	PreRun: func(_ *cobra.Command, _ []string) {
+		initialize.FullInitWithoutAsync()
+		initialize.InitEvents()
+	},
+	Run: func(_ *cobra.Command, _ []string) {
+		if !models.IsTaskCommentReplyEnabled() {
+			log.Fatal("Replying to notification mails is not enabled. Please enable inboundmail.enabled, configure inboundmail.address and make sure task comments are enabled.")
+		}
+
+		address := config.InboundMailListen.GetString()
+		listener, err := mail.ListenLMTP(address)
+		if err != nil {
+			log.Fatalf("Could not listen on %s: %s", address, err)
+		}
+
+		server := &mail.LMTPServer{Handler: models.HandleTaskCommentReply}
+		go func() {
+			err := server.Serve(listener)
+			if err != nil {
+				log.Fatalf("Could not receive mails: %s", err)
+			}
+		}()
+		log.Infof("Receiving replies to notification mails via LMTP on %s", address)
+
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt)
+		<-quit
+		log.Infof("Shutting down...")
+		err = server.Close()
+		if err != nil {
+			log.Errorf("Could not stop the LMTP server: %s", err)
+		}
+		events.Shutdown()
+	},
+}
@@ -28,6 +28,7 @@ import (
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/initialize"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/plugins"
@@ -162,6 +163,7 @@ var webCmd = &cobra.Command{
 			e.Logger.Fatal(err)
 		}
 		cron.Stop()
+		events.Shutdown()
 		plugins.Shutdown()
 	},
 }
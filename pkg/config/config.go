@@ -60,8 +60,10 @@ const (
 	ServiceEnableTaskComments             Key = `service.enabletaskcomments`
 	ServiceEnableTotp                     Key = `service.enabletotp`
 	ServiceTestingtoken                   Key = `service.testingtoken`
+	ServiceAdminToken                     Key = `service.admintoken`
 	ServiceEnableEmailReminders           Key = `service.enableemailreminders`
 	ServiceEnableUserDeletion             Key = `service.enableuserdeletion`
+	ServiceTrashRetentionDays             Key = `service.trashretentiondays`
 	ServiceMaxAvatarSize                  Key = `service.maxavatarsize`
 	ServiceAllowIconChanges               Key = `service.allowiconchanges`
 	ServiceCustomLogoURL                  Key = `service.customlogourl`
@@ -196,6 +198,8 @@ const (
 
 	KeyvalueType Key = `keyvalue.type`
 
+	EventsBackend Key = `events.backend`
+
 	MetricsEnabled  Key = `metrics.enabled`
 	MetricsUsername Key = `metrics.username`
 	MetricsPassword Key = `metrics.password`
@@ -212,10 +216,24 @@ const (
 	DefaultSettingsTimezone                    Key = `defaultsettings.timezone`
 	DefaultSettingsOverdueTaskRemindersTime    Key = `defaultsettings.overdue_tasks_reminders_time`
 
-	WebhooksEnabled        Key = `webhooks.enabled`
-	WebhooksTimeoutSeconds Key = `webhooks.timeoutseconds`
-	WebhooksProxyURL       Key = `webhooks.proxyurl`
-	WebhooksProxyPassword  Key = `webhooks.proxypassword`
+	WebhooksEnabled               Key = `webhooks.enabled`
+	WebhooksTimeoutSeconds        Key = `webhooks.timeoutseconds`
+	WebhooksProxyURL              Key = `webhooks.proxyurl`
+	WebhooksProxyPassword         Key = `webhooks.proxypassword`
+	WebhooksMaxRetries            Key = `webhooks.maxretries`
+	WebhooksDisableAfterFailures  Key = `webhooks.disableafterfailures`
+	WebhooksDeliveryRetentionDays Key = `webhooks.deliveryretentiondays`
+
+	ChatNotificationsEnabled Key = `chatnotifications.enabled`
+
+	WebPushEnabled         Key = `webpush.enabled`
+	WebPushVAPIDPrivateKey Key = `webpush.vapidprivatekey`
+	WebPushSubject         Key = `webpush.subject`
+	WebPushTTL             Key = `webpush.ttl`
+
+	InboundMailEnabled Key = `inboundmail.enabled`
+	InboundMailAddress Key = `inboundmail.address`
+	InboundMailListen  Key = `inboundmail.listen`
 
 	AutoTLSEnabled     Key = `autotls.enabled`
 	AutoTLSEmail       Key = `autotls.email`
@@ -350,6 +368,7 @@ func InitDefaultConfig() {
 	ServiceEnableTotp.setDefault(true)
 	ServiceEnableEmailReminders.setDefault(true)
 	ServiceEnableUserDeletion.setDefault(true)
+	ServiceTrashRetentionDays.setDefault(30)
 	ServiceMaxAvatarSize.setDefault(1024)
 	ServiceDemoMode.setDefault(false)
 	ServiceAllowIconChanges.setDefault(true)
@@ -462,6 +481,8 @@ func InitDefaultConfig() {
 	BackgroundsUnsplashEnabled.setDefault(false)
 	// Key Value
 	KeyvalueType.setDefault("memory")
+	// Events
+	EventsBackend.setDefault("memory")
 	// Metrics
 	MetricsEnabled.setDefault(false)
 	// Settings
@@ -471,6 +492,20 @@ func InitDefaultConfig() {
 	// Webhook
 	WebhooksEnabled.setDefault(true)
 	WebhooksTimeoutSeconds.setDefault(30)
+	WebhooksMaxRetries.setDefault(5)
+	WebhooksDisableAfterFailures.setDefault(10)
+	WebhooksDeliveryRetentionDays.setDefault(14)
+	// Chat notifications
+	ChatNotificationsEnabled.setDefault(true)
+	// Web Push
+	WebPushEnabled.setDefault(false)
+	WebPushVAPIDPrivateKey.setDefault("")
+	WebPushSubject.setDefault("")
+	WebPushTTL.setDefault(86400)
+	// Inbound mail
+	InboundMailEnabled.setDefault(false)
+	InboundMailAddress.setDefault("")
+	InboundMailListen.setDefault("127.0.0.1:2525")
 	// AutoTLS
 	AutoTLSRenewBefore.setDefault("720h") // 30days in hours
 	// Plugins
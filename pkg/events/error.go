@@ -0,0 +1,70 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.vikunja.io/api/pkg/web"
+)
+
+// ErrPoisonedMessageDoesNotExist represents an error where a message in the poison queue does not exist
+type ErrPoisonedMessageDoesNotExist struct {
+	ID int64
+}
+
+// IsErrPoisonedMessageDoesNotExist checks if an error is ErrPoisonedMessageDoesNotExist.
+func IsErrPoisonedMessageDoesNotExist(err error) bool {
+	_, ok := err.(*ErrPoisonedMessageDoesNotExist)
+	return ok
+}
+
+func (err *ErrPoisonedMessageDoesNotExist) Error() string {
+	return fmt.Sprintf("Poisoned message does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodePoisonedMessageDoesNotExist holds the unique world-error code of this error
+const ErrCodePoisonedMessageDoesNotExist = 17001
+
+// HTTPError holds the http error description
+func (err *ErrPoisonedMessageDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodePoisonedMessageDoesNotExist,
+		Message:  "This message does not exist in the poison queue.",
+	}
+}
+
+// ErrEventsNotRunning represents an error where events cannot be dispatched because the event system was not started
+type ErrEventsNotRunning struct{}
+
+func (err *ErrEventsNotRunning) Error() string {
+	return "The event system is not running"
+}
+
+// ErrCodeEventsNotRunning holds the unique world-error code of this error
+const ErrCodeEventsNotRunning = 17002
+
+// HTTPError holds the http error description
+func (err *ErrEventsNotRunning) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusServiceUnavailable,
+		Code:     ErrCodeEventsNotRunning,
+		Message:  "The event system is not running.",
+	}
+}
@@ -19,11 +19,8 @@ package events
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
-	"github.com/getsentry/sentry-go"
-
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/log"
 	vmetrics "code.vikunja.io/api/pkg/metrics"
@@ -31,29 +28,23 @@ import (
 	"github.com/ThreeDotsLabs/watermill/components/metrics"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
-	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
 )
 
-var pubsub *gochannel.GoChannel
+var (
+	pubsub pubSub
+	router *message.Router
+)
 
 // Event represents the event interface used by all events
 type Event interface {
 	Name() string
 }
 
-type messageHandleFailedError struct {
-	Metadata message.Metadata
-}
-
-func (m *messageHandleFailedError) Error() string {
-	return fmt.Sprintf("Failed to handle message: %v", m.Metadata)
-}
-
 // InitEvents sets up everything needed to work with events
 func InitEvents() (err error) {
 	logger := log.NewWatermillLogger(config.LogEnabled.GetBool(), config.LogEvents.GetString(), config.LogEventsLevel.GetString(), config.LogFormat.GetString())
 
-	router, err := message.NewRouter(
+	router, err = message.NewRouter(
 		message.RouterConfig{},
 		logger,
 	)
@@ -64,31 +55,20 @@ func InitEvents() (err error) {
 	metricsBuilder := metrics.NewPrometheusMetricsBuilder(vmetrics.GetRegistry(), "", "")
 	metricsBuilder.AddPrometheusRouterMetrics(router)
 
-	pubsub = gochannel.NewGoChannel(
-		gochannel.Config{
-			OutputChannelBuffer: 1024,
-		},
-		logger,
-	)
-
-	poison, err := middleware.PoisonQueue(pubsub, "poison")
+	pubsub, err = newPubSub(logger)
 	if err != nil {
 		return err
 	}
-	router.AddConsumerHandler("poison.logger", "poison", pubsub, func(msg *message.Message) error {
-		meta := ""
-		for s, m := range msg.Metadata {
-			meta += s + "=" + m + ", "
-		}
-		log.Errorf("Error while handling message %s, %s payload=%s", msg.UUID, meta, string(msg.Payload))
 
-		if config.SentryEnabled.GetBool() {
-			sentry.CaptureException(&messageHandleFailedError{
-				Metadata: msg.Metadata,
-			})
-		}
-		return nil
-	})
+	poison, err := middleware.PoisonQueue(pubsub, poisonTopic)
+	if err != nil {
+		return err
+	}
+	poisonSubscriber, err := pubsub.subscriber(poisonHandlerName)
+	if err != nil {
+		return err
+	}
+	router.AddConsumerHandler(poisonHandlerName, poisonTopic, poisonSubscriber, handlePoisonedMessage)
 
 	router.AddMiddleware(
 		poison,
@@ -106,13 +86,35 @@ func InitEvents() (err error) {
 
 	for topic, funcs := range listeners {
 		for _, handler := range funcs {
-			router.AddConsumerHandler(topic+"."+handler.Name(), topic, pubsub, handler.Handle)
+			name := handlerName(topic, handler)
+			subscriber, err := pubsub.subscriber(name)
+			if err != nil {
+				return err
+			}
+			router.AddConsumerHandler(name, topic, subscriber, onlyForHandler(name, handler.Handle))
 		}
 	}
 
 	return router.Run(context.Background())
 }
 
+// Shutdown stops handling events and waits until all dispatched events are stored in the configured backend.
+func Shutdown() {
+	if router != nil {
+		err := router.Close()
+		if err != nil {
+			log.Errorf("Could not stop handling events: %s", err)
+		}
+	}
+
+	if pubsub != nil {
+		err := pubsub.Close()
+		if err != nil {
+			log.Errorf("Could not close the event backend: %s", err)
+		}
+	}
+}
+
 // Dispatch dispatches an event
 func Dispatch(event Event) error {
 	if isUnderTest {
@@ -0,0 +1,205 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	poisonTopic       = "poison"
+	poisonHandlerName = "poison.logger"
+
+	// Set on requeued messages so that only the handler which failed before handles them again.
+	requeuedForHandlerKey = "requeued_for_handler"
+)
+
+// PoisonedMessage is an event which could not be handled, even after retrying.
+type PoisonedMessage struct {
+	// The unique, numeric id of this message in the poison queue.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The uuid of the original message.
+	UUID string `xorm:"varchar(36) not null" json:"uuid"`
+	// The topic the message was published to. This is the name of the event.
+	Topic string `xorm:"varchar(250) not null INDEX" json:"topic"`
+	// The name of the handler which failed to handle the message.
+	Handler string `xorm:"varchar(250) not null" json:"handler"`
+	// The error returned by the handler.
+	Reason string `xorm:"text null" json:"reason"`
+	// The event payload as json.
+	Payload string `xorm:"longtext not null" json:"payload"`
+	// All metadata of the message.
+	Metadata map[string]string `xorm:"json null" json:"metadata"`
+	// A timestamp when the message was put in the poison queue.
+	Created time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName returns the table name for poisoned messages
+func (*PoisonedMessage) TableName() string {
+	return "event_poison_queue"
+}
+
+type messageHandleFailedError struct {
+	Metadata message.Metadata
+}
+
+func (m *messageHandleFailedError) Error() string {
+	return fmt.Sprintf("Failed to handle message: %v", m.Metadata)
+}
+
+// handlePoisonedMessage logs a message which could not be handled and keeps it in the poison queue for inspection.
+func handlePoisonedMessage(msg *message.Message) error {
+	meta := ""
+	for s, m := range msg.Metadata {
+		meta += s + "=" + m + ", "
+	}
+	log.Errorf("Error while handling message %s, %s payload=%s", msg.UUID, meta, string(msg.Payload))
+
+	if config.SentryEnabled.GetBool() {
+		sentry.CaptureException(&messageHandleFailedError{
+			Metadata: msg.Metadata,
+		})
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err := s.Insert(&PoisonedMessage{
+		UUID:     msg.UUID,
+		Topic:    msg.Metadata.Get(middleware.PoisonedTopicKey),
+		Handler:  msg.Metadata.Get(middleware.PoisonedHandlerKey),
+		Reason:   msg.Metadata.Get(middleware.ReasonForPoisonedKey),
+		Payload:  string(msg.Payload),
+		Metadata: msg.Metadata,
+	})
+	if err != nil {
+		// Returning the error here would only put the message in the poison queue again.
+		log.Errorf("Could not save message %s in the poison queue: %s", msg.UUID, err)
+	}
+
+	return nil
+}
+
+// onlyForHandler skips messages which were requeued for another handler of the same topic.
+func onlyForHandler(name string, handle message.NoPublishHandlerFunc) message.NoPublishHandlerFunc {
+	return func(msg *message.Message) error {
+		target := msg.Metadata.Get(requeuedForHandlerKey)
+		if target != "" && target != name {
+			return nil
+		}
+		return handle(msg)
+	}
+}
+
+// GetPoisonedMessages returns the messages in the poison queue, newest first.
+func GetPoisonedMessages(page, perPage int) (messages []*PoisonedMessage, total int64, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	if perPage < 1 {
+		perPage = config.ServiceMaxItemsPerPage.GetInt()
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	messages = []*PoisonedMessage{}
+	err = s.
+		OrderBy("id desc").
+		Limit(perPage, (page-1)*perPage).
+		Find(&messages)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err = s.Count(&PoisonedMessage{})
+	return
+}
+
+// GetPoisonedMessage returns one message from the poison queue.
+func GetPoisonedMessage(id int64) (*PoisonedMessage, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	msg := &PoisonedMessage{}
+	exists, err := s.Where("id = ?", id).Get(msg)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrPoisonedMessageDoesNotExist{ID: id}
+	}
+	return msg, nil
+}
+
+// RequeuePoisonedMessage publishes a message from the poison queue again. Only the handler which failed
+// to handle it will handle it again. The message is removed from the poison queue.
+func RequeuePoisonedMessage(id int64) error {
+	if pubsub == nil {
+		return &ErrEventsNotRunning{}
+	}
+
+	poisoned, err := GetPoisonedMessage(id)
+	if err != nil {
+		return err
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(poisoned.Payload))
+	for k, v := range poisoned.Metadata {
+		switch k {
+		case middleware.ReasonForPoisonedKey,
+			middleware.PoisonedTopicKey,
+			middleware.PoisonedHandlerKey,
+			middleware.PoisonedSubscriberKey:
+			continue
+		}
+		msg.Metadata.Set(k, v)
+	}
+	msg.Metadata.Set(requeuedForHandlerKey, poisoned.Handler)
+
+	err = pubsub.Publish(poisoned.Topic, msg)
+	if err != nil {
+		return err
+	}
+
+	return DeletePoisonedMessage(id)
+}
+
+// DeletePoisonedMessage removes a message from the poison queue without handling it.
+func DeletePoisonedMessage(id int64) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	deleted, err := s.Where("id = ?", id).Delete(&PoisonedMessage{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return &ErrPoisonedMessageDoesNotExist{ID: id}
+	}
+	return nil
+}
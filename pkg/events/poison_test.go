@@ -0,0 +1,136 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func poisonMessage(t *testing.T, uuid, topic, handler string) *PoisonedMessage {
+	msg := message.NewMessage(uuid, []byte(`{"id":42}`))
+	msg.Metadata.Set("foo", "bar")
+	msg.Metadata.Set(middleware.PoisonedTopicKey, topic)
+	msg.Metadata.Set(middleware.PoisonedHandlerKey, handler)
+	msg.Metadata.Set(middleware.ReasonForPoisonedKey, "something went wrong")
+	require.NoError(t, handlePoisonedMessage(msg))
+
+	s := db.NewSession()
+	defer s.Close()
+	poisoned := &PoisonedMessage{}
+	has, err := s.Where("uuid = ?", uuid).Get(poisoned)
+	require.NoError(t, err)
+	require.True(t, has)
+	return poisoned
+}
+
+func TestPoisonQueue(t *testing.T) {
+	t.Run("store", func(t *testing.T) {
+		poisoned := poisonMessage(t, "uuid-store", "task.created", "task.created.listener")
+		assert.Equal(t, "task.created", poisoned.Topic)
+		assert.Equal(t, "task.created.listener", poisoned.Handler)
+		assert.Equal(t, "something went wrong", poisoned.Reason)
+		assert.Equal(t, `{"id":42}`, poisoned.Payload)
+		assert.Equal(t, "bar", poisoned.Metadata["foo"])
+	})
+	t.Run("get", func(t *testing.T) {
+		poisoned := poisonMessage(t, "uuid-get", "task.created", "task.created.listener")
+		msg, err := GetPoisonedMessage(poisoned.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "uuid-get", msg.UUID)
+
+		_, err = GetPoisonedMessage(9999)
+		require.Error(t, err)
+		assert.True(t, IsErrPoisonedMessageDoesNotExist(err))
+
+		messages, total, err := GetPoisonedMessages(1, 50)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, total, int64(2))
+		assert.Equal(t, poisoned.ID, messages[0].ID)
+	})
+	t.Run("requeue", func(t *testing.T) {
+		poisoned := poisonMessage(t, "uuid-requeue", "poison.requeue", "poison.requeue.listener")
+
+		pubsub = newSQLPubSub(func(topic string) []string {
+			return []string{topic + ".listener", topic + ".other"}
+		}, watermill.NopLogger{})
+		defer func() {
+			pubsub = nil
+		}()
+
+		require.NoError(t, RequeuePoisonedMessage(poisoned.ID))
+		require.NoError(t, pubsub.Close())
+
+		db.AssertMissing(t, "event_poison_queue", map[string]interface{}{
+			"id": poisoned.ID,
+		})
+
+		row, err := claimQueuedMessage("poison.requeue.listener", "poison.requeue")
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		assert.Equal(t, `{"id":42}`, row.Payload)
+		assert.Equal(t, "bar", row.Metadata["foo"])
+		assert.Equal(t, "poison.requeue.listener", row.Metadata[requeuedForHandlerKey])
+		assert.NotContains(t, row.Metadata, middleware.ReasonForPoisonedKey)
+	})
+	t.Run("requeue without running events", func(t *testing.T) {
+		poisoned := poisonMessage(t, "uuid-requeue-stopped", "task.created", "task.created.listener")
+		err := RequeuePoisonedMessage(poisoned.ID)
+		require.Error(t, err)
+		db.AssertExists(t, "event_poison_queue", map[string]interface{}{
+			"id": poisoned.ID,
+		}, false)
+	})
+	t.Run("delete", func(t *testing.T) {
+		poisoned := poisonMessage(t, "uuid-delete", "task.created", "task.created.listener")
+		require.NoError(t, DeletePoisonedMessage(poisoned.ID))
+		db.AssertMissing(t, "event_poison_queue", map[string]interface{}{
+			"id": poisoned.ID,
+		})
+
+		err := DeletePoisonedMessage(poisoned.ID)
+		require.Error(t, err)
+		assert.True(t, IsErrPoisonedMessageDoesNotExist(err))
+	})
+}
+
+func TestOnlyForHandler(t *testing.T) {
+	var handled int
+	handle := onlyForHandler("task.created.first", func(_ *message.Message) error {
+		handled++
+		return nil
+	})
+
+	require.NoError(t, handle(message.NewMessage("1", nil)))
+
+	requeued := message.NewMessage("2", nil)
+	requeued.Metadata.Set(requeuedForHandlerKey, "task.created.first")
+	require.NoError(t, handle(requeued))
+
+	other := message.NewMessage("3", nil)
+	other.Metadata.Set(requeuedForHandlerKey, "task.created.second")
+	require.NoError(t, handle(other))
+
+	assert.Equal(t, 2, handled)
+}
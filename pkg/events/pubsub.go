@@ -0,0 +1,90 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"errors"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/red"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+)
+
+var errPubSubClosed = errors.New("the event backend is closed")
+
+// pubSub is a backend which stores events until they are handled.
+type pubSub interface {
+	message.Publisher
+
+	// subscriber returns a subscriber for one consumer group. Every consumer group receives all messages
+	// published to the topics it subscribes to, but each message is only handled by one subscriber of a group,
+	// even when multiple Vikunja instances share the same backend.
+	subscriber(consumerGroup string) (message.Subscriber, error)
+}
+
+func newPubSub(logger watermill.LoggerAdapter) (pubSub, error) {
+	switch config.EventsBackend.GetString() {
+	case "database":
+		return newSQLPubSub(consumerGroupsForTopic, logger), nil
+	case "redis":
+		red.InitRedis()
+		if red.GetRedis() == nil {
+			return nil, errors.New("the redis event backend needs redis to be enabled and configured")
+		}
+		return newRedisPubSub(red.GetRedis(), logger), nil
+	case "memory":
+		fallthrough
+	default:
+		return &memoryPubSub{
+			GoChannel: gochannel.NewGoChannel(
+				gochannel.Config{
+					OutputChannelBuffer: 1024,
+				},
+				logger,
+			),
+		}, nil
+	}
+}
+
+// memoryPubSub keeps all events in memory. Queued events are lost on restart.
+type memoryPubSub struct {
+	*gochannel.GoChannel
+}
+
+func (m *memoryPubSub) subscriber(_ string) (message.Subscriber, error) {
+	return m.GoChannel, nil
+}
+
+// consumerGroupsForTopic returns the names of all handlers which subscribe to a topic.
+func consumerGroupsForTopic(topic string) []string {
+	if topic == poisonTopic {
+		return []string{poisonHandlerName}
+	}
+
+	groups := make([]string, 0, len(listeners[topic]))
+	for _, listener := range listeners[topic] {
+		groups = append(groups, handlerName(topic, listener))
+	}
+	return groups
+}
+
+func handlerName(topic string, listener Listener) string {
+	return topic + "." + listener.Name()
+}
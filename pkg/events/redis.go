@@ -0,0 +1,272 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisStreamPrefix = "vikunja:events:"
+	// Roughly how many messages are kept per stream. Redis trims the oldest messages regardless of whether all
+	// consumer groups read and acknowledged them, so this must be large enough to cover any backlog.
+	redisStreamMaxLen = 10000
+	// How long a consumer may take to handle a message before another consumer may claim it.
+	redisMessageLease = 5 * time.Minute
+	// How long a consumer blocks while waiting for new messages.
+	redisBlockTimeout = time.Second
+)
+
+// redisPubSub stores events in redis streams, one stream per topic. Every handler is its own consumer group.
+type redisPubSub struct {
+	client       *redis.Client
+	logger       watermill.LoggerAdapter
+	consumerName string
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newRedisPubSub(client *redis.Client, logger watermill.LoggerAdapter) *redisPubSub {
+	hostname, _ := os.Hostname()
+	return &redisPubSub{
+		client:       client,
+		logger:       logger,
+		consumerName: hostname + "-" + watermill.NewShortUUID(),
+	}
+}
+
+func (p *redisPubSub) Publish(topic string, messages ...*message.Message) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return errPubSubClosed
+	}
+
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return err
+		}
+
+		err = p.client.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: redisStreamPrefix + topic,
+			MaxLen: redisStreamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{
+				"uuid":     msg.UUID,
+				"payload":  string(msg.Payload),
+				"metadata": string(metadata),
+			},
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *redisPubSub) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *redisPubSub) subscriber(consumerGroup string) (message.Subscriber, error) {
+	return &redisSubscriber{
+		client:        p.client,
+		logger:        p.logger,
+		consumerGroup: consumerGroup,
+		consumerName:  p.consumerName,
+		closing:       make(chan struct{}),
+	}, nil
+}
+
+type redisSubscriber struct {
+	client        *redis.Client
+	logger        watermill.LoggerAdapter
+	consumerGroup string
+	consumerName  string
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Subscribe delivers the messages of a topic for the subscriber's consumer group, one at a time.
+// Messages are acknowledged in redis once the handler acknowledged them. Messages which were delivered to a
+// consumer that died before acknowledging them are claimed again after their lease expired.
+func (s *redisSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	stream := redisStreamPrefix + topic
+
+	// The group starts at the beginning of the stream so it also gets messages which were published before
+	// any of its consumers subscribed for the first time.
+	err := s.client.XGroupCreateMkStream(ctx, stream, s.consumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+
+	out := make(chan *message.Message)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(out)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.closing:
+				return
+			default:
+			}
+
+			entry, err := s.next(ctx, stream)
+			if err != nil {
+				s.logger.Error("Could not read from event stream", err, watermill.LogFields{"stream": stream, "consumer_group": s.consumerGroup})
+				s.wait(ctx, redisBlockTimeout)
+				continue
+			}
+			if entry == nil {
+				continue
+			}
+
+			if !s.deliver(ctx, stream, entry, out) {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// next returns a message which was abandoned by another consumer or, if there is none, the next new message.
+func (s *redisSubscriber) next(ctx context.Context, stream string) (*redis.XMessage, error) {
+	claimed, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    s.consumerGroup,
+		Consumer: s.consumerName,
+		MinIdle:  redisMessageLease,
+		Start:    "0-0",
+		Count:    1,
+	}).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if len(claimed) > 0 {
+		return &claimed[0], nil
+	}
+
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    s.consumerGroup,
+		Consumer: s.consumerName,
+		Streams:  []string{stream, ">"},
+		Count:    1,
+		Block:    redisBlockTimeout,
+	}).Result()
+	if errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(streams) == 0 || len(streams[0].Messages) == 0 {
+		return nil, nil
+	}
+
+	return &streams[0].Messages[0], nil
+}
+
+// deliver sends a message to the handler until it was acknowledged. It returns false if the subscriber was closed.
+func (s *redisSubscriber) deliver(ctx context.Context, stream string, entry *redis.XMessage, out chan<- *message.Message) bool {
+	uuid, _ := entry.Values["uuid"].(string)
+	payload, _ := entry.Values["payload"].(string)
+	metadata := message.Metadata{}
+	if raw, is := entry.Values["metadata"].(string); is && raw != "" {
+		err := json.Unmarshal([]byte(raw), &metadata)
+		if err != nil {
+			s.logger.Error("Could not parse event metadata", err, watermill.LogFields{"uuid": uuid})
+		}
+	}
+
+	for {
+		msg := message.NewMessage(uuid, []byte(payload))
+		for k, v := range metadata {
+			msg.Metadata.Set(k, v)
+		}
+		msgCtx, cancel := context.WithCancel(ctx)
+		msg.SetContext(msgCtx)
+
+		select {
+		case out <- msg:
+		case <-ctx.Done():
+			cancel()
+			return false
+		case <-s.closing:
+			cancel()
+			return false
+		}
+
+		select {
+		case <-msg.Acked():
+			cancel()
+			err := s.client.XAck(context.Background(), stream, s.consumerGroup, entry.ID).Err()
+			if err != nil {
+				s.logger.Error("Could not acknowledge event", err, watermill.LogFields{"uuid": uuid})
+			}
+			return true
+		case <-msg.Nacked():
+			cancel()
+			continue
+		case <-ctx.Done():
+			cancel()
+			return false
+		case <-s.closing:
+			cancel()
+			return false
+		}
+	}
+}
+
+func (s *redisSubscriber) wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	case <-s.closing:
+	}
+}
+
+func (s *redisSubscriber) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+	})
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,163 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/red"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisPubSub(t *testing.T) {
+	logger := watermill.NopLogger{}
+
+	t.Run("publish after close", func(t *testing.T) {
+		p := newRedisPubSub(redis.NewClient(&redis.Options{}), logger)
+		require.NoError(t, p.Close())
+		err := p.Publish("redis.closed", message.NewMessage("uuid-closed", []byte(`{}`)))
+		assert.ErrorIs(t, err, errPubSubClosed)
+	})
+
+	if !config.RedisEnabled.GetBool() {
+		t.Skip("Skipping redis event backend tests because redis is not configured")
+	}
+	red.InitRedis()
+	client := red.GetRedis()
+
+	// Every test uses its own topic so that runs don't see each other's leftover streams.
+	newTopic := func(t *testing.T, name string) string {
+		topic := "test." + name + "." + watermill.NewShortUUID()
+		t.Cleanup(func() {
+			client.Del(context.Background(), redisStreamPrefix+topic)
+		})
+		return topic
+	}
+
+	t.Run("messages published before subscribing are delivered", func(t *testing.T) {
+		topic := newTopic(t, "before")
+		p := newRedisPubSub(client, logger)
+		defer p.Close()
+
+		msg := message.NewMessage("uuid-before", []byte(`{"id":1}`))
+		msg.Metadata.Set("foo", "bar")
+		require.NoError(t, p.Publish(topic, msg))
+
+		sub, err := p.subscriber(topic + ".first")
+		require.NoError(t, err)
+		defer sub.Close()
+		messages, err := sub.Subscribe(context.Background(), topic)
+		require.NoError(t, err)
+
+		received := receive(t, messages)
+		assert.Equal(t, "uuid-before", received.UUID)
+		assert.JSONEq(t, `{"id":1}`, string(received.Payload))
+		assert.Equal(t, "bar", received.Metadata.Get("foo"))
+		received.Ack()
+	})
+	t.Run("every consumer group gets every message", func(t *testing.T) {
+		topic := newTopic(t, "groups")
+		p := newRedisPubSub(client, logger)
+		defer p.Close()
+
+		first, err := p.subscriber(topic + ".first")
+		require.NoError(t, err)
+		defer first.Close()
+		second, err := p.subscriber(topic + ".second")
+		require.NoError(t, err)
+		defer second.Close()
+
+		firstMessages, err := first.Subscribe(context.Background(), topic)
+		require.NoError(t, err)
+		secondMessages, err := second.Subscribe(context.Background(), topic)
+		require.NoError(t, err)
+
+		require.NoError(t, p.Publish(topic, message.NewMessage("uuid-groups", []byte(`{}`))))
+
+		received := receive(t, firstMessages)
+		assert.Equal(t, "uuid-groups", received.UUID)
+		received.Ack()
+		received = receive(t, secondMessages)
+		assert.Equal(t, "uuid-groups", received.UUID)
+		received.Ack()
+	})
+	t.Run("ack removes the message from the pending list", func(t *testing.T) {
+		topic := newTopic(t, "ack")
+		p := newRedisPubSub(client, logger)
+		defer p.Close()
+		require.NoError(t, p.Publish(topic, message.NewMessage("uuid-ack", []byte(`{}`))))
+
+		sub, err := p.subscriber(topic + ".first")
+		require.NoError(t, err)
+		messages, err := sub.Subscribe(context.Background(), topic)
+		require.NoError(t, err)
+
+		receive(t, messages).Ack()
+		require.NoError(t, sub.Close())
+
+		pending, err := client.XPending(context.Background(), redisStreamPrefix+topic, topic+".first").Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), pending.Count)
+	})
+	t.Run("nack redelivers the message", func(t *testing.T) {
+		topic := newTopic(t, "nack")
+		p := newRedisPubSub(client, logger)
+		defer p.Close()
+		require.NoError(t, p.Publish(topic, message.NewMessage("uuid-nack", []byte(`{}`))))
+
+		sub, err := p.subscriber(topic + ".first")
+		require.NoError(t, err)
+		defer sub.Close()
+		messages, err := sub.Subscribe(context.Background(), topic)
+		require.NoError(t, err)
+
+		receive(t, messages).Nack()
+		received := receive(t, messages)
+		assert.Equal(t, "uuid-nack", received.UUID)
+		received.Ack()
+	})
+	t.Run("messages held by another consumer are not handed out before their lease expired", func(t *testing.T) {
+		topic := newTopic(t, "claim")
+		p := newRedisPubSub(client, logger)
+		defer p.Close()
+		require.NoError(t, p.Publish(topic, message.NewMessage("uuid-claim", []byte(`{}`))))
+
+		stream := redisStreamPrefix + topic
+		group := topic + ".first"
+		require.NoError(t, client.XGroupCreateMkStream(context.Background(), stream, group, "0").Err())
+		// A consumer which reads the message and dies before acknowledging it
+		_, err := client.XReadGroup(context.Background(), &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: "dead",
+			Streams:  []string{stream, ">"},
+			Count:    1,
+		}).Result()
+		require.NoError(t, err)
+
+		s := &redisSubscriber{client: client, logger: logger, consumerGroup: group, consumerName: "alive"}
+		entry, err := s.next(context.Background(), stream)
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+}
@@ -0,0 +1,287 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+const (
+	// How long a consumer may take to handle a message before it is handed to another consumer.
+	sqlMessageLease = 5 * time.Minute
+	// How often consumers look for new messages when the queue is empty.
+	sqlPollInterval = time.Second
+)
+
+// QueuedMessage is an event waiting to be handled by one consumer group when using the database backend.
+type QueuedMessage struct {
+	ID            int64             `xorm:"bigint autoincr not null unique pk"`
+	UUID          string            `xorm:"varchar(36) not null"`
+	Topic         string            `xorm:"varchar(250) not null"`
+	ConsumerGroup string            `xorm:"varchar(250) not null INDEX"`
+	Payload       string            `xorm:"longtext not null"`
+	Metadata      map[string]string `xorm:"json null"`
+	Attempts      int64             `xorm:"bigint not null default 0"`
+	// Unix timestamp until which a consumer holds the message. Zero or a past timestamp means the message is available.
+	LockedUntil int64     `xorm:"bigint not null default 0 INDEX"`
+	LockToken   string    `xorm:"varchar(36) null"`
+	Created     time.Time `xorm:"created not null"`
+}
+
+// TableName returns the table name for queued messages
+func (*QueuedMessage) TableName() string {
+	return "event_queue"
+}
+
+// sqlPubSub stores events in the database. Every message is stored once per consumer group so that
+// each group can acknowledge it independently.
+type sqlPubSub struct {
+	consumerGroups func(topic string) []string
+	logger         watermill.LoggerAdapter
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newSQLPubSub(consumerGroups func(topic string) []string, logger watermill.LoggerAdapter) *sqlPubSub {
+	return &sqlPubSub{
+		consumerGroups: consumerGroups,
+		logger:         logger,
+	}
+}
+
+// Publish stores messages for all consumer groups of a topic. It only returns once the messages are persisted,
+// so an event is never lost after it was published successfully.
+func (p *sqlPubSub) Publish(topic string, messages ...*message.Message) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return errPubSubClosed
+	}
+
+	groups := p.consumerGroups(topic)
+	if len(groups) == 0 {
+		return nil
+	}
+
+	rows := make([]*QueuedMessage, 0, len(messages)*len(groups))
+	for _, msg := range messages {
+		for _, group := range groups {
+			rows = append(rows, &QueuedMessage{
+				UUID:          msg.UUID,
+				Topic:         topic,
+				ConsumerGroup: group,
+				Payload:       string(msg.Payload),
+				Metadata:      msg.Metadata,
+			})
+		}
+	}
+
+	return insertQueuedMessages(rows)
+}
+
+func insertQueuedMessages(rows []*QueuedMessage) (err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err = s.Insert(&rows)
+	return
+}
+
+// Close waits for running publishes and rejects all later ones.
+func (p *sqlPubSub) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *sqlPubSub) subscriber(consumerGroup string) (message.Subscriber, error) {
+	return &sqlSubscriber{
+		consumerGroup: consumerGroup,
+		logger:        p.logger,
+		closing:       make(chan struct{}),
+	}, nil
+}
+
+type sqlSubscriber struct {
+	consumerGroup string
+	logger        watermill.LoggerAdapter
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Subscribe delivers the messages of a topic for the subscriber's consumer group, one at a time.
+// A message is removed from the queue once it was acknowledged. If the process dies before that,
+// the message becomes available again after its lease expired.
+func (s *sqlSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	out := make(chan *message.Message)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(out)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.closing:
+				return
+			default:
+			}
+
+			row, err := claimQueuedMessage(s.consumerGroup, topic)
+			if err != nil {
+				s.logger.Error("Could not claim queued event", err, watermill.LogFields{"consumer_group": s.consumerGroup})
+			}
+			if row == nil {
+				s.wait(ctx, sqlPollInterval)
+				continue
+			}
+
+			if !s.deliver(ctx, row, out) {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// deliver sends a message to the handler until it was acknowledged. It returns false if the subscriber was closed.
+func (s *sqlSubscriber) deliver(ctx context.Context, row *QueuedMessage, out chan<- *message.Message) bool {
+	for {
+		msg := message.NewMessage(row.UUID, []byte(row.Payload))
+		for k, v := range row.Metadata {
+			msg.Metadata.Set(k, v)
+		}
+		msgCtx, cancel := context.WithCancel(ctx)
+		msg.SetContext(msgCtx)
+
+		select {
+		case out <- msg:
+		case <-ctx.Done():
+			cancel()
+			releaseQueuedMessage(row)
+			return false
+		case <-s.closing:
+			cancel()
+			releaseQueuedMessage(row)
+			return false
+		}
+
+		select {
+		case <-msg.Acked():
+			cancel()
+			err := deleteQueuedMessage(row)
+			if err != nil {
+				s.logger.Error("Could not remove acknowledged event from queue", err, watermill.LogFields{"uuid": row.UUID})
+			}
+			return true
+		case <-msg.Nacked():
+			cancel()
+			continue
+		case <-ctx.Done():
+			cancel()
+			releaseQueuedMessage(row)
+			return false
+		case <-s.closing:
+			cancel()
+			releaseQueuedMessage(row)
+			return false
+		}
+	}
+}
+
+func (s *sqlSubscriber) wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	case <-s.closing:
+	}
+}
+
+func (s *sqlSubscriber) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+	})
+	s.wg.Wait()
+	return nil
+}
+
+// claimQueuedMessage locks the oldest available message of a consumer group. It returns nil if there is none
+// or another consumer claimed it first.
+func claimQueuedMessage(consumerGroup, topic string) (*QueuedMessage, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	now := time.Now().Unix()
+	row := &QueuedMessage{}
+	has, err := s.
+		Where("consumer_group = ? AND topic = ? AND locked_until < ?", consumerGroup, topic, now).
+		OrderBy("id asc").
+		Get(row)
+	if err != nil || !has {
+		return nil, err
+	}
+
+	row.LockToken = watermill.NewUUID()
+	row.LockedUntil = time.Now().Add(sqlMessageLease).Unix()
+	row.Attempts++
+	claimed, err := s.
+		Where("id = ? AND locked_until < ?", row.ID, now).
+		Cols("locked_until", "lock_token", "attempts").
+		Update(row)
+	if err != nil || claimed == 0 {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+func deleteQueuedMessage(row *QueuedMessage) (err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err = s.Where("id = ? AND lock_token = ?", row.ID, row.LockToken).Delete(&QueuedMessage{})
+	return
+}
+
+// releaseQueuedMessage makes a claimed message available to other consumers right away.
+func releaseQueuedMessage(row *QueuedMessage) {
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err := s.
+		Where("id = ? AND lock_token = ?", row.ID, row.LockToken).
+		Cols("locked_until").
+		Update(&QueuedMessage{LockedUntil: 0})
+	if err != nil {
+		log.Errorf("Could not release queued event %s: %s", row.UUID, err)
+	}
+}
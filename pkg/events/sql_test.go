@@ -0,0 +1,139 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+func TestSQLPubSub(t *testing.T) {
+	groups := func(topic string) []string {
+		return []string{topic + ".first", topic + ".second"}
+	}
+	logger := watermill.NopLogger{}
+
+	t.Run("publish stores one message per consumer group", func(t *testing.T) {
+		p := newSQLPubSub(groups, logger)
+		defer p.Close()
+		err := p.Publish("sql.publish", message.NewMessage("uuid-publish", []byte(`{"id":1}`)))
+		require.NoError(t, err)
+
+		db.AssertExists(t, "event_queue", map[string]interface{}{
+			"uuid":           "uuid-publish",
+			"topic":          "sql.publish",
+			"consumer_group": "sql.publish.first",
+			"payload":        `{"id":1}`,
+		}, false)
+		db.AssertExists(t, "event_queue", map[string]interface{}{
+			"uuid":           "uuid-publish",
+			"consumer_group": "sql.publish.second",
+		}, false)
+	})
+	t.Run("publish after close", func(t *testing.T) {
+		p := newSQLPubSub(groups, logger)
+		require.NoError(t, p.Close())
+		err := p.Publish("sql.closed", message.NewMessage("uuid-closed", []byte(`{}`)))
+		assert.ErrorIs(t, err, errPubSubClosed)
+	})
+	t.Run("ack removes the message only for that group", func(t *testing.T) {
+		p := newSQLPubSub(groups, logger)
+		msg := message.NewMessage("uuid-ack", []byte(`{}`))
+		msg.Metadata.Set("foo", "bar")
+		require.NoError(t, p.Publish("sql.ack", msg))
+		require.NoError(t, p.Close())
+
+		sub, err := p.subscriber("sql.ack.first")
+		require.NoError(t, err)
+		messages, err := sub.Subscribe(context.Background(), "sql.ack")
+		require.NoError(t, err)
+
+		received := receive(t, messages)
+		assert.Equal(t, "uuid-ack", received.UUID)
+		assert.Equal(t, "bar", received.Metadata.Get("foo"))
+		received.Ack()
+		require.NoError(t, sub.Close())
+
+		db.AssertMissing(t, "event_queue", map[string]interface{}{
+			"uuid":           "uuid-ack",
+			"consumer_group": "sql.ack.first",
+		})
+		db.AssertExists(t, "event_queue", map[string]interface{}{
+			"uuid":           "uuid-ack",
+			"consumer_group": "sql.ack.second",
+		}, false)
+	})
+	t.Run("nack redelivers the message", func(t *testing.T) {
+		p := newSQLPubSub(groups, logger)
+		require.NoError(t, p.Publish("sql.nack", message.NewMessage("uuid-nack", []byte(`{}`))))
+		require.NoError(t, p.Close())
+
+		sub, err := p.subscriber("sql.nack.first")
+		require.NoError(t, err)
+		messages, err := sub.Subscribe(context.Background(), "sql.nack")
+		require.NoError(t, err)
+
+		receive(t, messages).Nack()
+		received := receive(t, messages)
+		assert.Equal(t, "uuid-nack", received.UUID)
+		received.Ack()
+		require.NoError(t, sub.Close())
+
+		db.AssertMissing(t, "event_queue", map[string]interface{}{
+			"uuid":           "uuid-nack",
+			"consumer_group": "sql.nack.first",
+		})
+	})
+	t.Run("claimed messages are not handed out twice", func(t *testing.T) {
+		p := newSQLPubSub(groups, logger)
+		require.NoError(t, p.Publish("sql.claim", message.NewMessage("uuid-claim", []byte(`{}`))))
+		require.NoError(t, p.Close())
+
+		row, err := claimQueuedMessage("sql.claim.first", "sql.claim")
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		assert.Equal(t, int64(1), row.Attempts)
+
+		again, err := claimQueuedMessage("sql.claim.first", "sql.claim")
+		require.NoError(t, err)
+		assert.Nil(t, again)
+
+		releaseQueuedMessage(row)
+		again, err = claimQueuedMessage("sql.claim.first", "sql.claim")
+		require.NoError(t, err)
+		require.NotNil(t, again)
+		assert.Equal(t, int64(2), again.Attempts)
+	})
+}
@@ -124,17 +124,18 @@ func FullInit() {
 	models.RegisterOverdueReminderCron()
 	models.RegisterUserDeletionCron()
 	models.RegisterOldExportCleanupCron()
+	models.RegisterTrashPurgeCron()
+	models.RegisterWebhookDeliveryCron()
 	models.RegisterAddTaskToFilterViewCron()
 	user.RegisterTokenCleanupCron()
+	user.RegisterNotificationDigestCron()
 	user.RegisterDeletionNotificationCron()
 	openid.CleanupSavedOpenIDProviders()
 	openid.RegisterEmptyOpenIDTeamCleanupCron()
 
 	// Start processing events
 	go func() {
-		models.RegisterListeners()
-		user.RegisterListeners()
-		migrationHandler.RegisterListeners()
+		registerListeners()
 		err := events.InitEvents()
 		if err != nil {
 			log.Fatal(err.Error())
@@ -148,3 +149,21 @@ func FullInit() {
 		}
 	}()
 }
+
+// InitEvents starts processing events in the background, without the cron jobs FullInit starts as well. It is meant
+// for commands which run next to the web server and dispatch events, like the inbound mail server.
+func InitEvents() {
+	go func() {
+		registerListeners()
+		err := events.InitEvents()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+}
+
+func registerListeners() {
+	models.RegisterListeners()
+	user.RegisterListeners()
+	migrationHandler.RegisterListeners()
+}
@@ -0,0 +1,244 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mail
+
+import (
+	"encoding/base64"
+	"errors"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// InboundMessage is a mail Vikunja received, usually a reply to a notification.
+type InboundMessage struct {
+	// The address of the sender, without the name.
+	From    string
+	Subject string
+	// The text of the mail. If the mail only has an html part, it is converted to text.
+	Text string
+	// Whether the mail was sent automatically, like an out of office reply. These must never be answered or acted
+	// upon to avoid mail loops.
+	AutoSubmitted bool
+}
+
+// RejectedError is returned by an InboundHandler to reject a mail permanently. The mail server bounces it to the
+// sender with the message instead of trying to deliver it again.
+type RejectedError struct {
+	Message string
+}
+
+func (err *RejectedError) Error() string {
+	return "Mail rejected: " + err.Message
+}
+
+// ParseInboundMessage reads a raw mail.
+func ParseInboundMessage(r io.Reader) (*InboundMessage, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := m.Header.AddressList("From")
+	if err != nil {
+		return nil, err
+	}
+	if len(from) != 1 {
+		return nil, errors.New("the mail must have exactly one sender")
+	}
+
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	subject, err := decoder.DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		subject = m.Header.Get("Subject")
+	}
+
+	plain, htmlText, err := readTextParts(m.Header, m.Body)
+	if err != nil {
+		return nil, err
+	}
+	if plain == "" && htmlText != "" {
+		plain = htmlToText(htmlText)
+	}
+
+	return &InboundMessage{
+		From:          from[0].Address,
+		Subject:       subject,
+		Text:          strings.ReplaceAll(plain, "\r\n", "\n"),
+		AutoSubmitted: isAutoSubmitted(m.Header),
+	}, nil
+}
+
+// isAutoSubmitted checks the headers RFC 3834 and the most common mail clients use to mark automatic replies.
+func isAutoSubmitted(header mail.Header) bool {
+	autoSubmitted := header.Get("Auto-Submitted")
+	if autoSubmitted != "" && !strings.EqualFold(autoSubmitted, "no") {
+		return true
+	}
+
+	switch strings.ToLower(header.Get("Precedence")) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+
+	return header.Get("X-Autoreply") != "" || header.Get("X-Autorespond") != ""
+}
+
+type headerGetter interface {
+	Get(key string) string
+}
+
+// readTextParts returns the first text/plain and the first text/html part of a mail body. Attachments are ignored.
+func readTextParts(header headerGetter, body io.Reader) (plain, htmlText string, err error) {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", "", err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return plain, htmlText, nil
+			}
+			if err != nil {
+				return "", "", err
+			}
+
+			disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			if disposition == "attachment" {
+				continue
+			}
+
+			partPlain, partHTML, err := readTextParts(part.Header, part)
+			if err != nil {
+				return "", "", err
+			}
+			if plain == "" {
+				plain = partPlain
+			}
+			if htmlText == "" {
+				htmlText = partHTML
+			}
+		}
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	content, err := decodeBody(body, header.Get("Content-Transfer-Encoding"), params["charset"])
+	if err != nil {
+		return "", "", err
+	}
+
+	if mediaType == "text/html" {
+		return "", content, nil
+	}
+	return content, "", nil
+}
+
+func decodeBody(body io.Reader, transferEncoding, charset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	reader, err := charsetReader(charset, body)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := io.ReadAll(reader)
+	return string(content), err
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	charset = strings.ToLower(charset)
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return input, nil
+	}
+
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+var htmlLineBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6]|blockquote)>`)
+
+// htmlQuotes matches the quoted mail most clients add to html replies.
+var htmlQuotes = regexp.MustCompile(`(?is)<blockquote.*</blockquote>|<div[^>]*class="gmail_quote".*`)
+
+func htmlToText(content string) string {
+	content = htmlQuotes.ReplaceAllString(content, "")
+	content = htmlLineBreaks.ReplaceAllString(content, "\n")
+	content = bluemonday.StrictPolicy().Sanitize(content)
+	return html.UnescapeString(content)
+}
+
+var (
+	// replyHeaders match the line mail clients put above the quoted mail, like "On Mon, 1 Jan 2024 at 10:00,
+	// Vikunja <mail@vikunja> wrote:" or "Am 01.01.2024 um 10:00 schrieb Vikunja <mail@vikunja>:". Long lines are
+	// wrapped by some clients, so they may span two lines.
+	replyHeaders = regexp.MustCompile(`(?im)^(On|Am|Le|El|Il|Op|Em)\s[^\n]*(\n[^\n]*)?(wrote|schrieb|a écrit|escribió|ha scritto|schreef|escreveu)[^\n]*:\s*$`)
+	// originalMessageSeparators match the lines Outlook and others put above a forwarded or quoted mail.
+	originalMessageSeparators = regexp.MustCompile(`(?im)^(-{2,}\s*(Original Message|Ursprüngliche Nachricht|Message d'origine|Mensaje original)\s*-{2,}|_{20,}|From:\s.*\n(Sent|Date):\s.*)\s*$`)
+	// signatureSeparators match the standard "-- " separator and the signatures mobile clients add.
+	signatureSeparators = regexp.MustCompile(`(?im)^(--\s?|Sent from my .*|Von meinem .* gesendet|Get Outlook for .*)$`)
+	multipleBlankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// StripReply removes the quoted mail and the signature from the text of a reply, leaving only what the sender
+// wrote.
+func StripReply(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	for _, separator := range []*regexp.Regexp{replyHeaders, originalMessageSeparators, signatureSeparators} {
+		if loc := separator.FindStringIndex(text); loc != nil {
+			text = text[:loc[0]]
+		}
+	}
+
+	// Inline replies keep the text between the quotes
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+
+	text = multipleBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
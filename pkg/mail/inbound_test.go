@@ -0,0 +1,152 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mail
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInboundMessage(t *testing.T) {
+	t.Run("multipart", func(t *testing.T) {
+		raw := "From: \"User 1\" <user1@example.com>\r\n" +
+			"To: reply+1-1-abc@vikunja.example.com\r\n" +
+			"Subject: =?utf-8?q?Re:_Caf=C3=A9?=\r\n" +
+			"MIME-Version: 1.0\r\n" +
+			"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+			"\r\n" +
+			"--b1\r\n" +
+			"Content-Type: text/plain; charset=utf-8\r\n" +
+			"Content-Transfer-Encoding: quoted-printable\r\n" +
+			"\r\n" +
+			"Sounds good, caf=C3=A9 at 10.\r\n" +
+			"--b1\r\n" +
+			"Content-Type: text/html; charset=utf-8\r\n" +
+			"\r\n" +
+			"<p>Sounds good, café at 10.</p>\r\n" +
+			"--b1--\r\n"
+
+		msg, err := ParseInboundMessage(strings.NewReader(raw))
+		require.NoError(t, err)
+		assert.Equal(t, "user1@example.com", msg.From)
+		assert.Equal(t, "Re: Café", msg.Subject)
+		assert.Equal(t, "Sounds good, café at 10.", msg.Text)
+		assert.False(t, msg.AutoSubmitted)
+	})
+	t.Run("html only", func(t *testing.T) {
+		raw := "From: user1@example.com\r\n" +
+			"Content-Type: text/html; charset=iso-8859-1\r\n" +
+			"Content-Transfer-Encoding: base64\r\n" +
+			"\r\n" +
+			// <p>Caf\xe9 &amp; cake<br>tomorrow</p><blockquote>quoted</blockquote>
+			"PHA+Q2Fm6SAmYW1wOyBjYWtlPGJyPnRvbW9ycm93PC9wPjxibG9ja3F1b3RlPnF1b3RlZDwvYmxv\r\nY2txdW90ZT4=\r\n"
+
+		msg, err := ParseInboundMessage(strings.NewReader(raw))
+		require.NoError(t, err)
+		assert.Equal(t, "Café & cake\ntomorrow\n", msg.Text)
+	})
+	t.Run("attachments are ignored", func(t *testing.T) {
+		raw := "From: user1@example.com\r\n" +
+			"Content-Type: multipart/mixed; boundary=\"b1\"\r\n" +
+			"\r\n" +
+			"--b1\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+			"\r\n" +
+			"Not the comment\r\n" +
+			"--b1\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"\r\n" +
+			"The comment\r\n" +
+			"--b1--\r\n"
+
+		msg, err := ParseInboundMessage(strings.NewReader(raw))
+		require.NoError(t, err)
+		assert.Equal(t, "The comment", msg.Text)
+	})
+	t.Run("automatic reply", func(t *testing.T) {
+		raw := "From: user1@example.com\r\n" +
+			"Auto-Submitted: auto-replied\r\n" +
+			"\r\n" +
+			"I am out of office.\r\n"
+
+		msg, err := ParseInboundMessage(strings.NewReader(raw))
+		require.NoError(t, err)
+		assert.True(t, msg.AutoSubmitted)
+	})
+	t.Run("without sender", func(t *testing.T) {
+		_, err := ParseInboundMessage(strings.NewReader("Subject: Hi\r\n\r\nHi\r\n"))
+		require.Error(t, err)
+	})
+}
+
+func TestStripReply(t *testing.T) {
+	tests := map[string]struct {
+		text     string
+		expected string
+	}{
+		"plain": {
+			text:     "Done, see the attached list.\n",
+			expected: "Done, see the attached list.",
+		},
+		"quoted with attribution": {
+			text:     "Thanks, I'll take it.\n\nOn Mon, 1 Jan 2024 at 10:00, Vikunja <mail@vikunja> wrote:\n> User 2 commented:\n> Who does this?\n",
+			expected: "Thanks, I'll take it.",
+		},
+		"wrapped attribution": {
+			text:     "Thanks!\n\nOn Mon, 1 Jan 2024 at 10:00, Vikunja\n<mail@vikunja> wrote:\n> Who does this?\n",
+			expected: "Thanks!",
+		},
+		"german attribution": {
+			text:     "Danke!\n\nAm 01.01.2024 um 10:00 schrieb Vikunja <mail@vikunja>:\n> Wer macht das?\n",
+			expected: "Danke!",
+		},
+		"outlook": {
+			text:     "Yes.\r\n\r\n________________________________\r\nFrom: Vikunja <mail@vikunja>\r\nSent: Monday\r\nWho does this?\r\n",
+			expected: "Yes.",
+		},
+		"outlook without separator": {
+			text:     "Yes.\n\nFrom: Vikunja <mail@vikunja>\nSent: Monday, 1 January 2024 10:00\nWho does this?\n",
+			expected: "Yes.",
+		},
+		"signature": {
+			text:     "Let's do it tomorrow.\n\n-- \nUser 1\nExample Inc.\n",
+			expected: "Let's do it tomorrow.",
+		},
+		"mobile signature": {
+			text:     "On it\n\nSent from my iPhone\n",
+			expected: "On it",
+		},
+		"inline reply": {
+			text:     "> Who does this?\nI do.\n\n> By when?\nFriday.\n",
+			expected: "I do.\n\nFriday.",
+		},
+		"only quote": {
+			text:     "On Mon, 1 Jan 2024 at 10:00, Vikunja <mail@vikunja> wrote:\n> Who does this?\n",
+			expected: "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, StripReply(tc.text))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+)
+
+// InboundHandler handles a mail for one of its recipients. Returning a *RejectedError bounces the mail for that
+// recipient, any other error makes the mail server try to deliver it again later.
+type InboundHandler func(recipient string, msg *InboundMessage) error
+
+// LMTPServer receives mails from a mail server via LMTP (RFC 2033). LMTP has no authentication, it must only be
+// reachable by the mail server.
+type LMTPServer struct {
+	Handler InboundHandler
+	// The maximum size of a mail in bytes. Defaults to 25 MiB.
+	MaxSize int64
+
+	listener net.Listener
+	conns    sync.WaitGroup
+	closed   bool
+	mu       sync.Mutex
+}
+
+const (
+	lmtpCommandTimeout = 5 * time.Minute
+	lmtpDefaultMaxSize = 25 << 20
+)
+
+// ListenLMTP listens on a tcp address like 127.0.0.1:2525 or, if the address starts with a slash, on a unix socket.
+func ListenLMTP(address string) (net.Listener, error) {
+	cfg := net.ListenConfig{}
+	if !strings.HasPrefix(address, "/") {
+		return cfg.Listen(context.Background(), "tcp", address)
+	}
+
+	// Remove old unix socket that may have remained after a crash
+	if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return cfg.Listen(context.Background(), "unix", address)
+}
+
+// Serve accepts connections on the listener until Close is called.
+func (s *LMTPServer) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// Close stops accepting new connections and waits until all mails which are currently delivered are handled.
+func (s *LMTPServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	listener := s.listener
+	s.mu.Unlock()
+
+	var err error
+	if listener != nil {
+		err = listener.Close()
+	}
+	s.conns.Wait()
+	return err
+}
+
+type lmtpSession struct {
+	greeted    bool
+	from       string
+	hasFrom    bool
+	recipients []string
+}
+
+func (session *lmtpSession) reset() {
+	session.from = ""
+	session.hasFrom = false
+	session.recipients = nil
+}
+
+func (s *LMTPServer) handleConn(netConn net.Conn) {
+	conn := textproto.NewConn(netConn)
+	defer conn.Close()
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "vikunja"
+	}
+
+	session := &lmtpSession{}
+	reply := func(format string, args ...interface{}) bool {
+		return conn.PrintfLine(format, args...) == nil
+	}
+
+	if !reply("220 %s LMTP Vikunja ready", hostname) {
+		return
+	}
+
+	for {
+		_ = netConn.SetDeadline(time.Now().Add(lmtpCommandTimeout))
+
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+
+		var ok bool
+		switch verb {
+		case "LHLO":
+			session.greeted = true
+			session.reset()
+			ok = reply("250-%s\r\n250-PIPELINING\r\n250-ENHANCEDSTATUSCODES\r\n250-8BITMIME\r\n250 SIZE %d", hostname, s.maxSize())
+		case "HELO", "EHLO":
+			ok = reply("500 5.5.1 This is an LMTP server, use LHLO")
+		case "MAIL":
+			from, isFrom := parsePath(arg, "FROM:")
+			switch {
+			case !session.greeted:
+				ok = reply("503 5.5.1 Send LHLO first")
+			case session.hasFrom:
+				ok = reply("503 5.5.1 Sender already given")
+			case !isFrom:
+				ok = reply("501 5.5.4 Syntax: MAIL FROM:<address>")
+			default:
+				session.from = from
+				session.hasFrom = true
+				ok = reply("250 2.1.0 OK")
+			}
+		case "RCPT":
+			to, isTo := parsePath(arg, "TO:")
+			switch {
+			case !session.hasFrom:
+				ok = reply("503 5.5.1 Send MAIL first")
+			case !isTo || to == "":
+				ok = reply("501 5.5.4 Syntax: RCPT TO:<address>")
+			default:
+				session.recipients = append(session.recipients, to)
+				ok = reply("250 2.1.5 OK")
+			}
+		case "DATA":
+			if len(session.recipients) == 0 {
+				ok = reply("503 5.5.1 Send RCPT first")
+				break
+			}
+			if !reply("354 Start mail input; end with <CRLF>.<CRLF>") {
+				return
+			}
+			ok = s.handleData(conn, session)
+			session.reset()
+		case "RSET":
+			session.reset()
+			ok = reply("250 2.0.0 OK")
+		case "NOOP":
+			ok = reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			ok = reply("502 5.5.2 Command not implemented")
+		}
+
+		if !ok {
+			return
+		}
+	}
+}
+
+// parsePath returns the address of a MAIL FROM or RCPT TO argument. Parameters after the address are ignored.
+func parsePath(arg, prefix string) (address string, ok bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		return "", false
+	}
+	end := strings.Index(path, ">")
+	if end == -1 {
+		return "", false
+	}
+	return path[1:end], true
+}
+
+// handleData reads the mail and, as LMTP demands, replies with one status per recipient.
+func (s *LMTPServer) handleData(conn *textproto.Conn, session *lmtpSession) bool {
+	dot := conn.DotReader()
+	raw, err := io.ReadAll(io.LimitReader(dot, s.maxSize()+1))
+	if err != nil {
+		return false
+	}
+
+	statuses := make([]string, len(session.recipients))
+	if int64(len(raw)) > s.maxSize() {
+		// Read the rest of the mail so the connection can be used for the next one
+		_, err = io.Copy(io.Discard, dot)
+		if err != nil {
+			return false
+		}
+		for i := range statuses {
+			statuses[i] = "552 5.3.4 Message too big"
+		}
+	} else {
+		msg, err := ParseInboundMessage(bytes.NewReader(raw))
+		for i, recipient := range session.recipients {
+			if err != nil {
+				statuses[i] = "550 5.6.0 Could not read the mail"
+				continue
+			}
+			statuses[i] = s.deliver(recipient, msg)
+		}
+	}
+
+	for _, status := range statuses {
+		if conn.PrintfLine("%s", status) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *LMTPServer) maxSize() int64 {
+	if s.MaxSize <= 0 {
+		return lmtpDefaultMaxSize
+	}
+	return s.MaxSize
+}
+
+func (s *LMTPServer) deliver(recipient string, msg *InboundMessage) string {
+	err := s.Handler(recipient, msg)
+	if err == nil {
+		return "250 2.0.0 OK"
+	}
+
+	var rejected *RejectedError
+	if errors.As(err, &rejected) {
+		log.Debugf("Rejected mail from %s to %s: %s", msg.From, recipient, rejected.Message)
+		return fmt.Sprintf("550 5.7.1 %s", strings.ReplaceAll(rejected.Message, "\n", " "))
+	}
+
+	log.Errorf("Could not handle mail from %s to %s: %s", msg.From, recipient, err)
+	return "451 4.3.0 Temporary failure, please try again later"
+}
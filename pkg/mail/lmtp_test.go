@@ -0,0 +1,145 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mail
+
+import (
+	"errors"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestLMTPServer(t *testing.T, handler InboundHandler, maxSize int64) *textproto.Conn {
+	log.InitLogger()
+
+	listener, err := ListenLMTP("127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &LMTPServer{Handler: handler, MaxSize: maxSize}
+	go func() {
+		assert.NoError(t, server.Serve(listener))
+	}()
+	t.Cleanup(func() {
+		assert.NoError(t, server.Close())
+	})
+
+	conn, err := textproto.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	_, _, err = conn.ReadResponse(220)
+	require.NoError(t, err)
+	return conn
+}
+
+func lmtpCommand(t *testing.T, conn *textproto.Conn, expectedCode int, command string) string {
+	require.NoError(t, conn.PrintfLine("%s", command))
+	_, message, err := conn.ReadResponse(expectedCode)
+	require.NoError(t, err, command)
+	return message
+}
+
+func TestLMTPServer(t *testing.T) {
+	mail := "From: user1@example.com\r\nSubject: Re: Task\r\n\r\nDone.\r\n"
+
+	t.Run("delivers to each recipient", func(t *testing.T) {
+		var lock sync.Mutex
+		received := map[string]*InboundMessage{}
+		conn := startTestLMTPServer(t, func(recipient string, msg *InboundMessage) error {
+			lock.Lock()
+			defer lock.Unlock()
+			received[recipient] = msg
+			switch recipient {
+			case "rejected@vikunja.example.com":
+				return &RejectedError{Message: "This reply address does not exist."}
+			case "broken@vikunja.example.com":
+				return errors.New("database is down")
+			}
+			return nil
+		}, 0)
+
+		message := lmtpCommand(t, conn, 250, "LHLO mail.example.com")
+		assert.Contains(t, message, "PIPELINING")
+		lmtpCommand(t, conn, 250, "MAIL FROM:<user1@example.com> SIZE=100")
+		lmtpCommand(t, conn, 250, "RCPT TO:<reply@vikunja.example.com>")
+		lmtpCommand(t, conn, 250, "RCPT TO:<rejected@vikunja.example.com>")
+		lmtpCommand(t, conn, 250, "RCPT TO:<broken@vikunja.example.com>")
+		lmtpCommand(t, conn, 354, "DATA")
+
+		w := conn.DotWriter()
+		_, err := w.Write([]byte(mail))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, _, err = conn.ReadResponse(250)
+		require.NoError(t, err)
+		_, message, err = conn.ReadResponse(550)
+		require.NoError(t, err)
+		assert.Equal(t, "5.7.1 This reply address does not exist.", message)
+		_, _, err = conn.ReadResponse(451)
+		require.NoError(t, err)
+
+		require.Len(t, received, 3)
+		assert.Equal(t, "Done.", strings.TrimSpace(received["reply@vikunja.example.com"].Text))
+
+		lmtpCommand(t, conn, 221, "QUIT")
+	})
+	t.Run("commands out of order", func(t *testing.T) {
+		conn := startTestLMTPServer(t, func(_ string, _ *InboundMessage) error {
+			return nil
+		}, 0)
+
+		lmtpCommand(t, conn, 500, "EHLO mail.example.com")
+		lmtpCommand(t, conn, 503, "MAIL FROM:<user1@example.com>")
+		lmtpCommand(t, conn, 250, "LHLO mail.example.com")
+		lmtpCommand(t, conn, 503, "RCPT TO:<reply@vikunja.example.com>")
+		lmtpCommand(t, conn, 250, "MAIL FROM:<>")
+		lmtpCommand(t, conn, 503, "DATA")
+		lmtpCommand(t, conn, 501, "RCPT TO:reply@vikunja.example.com")
+		lmtpCommand(t, conn, 250, "RSET")
+		lmtpCommand(t, conn, 503, "RCPT TO:<reply@vikunja.example.com>")
+	})
+	t.Run("too big", func(t *testing.T) {
+		conn := startTestLMTPServer(t, func(_ string, _ *InboundMessage) error {
+			t.Error("The handler should not be called for mails which are too big")
+			return nil
+		}, 10)
+
+		lmtpCommand(t, conn, 250, "LHLO mail.example.com")
+		lmtpCommand(t, conn, 250, "MAIL FROM:<user1@example.com>")
+		lmtpCommand(t, conn, 250, "RCPT TO:<reply@vikunja.example.com>")
+		lmtpCommand(t, conn, 354, "DATA")
+
+		w := conn.DotWriter()
+		_, err := w.Write([]byte(mail))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, _, err = conn.ReadResponse(552)
+		require.NoError(t, err)
+		// The connection can still be used
+		lmtpCommand(t, conn, 250, "NOOP")
+	})
+}
@@ -41,6 +41,7 @@ type Opts struct {
 	Embeds      map[string]io.Reader
 	EmbedFS     map[string]*embed.FS
 	ThreadID    string
+	ReplyTo     string
 }
 
 // ContentType represents mail content types
@@ -94,6 +95,10 @@ func getMessage(opts *Opts) *mail.Msg {
 		m.SetGenHeader(mail.HeaderReferences, opts.ThreadID)
 	}
 
+	if opts.ReplyTo != "" {
+		_ = m.ReplyTo(opts.ReplyTo)
+	}
+
 	for name, content := range opts.Embeds {
 		err := m.EmbedReader(name, content)
 		if err != nil {
@@ -0,0 +1,55 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type AuditLogEntry20261018120000 struct {
+	ID         int64                  `xorm:"bigint autoincr not null unique pk"`
+	EntityKind string                 `xorm:"varchar(50) not null INDEX"`
+	EntityID   int64                  `xorm:"bigint not null INDEX"`
+	TaskID     int64                  `xorm:"bigint null INDEX"`
+	ProjectID  int64                  `xorm:"bigint null INDEX"`
+	Action     string                 `xorm:"varchar(20) not null"`
+	Changes    map[string]interface{} `xorm:"json null"`
+	AuthType   string                 `xorm:"varchar(20) not null"`
+	APITokenID int64                  `xorm:"bigint null"`
+	DoerID     int64                  `xorm:"bigint not null"`
+	Created    time.Time              `xorm:"created not null INDEX"`
+}
+
+func (AuditLogEntry20261018120000) TableName() string {
+	return "audit_log"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261018120000",
+		Description: "Add audit log",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(AuditLogEntry20261018120000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(AuditLogEntry20261018120000{})
+		},
+	})
+}
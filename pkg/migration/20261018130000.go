@@ -0,0 +1,84 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type tasks20261018130000 struct {
+	Deleted time.Time `xorm:"deleted"`
+	TrashID int64     `xorm:"bigint null INDEX"`
+}
+
+func (tasks20261018130000) TableName() string {
+	return "tasks"
+}
+
+type projects20261018130000 struct {
+	Deleted time.Time `xorm:"deleted"`
+	TrashID int64     `xorm:"bigint null INDEX"`
+}
+
+func (projects20261018130000) TableName() string {
+	return "projects"
+}
+
+type taskComments20261018130000 struct {
+	Deleted time.Time `xorm:"deleted"`
+	TrashID int64     `xorm:"bigint null INDEX"`
+}
+
+func (taskComments20261018130000) TableName() string {
+	return "task_comments"
+}
+
+type TrashItem20261018130000 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	Kind        string    `xorm:"varchar(20) not null INDEX"`
+	EntityID    int64     `xorm:"bigint not null INDEX"`
+	Title       string    `xorm:"text null"`
+	ProjectID   int64     `xorm:"bigint null INDEX"`
+	TaskID      int64     `xorm:"bigint null INDEX"`
+	DeletedByID int64     `xorm:"bigint not null INDEX"`
+	Created     time.Time `xorm:"created not null"`
+}
+
+func (TrashItem20261018130000) TableName() string {
+	return "trash"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261018130000",
+		Description: "Add trash for tasks, projects and task comments",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(
+				tasks20261018130000{},
+				projects20261018130000{},
+				taskComments20261018130000{},
+				TrashItem20261018130000{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(TrashItem20261018130000{})
+		},
+	})
+}
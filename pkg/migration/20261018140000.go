@@ -0,0 +1,75 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type eventQueue20261018140000 struct {
+	ID            int64             `xorm:"bigint autoincr not null unique pk"`
+	UUID          string            `xorm:"varchar(36) not null"`
+	Topic         string            `xorm:"varchar(250) not null"`
+	ConsumerGroup string            `xorm:"varchar(250) not null INDEX"`
+	Payload       string            `xorm:"longtext not null"`
+	Metadata      map[string]string `xorm:"json null"`
+	Attempts      int64             `xorm:"bigint not null default 0"`
+	LockedUntil   int64             `xorm:"bigint not null default 0 INDEX"`
+	LockToken     string            `xorm:"varchar(36) null"`
+	Created       time.Time         `xorm:"created not null"`
+}
+
+func (eventQueue20261018140000) TableName() string {
+	return "event_queue"
+}
+
+type eventPoisonQueue20261018140000 struct {
+	ID       int64             `xorm:"bigint autoincr not null unique pk"`
+	UUID     string            `xorm:"varchar(36) not null"`
+	Topic    string            `xorm:"varchar(250) not null INDEX"`
+	Handler  string            `xorm:"varchar(250) not null"`
+	Reason   string            `xorm:"text null"`
+	Payload  string            `xorm:"longtext not null"`
+	Metadata map[string]string `xorm:"json null"`
+	Created  time.Time         `xorm:"created not null"`
+}
+
+func (eventPoisonQueue20261018140000) TableName() string {
+	return "event_poison_queue"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261018140000",
+		Description: "Add tables for the database event backend and the poison queue",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(
+				eventQueue20261018140000{},
+				eventPoisonQueue20261018140000{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(
+				eventQueue20261018140000{},
+				eventPoisonQueue20261018140000{},
+			)
+		},
+	})
+}
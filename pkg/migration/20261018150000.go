@@ -0,0 +1,71 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type webhooks20261018150000 struct {
+	IsDisabled          bool  `xorm:"not null default false"`
+	ConsecutiveFailures int64 `xorm:"bigint not null default 0"`
+}
+
+func (webhooks20261018150000) TableName() string {
+	return "webhooks"
+}
+
+type webhookDeliveries20261018150000 struct {
+	ID             int64             `xorm:"bigint autoincr not null unique pk"`
+	WebhookID      int64             `xorm:"bigint not null index"`
+	EventName      string            `xorm:"varchar(250) not null"`
+	RequestURL     string            `xorm:"text not null"`
+	RequestHeaders map[string]string `xorm:"json null"`
+	RequestBody    string            `xorm:"longtext null"`
+	ResponseStatus int               `xorm:"null"`
+	ResponseBody   string            `xorm:"text null"`
+	Error          string            `xorm:"text null"`
+	DurationMs     int64             `xorm:"bigint not null default 0"`
+	Success        bool              `xorm:"not null default false"`
+	Attempt        int64             `xorm:"bigint not null default 1"`
+	RedeliveryOf   int64             `xorm:"bigint null"`
+	NextAttempt    time.Time         `xorm:"DATETIME null index"`
+	Created        time.Time         `xorm:"created not null"`
+}
+
+func (webhookDeliveries20261018150000) TableName() string {
+	return "webhook_deliveries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261018150000",
+		Description: "Add webhook deliveries and automatic disabling of failing webhooks",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(
+				webhooks20261018150000{},
+				webhookDeliveries20261018150000{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(webhookDeliveries20261018150000{})
+		},
+	})
+}
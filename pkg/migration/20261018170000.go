@@ -0,0 +1,59 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type webhooks20261018170000 struct {
+	SignatureScheme string            `xorm:"varchar(20) null"`
+	PayloadTemplate string            `xorm:"text null"`
+	Headers         map[string]string `xorm:"json null"`
+	AuthType        string            `xorm:"varchar(10) null"`
+	AuthUsername    string            `xorm:"null"`
+	AuthPassword    string            `xorm:"null"`
+}
+
+func (webhooks20261018170000) TableName() string {
+	return "webhooks"
+}
+
+type webhookDeliveries20261018170000 struct {
+	IsTest bool `xorm:"not null default false"`
+}
+
+func (webhookDeliveries20261018170000) TableName() string {
+	return "webhook_deliveries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261018170000",
+		Description: "Add payload templates, custom headers, authentication and signature schemes to webhooks",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(
+				webhooks20261018170000{},
+				webhookDeliveries20261018170000{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,69 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type automationRules20261018200000 struct {
+	ID          int64       `xorm:"bigint autoincr not null unique pk"`
+	ProjectID   int64       `xorm:"bigint not null index"`
+	Title       string      `xorm:"varchar(250) not null"`
+	Event       string      `xorm:"varchar(250) not null index"`
+	Filter      string      `xorm:"text null"`
+	Actions     interface{} `xorm:"json not null"`
+	IsDisabled  bool        `xorm:"not null default false"`
+	CreatedByID int64       `xorm:"bigint not null index"`
+	Created     time.Time   `xorm:"created not null"`
+	Updated     time.Time   `xorm:"updated not null"`
+}
+
+func (automationRules20261018200000) TableName() string {
+	return "automation_rules"
+}
+
+type automationRuleExecutions20261018200000 struct {
+	ID             int64     `xorm:"bigint autoincr not null unique pk"`
+	RuleID         int64     `xorm:"bigint not null index"`
+	TaskID         int64     `xorm:"bigint not null index"`
+	EventName      string    `xorm:"varchar(250) not null"`
+	Status         string    `xorm:"varchar(20) not null"`
+	ChangedActions int64     `xorm:"bigint not null default 0"`
+	Error          string    `xorm:"text null"`
+	Created        time.Time `xorm:"created not null index"`
+}
+
+func (automationRuleExecutions20261018200000) TableName() string {
+	return "automation_rule_executions"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261018200000",
+		Description: "Add automation rules and their execution log",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(automationRules20261018200000{}, automationRuleExecutions20261018200000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type wipLimits20261019000000 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	UserID      int64     `xorm:"bigint not null index"`
+	BucketTitle string    `xorm:"varchar(250) not null"`
+	Limit       int64     `xorm:"bigint not null"`
+	Soft        bool      `xorm:"not null default false"`
+	Created     time.Time `xorm:"created not null"`
+	Updated     time.Time `xorm:"updated not null"`
+}
+
+func (wipLimits20261019000000) TableName() string {
+	return "wip_limits"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261019000000",
+		Description: "add work-in-progress limits",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(wipLimits20261019000000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,52 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type calendarFeeds20261019010000 struct {
+	ID             int64     `xorm:"bigint autoincr not null unique pk"`
+	TokenSalt      string    `xorm:"not null"`
+	TokenHash      string    `xorm:"not null unique"`
+	TokenLastEight string    `xorm:"not null index varchar(8)"`
+	ProjectID      int64     `xorm:"bigint not null INDEX"`
+	ProjectViewID  int64     `xorm:"bigint not null INDEX"`
+	OwnerID        int64     `xorm:"bigint not null INDEX"`
+	Created        time.Time `xorm:"created not null"`
+}
+
+func (calendarFeeds20261019010000) TableName() string {
+	return "calendar_feeds"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261019010000",
+		Description: "add calendar feeds",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(calendarFeeds20261019010000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
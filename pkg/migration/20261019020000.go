@@ -0,0 +1,55 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskChecklistItems20261019020000 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	TaskID      int64     `xorm:"bigint not null INDEX"`
+	Title       string    `xorm:"varchar(250) not null"`
+	Done        bool      `xorm:"INDEX null"`
+	DoneAt      time.Time `xorm:"DATETIME null 'done_at'"`
+	AssigneeID  int64     `xorm:"bigint null INDEX"`
+	DueDate     time.Time `xorm:"DATETIME INDEX null 'due_date'"`
+	Position    float64   `xorm:"double null"`
+	CreatedByID int64     `xorm:"bigint not null"`
+	Created     time.Time `xorm:"created not null"`
+	Updated     time.Time `xorm:"updated not null"`
+}
+
+func (taskChecklistItems20261019020000) TableName() string {
+	return "task_checklist_items"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261019020000",
+		Description: "add task checklist items",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(taskChecklistItems20261019020000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
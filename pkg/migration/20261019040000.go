@@ -0,0 +1,73 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type tasks20261019040000 struct {
+	StoryPoints float64 `xorm:"DOUBLE null"`
+	Estimate    int64   `xorm:"bigint null"`
+}
+
+func (tasks20261019040000) TableName() string {
+	return "tasks"
+}
+
+type sprints20261019040000 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	ProjectID   int64     `xorm:"bigint not null INDEX"`
+	Title       string    `xorm:"varchar(250) not null"`
+	Goal        string    `xorm:"longtext null"`
+	StartDate   time.Time `xorm:"DATETIME not null 'start_date'"`
+	EndDate     time.Time `xorm:"DATETIME not null 'end_date'"`
+	CreatedByID int64     `xorm:"bigint not null"`
+	Created     time.Time `xorm:"created not null"`
+	Updated     time.Time `xorm:"updated not null"`
+}
+
+func (sprints20261019040000) TableName() string {
+	return "sprints"
+}
+
+type sprintTasks20261019040000 struct {
+	ID       int64     `xorm:"bigint autoincr not null unique pk"`
+	SprintID int64     `xorm:"bigint not null INDEX"`
+	TaskID   int64     `xorm:"bigint not null INDEX"`
+	Created  time.Time `xorm:"created not null"`
+}
+
+func (sprintTasks20261019040000) TableName() string {
+	return "sprint_tasks"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261019040000",
+		Description: "add estimates to tasks and sprints to projects",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(tasks20261019040000{}, sprints20261019040000{}, sprintTasks20261019040000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type userNotificationPreferences20261019050000 struct {
+	ID           int64     `xorm:"bigint autoincr not null unique pk"`
+	UserID       int64     `xorm:"bigint not null INDEX"`
+	ProjectID    int64     `xorm:"bigint not null default 0 INDEX"`
+	Notification string    `xorm:"varchar(250) not null"`
+	Channel      string    `xorm:"varchar(50) not null"`
+	Enabled      bool      `xorm:"not null default false"`
+	Updated      time.Time `xorm:"updated not null"`
+}
+
+func (userNotificationPreferences20261019050000) TableName() string {
+	return "user_notification_preferences"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261019050000",
+		Description: "add notification preferences for users",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(userNotificationPreferences20261019050000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,64 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type users20261019060000 struct {
+	NotificationDigest     string `xorm:"varchar(10) not null default ''"`
+	NotificationDigestTime string `xorm:"varchar(5) not null default ''"`
+}
+
+func (users20261019060000) TableName() string {
+	return "users"
+}
+
+type notificationDigestItems20261019060000 struct {
+	ID           int64     `xorm:"bigint autoincr not null unique pk"`
+	NotifiableID int64     `xorm:"bigint not null INDEX"`
+	Name         string    `xorm:"varchar(250) not null"`
+	ProjectID    int64     `xorm:"bigint not null default 0"`
+	ProjectTitle string    `xorm:"varchar(250) null"`
+	ThreadID     string    `xorm:"varchar(250) null"`
+	Title        string    `xorm:"text null"`
+	URL          string    `xorm:"text null"`
+	Summary      string    `xorm:"text null"`
+	Lines        []any     `xorm:"json null"`
+	Created      time.Time `xorm:"created not null"`
+}
+
+func (notificationDigestItems20261019060000) TableName() string {
+	return "notification_digest_items"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20261019060000",
+		Description: "add notification digests",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync(users20261019060000{}, notificationDigestItems20261019060000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
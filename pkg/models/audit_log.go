@@ -0,0 +1,491 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"github.com/labstack/echo/v4"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// AuditLogAction is the kind of change an audit log entry records
+type AuditLogAction string
+
+const (
+	AuditLogActionCreated AuditLogAction = "created"
+	AuditLogActionUpdated AuditLogAction = "updated"
+	AuditLogActionDeleted AuditLogAction = "deleted"
+)
+
+// AuditLogAuthType is the way a change was authenticated
+type AuditLogAuthType string
+
+const (
+	AuditLogAuthTypeUser      AuditLogAuthType = "user"
+	AuditLogAuthTypeLinkShare AuditLogAuthType = "link_share"
+	AuditLogAuthTypeAPIToken  AuditLogAuthType = "api_token"
+	AuditLogAuthTypeCalDAV    AuditLogAuthType = "caldav"
+)
+
+// AuditLogChange holds the old and new value of a single changed field
+type AuditLogChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditLogEntry is a single, persisted change to an entity
+type AuditLogEntry struct {
+	// The unique, numeric id of this entry.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The kind of entity which was changed, for example `task`, `project` or `task_assignee`.
+	EntityKind string `xorm:"varchar(50) not null INDEX" json:"entity_kind"`
+	// The id of the changed entity. For entities which only link two other entities (like assignees or labels), this is the id of the linked entity.
+	EntityID int64 `xorm:"bigint not null INDEX" json:"entity_id"`
+	// The task this change belongs to, if any.
+	TaskID int64 `xorm:"bigint null INDEX" json:"task_id"`
+	// The project this change belongs to.
+	ProjectID int64 `xorm:"bigint null INDEX" json:"project_id"`
+	// What happened to the entity. Can be `created`, `updated` or `deleted`.
+	Action AuditLogAction `xorm:"varchar(20) not null" json:"action"`
+	// All changed fields with their old and new value.
+	Changes map[string]*AuditLogChange `xorm:"json null" json:"changes"`
+	// How the change was authenticated. Can be `user`, `link_share`, `api_token` or `caldav`.
+	AuthType AuditLogAuthType `xorm:"varchar(20) not null" json:"auth_type"`
+	// The id of the api token used to make this change, if any.
+	APITokenID int64 `xorm:"bigint null" json:"api_token_id,omitempty"`
+
+	DoerID int64 `xorm:"bigint not null" json:"-"`
+	// The user (or link share) who made this change.
+	Doer *user.User `xorm:"-" json:"doer"`
+
+	// A timestamp when this change was made.
+	Created time.Time `xorm:"created not null INDEX" json:"created"`
+}
+
+func (*AuditLogEntry) TableName() string {
+	return "audit_log"
+}
+
+// AuditLogActor describes who made a change and how they were authenticated.
+type AuditLogActor struct {
+	Auth       web.Auth
+	AuthType   AuditLogAuthType
+	APITokenID int64
+}
+
+// NewAuditLogActor returns an actor for a user or link share auth.
+func NewAuditLogActor(a web.Auth) *AuditLogActor {
+	actor := &AuditLogActor{
+		Auth:     a,
+		AuthType: AuditLogAuthTypeUser,
+	}
+	if _, is := a.(*LinkSharing); is {
+		actor.AuthType = AuditLogAuthTypeLinkShare
+	}
+	return actor
+}
+
+// AuditLogChangeRecorder records all changes made through the standard web handlers in the audit log.
+type AuditLogChangeRecorder struct{}
+
+// BeforeChange prepares recording a change to subject, the returned function records it.
+func (AuditLogChangeRecorder) BeforeChange(s *xorm.Session, c echo.Context, a web.Auth, subject interface{}, kind web.ChangeKind) (after func(*xorm.Session) error, err error) {
+	var action AuditLogAction
+	switch kind {
+	case web.ChangeKindCreated:
+		action = AuditLogActionCreated
+	case web.ChangeKindUpdated:
+		action = AuditLogActionUpdated
+	case web.ChangeKindDeleted:
+		action = AuditLogActionDeleted
+	}
+
+	recorder, err := NewAuditLogRecorder(s, subject, action)
+	if err != nil || recorder == nil {
+		return nil, err
+	}
+
+	actor := NewAuditLogActor(a)
+	if token, is := c.Get("api_token").(*APIToken); is {
+		actor.AuthType = AuditLogAuthTypeAPIToken
+		actor.APITokenID = token.ID
+	}
+
+	return func(s *xorm.Session) error {
+		return recorder.Record(s, actor)
+	}, nil
+}
+
+// auditLogSnapshot is the state of a single entity at one point in time.
+type auditLogSnapshot struct {
+	entityKind string
+	entityID   int64
+	taskID     int64
+	projectID  int64
+	state      interface{}
+}
+
+func (snap *auditLogSnapshot) key() string {
+	return snap.entityKind + ":" + strconv.FormatInt(snap.entityID, 10)
+}
+
+// auditable is implemented by all models whose changes are recorded in the audit log.
+type auditable interface {
+	// auditLogSnapshots returns the current state of all entities a change to this model touches.
+	// Entities which do not exist (anymore) must be left out.
+	auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error)
+}
+
+// These fields change with every update and are therefore not recorded.
+var auditLogIgnoredFields = map[string]bool{
+	"created": true,
+	"updated": true,
+}
+
+// AuditLogRecorder captures the state of a model before a change and records the difference afterwards.
+type AuditLogRecorder struct {
+	action  AuditLogAction
+	subject auditable
+	before  []*auditLogSnapshot
+}
+
+// NewAuditLogRecorder prepares recording a change to subject. It must be called before the change is made.
+// If subject is not auditable, it returns nil, which is safe to call Record on.
+func NewAuditLogRecorder(s *xorm.Session, subject interface{}, action AuditLogAction) (recorder *AuditLogRecorder, err error) {
+	a, is := subject.(auditable)
+	if !is {
+		return nil, nil
+	}
+
+	recorder = &AuditLogRecorder{
+		action:  action,
+		subject: a,
+	}
+	if action != AuditLogActionCreated {
+		recorder.before, err = a.auditLogSnapshots(s)
+	}
+	return
+}
+
+// Record saves the changes made since the recorder was created.
+func (r *AuditLogRecorder) Record(s *xorm.Session, actor *AuditLogActor) (err error) {
+	if r == nil {
+		return nil
+	}
+
+	var after []*auditLogSnapshot
+	if r.action != AuditLogActionDeleted {
+		after, err = r.subject.auditLogSnapshots(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	befores := make(map[string]*auditLogSnapshot, len(r.before))
+	for _, snap := range r.before {
+		befores[snap.key()] = snap
+	}
+
+	// Entries are recorded in the order of the new state, followed by all entities which are gone now.
+	type snapshotPair struct {
+		before *auditLogSnapshot
+		after  *auditLogSnapshot
+	}
+	pairs := []*snapshotPair{}
+	for _, snap := range after {
+		pairs = append(pairs, &snapshotPair{before: befores[snap.key()], after: snap})
+		delete(befores, snap.key())
+	}
+	for _, snap := range r.before {
+		if _, gone := befores[snap.key()]; gone {
+			pairs = append(pairs, &snapshotPair{before: snap})
+		}
+	}
+
+	entries := []*AuditLogEntry{}
+	for _, pair := range pairs {
+		ref := pair.after
+		var beforeState, afterState interface{}
+		if pair.before != nil {
+			beforeState = pair.before.state
+			if ref == nil {
+				ref = pair.before
+			}
+		}
+		if pair.after != nil {
+			afterState = pair.after.state
+		}
+
+		changes, err := diffAuditLogStates(beforeState, afterState)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 && r.action == AuditLogActionUpdated {
+			continue
+		}
+
+		entries = append(entries, &AuditLogEntry{
+			EntityKind: ref.entityKind,
+			EntityID:   ref.entityID,
+			TaskID:     ref.taskID,
+			ProjectID:  ref.projectID,
+			Action:     r.action,
+			Changes:    changes,
+			AuthType:   actor.AuthType,
+			APITokenID: actor.APITokenID,
+			DoerID:     getUserOrLinkShareID(actor.Auth),
+		})
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err = addProjectIDsToAuditLogEntries(s, entries)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(&entries)
+	return err
+}
+
+// addProjectIDsToAuditLogEntries fills in the project of all entries which only know their task.
+func addProjectIDsToAuditLogEntries(s *xorm.Session, entries []*AuditLogEntry) error {
+	taskIDs := []int64{}
+	for _, entry := range entries {
+		if entry.ProjectID == 0 && entry.TaskID != 0 {
+			taskIDs = append(taskIDs, entry.TaskID)
+		}
+	}
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	tasks, err := GetTasksSimpleByIDs(s, taskIDs)
+	if err != nil {
+		return err
+	}
+	projectIDs := make(map[int64]int64, len(tasks))
+	for _, t := range tasks {
+		projectIDs[t.ID] = t.ProjectID
+	}
+	for _, entry := range entries {
+		if entry.ProjectID == 0 {
+			entry.ProjectID = projectIDs[entry.TaskID]
+		}
+	}
+	return nil
+}
+
+func auditLogStateToMap(state interface{}) (fields map[string]interface{}, err error) {
+	fields = make(map[string]interface{})
+	if state == nil {
+		return
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &fields)
+	return
+}
+
+func isEmptyAuditLogValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == "" || v == "0001-01-01T00:00:00Z"
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// diffAuditLogStates returns all fields which differ between the two states.
+// A nil state means the entity did not exist at that point.
+func diffAuditLogStates(before, after interface{}) (changes map[string]*AuditLogChange, err error) {
+	oldFields, err := auditLogStateToMap(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := auditLogStateToMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key := range oldFields {
+		keys = append(keys, key)
+	}
+	for key := range newFields {
+		if _, has := oldFields[key]; !has {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes = make(map[string]*AuditLogChange)
+	for _, key := range keys {
+		if auditLogIgnoredFields[key] {
+			continue
+		}
+
+		oldValue := oldFields[key]
+		newValue := newFields[key]
+		if isEmptyAuditLogValue(oldValue) && isEmptyAuditLogValue(newValue) {
+			continue
+		}
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		changes[key] = &AuditLogChange{
+			Old: oldValue,
+			New: newValue,
+		}
+	}
+
+	return
+}
+
+func getAuditLogEntries(s *xorm.Session, cond builder.Cond, page, perPage int) (entries []*AuditLogEntry, resultCount int, numberOfTotalItems int64, err error) {
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	entries = []*AuditLogEntry{}
+	query := s.
+		Where(cond).
+		OrderBy("created desc, id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&entries)
+	if err != nil {
+		return
+	}
+
+	doerIDs := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		doerIDs = append(doerIDs, entry.DoerID)
+	}
+	doers, err := getUsersOrLinkSharesFromIDs(s, doerIDs)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		entry.Doer = doers[entry.DoerID]
+	}
+
+	numberOfTotalItems, err = s.Where(cond).Count(&AuditLogEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+// TaskHistory is the audit log of a single task
+type TaskHistory struct {
+	TaskID int64 `json:"-" param:"task"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// CanRead checks if a user can see the history of a task
+func (th *TaskHistory) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	t := &Task{ID: th.TaskID}
+	return t.CanRead(s, a)
+}
+
+// ReadAll returns all changes made to a task
+// @Summary Get the history of a task
+// @Description Returns all recorded changes to a task and everything belonging to it, newest first. The user needs to have at least read access to the task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.AuditLogEntry "The history of the task"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task"
+// @Failure 404 {object} web.HTTPError "The task does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/history [get]
+func (th *TaskHistory) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := th.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	return getAuditLogEntries(s, builder.Eq{"task_id": th.TaskID}, page, perPage)
+}
+
+// ProjectHistory is the audit log of a project and all its tasks
+type ProjectHistory struct {
+	ProjectID int64 `json:"-" param:"project"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// CanRead checks if a user can see the history of a project
+func (ph *ProjectHistory) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: ph.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// ReadAll returns all changes made to a project
+// @Summary Get the history of a project
+// @Description Returns all recorded changes to a project and all of its tasks, newest first. The user needs to have at least read access to the project.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.AuditLogEntry "The history of the project"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The project does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/history [get]
+func (ph *ProjectHistory) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := ph.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	return getAuditLogEntries(s, builder.Eq{"project_id": ph.ProjectID}, page, perPage)
+}
@@ -0,0 +1,294 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/user"
+
+	"xorm.io/xorm"
+)
+
+func (t *Task) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if t.ID == 0 {
+		return nil, nil
+	}
+
+	task, err := GetTaskByIDSimple(s, t.ID)
+	if err != nil {
+		if IsErrTaskDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task",
+		entityID:   task.ID,
+		taskID:     task.ID,
+		projectID:  task.ProjectID,
+		state:      &task,
+	}}, nil
+}
+
+func (bt *BulkTask) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if len(bt.TaskIDs) == 0 {
+		return nil, nil
+	}
+
+	tasks, err := GetTasksSimpleByIDs(s, bt.TaskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*auditLogSnapshot, 0, len(tasks))
+	for _, task := range tasks {
+		snapshots = append(snapshots, &auditLogSnapshot{
+			entityKind: "task",
+			entityID:   task.ID,
+			taskID:     task.ID,
+			projectID:  task.ProjectID,
+			state:      task,
+		})
+	}
+	return snapshots, nil
+}
+
+func (p *Project) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if p.ID == 0 {
+		return nil, nil
+	}
+
+	project, err := GetProjectSimpleByID(s, p.ID)
+	if err != nil {
+		if IsErrProjectDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "project",
+		entityID:   project.ID,
+		projectID:  project.ID,
+		state:      project,
+	}}, nil
+}
+
+func (tc *TaskComment) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if tc.ID == 0 {
+		return nil, nil
+	}
+
+	comment := &TaskComment{ID: tc.ID, TaskID: tc.TaskID}
+	err := getTaskCommentSimple(s, comment)
+	if err != nil {
+		if IsErrTaskCommentDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task_comment",
+		entityID:   comment.ID,
+		taskID:     comment.TaskID,
+		state:      map[string]interface{}{"comment": comment.Comment},
+	}}, nil
+}
+
+func (la *TaskAssginee) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	exists, err := s.
+		Where("task_id = ? AND user_id = ?", la.TaskID, la.UserID).
+		Exist(&TaskAssginee{})
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task_assignee",
+		entityID:   la.UserID,
+		taskID:     la.TaskID,
+		state:      map[string]interface{}{"user_id": la.UserID},
+	}}, nil
+}
+
+func (lt *LabelTask) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	exists, err := s.
+		Where("task_id = ? AND label_id = ?", lt.TaskID, lt.LabelID).
+		Exist(&LabelTask{})
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task_label",
+		entityID:   lt.LabelID,
+		taskID:     lt.TaskID,
+		state:      map[string]interface{}{"label_id": lt.LabelID},
+	}}, nil
+}
+
+func (rel *TaskRelation) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	exists, err := s.
+		Where("task_id = ? AND other_task_id = ? AND relation_kind = ?", rel.TaskID, rel.OtherTaskID, rel.RelationKind).
+		Exist(&TaskRelation{})
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task_relation",
+		entityID:   rel.OtherTaskID,
+		taskID:     rel.TaskID,
+		state: map[string]interface{}{
+			"other_task_id": rel.OtherTaskID,
+			"relation_kind": rel.RelationKind,
+		},
+	}}, nil
+}
+
+func (te *TaskTimeEntry) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if te.ID == 0 {
+		return nil, nil
+	}
+
+	entry, err := getTimeEntryByID(s, te.ID)
+	if err != nil {
+		if IsErrTimeEntryDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task_time_entry",
+		entityID:   entry.ID,
+		taskID:     entry.TaskID,
+		state:      entry,
+	}}, nil
+}
+
+func (ci *TaskChecklistItem) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if ci.ID == 0 {
+		return nil, nil
+	}
+
+	item, err := GetChecklistItemByID(s, ci.ID)
+	if err != nil {
+		if IsErrChecklistItemDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "task_checklist_item",
+		entityID:   item.ID,
+		taskID:     item.TaskID,
+		state:      item,
+	}}, nil
+}
+
+func (lu *ProjectUser) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	u, err := user.GetUserByUsername(s, lu.Username)
+	if err != nil {
+		if user.IsErrUserDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	stored := &ProjectUser{}
+	exists, err := s.
+		Where("project_id = ? AND user_id = ?", lu.ProjectID, u.ID).
+		Get(stored)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "project_user",
+		entityID:   u.ID,
+		projectID:  lu.ProjectID,
+		state: map[string]interface{}{
+			"username":   u.Username,
+			"permission": stored.Permission,
+		},
+	}}, nil
+}
+
+func (tl *TeamProject) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	stored := &TeamProject{}
+	exists, err := s.
+		Where("project_id = ? AND team_id = ?", tl.ProjectID, tl.TeamID).
+		Get(stored)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "project_team",
+		entityID:   tl.TeamID,
+		projectID:  tl.ProjectID,
+		state: map[string]interface{}{
+			"team_id":    tl.TeamID,
+			"permission": stored.Permission,
+		},
+	}}, nil
+}
+
+func (pv *ProjectView) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if pv.ID == 0 {
+		return nil, nil
+	}
+
+	view, err := GetProjectViewByIDAndProject(s, pv.ID, pv.ProjectID)
+	if err != nil {
+		if IsErrProjectViewDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "project_view",
+		entityID:   view.ID,
+		projectID:  view.ProjectID,
+		state:      view,
+	}}, nil
+}
+
+func (cf *ProjectCustomField) auditLogSnapshots(s *xorm.Session) ([]*auditLogSnapshot, error) {
+	if cf.ID == 0 {
+		return nil, nil
+	}
+
+	field, err := getCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		if IsErrCustomFieldDoesNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return []*auditLogSnapshot{{
+		entityKind: "project_custom_field",
+		entityID:   field.ID,
+		projectID:  field.ProjectID,
+		state:      field,
+	}}, nil
+}
@@ -0,0 +1,296 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getLatestAuditLogEntries(t *testing.T, after int64) []*AuditLogEntry {
+	s := db.NewSession()
+	defer s.Close()
+
+	entries := []*AuditLogEntry{}
+	err := s.Where("id > ?", after).OrderBy("id asc").Find(&entries)
+	require.NoError(t, err)
+	return entries
+}
+
+func TestAuditLogRecorder(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, ProjectID: 1, Title: "changed", Description: "Lorem Ipsum"}
+		recorder, err := NewAuditLogRecorder(s, task, AuditLogActionUpdated)
+		require.NoError(t, err)
+		err = task.Update(s, u)
+		require.NoError(t, err)
+		err = recorder.Record(s, NewAuditLogActor(u))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		entries := getLatestAuditLogEntries(t, 5)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "task", entries[0].EntityKind)
+		assert.Equal(t, int64(1), entries[0].EntityID)
+		assert.Equal(t, int64(1), entries[0].TaskID)
+		assert.Equal(t, int64(1), entries[0].ProjectID)
+		assert.Equal(t, AuditLogActionUpdated, entries[0].Action)
+		assert.Equal(t, AuditLogAuthTypeUser, entries[0].AuthType)
+		assert.Equal(t, int64(1), entries[0].DoerID)
+		require.Len(t, entries[0].Changes, 1)
+		assert.Equal(t, "task #1", entries[0].Changes["title"].Old)
+		assert.Equal(t, "changed", entries[0].Changes["title"].New)
+	})
+	t.Run("update without changes", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, ProjectID: 1, Title: "task #1", Description: "Lorem Ipsum"}
+		recorder, err := NewAuditLogRecorder(s, task, AuditLogActionUpdated)
+		require.NoError(t, err)
+		err = task.Update(s, u)
+		require.NoError(t, err)
+		err = recorder.Record(s, NewAuditLogActor(u))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Empty(t, getLatestAuditLogEntries(t, 5))
+	})
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ProjectID: 1, Title: "new task"}
+		recorder, err := NewAuditLogRecorder(s, task, AuditLogActionCreated)
+		require.NoError(t, err)
+		err = task.Create(s, u)
+		require.NoError(t, err)
+		err = recorder.Record(s, &AuditLogActor{Auth: u, AuthType: AuditLogAuthTypeAPIToken, APITokenID: 1})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		entries := getLatestAuditLogEntries(t, 5)
+		require.Len(t, entries, 1)
+		assert.Equal(t, task.ID, entries[0].EntityID)
+		assert.Equal(t, AuditLogActionCreated, entries[0].Action)
+		assert.Equal(t, AuditLogAuthTypeAPIToken, entries[0].AuthType)
+		assert.Equal(t, int64(1), entries[0].APITokenID)
+		assert.Nil(t, entries[0].Changes["title"].Old)
+		assert.Equal(t, "new task", entries[0].Changes["title"].New)
+	})
+	t.Run("through the web handler with an api token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodPut, "/", nil), httptest.NewRecorder())
+		c.Set("api_token", &APIToken{ID: 1})
+
+		task := &Task{ProjectID: 1, Title: "new task"}
+		after, err := AuditLogChangeRecorder{}.BeforeChange(s, c, u, task, web.ChangeKindCreated)
+		require.NoError(t, err)
+		require.NotNil(t, after)
+		err = task.Create(s, u)
+		require.NoError(t, err)
+		err = after(s)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		entries := getLatestAuditLogEntries(t, 5)
+		require.Len(t, entries, 1)
+		assert.Equal(t, AuditLogActionCreated, entries[0].Action)
+		assert.Equal(t, AuditLogAuthTypeAPIToken, entries[0].AuthType)
+		assert.Equal(t, int64(1), entries[0].APITokenID)
+	})
+	t.Run("delete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		recorder, err := NewAuditLogRecorder(s, task, AuditLogActionDeleted)
+		require.NoError(t, err)
+		err = task.Delete(s, u)
+		require.NoError(t, err)
+		err = recorder.Record(s, NewAuditLogActor(u))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		entries := getLatestAuditLogEntries(t, 5)
+		require.Len(t, entries, 1)
+		assert.Equal(t, AuditLogActionDeleted, entries[0].Action)
+		assert.Equal(t, int64(1), entries[0].ProjectID)
+		assert.Equal(t, "task #1", entries[0].Changes["title"].Old)
+		assert.Nil(t, entries[0].Changes["title"].New)
+	})
+	t.Run("bulk update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		bt := &BulkTask{
+			TaskIDs: []int64{1, 2},
+			Fields:  []string{"priority"},
+			Values:  &Task{Priority: 3},
+		}
+		recorder, err := NewAuditLogRecorder(s, bt, AuditLogActionUpdated)
+		require.NoError(t, err)
+		err = bt.Update(s, u)
+		require.NoError(t, err)
+		err = recorder.Record(s, NewAuditLogActor(u))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		entries := getLatestAuditLogEntries(t, 5)
+		require.Len(t, entries, 2)
+		assert.Equal(t, int64(1), entries[0].EntityID)
+		assert.Equal(t, int64(2), entries[1].EntityID)
+		for _, entry := range entries {
+			assert.InDelta(t, float64(3), entry.Changes["priority"].New, 0)
+		}
+	})
+	t.Run("task assignee by link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share := &LinkSharing{ID: 3, ProjectID: 3, Permission: PermissionAdmin}
+		ta := &TaskAssginee{TaskID: 32, UserID: 1}
+		recorder, err := NewAuditLogRecorder(s, ta, AuditLogActionCreated)
+		require.NoError(t, err)
+		err = ta.Create(s, share)
+		require.NoError(t, err)
+		err = recorder.Record(s, NewAuditLogActor(share))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		entries := getLatestAuditLogEntries(t, 5)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "task_assignee", entries[0].EntityKind)
+		assert.Equal(t, int64(1), entries[0].EntityID)
+		assert.Equal(t, int64(32), entries[0].TaskID)
+		assert.Equal(t, int64(3), entries[0].ProjectID)
+		assert.Equal(t, AuditLogAuthTypeLinkShare, entries[0].AuthType)
+		assert.Equal(t, int64(-3), entries[0].DoerID)
+	})
+	t.Run("not auditable", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		recorder, err := NewAuditLogRecorder(s, &Label{ID: 1}, AuditLogActionUpdated)
+		require.NoError(t, err)
+		assert.Nil(t, recorder)
+		err = recorder.Record(s, NewAuditLogActor(u))
+		require.NoError(t, err)
+	})
+}
+
+func TestTaskHistory_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		th := &TaskHistory{TaskID: 1}
+		result, resultCount, total, err := th.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		entries := result.([]*AuditLogEntry)
+		assert.Equal(t, 3, resultCount)
+		assert.Equal(t, int64(3), total)
+		// Newest first
+		assert.Equal(t, int64(3), entries[0].ID)
+		assert.Equal(t, int64(2), entries[1].ID)
+		assert.Equal(t, int64(1), entries[2].ID)
+		assert.Equal(t, int64(-1), entries[0].Doer.ID)
+		assert.Equal(t, "user1", entries[1].Doer.Username)
+		assert.Equal(t, "Lorem Ipsum", entries[1].Changes["description"].New)
+	})
+	t.Run("pagination", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		th := &TaskHistory{TaskID: 1}
+		result, resultCount, total, err := th.ReadAll(s, u, "", 2, 2)
+		require.NoError(t, err)
+		entries := result.([]*AuditLogEntry)
+		assert.Equal(t, 1, resultCount)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, int64(1), entries[0].ID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		th := &TaskHistory{TaskID: 14}
+		_, _, _, err := th.ReadAll(s, u, "", 0, 50)
+		require.Error(t, err)
+	})
+}
+
+func TestProjectHistory_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ph := &ProjectHistory{ProjectID: 1}
+		result, resultCount, _, err := ph.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		entries := result.([]*AuditLogEntry)
+		assert.Equal(t, 4, resultCount)
+		assert.Equal(t, int64(4), entries[0].ID)
+		assert.Equal(t, "project", entries[0].EntityKind)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ph := &ProjectHistory{ProjectID: 2}
+		_, _, _, err := ph.ReadAll(s, u, "", 0, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
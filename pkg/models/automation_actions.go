@@ -0,0 +1,331 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// AutomationActionType defines what an action of an automation rule does
+type AutomationActionType string
+
+const (
+	// AutomationActionSetField changes a field of the task.
+	AutomationActionSetField AutomationActionType = "set_field"
+	// AutomationActionAddLabel adds a label to the task.
+	AutomationActionAddLabel AutomationActionType = "add_label"
+	// AutomationActionRemoveLabel removes a label from the task.
+	AutomationActionRemoveLabel AutomationActionType = "remove_label"
+	// AutomationActionAssign assigns a user to the task.
+	AutomationActionAssign AutomationActionType = "assign"
+	// AutomationActionUnassign removes an assignee or all assignees from the task.
+	AutomationActionUnassign AutomationActionType = "unassign"
+	// AutomationActionMoveBucket moves the task into a bucket of a kanban view.
+	AutomationActionMoveBucket AutomationActionType = "move_bucket"
+	// AutomationActionAddComment adds a comment to the task.
+	AutomationActionAddComment AutomationActionType = "add_comment"
+	// AutomationActionCreateTask creates a follow-up task.
+	AutomationActionCreateTask AutomationActionType = "create_task"
+)
+
+// The fields an automation rule can change with a set_field action.
+var automationSettableFields = map[string]bool{
+	"title":        true,
+	"description":  true,
+	"done":         true,
+	"priority":     true,
+	"percent_done": true,
+	"hex_color":    true,
+	"due_date":     true,
+}
+
+// AutomationAction is a single action of an automation rule. Which fields are used depends on the type of the action.
+type AutomationAction struct {
+	// What the action does. One of `set_field`, `add_label`, `remove_label`, `assign`, `unassign`, `move_bucket`,
+	// `add_comment` or `create_task`.
+	Type AutomationActionType `json:"type"`
+	// For set_field: the field to change. One of `title`, `description`, `done`, `priority`, `percent_done`,
+	// `hex_color` or `due_date`.
+	Field string `json:"field,omitempty"`
+	// For set_field: the new value of the field. For `due_date` the number of seconds after the rule ran, leave it
+	// empty to remove the due date.
+	Value string `json:"value,omitempty"`
+	// For add_label and remove_label: the label.
+	LabelID int64 `json:"label_id,omitempty"`
+	// For assign and unassign: the user. Unassign without a user removes all assignees.
+	UserID int64 `json:"user_id,omitempty"`
+	// For move_bucket: the bucket to move the task into. It must belong to a kanban view of the project.
+	BucketID int64 `json:"bucket_id,omitempty"`
+	// For add_comment: the text of the comment.
+	Comment string `json:"comment,omitempty"`
+	// For create_task: the title of the new task.
+	Title string `json:"title,omitempty"`
+	// For create_task: the description of the new task.
+	Description string `json:"description,omitempty"`
+	// For create_task: the project of the new task. Defaults to the project of the task which triggered the rule.
+	ProjectID int64 `json:"project_id,omitempty"`
+	// For create_task: the due date of the new task as the number of seconds after the rule ran. 0 for no due date.
+	DueAfter int64 `json:"due_after,omitempty"`
+}
+
+// parseValue returns the value of a set_field action in the type of its field.
+func (aa *AutomationAction) parseValue() (value interface{}, err error) {
+	switch aa.Field {
+	case "done":
+		return strconv.ParseBool(aa.Value)
+	case "priority":
+		return strconv.ParseInt(aa.Value, 10, 64)
+	case "percent_done":
+		return strconv.ParseFloat(aa.Value, 64)
+	case "due_date":
+		if aa.Value == "" {
+			return int64(0), nil
+		}
+		return strconv.ParseInt(aa.Value, 10, 64)
+	default:
+		return aa.Value, nil
+	}
+}
+
+//nolint:gocyclo
+func (aa *AutomationAction) validate(s *xorm.Session, a web.Auth, projectID int64) (err error) {
+	switch aa.Type {
+	case AutomationActionSetField:
+		if !automationSettableFields[aa.Field] {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "The field "+aa.Field+" cannot be set by an automation rule.")
+		}
+		if aa.Field == "title" && aa.Value == "" {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "The title of a task cannot be empty.")
+		}
+		if _, err := aa.parseValue(); err != nil {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "The value "+aa.Value+" is invalid for the field "+aa.Field+".")
+		}
+	case AutomationActionAddLabel, AutomationActionRemoveLabel:
+		if aa.LabelID == 0 {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "Adding or removing a label needs a label.")
+		}
+		has, _, err := (&Label{ID: aa.LabelID}).hasAccessToLabel(s, a)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrUserHasNoAccessToLabel{LabelID: aa.LabelID, UserID: a.GetID()}
+		}
+	case AutomationActionAssign:
+		if aa.UserID == 0 {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "Assigning a user needs a user.")
+		}
+		_, err = user.GetUserByID(s, aa.UserID)
+		if err != nil {
+			return err
+		}
+	case AutomationActionUnassign:
+	case AutomationActionMoveBucket:
+		bucket, err := getBucketByID(s, aa.BucketID)
+		if err != nil {
+			return err
+		}
+		view, err := GetProjectViewByID(s, bucket.ProjectViewID)
+		if err != nil {
+			return err
+		}
+		if view.ProjectID != projectID || view.ViewKind != ProjectViewKindKanban || view.BucketConfigurationMode != BucketConfigurationModeManual {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "The bucket must belong to a kanban view of this project.")
+		}
+	case AutomationActionAddComment:
+		if aa.Comment == "" {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "A comment cannot be empty.")
+		}
+	case AutomationActionCreateTask:
+		if aa.Title == "" {
+			return InvalidFieldErrorWithMessage([]string{"actions"}, "A new task needs a title.")
+		}
+		if aa.ProjectID != 0 {
+			can, err := (&Project{ID: aa.ProjectID}).CanWrite(s, a)
+			if err != nil {
+				return err
+			}
+			if !can {
+				return ErrGenericForbidden{}
+			}
+		}
+	default:
+		return InvalidFieldErrorWithMessage([]string{"actions"}, "The action "+string(aa.Type)+" does not exist.")
+	}
+
+	return nil
+}
+
+// run runs the action on a task. It returns whether the action changed anything. Actions which would not change
+// anything are skipped so that they don't cause any events, which keeps rules from triggering each other endlessly.
+//
+//nolint:gocyclo
+func (aa *AutomationAction) run(s *xorm.Session, doer *user.User, task *Task) (changed bool, err error) {
+	switch aa.Type {
+	case AutomationActionSetField:
+		return aa.setField(s, doer, task)
+	case AutomationActionAddLabel:
+		exists, err := s.Exist(&LabelTask{LabelID: aa.LabelID, TaskID: task.ID})
+		if err != nil || exists {
+			return false, err
+		}
+		has, _, err := (&Label{ID: aa.LabelID}).hasAccessToLabel(s, doer)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			return false, ErrUserHasNoAccessToLabel{LabelID: aa.LabelID, UserID: doer.ID}
+		}
+		return true, (&LabelTask{LabelID: aa.LabelID, TaskID: task.ID}).Create(s, doer)
+	case AutomationActionRemoveLabel:
+		exists, err := s.Exist(&LabelTask{LabelID: aa.LabelID, TaskID: task.ID})
+		if err != nil || !exists {
+			return false, err
+		}
+		return true, (&LabelTask{LabelID: aa.LabelID, TaskID: task.ID}).Delete(s, doer)
+	case AutomationActionAssign:
+		exists, err := s.Exist(&TaskAssginee{TaskID: task.ID, UserID: aa.UserID})
+		if err != nil || exists {
+			return false, err
+		}
+		return true, (&TaskAssginee{TaskID: task.ID, UserID: aa.UserID}).Create(s, doer)
+	case AutomationActionUnassign:
+		assignees := []*TaskAssginee{}
+		err = s.Where("task_id = ?", task.ID).Find(&assignees)
+		if err != nil {
+			return false, err
+		}
+		for _, assignee := range assignees {
+			if aa.UserID != 0 && assignee.UserID != aa.UserID {
+				continue
+			}
+			err = (&TaskAssginee{TaskID: task.ID, UserID: assignee.UserID}).Delete(s, doer)
+			if err != nil {
+				return false, err
+			}
+			changed = true
+		}
+		return changed, nil
+	case AutomationActionMoveBucket:
+		bucket, err := getBucketByID(s, aa.BucketID)
+		if err != nil {
+			return false, err
+		}
+		exists, err := s.Exist(&TaskBucket{TaskID: task.ID, ProjectViewID: bucket.ProjectViewID, BucketID: bucket.ID})
+		if err != nil || exists {
+			return false, err
+		}
+		return true, (&TaskBucket{
+			TaskID:        task.ID,
+			BucketID:      bucket.ID,
+			ProjectViewID: bucket.ProjectViewID,
+			ProjectID:     task.ProjectID,
+			Task:          task,
+		}).Update(s, doer)
+	case AutomationActionAddComment:
+		return true, (&TaskComment{TaskID: task.ID, Comment: aa.Comment}).Create(s, doer)
+	case AutomationActionCreateTask:
+		return true, aa.createTask(s, doer, task)
+	}
+
+	return false, nil
+}
+
+func (aa *AutomationAction) setField(s *xorm.Session, doer *user.User, task *Task) (changed bool, err error) {
+	value, err := aa.parseValue()
+	if err != nil {
+		return false, err
+	}
+
+	// The task is updated from its stored values so that only the field of the action changes. Assignees and
+	// reminders are part of every update and would otherwise be removed.
+	simpleTask, err := GetTaskByIDSimple(s, task.ID)
+	if err != nil {
+		return false, err
+	}
+	simpleTask.Assignees = task.Assignees
+	simpleTask.Reminders = task.Reminders
+	task = &simpleTask
+
+	switch aa.Field {
+	case "title":
+		changed = task.Title != value.(string)
+		task.Title = value.(string)
+	case "description":
+		changed = task.Description != value.(string)
+		task.Description = value.(string)
+	case "done":
+		changed = task.Done != value.(bool)
+		task.Done = value.(bool)
+	case "priority":
+		changed = task.Priority != value.(int64)
+		task.Priority = value.(int64)
+	case "percent_done":
+		changed = task.PercentDone != value.(float64)
+		task.PercentDone = value.(float64)
+	case "hex_color":
+		color := utils.NormalizeHex(value.(string))
+		changed = task.HexColor != color
+		task.HexColor = color
+	case "due_date":
+		dueDate := time.Time{}
+		if value.(int64) != 0 {
+			dueDate = time.Now().Add(time.Duration(value.(int64)) * time.Second).Truncate(time.Second)
+		}
+		changed = !task.DueDate.Equal(dueDate)
+		task.DueDate = dueDate
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	return true, task.updateSingleTask(s, doer, []string{aa.Field})
+}
+
+// createTask creates a follow-up task which follows the task the rule ran for.
+func (aa *AutomationAction) createTask(s *xorm.Session, doer *user.User, task *Task) (err error) {
+	newTask := &Task{
+		Title:       aa.Title,
+		Description: aa.Description,
+		ProjectID:   aa.ProjectID,
+	}
+	if newTask.ProjectID == 0 {
+		newTask.ProjectID = task.ProjectID
+	}
+	if aa.DueAfter != 0 {
+		newTask.DueDate = time.Now().Add(time.Duration(aa.DueAfter) * time.Second).Truncate(time.Second)
+	}
+
+	err = createTask(s, newTask, doer, false, true)
+	if err != nil {
+		return err
+	}
+
+	return (&TaskRelation{
+		TaskID:       newTask.ID,
+		OtherTaskID:  task.ID,
+		RelationKind: RelationKindFollows,
+	}).Create(s, doer)
+}
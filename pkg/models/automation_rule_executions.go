@@ -0,0 +1,251 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// How often a rule may run for the same task within automationLoopWindow. Rules which change a task in a way that
+// triggers them again, or two rules undoing each other's changes, are stopped once they reach this limit.
+const (
+	automationLoopLimit  = 10
+	automationLoopWindow = time.Minute
+)
+
+// AutomationExecutionStatus is the outcome of running an automation rule
+type AutomationExecutionStatus string
+
+const (
+	// AutomationExecutionStatusSuccess means all actions of the rule ran.
+	AutomationExecutionStatusSuccess AutomationExecutionStatus = "success"
+	// AutomationExecutionStatusFailed means an action failed and none of the changes were saved.
+	AutomationExecutionStatusFailed AutomationExecutionStatus = "failed"
+	// AutomationExecutionStatusLoopDetected means the rule ran too often for the same task and was stopped.
+	AutomationExecutionStatusLoopDetected AutomationExecutionStatus = "loop_detected"
+)
+
+// AutomationRuleExecution is a single run of an automation rule for a task whose event matched the rule.
+type AutomationRuleExecution struct {
+	// The unique, numeric id of this execution.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The rule which ran.
+	RuleID    int64 `xorm:"bigint not null index" json:"rule_id" param:"automation"`
+	ProjectID int64 `xorm:"-" json:"-" param:"project"`
+	// The task the rule ran for.
+	TaskID int64 `xorm:"bigint not null index" json:"task_id"`
+	// The event which triggered the rule.
+	EventName string `xorm:"varchar(250) not null" json:"event_name"`
+	// Either `success`, `failed` or `loop_detected`.
+	Status AutomationExecutionStatus `xorm:"varchar(20) not null" json:"status"`
+	// How many actions changed the task. Actions which would not change anything are skipped.
+	ChangedActions int64 `xorm:"bigint not null default 0" json:"changed_actions"`
+	// The error which made the execution fail.
+	Error string `xorm:"text null" json:"error"`
+
+	// A timestamp when the rule ran.
+	Created time.Time `xorm:"created not null index" json:"created"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for automation rule executions
+func (*AutomationRuleExecution) TableName() string {
+	return "automation_rule_executions"
+}
+
+// ReadAll returns the execution log of an automation rule
+// @Summary Get the execution log of an automation rule
+// @Description Returns every time the rule ran because a task matched its event and filter, newest first.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param id path int true "Project ID"
+// @Param automation path int true "Rule ID"
+// @Success 200 {array} models.AutomationRuleExecution "The executions"
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 404 {object} web.HTTPError "The rule does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations/{automation}/executions [get]
+func (e *AutomationRuleExecution) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, err := canDoExistingAutomationRule(s, a, e.RuleID, e.ProjectID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	executions := []*AutomationRuleExecution{}
+	err = s.
+		Where("rule_id = ?", e.RuleID).
+		OrderBy("id desc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&executions)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	total, err := s.Where("rule_id = ?", e.RuleID).Count(&AutomationRuleExecution{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return executions, len(executions), total, nil
+}
+
+// matches checks if a task matches the filter of the rule.
+func (r *AutomationRule) matches(s *xorm.Session, doer *user.User, taskID int64) (bool, error) {
+	if r.Filter == "" {
+		return true, nil
+	}
+
+	filters, err := getTaskFiltersFromFilterString(r.Filter, doer.Timezone)
+	if err != nil {
+		return false, err
+	}
+	cond, err := convertFiltersToDBFilterCond(filters, false)
+	if err != nil {
+		return false, err
+	}
+
+	return s.
+		Table("tasks").
+		Join("LEFT", "task_buckets", "task_buckets.task_id = tasks.id").
+		Where(builder.And(cond, builder.Eq{"tasks.id": taskID})).
+		Exist()
+}
+
+// runActions runs all actions of the rule on a task as the user who created the rule.
+func (r *AutomationRule) runActions(s *xorm.Session, doer *user.User, taskID int64) (changed int64, err error) {
+	can, err := (&Task{ID: taskID}).CanUpdate(s, doer)
+	if err != nil {
+		return 0, err
+	}
+	if !can {
+		return 0, ErrGenericForbidden{}
+	}
+
+	for _, action := range r.Actions {
+		// Reloaded before every action to see the changes of the previous ones
+		task := &Task{ID: taskID}
+		err = task.ReadOne(s, doer)
+		if err != nil {
+			return 0, err
+		}
+
+		actionChanged, err := action.run(s, doer, task)
+		if err != nil {
+			return 0, err
+		}
+		if actionChanged {
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// run runs the rule for a task if it matches the filter of the rule and records the execution. The changes of all
+// actions are only saved if every one of them succeeded.
+func (r *AutomationRule) run(eventName string, taskID int64) (err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	execution := &AutomationRuleExecution{
+		RuleID:    r.ID,
+		TaskID:    taskID,
+		EventName: eventName,
+		Status:    AutomationExecutionStatusSuccess,
+	}
+
+	recentRuns, err := s.
+		Where("rule_id = ? AND task_id = ? AND status != ? AND created > ?",
+			r.ID, taskID, AutomationExecutionStatusLoopDetected, time.Now().Add(-automationLoopWindow)).
+		Count(&AutomationRuleExecution{})
+	if err != nil {
+		return err
+	}
+	if recentRuns >= automationLoopLimit {
+		log.Warningf("Automation rule %d ran %d times for task %d within %s, not running it again", r.ID, recentRuns, taskID, automationLoopWindow)
+		execution.Status = AutomationExecutionStatusLoopDetected
+		_, err = s.Insert(execution)
+		return err
+	}
+
+	doer, err := user.GetUserByID(s, r.CreatedByID)
+	if err != nil && !user.IsErrUserDoesNotExist(err) {
+		return err
+	}
+	if err != nil {
+		log.Debugf("Creator %d of automation rule %d does not exist, not running it", r.CreatedByID, r.ID)
+		return nil
+	}
+
+	matches, err := r.matches(s, doer, taskID)
+	if err != nil {
+		// An invalid filter would fail every time, so this is only recorded
+		log.Errorf("Could not check the filter of automation rule %d for task %d: %s", r.ID, taskID, err)
+		execution.Status = AutomationExecutionStatusFailed
+		execution.Error = err.Error()
+		_, err = s.Insert(execution)
+		return err
+	}
+	if !matches {
+		return nil
+	}
+
+	err = s.Begin()
+	if err != nil {
+		return err
+	}
+
+	execution.ChangedActions, err = r.runActions(s, doer, taskID)
+	if err != nil {
+		log.Errorf("Could not run automation rule %d for task %d: %s", r.ID, taskID, err)
+		_ = s.Rollback()
+
+		execution.Status = AutomationExecutionStatusFailed
+		execution.ChangedActions = 0
+		execution.Error = err.Error()
+
+		logSession := db.NewSession()
+		defer logSession.Close()
+		_, err = logSession.Insert(execution)
+		return err
+	}
+
+	_, err = s.Insert(execution)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	return s.Commit()
+}
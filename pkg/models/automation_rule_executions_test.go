@@ -0,0 +1,357 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestAutomationRule(t *testing.T, actions ...*AutomationAction) *AutomationRule {
+	s := db.NewSession()
+	defer s.Close()
+
+	rule := &AutomationRule{
+		ProjectID:   1,
+		Title:       "Test",
+		Event:       "task.updated",
+		Actions:     actions,
+		CreatedByID: 1,
+	}
+	_, err := s.Insert(rule)
+	require.NoError(t, err)
+	return rule
+}
+
+func TestAutomationListener(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("matching task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		events.TestListener(t, &TaskUpdatedEvent{Task: &Task{ID: 1, ProjectID: 1}, Doer: u}, &AutomationListener{EventName: "task.updated"})
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"priority": 4,
+		}, false)
+		db.AssertExists(t, "task_assignees", map[string]interface{}{
+			"task_id": 1,
+			"user_id": 1,
+		}, false)
+		db.AssertExists(t, "automation_rule_executions", map[string]interface{}{
+			"rule_id":         1,
+			"task_id":         1,
+			"event_name":      "task.updated",
+			"status":          AutomationExecutionStatusSuccess,
+			"changed_actions": 2,
+		}, false)
+		// The disabled rule did not run
+		db.AssertMissing(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+			"comment": "Updated",
+		})
+		events.AssertDispatched(t, &TaskAssigneeCreatedEvent{})
+	})
+	t.Run("task not matching the filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		events.TestListener(t, &TaskUpdatedEvent{Task: &Task{ID: 3, ProjectID: 1}, Doer: u}, &AutomationListener{EventName: "task.updated"})
+
+		db.AssertMissing(t, "automation_rule_executions", map[string]interface{}{
+			"task_id": 3,
+		})
+	})
+	t.Run("other event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		events.TestListener(t, &TaskCreatedEvent{Task: &Task{ID: 1, ProjectID: 1}, Doer: u}, &AutomationListener{EventName: "task.created"})
+
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"priority": 4,
+		})
+	})
+	t.Run("nothing to change", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		listener := &AutomationListener{EventName: "task.updated"}
+		events.TestListener(t, &TaskUpdatedEvent{Task: &Task{ID: 1, ProjectID: 1}, Doer: u}, listener)
+		events.ClearDispatchedEvents()
+		events.TestListener(t, &TaskUpdatedEvent{Task: &Task{ID: 1, ProjectID: 1}, Doer: u}, listener)
+
+		// The second run changed nothing and therefore did not trigger any events which could run the rule again
+		assert.Equal(t, 0, events.CountDispatchedEvents((&TaskUpdatedEvent{}).Name()))
+		db.AssertExists(t, "automation_rule_executions", map[string]interface{}{
+			"rule_id":         1,
+			"task_id":         1,
+			"changed_actions": 0,
+		}, false)
+	})
+	t.Run("loop protection", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		for i := 0; i < automationLoopLimit; i++ {
+			_, err := s.Insert(&AutomationRuleExecution{
+				RuleID:    1,
+				TaskID:    1,
+				EventName: "task.updated",
+				Status:    AutomationExecutionStatusSuccess,
+			})
+			require.NoError(t, err)
+		}
+
+		events.TestListener(t, &TaskUpdatedEvent{Task: &Task{ID: 1, ProjectID: 1}, Doer: u}, &AutomationListener{EventName: "task.updated"})
+
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"priority": 4,
+		})
+		db.AssertExists(t, "automation_rule_executions", map[string]interface{}{
+			"rule_id": 1,
+			"task_id": 1,
+			"status":  AutomationExecutionStatusLoopDetected,
+		}, false)
+	})
+	t.Run("runs again after the loop window", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		for i := 0; i < automationLoopLimit; i++ {
+			_, err := s.NoAutoTime().Insert(&AutomationRuleExecution{
+				RuleID:    1,
+				TaskID:    1,
+				EventName: "task.updated",
+				Status:    AutomationExecutionStatusSuccess,
+				Created:   time.Now().Add(-2 * automationLoopWindow),
+			})
+			require.NoError(t, err)
+		}
+
+		events.TestListener(t, &TaskUpdatedEvent{Task: &Task{ID: 1, ProjectID: 1}, Doer: u}, &AutomationListener{EventName: "task.updated"})
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"priority": 4,
+		}, false)
+	})
+	t.Run("failing action", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		// User 2 does not have access to the project and can therefore not be assigned
+		rule := createTestAutomationRule(t,
+			&AutomationAction{Type: AutomationActionSetField, Field: "priority", Value: "5"},
+			&AutomationAction{Type: AutomationActionAssign, UserID: 2},
+		)
+		err := rule.run("task.updated", 3)
+		require.NoError(t, err)
+
+		// The changes of the first action were not saved
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       3,
+			"priority": 100,
+		}, false)
+		db.AssertExists(t, "automation_rule_executions", map[string]interface{}{
+			"rule_id": rule.ID,
+			"task_id": 3,
+			"status":  AutomationExecutionStatusFailed,
+		}, false)
+	})
+	t.Run("creator without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		// User 2 cannot edit tasks of project 1
+		rule := &AutomationRule{
+			ProjectID:   1,
+			Title:       "No access",
+			Event:       "task.updated",
+			Actions:     []*AutomationAction{{Type: AutomationActionSetField, Field: "done", Value: "true"}},
+			CreatedByID: 2,
+		}
+		_, err := s.Insert(rule)
+		require.NoError(t, err)
+
+		err = rule.run("task.updated", 1)
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"id":   1,
+			"done": true,
+		})
+		db.AssertExists(t, "automation_rule_executions", map[string]interface{}{
+			"rule_id": rule.ID,
+			"status":  AutomationExecutionStatusFailed,
+		}, false)
+	})
+}
+
+func TestAutomationAction_run(t *testing.T) {
+	t.Run("set done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		rule := createTestAutomationRule(t, &AutomationAction{Type: AutomationActionSetField, Field: "done", Value: "true"})
+		err := rule.run("task.updated", 1)
+		require.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":   1,
+			"done": true,
+		}, false)
+		// Other fields are kept
+		db.AssertExists(t, "task_assignees", map[string]interface{}{
+			"task_id": 30,
+		}, false)
+	})
+	t.Run("set due date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		rule := createTestAutomationRule(t, &AutomationAction{Type: AutomationActionSetField, Field: "due_date", Value: "86400"})
+		err := rule.run("task.updated", 1)
+		require.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+		task, err := GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), task.DueDate, time.Minute)
+	})
+	t.Run("labels", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		rule := createTestAutomationRule(t,
+			&AutomationAction{Type: AutomationActionAddLabel, LabelID: 1},
+			&AutomationAction{Type: AutomationActionRemoveLabel, LabelID: 4},
+		)
+		err := rule.run("task.updated", 1)
+		require.NoError(t, err)
+
+		db.AssertExists(t, "label_tasks", map[string]interface{}{
+			"task_id":  1,
+			"label_id": 1,
+		}, false)
+		db.AssertMissing(t, "label_tasks", map[string]interface{}{
+			"task_id":  1,
+			"label_id": 4,
+		})
+	})
+	t.Run("unassign everyone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		rule := createTestAutomationRule(t, &AutomationAction{Type: AutomationActionUnassign})
+		err := rule.run("task.updated", 30)
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "task_assignees", map[string]interface{}{
+			"task_id": 30,
+		})
+		db.AssertExists(t, "automation_rule_executions", map[string]interface{}{
+			"rule_id":         rule.ID,
+			"changed_actions": 1,
+		}, false)
+	})
+	t.Run("move bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		rule := createTestAutomationRule(t, &AutomationAction{Type: AutomationActionMoveBucket, BucketID: 3})
+		err := rule.run("task.updated", 1)
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":         1,
+			"bucket_id":       3,
+			"project_view_id": 4,
+		}, false)
+		// Bucket 3 is the done bucket
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":   1,
+			"done": true,
+		}, false)
+	})
+	t.Run("comment and follow-up task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		rule := createTestAutomationRule(t,
+			&AutomationAction{Type: AutomationActionAddComment, Comment: "Please review"},
+			&AutomationAction{Type: AutomationActionCreateTask, Title: "Review task #1", DueAfter: 3600},
+		)
+		err := rule.run("task.updated", 1)
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"task_id":   1,
+			"comment":   "Please review",
+			"author_id": 1,
+		}, false)
+
+		s := db.NewSession()
+		defer s.Close()
+		followUp := &Task{}
+		has, err := s.Where("title = ?", "Review task #1").Get(followUp)
+		require.NoError(t, err)
+		require.True(t, has)
+		assert.Equal(t, int64(1), followUp.ProjectID)
+		assert.False(t, followUp.DueDate.IsZero())
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       followUp.ID,
+			"other_task_id": 1,
+			"relation_kind": RelationKindFollows,
+		}, false)
+	})
+}
+
+func TestAutomationRuleExecution_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	execution := &AutomationRuleExecution{RuleID: 1, ProjectID: 1}
+	result, _, total, err := execution.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.NoError(t, err)
+	executions := result.([]*AutomationRuleExecution)
+	require.Len(t, executions, 1)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, AutomationExecutionStatusSuccess, executions[0].Status)
+
+	_, _, _, err = (&AutomationRuleExecution{RuleID: 3, ProjectID: 2}).ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.Error(t, err)
+}
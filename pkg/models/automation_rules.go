@@ -0,0 +1,334 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// AutomationRule runs actions on a task whenever an event happens to a task of its project and the task matches the
+// rule's filter.
+type AutomationRule struct {
+	// The unique, numeric id of this rule.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"automation"`
+	// The project this rule belongs to. Only events of tasks in this project trigger the rule.
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// A name for the rule.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The event which triggers the rule, for example `task.updated` or `task.assignee.created`. Check out
+	// /automations/events for all possible events.
+	Event string `xorm:"varchar(250) not null index" json:"event"`
+	// The condition a task must match for the actions to run, in the same syntax as task filters, for example
+	// `labels in 4 && priority < 4`. The task is checked after the event happened. Leave it empty to run the actions
+	// for every task.
+	Filter string `xorm:"text null" json:"filter"`
+	// The actions to run, in this order.
+	Actions []*AutomationAction `xorm:"json not null" json:"actions"`
+	// Whether the rule is disabled. Disabled rules don't run.
+	IsDisabled bool `xorm:"not null default false" json:"is_disabled"`
+
+	// The user who created the rule. All actions are run as this user and only if they can still edit the task.
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+	CreatedByID int64      `xorm:"bigint not null index" json:"-"`
+
+	// A timestamp when this rule was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this rule was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for automation rules
+func (*AutomationRule) TableName() string {
+	return "automation_rules"
+}
+
+var availableAutomationEvents map[string]bool
+var availableAutomationEventsLock *sync.Mutex
+
+func init() {
+	availableAutomationEvents = make(map[string]bool)
+	availableAutomationEventsLock = &sync.Mutex{}
+}
+
+// RegisterEventForAutomation makes an event available as the trigger of automation rules. Only events with a task
+// can be used.
+func RegisterEventForAutomation(event events.Event) {
+	availableAutomationEventsLock.Lock()
+	defer availableAutomationEventsLock.Unlock()
+
+	availableAutomationEvents[event.Name()] = true
+	events.RegisterListener(event.Name(), &AutomationListener{
+		EventName: event.Name(),
+	})
+}
+
+// GetAvailableAutomationEvents returns all events which can trigger an automation rule
+func GetAvailableAutomationEvents() []string {
+	evts := []string{}
+	for e := range availableAutomationEvents {
+		evts = append(evts, e)
+	}
+
+	sort.Strings(evts)
+
+	return evts
+}
+
+func getAutomationRuleByID(s *xorm.Session, id int64) (rule *AutomationRule, err error) {
+	rule = &AutomationRule{}
+	exists, err := s.Where("id = ?", id).Get(rule)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrAutomationRuleDoesNotExist{ID: id}
+	}
+	return
+}
+
+// validate checks the event, filter and actions of a rule. The actions are checked with the permissions of the
+// given user.
+func (r *AutomationRule) validate(s *xorm.Session, a web.Auth) (err error) {
+	if _, has := availableAutomationEvents[r.Event]; !has {
+		return InvalidFieldErrorWithMessage([]string{"event"}, "This event cannot trigger automation rules.")
+	}
+
+	if r.Filter != "" {
+		filters, err := getTaskFiltersFromFilterString(r.Filter, "")
+		if err != nil {
+			return err
+		}
+		_, err = convertFiltersToDBFilterCond(filters, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(r.Actions) == 0 {
+		return InvalidFieldErrorWithMessage([]string{"actions"}, "A rule needs at least one action.")
+	}
+	for _, action := range r.Actions {
+		err = action.validate(s, a, r.ProjectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Create creates an automation rule
+// @Summary Create an automation rule
+// @Description Creates a rule which runs actions on tasks of the project whenever an event happens to them and they match the filter of the rule. All actions are run as the user who created the rule.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param rule body models.AutomationRule true "The rule with its trigger, condition and actions."
+// @Success 201 {object} models.AutomationRule "The created rule."
+// @Failure 400 {object} web.HTTPError "Invalid rule object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations [put]
+func (r *AutomationRule) Create(s *xorm.Session, a web.Auth) (err error) {
+	err = r.validate(s, a)
+	if err != nil {
+		return err
+	}
+
+	r.ID = 0
+	r.CreatedByID = a.GetID()
+	_, err = s.Insert(r)
+	if err != nil {
+		return err
+	}
+
+	r.CreatedBy, err = user.GetUserByID(s, a.GetID())
+	return
+}
+
+// ReadAll returns all automation rules of a project
+// @Summary Get all automation rules of a project
+// @Description Returns all automation rules of a project.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param id path int true "Project ID"
+// @Success 200 {array} models.AutomationRule "The rules"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations [get]
+func (r *AutomationRule) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := (&Project{ID: r.ProjectID}).CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.Eq{"project_id": r.ProjectID}
+
+	rules := []*AutomationRule{}
+	err = s.Where(cond).
+		OrderBy("id asc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&rules)
+	if err != nil {
+		return
+	}
+
+	total, err := s.Where(cond).
+		Count(&AutomationRule{})
+	if err != nil {
+		return
+	}
+
+	userIDs := []int64{}
+	for _, rule := range rules {
+		userIDs = append(userIDs, rule.CreatedByID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, rule := range rules {
+		if createdBy, has := users[rule.CreatedByID]; has {
+			rule.CreatedBy = createdBy
+		}
+	}
+
+	return rules, len(rules), total, err
+}
+
+// ReadOne returns an automation rule
+// @Summary Get one automation rule
+// @Description Returns one automation rule of a project.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param automation path int true "Rule ID"
+// @Success 200 {object} models.AutomationRule "The rule"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The rule does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations/{automation} [get]
+func (r *AutomationRule) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	rule, err := getAutomationRuleByID(s, r.ID)
+	if err != nil {
+		return err
+	}
+	*r = *rule
+
+	r.CreatedBy, err = user.GetUserByID(s, r.CreatedByID)
+	if user.IsErrUserDoesNotExist(err) {
+		return nil
+	}
+	return
+}
+
+// Update changes an automation rule
+// @Summary Update an automation rule
+// @Description Changes the title, trigger, condition or actions of a rule or disables it. The actions of the rule will be run as the user who changed it.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param automation path int true "Rule ID"
+// @Param rule body models.AutomationRule true "The rule with updated values."
+// @Success 200 {object} models.AutomationRule "The updated rule."
+// @Failure 400 {object} web.HTTPError "Invalid rule object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 404 {object} web.HTTPError "The rule does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations/{automation} [post]
+func (r *AutomationRule) Update(s *xorm.Session, a web.Auth) (err error) {
+	err = r.validate(s, a)
+	if err != nil {
+		return err
+	}
+
+	// The actions were checked with the permissions of the current user, so they are run as them from now on
+	r.CreatedByID = a.GetID()
+	_, err = s.
+		Where("id = ?", r.ID).
+		Cols("title", "event", "filter", "actions", "is_disabled", "created_by_id").
+		Update(r)
+	if err != nil {
+		return err
+	}
+
+	return r.ReadOne(s, a)
+}
+
+// Delete removes an automation rule
+// @Summary Delete an automation rule
+// @Description Deletes an automation rule together with its execution log.
+// @tags automations
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param automation path int true "Rule ID"
+// @Success 200 {object} models.Message "The rule was deleted successfully."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 404 {object} web.HTTPError "The rule does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations/{automation} [delete]
+func (r *AutomationRule) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	return deleteAutomationRules(s, builder.Eq{"id": r.ID})
+}
+
+// deleteAutomationRules removes all automation rules matching the condition together with their execution log.
+func deleteAutomationRules(s *xorm.Session, cond builder.Cond) (err error) {
+	ruleIDs := []int64{}
+	err = s.Table("automation_rules").Where(cond).Cols("id").Find(&ruleIDs)
+	if err != nil {
+		return err
+	}
+	if len(ruleIDs) == 0 {
+		return nil
+	}
+
+	_, err = s.In("rule_id", ruleIDs).Delete(&AutomationRuleExecution{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.In("id", ruleIDs).Delete(&AutomationRule{})
+	return
+}
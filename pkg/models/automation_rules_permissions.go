@@ -0,0 +1,75 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can see an automation rule. Everyone who can see the project can see its rules.
+func (r *AutomationRule) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	rule, err := getAutomationRuleByID(s, r.ID)
+	if err != nil {
+		return false, 0, err
+	}
+	if rule.ProjectID != r.ProjectID {
+		return false, 0, &ErrAutomationRuleDoesNotExist{ID: r.ID}
+	}
+
+	return (&Project{ID: r.ProjectID}).CanRead(s, a)
+}
+
+// CanCreate checks if a user can create automation rules in a project
+func (r *AutomationRule) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return canDoAutomationRules(s, a, r.ProjectID)
+}
+
+// CanUpdate checks if a user can change an automation rule
+func (r *AutomationRule) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return canDoExistingAutomationRule(s, a, r.ID, r.ProjectID)
+}
+
+// CanDelete checks if a user can delete an automation rule
+func (r *AutomationRule) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return canDoExistingAutomationRule(s, a, r.ID, r.ProjectID)
+}
+
+// canDoAutomationRules checks if a user can manage the automation rules of a project, which needs write access to it.
+// Link shares cannot manage rules because the actions of a rule are run as the user who created it.
+func canDoAutomationRules(s *xorm.Session, a web.Auth, projectID int64) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	return (&Project{ID: projectID}).CanWrite(s, a)
+}
+
+// canDoExistingAutomationRule checks if the rule exists, belongs to the project from the request and the user can
+// manage it.
+func canDoExistingAutomationRule(s *xorm.Session, a web.Auth, ruleID, projectID int64) (bool, error) {
+	rule, err := getAutomationRuleByID(s, ruleID)
+	if err != nil {
+		return false, err
+	}
+	if rule.ProjectID != projectID {
+		return false, &ErrAutomationRuleDoesNotExist{ID: ruleID}
+	}
+
+	return canDoAutomationRules(s, a, projectID)
+}
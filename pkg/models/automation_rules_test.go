@@ -0,0 +1,242 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutomationRule_Create(t *testing.T) {
+	RegisterEventForAutomation(&TaskUpdatedEvent{})
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rule := &AutomationRule{
+			ProjectID: 1,
+			Title:     "Done",
+			Event:     "task.updated",
+			Filter:    "bucket_id = 3",
+			Actions: []*AutomationAction{
+				{Type: AutomationActionUnassign},
+				{Type: AutomationActionAddLabel, LabelID: 1},
+				{Type: AutomationActionMoveBucket, BucketID: 1},
+				{Type: AutomationActionCreateTask, Title: "Follow up"},
+			},
+		}
+		can, err := rule.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = rule.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rule.CreatedBy.ID)
+
+		db.AssertExists(t, "automation_rules", map[string]interface{}{
+			"id":            rule.ID,
+			"project_id":    1,
+			"event":         "task.updated",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("invalid event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rule := &AutomationRule{
+			ProjectID: 1,
+			Title:     "Invalid",
+			Event:     "task.exploded",
+			Actions:   []*AutomationAction{{Type: AutomationActionAddComment, Comment: "Boom"}},
+		}
+		err := rule.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("invalid filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rule := &AutomationRule{
+			ProjectID: 1,
+			Title:     "Invalid",
+			Event:     "task.updated",
+			Filter:    "foo = bar",
+			Actions:   []*AutomationAction{{Type: AutomationActionAddComment, Comment: "Hi"}},
+		}
+		err := rule.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskField(err))
+	})
+	t.Run("no actions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rule := &AutomationRule{ProjectID: 1, Title: "Empty", Event: "task.updated"}
+		err := rule.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("invalid actions", func(t *testing.T) {
+		actions := map[string]*AutomationAction{
+			"unknown type":            {Type: "explode"},
+			"unknown field":           {Type: AutomationActionSetField, Field: "created", Value: "now"},
+			"invalid value":           {Type: AutomationActionSetField, Field: "priority", Value: "high"},
+			"empty title":             {Type: AutomationActionSetField, Field: "title"},
+			"empty comment":           {Type: AutomationActionAddComment},
+			"task without title":      {Type: AutomationActionCreateTask},
+			"assign without user":     {Type: AutomationActionAssign},
+			"label without label":     {Type: AutomationActionAddLabel},
+			"bucket of other project": {Type: AutomationActionMoveBucket, BucketID: 4},
+		}
+		for name, action := range actions {
+			t.Run(name, func(t *testing.T) {
+				db.LoadAndAssertFixtures(t)
+				s := db.NewSession()
+				defer s.Close()
+
+				rule := &AutomationRule{
+					ProjectID: 1,
+					Title:     "Invalid",
+					Event:     "task.updated",
+					Actions:   []*AutomationAction{action},
+				}
+				err := rule.Create(s, u)
+				require.Error(t, err)
+				assert.IsType(t, ValidationHTTPError{}, err)
+			})
+		}
+	})
+	t.Run("label without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rule := &AutomationRule{
+			ProjectID: 1,
+			Title:     "Label",
+			Event:     "task.updated",
+			Actions:   []*AutomationAction{{Type: AutomationActionAddLabel, LabelID: 3}},
+		}
+		err := rule.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrUserHasNoAccessToLabel(err))
+	})
+	t.Run("no write access to project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rule := &AutomationRule{ProjectID: 2}
+		can, err := rule.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestAutomationRule_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	rule := &AutomationRule{ProjectID: 1}
+	result, _, total, err := rule.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.NoError(t, err)
+	rules := result.([]*AutomationRule)
+	require.Len(t, rules, 2)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "Urgent tasks", rules[0].Title)
+	require.Len(t, rules[0].Actions, 2)
+	assert.Equal(t, AutomationActionSetField, rules[0].Actions[0].Type)
+	assert.Equal(t, int64(1), rules[0].CreatedBy.ID)
+
+	_, _, _, err = (&AutomationRule{ProjectID: 2}).ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.Error(t, err)
+}
+
+func TestAutomationRule_Permissions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	can, err := (&AutomationRule{ID: 1, ProjectID: 1}).CanUpdate(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	assert.True(t, can)
+
+	can, err = (&AutomationRule{ID: 3, ProjectID: 2}).CanDelete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	assert.False(t, can)
+
+	// The rule does not belong to the project from the request
+	_, err = (&AutomationRule{ID: 3, ProjectID: 1}).CanUpdate(s, &user.User{ID: 1})
+	require.Error(t, err)
+	assert.True(t, IsErrAutomationRuleDoesNotExist(err))
+
+	_, _, err = (&AutomationRule{ID: 9999, ProjectID: 1}).CanRead(s, &user.User{ID: 1})
+	require.Error(t, err)
+	assert.True(t, IsErrAutomationRuleDoesNotExist(err))
+
+	can, err = (&AutomationRule{ProjectID: 1}).CanCreate(s, &LinkSharing{ID: 2, ProjectID: 1, Permission: PermissionAdmin})
+	require.NoError(t, err)
+	assert.False(t, can)
+}
+
+func TestAutomationRule_Update(t *testing.T) {
+	RegisterEventForAutomation(&TaskUpdatedEvent{})
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	rule := &AutomationRule{
+		ID:         2,
+		ProjectID:  1,
+		Title:      "Enabled",
+		Event:      "task.updated",
+		IsDisabled: false,
+		Actions:    []*AutomationAction{{Type: AutomationActionSetField, Field: "done", Value: "true"}},
+	}
+	err := rule.Update(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rule.ProjectID)
+	db.AssertExists(t, "automation_rules", map[string]interface{}{
+		"id":          2,
+		"title":       "Enabled",
+		"is_disabled": false,
+	}, false)
+}
+
+func TestAutomationRule_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	err := (&AutomationRule{ID: 1, ProjectID: 1}).Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	db.AssertMissing(t, "automation_rules", map[string]interface{}{"id": 1})
+	db.AssertMissing(t, "automation_rule_executions", map[string]interface{}{"rule_id": 1})
+}
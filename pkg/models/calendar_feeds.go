@@ -0,0 +1,250 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CalendarFeed is a secret, read-only iCalendar feed of the tasks in a project view or saved filter view.
+// It allows subscribing to tasks in calendar apps like Google Calendar or Outlook without CalDAV credentials.
+type CalendarFeed struct {
+	// The unique, numeric id of this feed.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"feed"`
+	// The secret token of this feed. Everyone who knows it can read the tasks in the view.
+	// It is only returned once, when the feed was created.
+	Token          string `xorm:"-" json:"token,omitempty"`
+	TokenSalt      string `xorm:"not null" json:"-"`
+	TokenHash      string `xorm:"not null unique" json:"-"`
+	TokenLastEight string `xorm:"not null index varchar(8)" json:"-"`
+	// The url calendar apps can subscribe to. Like the token, it is only returned once, when the feed was created.
+	URL string `xorm:"-" json:"url,omitempty"`
+
+	ProjectID     int64 `xorm:"bigint not null INDEX" json:"project_id" param:"project"`
+	ProjectViewID int64 `xorm:"bigint not null INDEX" json:"project_view_id" param:"view"`
+
+	// The feed contains the tasks the user who created it can see.
+	OwnerID int64      `xorm:"bigint not null INDEX" json:"-"`
+	Owner   *user.User `xorm:"-" json:"owner"`
+
+	// A timestamp when this feed was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for calendar feeds
+func (*CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
+
+func (f *CalendarFeed) setURL() {
+	f.URL = config.ServicePublicURL.GetString() + "api/v1/calendar-feeds/" + f.Token + ".ics"
+}
+
+func getCalendarFeedByID(s *xorm.Session, id int64) (feed *CalendarFeed, err error) {
+	feed = &CalendarFeed{}
+	exists, err := s.Where("id = ?", id).Get(feed)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCalendarFeedDoesNotExist{ID: id}
+	}
+	return
+}
+
+// GetCalendarFeedByToken returns a calendar feed by its secret token.
+func GetCalendarFeedByToken(s *xorm.Session, token string) (feed *CalendarFeed, err error) {
+	if len(token) < 8 {
+		return nil, &ErrCalendarFeedDoesNotExist{}
+	}
+
+	feeds := []*CalendarFeed{}
+	err = s.Where("token_last_eight = ?", token[len(token)-8:]).Find(&feeds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range feeds {
+		tempHash := HashToken(token, f.TokenSalt)
+		if subtle.ConstantTimeCompare([]byte(f.TokenHash), []byte(tempHash)) == 1 {
+			return f, nil
+		}
+	}
+
+	return nil, &ErrCalendarFeedDoesNotExist{}
+}
+
+// Create creates a new calendar feed
+// @Summary Create a calendar feed for a view
+// @Description Creates a secret iCalendar feed url for the tasks in a project view or saved filter view. Calendar apps can subscribe to it without any other credentials. The feed contains the tasks the current user can see.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param view path int true "Project View ID"
+// @Success 201 {object} models.CalendarFeed "The created feed."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the view."
+// @Failure 404 {object} web.HTTPError "The view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{view}/calendar-feeds [put]
+func (f *CalendarFeed) Create(s *xorm.Session, a web.Auth) (err error) {
+	f.ID = 0
+	f.OwnerID = a.GetID()
+	f.TokenSalt, err = utils.CryptoRandomString(10)
+	if err != nil {
+		return err
+	}
+	f.Token, err = utils.CryptoRandomString(40)
+	if err != nil {
+		return err
+	}
+	f.TokenHash = HashToken(f.Token, f.TokenSalt)
+	f.TokenLastEight = f.Token[len(f.Token)-8:]
+
+	_, err = s.Insert(f)
+	if err != nil {
+		return err
+	}
+
+	f.Owner, err = user.GetUserByID(s, f.OwnerID)
+	f.setURL()
+	return
+}
+
+// ReadAll returns all calendar feeds of the current user for a view
+// @Summary Get all calendar feeds for a view
+// @Description Returns all calendar feeds the current user created for a project view.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param view path int true "Project View ID"
+// @Success 200 {array} models.CalendarFeed "The feeds"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the view."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{view}/calendar-feeds [get]
+func (f *CalendarFeed) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, err := f.CanCreate(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	where := "owner_id = ? AND project_id = ? AND project_view_id = ?"
+
+	feeds := []*CalendarFeed{}
+	query := s.Where(where, a.GetID(), f.ProjectID, f.ProjectViewID).OrderBy("id asc")
+	limit, start := getLimitFromPageIndex(page, perPage)
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&feeds)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	owner, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, feed := range feeds {
+		feed.Owner = owner
+	}
+
+	total, err := s.Where(where, a.GetID(), f.ProjectID, f.ProjectViewID).Count(&CalendarFeed{})
+	return feeds, len(feeds), total, err
+}
+
+// Delete removes a calendar feed
+// @Summary Delete a calendar feed
+// @Description Deletes a calendar feed. Its url stops working immediately.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param view path int true "Project View ID"
+// @Param feed path int true "Calendar Feed ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 403 {object} web.HTTPError "The feed does not belong to the user."
+// @Failure 404 {object} web.HTTPError "The feed does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{view}/calendar-feeds/{feed} [delete]
+func (f *CalendarFeed) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", f.ID).Delete(&CalendarFeed{})
+	return
+}
+
+// GetTasks returns the title of the feed and all tasks in its view as seen by the user who created it. When that
+// user lost access to the view or was disabled, the feed stops working.
+func (f *CalendarFeed) GetTasks(s *xorm.Session) (title string, tasks []*Task, err error) {
+	owner, err := user.GetUserByID(s, f.OwnerID)
+	if err != nil {
+		return "", nil, err
+	}
+	if owner.Status == user.StatusDisabled {
+		return "", nil, &user.ErrAccountDisabled{UserID: owner.ID}
+	}
+
+	view, err := GetProjectViewByIDAndProject(s, f.ProjectViewID, f.ProjectID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch filterID := GetSavedFilterIDFromProjectID(f.ProjectID); {
+	case f.ProjectID == FavoritesPseudoProjectID:
+		title = FavoritesPseudoProject.Title
+	case filterID > 0:
+		sf, err := GetSavedFilterSimpleByID(s, filterID)
+		if err != nil {
+			return "", nil, err
+		}
+		title = sf.Title
+	default:
+		project, err := GetProjectSimpleByID(s, f.ProjectID)
+		if err != nil {
+			return "", nil, err
+		}
+		title = project.Title
+	}
+	title += " (" + view.Title + ")"
+
+	tc := &TaskCollection{
+		ProjectID:     f.ProjectID,
+		ProjectViewID: f.ProjectViewID,
+		asTaskList:    true,
+	}
+	result, _, _, err := tc.ReadAll(s, owner, "", 0, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return title, result.([]*Task), nil
+}
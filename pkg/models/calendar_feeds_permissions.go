@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can create a calendar feed for a view. Everyone who can read the view can, except link shares.
+func (f *CalendarFeed) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	view, err := GetProjectViewByIDAndProject(s, f.ProjectViewID, f.ProjectID)
+	if err != nil {
+		return false, err
+	}
+
+	can, _, err := view.CanRead(s, a)
+	return can, err
+}
+
+// CanDelete checks if a user can delete a calendar feed. Only the user who created it can.
+func (f *CalendarFeed) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	existing, err := getCalendarFeedByID(s, f.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return existing.OwnerID == a.GetID() &&
+		existing.ProjectID == f.ProjectID &&
+		existing.ProjectViewID == f.ProjectViewID, nil
+}
@@ -0,0 +1,149 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	t.Run("create and get tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		feed := &CalendarFeed{ProjectID: 1, ProjectViewID: 1}
+		can, err := feed.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = feed.Create(s, u)
+		require.NoError(t, err)
+		assert.Len(t, feed.Token, 40)
+		assert.Contains(t, feed.URL, "api/v1/calendar-feeds/"+feed.Token+".ics")
+
+		// Only a hash of the token is stored
+		db.AssertExists(t, "calendar_feeds", map[string]interface{}{
+			"id":               feed.ID,
+			"token_last_eight": feed.Token[32:],
+		}, false)
+		assert.NotEqual(t, feed.Token, feed.TokenHash)
+
+		found, err := GetCalendarFeedByToken(s, feed.Token)
+		require.NoError(t, err)
+		assert.Equal(t, feed.ID, found.ID)
+
+		title, tasks, err := found.GetTasks(s)
+		require.NoError(t, err)
+		assert.Equal(t, "Test1 (List)", title)
+		assert.NotEmpty(t, tasks)
+		for _, task := range tasks {
+			assert.Equal(t, int64(1), task.ProjectID)
+		}
+	})
+	t.Run("kanban view returns a plain list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{ProjectID: 1, ProjectViewID: 4, OwnerID: 1}
+		_, tasks, err := feed.GetTasks(s)
+		require.NoError(t, err)
+		assert.NotEmpty(t, tasks)
+	})
+	t.Run("saved filter view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		view := &ProjectView{ProjectID: getProjectIDFromSavedFilterID(1), Title: "List", ViewKind: ProjectViewKindList}
+		_, err := s.Insert(view)
+		require.NoError(t, err)
+
+		feed := &CalendarFeed{ProjectID: view.ProjectID, ProjectViewID: view.ID, OwnerID: 1}
+		title, _, err := feed.GetTasks(s)
+		require.NoError(t, err)
+		assert.Equal(t, "testfilter1 (List)", title)
+	})
+	t.Run("disabled owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("status").Update(&user.User{Status: user.StatusDisabled})
+		require.NoError(t, err)
+
+		feed := &CalendarFeed{ProjectID: 1, ProjectViewID: 1, OwnerID: 1}
+		_, _, err = feed.GetTasks(s)
+		require.Error(t, err)
+		assert.True(t, user.IsErrAccountDisabled(err))
+	})
+	t.Run("unknown token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetCalendarFeedByToken(s, "doesnotexist")
+		require.Error(t, err)
+		assert.True(t, IsErrCalendarFeedDoesNotExist(err))
+
+		_, err = GetCalendarFeedByToken(s, "short")
+		require.Error(t, err)
+		assert.True(t, IsErrCalendarFeedDoesNotExist(err))
+	})
+	t.Run("no access to the view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{ProjectID: 1, ProjectViewID: 1}
+		can, err := feed.CanCreate(s, &user.User{ID: 13})
+		require.NoError(t, err)
+		assert.False(t, can)
+
+		can, err = feed.CanCreate(s, &LinkSharing{ID: 1, ProjectID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("only the owner can delete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{ProjectID: 1, ProjectViewID: 1}
+		err := feed.Create(s, &user.User{ID: 1})
+		require.NoError(t, err)
+
+		toDelete := &CalendarFeed{ID: feed.ID, ProjectID: 1, ProjectViewID: 1}
+		can, err := toDelete.CanDelete(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+
+		can, err = toDelete.CanDelete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = toDelete.Delete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		db.AssertMissing(t, "calendar_feeds", map[string]interface{}{"id": feed.ID})
+	})
+}
@@ -0,0 +1,182 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"net/url"
+	"strings"
+
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// ChatTarget is a wrapper around the crud operations that come with a chat notification target.
+type ChatTarget struct {
+	notifications.ChatTarget
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+func (c *ChatTarget) validate() error {
+	if !notifications.IsValidChatTargetKind(c.Kind) {
+		return InvalidFieldErrorWithMessage([]string{"kind"}, "The kind must be one of slack, matrix, ntfy or gotify.")
+	}
+	if c.Kind == notifications.ChatTargetMatrix && c.Room == "" {
+		return InvalidFieldErrorWithMessage([]string{"room"}, "Matrix targets need the id of a room.")
+	}
+	if (c.Kind == notifications.ChatTargetMatrix || c.Kind == notifications.ChatTargetGotify) && c.Token == "" {
+		return InvalidFieldErrorWithMessage([]string{"token"}, "Matrix and Gotify targets need a token.")
+	}
+	if c.Kind == notifications.ChatTargetNtfy {
+		u, err := url.Parse(c.URL)
+		if err != nil || strings.Trim(u.Path, "/") == "" {
+			return InvalidFieldErrorWithMessage([]string{"url"}, "The url of an ntfy target must contain the topic.")
+		}
+	}
+	return nil
+}
+
+// Create adds a chat target
+// @Summary Add a chat to get notifications in
+// @Description Adds a Slack-compatible incoming webhook, a Matrix room or an ntfy or Gotify topic the current user gets notifications in. Which notifications are sent to chats can be configured with the `chat` channel in the notification settings.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param target body models.ChatTarget true "The chat target"
+// @Success 201 {object} models.ChatTarget "The created chat target."
+// @Failure 400 {object} web.HTTPError "Invalid chat target object provided."
+// @Failure 412 {object} web.HTTPError "Chat notifications are not enabled on this instance."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /chat-targets [put]
+func (c *ChatTarget) Create(s *xorm.Session, a web.Auth) (err error) {
+	if !notifications.IsChatEnabled() {
+		return &ErrChatNotificationsDisabled{}
+	}
+
+	err = c.validate()
+	if err != nil {
+		return err
+	}
+
+	c.ID = 0
+	c.NotifiableID = a.GetID()
+	_, err = s.Insert(&c.ChatTarget)
+	return
+}
+
+// ReadAll returns all chat targets of the current user
+// @Summary Get all chat targets of the current user
+// @Description Returns all chats the current user gets notifications in. Tokens are not returned.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} models.ChatTarget "The chat targets"
+// @Failure 403 {object} web.HTTPError "Link shares cannot have chat targets."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /chat-targets [get]
+func (c *ChatTarget) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	targets, err := notifications.GetChatTargetsForNotifiable(s, a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, target := range targets {
+		target.Token = ""
+	}
+
+	return targets, len(targets), int64(len(targets)), nil
+}
+
+// ReadOne returns a chat target
+// @Summary Get a chat target
+// @Description Returns one of the chats the current user gets notifications in. The token is not returned.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param target path int true "Chat target ID"
+// @Success 200 {object} models.ChatTarget "The chat target"
+// @Failure 403 {object} web.HTTPError "The chat target belongs to another user."
+// @Failure 404 {object} web.HTTPError "The chat target does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /chat-targets/{target} [get]
+func (c *ChatTarget) ReadOne(_ *xorm.Session, _ web.Auth) (err error) {
+	// The target was already loaded when checking permissions
+	c.Token = ""
+	return nil
+}
+
+// Update changes a chat target
+// @Summary Update a chat target
+// @Description Changes one of the chats the current user gets notifications in. If no token is provided, the old one is kept.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param target path int true "Chat target ID"
+// @Param chatTarget body models.ChatTarget true "The chat target with updated values"
+// @Success 200 {object} models.ChatTarget "The updated chat target."
+// @Failure 400 {object} web.HTTPError "Invalid chat target object provided."
+// @Failure 403 {object} web.HTTPError "The chat target belongs to another user."
+// @Failure 404 {object} web.HTTPError "The chat target does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /chat-targets/{target} [post]
+func (c *ChatTarget) Update(s *xorm.Session, _ web.Auth) (err error) {
+	if c.Token == "" {
+		old, _, err := notifications.GetChatTargetByID(s, c.ID)
+		if err != nil {
+			return err
+		}
+		c.Token = old.Token
+	}
+
+	err = c.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", c.ID).
+		Cols("kind", "title", "url", "room", "token").
+		Update(&c.ChatTarget)
+	return
+}
+
+// Delete removes a chat target
+// @Summary Delete a chat target
+// @Description Stops sending notifications to one of the chats of the current user.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param target path int true "Chat target ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 403 {object} web.HTTPError "The chat target belongs to another user."
+// @Failure 404 {object} web.HTTPError "The chat target does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /chat-targets/{target} [delete]
+func (c *ChatTarget) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = s.Where("id = ? AND notifiable_id = ?", c.ID, a.GetID()).Delete(&notifications.ChatTarget{})
+	return
+}
@@ -0,0 +1,67 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can add a chat target. Only users can.
+func (c *ChatTarget) CanCreate(_ *xorm.Session, a web.Auth) (bool, error) {
+	_, isLinkShare := a.(*LinkSharing)
+	return !isLinkShare, nil
+}
+
+// CanRead checks if a user can see a chat target. Only the user it belongs to can.
+func (c *ChatTarget) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	target, can, err := c.isOwner(s, a)
+	if can {
+		c.ChatTarget = *target
+	}
+	return can, int(PermissionAdmin), err
+}
+
+// CanUpdate checks if a user can change a chat target. Only the user it belongs to can.
+func (c *ChatTarget) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	_, can, err := c.isOwner(s, a)
+	return can, err
+}
+
+// CanDelete checks if a user can delete a chat target. Only the user it belongs to can.
+func (c *ChatTarget) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	_, can, err := c.isOwner(s, a)
+	return can, err
+}
+
+func (c *ChatTarget) isOwner(s *xorm.Session, a web.Auth) (*notifications.ChatTarget, bool, error) {
+	target, exists, err := notifications.GetChatTargetByID(s, c.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		return nil, false, &ErrChatTargetDoesNotExist{ID: c.ID}
+	}
+
+	if _, is := a.(*LinkSharing); is {
+		return nil, false, nil
+	}
+
+	return target, target.NotifiableID == a.GetID(), nil
+}
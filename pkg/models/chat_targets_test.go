@@ -0,0 +1,192 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatTarget_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	c := &ChatTarget{}
+	result, count, total, err := c.ReadAll(s, &user.User{ID: 2}, "", 1, 50)
+	require.NoError(t, err)
+	targets := result.([]*notifications.ChatTarget)
+	require.Len(t, targets, 1)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int64(2), targets[0].ID)
+	assert.Equal(t, "!room:example.com", targets[0].Room)
+	assert.Empty(t, targets[0].Token)
+}
+
+func TestChatTarget_Create(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.Kind = notifications.ChatTargetNtfy
+		c.URL = "https://ntfy.example.com/vikunja"
+		err := c.Create(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertExists(t, "chat_notification_targets", map[string]interface{}{
+			"id":            c.ID,
+			"notifiable_id": 1,
+			"kind":          "ntfy",
+		}, false)
+	})
+	t.Run("invalid kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.Kind = "irc"
+		c.URL = "https://irc.example.com"
+		err := c.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("matrix without token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.Kind = notifications.ChatTargetMatrix
+		c.URL = "https://matrix.example.com"
+		c.Room = "!room:example.com"
+		err := c.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("chat notifications disabled", func(t *testing.T) {
+		config.ChatNotificationsEnabled.Set(false)
+		defer config.ChatNotificationsEnabled.Set(true)
+
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.Kind = notifications.ChatTargetSlack
+		c.URL = "https://chat.example.com/hooks/new"
+		err := c.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrChatNotificationsDisabled(err))
+	})
+}
+
+func TestChatTarget_Update(t *testing.T) {
+	t.Run("keeps the token when none is provided", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.ID = 2
+		c.Kind = notifications.ChatTargetMatrix
+		c.Title = "Renamed"
+		c.URL = "https://matrix.example.com"
+		c.Room = "!other:example.com"
+		err := c.Update(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertExists(t, "chat_notification_targets", map[string]interface{}{
+			"id":    2,
+			"title": "Renamed",
+			"room":  "!other:example.com",
+			"token": "syt_user2_secret",
+		}, false)
+	})
+}
+
+func TestChatTarget_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	c := &ChatTarget{}
+	c.ID = 1
+	err := c.Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	require.NoError(t, s.Commit())
+
+	db.AssertMissing(t, "chat_notification_targets", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestChatTarget_Permissions(t *testing.T) {
+	t.Run("owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.ID = 2
+		can, _, err := c.CanRead(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, "syt_user2_secret", c.Token)
+	})
+	t.Run("other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.ID = 2
+		can, err := c.CanDelete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		c := &ChatTarget{}
+		c.ID = 9999
+		can, err := c.CanUpdate(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.False(t, can)
+		assert.True(t, IsErrChatTargetDoesNotExist(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		c := &ChatTarget{}
+		can, err := c.CanCreate(nil, &LinkSharing{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
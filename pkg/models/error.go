@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/web"
@@ -471,6 +472,88 @@ func (err *ErrProjectViewDoesNotExist) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrCustomFieldDoesNotExist represents an error where a custom field does not exist
+type ErrCustomFieldDoesNotExist struct {
+	ID int64
+}
+
+// IsErrCustomFieldDoesNotExist checks if an error is ErrCustomFieldDoesNotExist.
+func IsErrCustomFieldDoesNotExist(err error) bool {
+	_, ok := err.(*ErrCustomFieldDoesNotExist)
+	return ok
+}
+
+func (err *ErrCustomFieldDoesNotExist) Error() string {
+	return fmt.Sprintf("Custom field does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeCustomFieldDoesNotExist holds the unique world-error code of this error
+const ErrCodeCustomFieldDoesNotExist = 3015
+
+// HTTPError holds the http error description
+func (err *ErrCustomFieldDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCustomFieldDoesNotExist,
+		Message:  "This custom field does not exist.",
+	}
+}
+
+// ErrInvalidCustomFieldValue represents an error where a value does not match the type of its custom field
+type ErrInvalidCustomFieldValue struct {
+	FieldID int64
+	Value   interface{}
+}
+
+// IsErrInvalidCustomFieldValue checks if an error is ErrInvalidCustomFieldValue.
+func IsErrInvalidCustomFieldValue(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldValue)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldValue) Error() string {
+	return fmt.Sprintf("Custom field value is invalid [FieldID: %d, Value: %v]", err.FieldID, err.Value)
+}
+
+// ErrCodeInvalidCustomFieldValue holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldValue = 3016
+
+// HTTPError holds the http error description
+func (err *ErrInvalidCustomFieldValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldValue,
+		Message:  fmt.Sprintf("The value '%v' is not valid for custom field %d.", err.Value, err.FieldID),
+	}
+}
+
+// ErrCustomFieldNeedsOptions represents an error where a select custom field is created without options
+type ErrCustomFieldNeedsOptions struct {
+	Title string
+}
+
+// IsErrCustomFieldNeedsOptions checks if an error is ErrCustomFieldNeedsOptions.
+func IsErrCustomFieldNeedsOptions(err error) bool {
+	_, ok := err.(*ErrCustomFieldNeedsOptions)
+	return ok
+}
+
+func (err *ErrCustomFieldNeedsOptions) Error() string {
+	return fmt.Sprintf("Custom field needs options [Title: %s]", err.Title)
+}
+
+// ErrCodeCustomFieldNeedsOptions holds the unique world-error code of this error
+const ErrCodeCustomFieldNeedsOptions = 3017
+
+// HTTPError holds the http error description
+func (err *ErrCustomFieldNeedsOptions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldNeedsOptions,
+		Message:  "A select custom field needs at least one option.",
+	}
+}
+
 // ==============
 // Task errors
 // ==============
@@ -1220,6 +1303,178 @@ func (err *ErrNeedsFullRecalculation) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrTimeEntryDoesNotExist represents an error where a time entry does not exist
+type ErrTimeEntryDoesNotExist struct {
+	ID int64
+}
+
+// IsErrTimeEntryDoesNotExist checks if an error is ErrTimeEntryDoesNotExist.
+func IsErrTimeEntryDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTimeEntryDoesNotExist)
+	return ok
+}
+
+func (err *ErrTimeEntryDoesNotExist) Error() string {
+	return fmt.Sprintf("Time entry does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeTimeEntryDoesNotExist holds the unique world-error code of this error
+const ErrCodeTimeEntryDoesNotExist = 4029
+
+// HTTPError holds the http error description
+func (err *ErrTimeEntryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTimeEntryDoesNotExist,
+		Message:  "This time entry does not exist.",
+	}
+}
+
+// ErrTimeEntryEndBeforeStart represents an error where the end of a time entry lies before its start
+type ErrTimeEntryEndBeforeStart struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsErrTimeEntryEndBeforeStart checks if an error is ErrTimeEntryEndBeforeStart.
+func IsErrTimeEntryEndBeforeStart(err error) bool {
+	_, ok := err.(*ErrTimeEntryEndBeforeStart)
+	return ok
+}
+
+func (err *ErrTimeEntryEndBeforeStart) Error() string {
+	return fmt.Sprintf("Time entry end is before its start [Start: %s, End: %s]", err.Start, err.End)
+}
+
+// ErrCodeTimeEntryEndBeforeStart holds the unique world-error code of this error
+const ErrCodeTimeEntryEndBeforeStart = 4030
+
+// HTTPError holds the http error description
+func (err *ErrTimeEntryEndBeforeStart) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeTimeEntryEndBeforeStart,
+		Message:  "The end of a time entry cannot be before its start.",
+	}
+}
+
+// ErrNoRunningTimer represents an error where a user tries to access a timer that is not running
+type ErrNoRunningTimer struct {
+	TaskID int64
+}
+
+// IsErrNoRunningTimer checks if an error is ErrNoRunningTimer.
+func IsErrNoRunningTimer(err error) bool {
+	_, ok := err.(*ErrNoRunningTimer)
+	return ok
+}
+
+func (err *ErrNoRunningTimer) Error() string {
+	return fmt.Sprintf("No timer running on this task [TaskID: %d]", err.TaskID)
+}
+
+// ErrCodeNoRunningTimer holds the unique world-error code of this error
+const ErrCodeNoRunningTimer = 4031
+
+// HTTPError holds the http error description
+func (err *ErrNoRunningTimer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNoRunningTimer,
+		Message:  "You don't have a timer running on this task.",
+	}
+}
+
+// ErrTaskDependencyCycle represents an error where the precedes or blocking relations of tasks form a cycle
+type ErrTaskDependencyCycle struct {
+	TaskIDs []int64
+}
+
+// IsErrTaskDependencyCycle checks if an error is ErrTaskDependencyCycle.
+func IsErrTaskDependencyCycle(err error) bool {
+	_, ok := err.(*ErrTaskDependencyCycle)
+	return ok
+}
+
+func (err *ErrTaskDependencyCycle) Error() string {
+	return fmt.Sprintf("Task dependencies contain a cycle [TaskIDs: %v]", err.TaskIDs)
+}
+
+// ErrCodeTaskDependencyCycle holds the unique world-error code of this error
+const ErrCodeTaskDependencyCycle = 4032
+
+// HTTPError holds the http error description
+func (err *ErrTaskDependencyCycle) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeTaskDependencyCycle,
+		Message:  "The precedes and blocking relations of these tasks form a cycle, they cannot be scheduled.",
+	}
+}
+
+// ErrTaskIsBlocked represents an error where a task cannot be marked done because it is blocked by open tasks
+type ErrTaskIsBlocked struct {
+	TaskID   int64
+	Blockers []*Task
+}
+
+// IsErrTaskIsBlocked checks if an error is ErrTaskIsBlocked.
+func IsErrTaskIsBlocked(err error) bool {
+	_, ok := err.(*ErrTaskIsBlocked)
+	return ok
+}
+
+func (err *ErrTaskIsBlocked) Error() string {
+	return fmt.Sprintf("Task is blocked by open tasks [TaskID: %d, Blockers: %d]", err.TaskID, len(err.Blockers))
+}
+
+// ErrCodeTaskIsBlocked holds the unique world-error code of this error
+const ErrCodeTaskIsBlocked = 4033
+
+// HTTPError holds the http error description
+func (err *ErrTaskIsBlocked) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskIsBlocked,
+		Message:  "This task cannot be marked done while it is blocked by tasks which are not done.",
+	}
+}
+
+// HTTPErrorDetails returns the tasks blocking the task
+func (err *ErrTaskIsBlocked) HTTPErrorDetails() interface{} {
+	return map[string]interface{}{
+		"task_id":  err.TaskID,
+		"blockers": err.Blockers,
+	}
+}
+
+// ErrChecklistItemDoesNotExist represents an error where a checklist item does not exist
+type ErrChecklistItemDoesNotExist struct {
+	ID int64
+}
+
+// IsErrChecklistItemDoesNotExist checks if an error is ErrChecklistItemDoesNotExist.
+func IsErrChecklistItemDoesNotExist(err error) bool {
+	_, ok := err.(*ErrChecklistItemDoesNotExist)
+	return ok
+}
+
+func (err *ErrChecklistItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Checklist item does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeChecklistItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeChecklistItemDoesNotExist = 4034
+
+// HTTPError holds the http error description
+func (err *ErrChecklistItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeChecklistItemDoesNotExist,
+		Message:  "This checklist item does not exist.",
+	}
+}
+
 // ============
 // Team errors
 // ============
@@ -1784,6 +2039,73 @@ func (err ErrTaskAlreadyExistsInBucket) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrWIPLimitExceeded represents an error where a task cannot be moved into a bucket or assigned to a user because it would exceed the user's work-in-progress limit.
+type ErrWIPLimitExceeded struct {
+	TaskID      int64
+	UserID      int64
+	BucketTitle string
+	Limit       int64
+}
+
+// IsErrWIPLimitExceeded checks if an error is ErrWIPLimitExceeded.
+func IsErrWIPLimitExceeded(err error) bool {
+	_, ok := err.(*ErrWIPLimitExceeded)
+	return ok
+}
+
+func (err *ErrWIPLimitExceeded) Error() string {
+	return fmt.Sprintf("Work-in-progress limit exceeded [TaskID: %d, UserID: %d, BucketTitle: %s, Limit: %d]", err.TaskID, err.UserID, err.BucketTitle, err.Limit)
+}
+
+// ErrCodeWIPLimitExceeded holds the unique world-error code of this error
+const ErrCodeWIPLimitExceeded = 10007
+
+// HTTPError holds the http error description
+func (err *ErrWIPLimitExceeded) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeWIPLimitExceeded,
+		Message:  fmt.Sprintf("This would exceed the work-in-progress limit of %d tasks in buckets named \"%s\" of an assignee.", err.Limit, err.BucketTitle),
+	}
+}
+
+// HTTPErrorDetails returns the limit which would be exceeded
+func (err *ErrWIPLimitExceeded) HTTPErrorDetails() interface{} {
+	return &WIPLimitWarning{
+		UserID:      err.UserID,
+		BucketTitle: err.BucketTitle,
+		Limit:       err.Limit,
+		Count:       err.Limit + 1,
+	}
+}
+
+// ErrWIPLimitDoesNotExist represents an error where a work-in-progress limit does not exist
+type ErrWIPLimitDoesNotExist struct {
+	ID int64
+}
+
+// IsErrWIPLimitDoesNotExist checks if an error is ErrWIPLimitDoesNotExist.
+func IsErrWIPLimitDoesNotExist(err error) bool {
+	_, ok := err.(*ErrWIPLimitDoesNotExist)
+	return ok
+}
+
+func (err *ErrWIPLimitDoesNotExist) Error() string {
+	return fmt.Sprintf("Work-in-progress limit does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeWIPLimitDoesNotExist holds the unique world-error code of this error
+const ErrCodeWIPLimitDoesNotExist = 10008
+
+// HTTPError holds the http error description
+func (err *ErrWIPLimitDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWIPLimitDoesNotExist,
+		Message:  "This work-in-progress limit does not exist.",
+	}
+}
+
 // =============
 // Saved Filters
 // =============
@@ -2098,3 +2420,572 @@ type ErrOpenIDBadRequestWithDetails struct {
 func (err *ErrOpenIDBadRequestWithDetails) Error() string {
 	return err.Message
 }
+
+// =============
+// Trash errors
+// =============
+
+// ErrTrashItemDoesNotExist represents an error where a trash item does not exist
+type ErrTrashItemDoesNotExist struct {
+	ID int64
+}
+
+// IsErrTrashItemDoesNotExist checks if an error is ErrTrashItemDoesNotExist.
+func IsErrTrashItemDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTrashItemDoesNotExist)
+	return ok
+}
+
+func (err *ErrTrashItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Trash item does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeTrashItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeTrashItemDoesNotExist = 16001
+
+// HTTPError holds the http error description
+func (err *ErrTrashItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTrashItemDoesNotExist,
+		Message:  "This trash item does not exist.",
+	}
+}
+
+// ErrCannotRestoreTrashItem represents an error where a trash item cannot be restored because the project or task it belonged to is gone
+type ErrCannotRestoreTrashItem struct {
+	ID int64
+}
+
+// IsErrCannotRestoreTrashItem checks if an error is ErrCannotRestoreTrashItem.
+func IsErrCannotRestoreTrashItem(err error) bool {
+	_, ok := err.(*ErrCannotRestoreTrashItem)
+	return ok
+}
+
+func (err *ErrCannotRestoreTrashItem) Error() string {
+	return fmt.Sprintf("Trash item cannot be restored because its parent does not exist anymore [ID: %d]", err.ID)
+}
+
+// ErrCodeCannotRestoreTrashItem holds the unique world-error code of this error
+const ErrCodeCannotRestoreTrashItem = 16002
+
+// HTTPError holds the http error description
+func (err *ErrCannotRestoreTrashItem) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeCannotRestoreTrashItem,
+		Message:  "This item cannot be restored because the project or task it belonged to does not exist anymore. Restore that first.",
+	}
+}
+
+// ===============
+// Webhook errors
+// ===============
+
+// ErrWebhookDoesNotExist represents an error where a webhook does not exist
+type ErrWebhookDoesNotExist struct {
+	ID int64
+}
+
+// IsErrWebhookDoesNotExist checks if an error is ErrWebhookDoesNotExist.
+func IsErrWebhookDoesNotExist(err error) bool {
+	_, ok := err.(*ErrWebhookDoesNotExist)
+	return ok
+}
+
+func (err *ErrWebhookDoesNotExist) Error() string {
+	return fmt.Sprintf("Webhook does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeWebhookDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebhookDoesNotExist = 18001
+
+// HTTPError holds the http error description
+func (err *ErrWebhookDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebhookDoesNotExist,
+		Message:  "This webhook does not exist.",
+	}
+}
+
+// ErrWebhookDeliveryDoesNotExist represents an error where a webhook delivery does not exist
+type ErrWebhookDeliveryDoesNotExist struct {
+	ID int64
+}
+
+// IsErrWebhookDeliveryDoesNotExist checks if an error is ErrWebhookDeliveryDoesNotExist.
+func IsErrWebhookDeliveryDoesNotExist(err error) bool {
+	_, ok := err.(*ErrWebhookDeliveryDoesNotExist)
+	return ok
+}
+
+func (err *ErrWebhookDeliveryDoesNotExist) Error() string {
+	return fmt.Sprintf("Webhook delivery does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeWebhookDeliveryDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebhookDeliveryDoesNotExist = 18002
+
+// HTTPError holds the http error description
+func (err *ErrWebhookDeliveryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebhookDeliveryDoesNotExist,
+		Message:  "This webhook delivery does not exist.",
+	}
+}
+
+// ================
+// Template errors
+// ================
+
+// ErrTemplateDoesNotExist represents an error where a template does not exist
+type ErrTemplateDoesNotExist struct {
+	ID int64
+}
+
+// IsErrTemplateDoesNotExist checks if an error is ErrTemplateDoesNotExist.
+func IsErrTemplateDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTemplateDoesNotExist)
+	return ok
+}
+
+func (err *ErrTemplateDoesNotExist) Error() string {
+	return fmt.Sprintf("Template does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeTemplateDoesNotExist holds the unique world-error code of this error
+const ErrCodeTemplateDoesNotExist = 19001
+
+// HTTPError holds the http error description
+func (err *ErrTemplateDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTemplateDoesNotExist,
+		Message:  "This template does not exist.",
+	}
+}
+
+// ErrTemplatePlaceholderValueMissing represents an error where no value was provided for a placeholder of a template
+type ErrTemplatePlaceholderValueMissing struct {
+	Placeholders []string
+}
+
+// IsErrTemplatePlaceholderValueMissing checks if an error is ErrTemplatePlaceholderValueMissing.
+func IsErrTemplatePlaceholderValueMissing(err error) bool {
+	_, ok := err.(*ErrTemplatePlaceholderValueMissing)
+	return ok
+}
+
+func (err *ErrTemplatePlaceholderValueMissing) Error() string {
+	return fmt.Sprintf("Template placeholder value missing [Placeholders: %v]", err.Placeholders)
+}
+
+// ErrCodeTemplatePlaceholderValueMissing holds the unique world-error code of this error
+const ErrCodeTemplatePlaceholderValueMissing = 19002
+
+// HTTPError holds the http error description
+func (err *ErrTemplatePlaceholderValueMissing) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeTemplatePlaceholderValueMissing,
+		Message:  "No value was provided for the placeholders " + strings.Join(err.Placeholders, ", ") + ".",
+	}
+}
+
+// ==================
+// Automation errors
+// ==================
+
+// ErrAutomationRuleDoesNotExist represents an error where an automation rule does not exist
+type ErrAutomationRuleDoesNotExist struct {
+	ID int64
+}
+
+// IsErrAutomationRuleDoesNotExist checks if an error is ErrAutomationRuleDoesNotExist.
+func IsErrAutomationRuleDoesNotExist(err error) bool {
+	_, ok := err.(*ErrAutomationRuleDoesNotExist)
+	return ok
+}
+
+func (err *ErrAutomationRuleDoesNotExist) Error() string {
+	return fmt.Sprintf("Automation rule does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeAutomationRuleDoesNotExist holds the unique world-error code of this error
+const ErrCodeAutomationRuleDoesNotExist = 20001
+
+// HTTPError holds the http error description
+func (err *ErrAutomationRuleDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeAutomationRuleDoesNotExist,
+		Message:  "This automation rule does not exist.",
+	}
+}
+
+// ======================
+// Calendar feed errors
+// ======================
+
+// ErrCalendarFeedDoesNotExist represents an error where a calendar feed does not exist
+type ErrCalendarFeedDoesNotExist struct {
+	ID int64
+}
+
+// IsErrCalendarFeedDoesNotExist checks if an error is ErrCalendarFeedDoesNotExist.
+func IsErrCalendarFeedDoesNotExist(err error) bool {
+	_, ok := err.(*ErrCalendarFeedDoesNotExist)
+	return ok
+}
+
+func (err *ErrCalendarFeedDoesNotExist) Error() string {
+	return fmt.Sprintf("Calendar feed does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeCalendarFeedDoesNotExist holds the unique world-error code of this error
+const ErrCodeCalendarFeedDoesNotExist = 21001
+
+// HTTPError holds the http error description
+func (err *ErrCalendarFeedDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCalendarFeedDoesNotExist,
+		Message:  "This calendar feed does not exist.",
+	}
+}
+
+// ==============
+// Sprint errors
+// ==============
+
+// ErrSprintDoesNotExist represents an error where a sprint does not exist
+type ErrSprintDoesNotExist struct {
+	ID int64
+}
+
+// IsErrSprintDoesNotExist checks if an error is ErrSprintDoesNotExist.
+func IsErrSprintDoesNotExist(err error) bool {
+	_, ok := err.(*ErrSprintDoesNotExist)
+	return ok
+}
+
+func (err *ErrSprintDoesNotExist) Error() string {
+	return fmt.Sprintf("Sprint does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeSprintDoesNotExist holds the unique world-error code of this error
+const ErrCodeSprintDoesNotExist = 22001
+
+// HTTPError holds the http error description
+func (err *ErrSprintDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeSprintDoesNotExist,
+		Message:  "This sprint does not exist.",
+	}
+}
+
+// ErrSprintEndBeforeStart represents an error where the end of a sprint lies before its start
+type ErrSprintEndBeforeStart struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsErrSprintEndBeforeStart checks if an error is ErrSprintEndBeforeStart.
+func IsErrSprintEndBeforeStart(err error) bool {
+	_, ok := err.(*ErrSprintEndBeforeStart)
+	return ok
+}
+
+func (err *ErrSprintEndBeforeStart) Error() string {
+	return fmt.Sprintf("Sprint end is before its start [Start: %s, End: %s]", err.Start, err.End)
+}
+
+// ErrCodeSprintEndBeforeStart holds the unique world-error code of this error
+const ErrCodeSprintEndBeforeStart = 22002
+
+// HTTPError holds the http error description
+func (err *ErrSprintEndBeforeStart) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeSprintEndBeforeStart,
+		Message:  "A sprint needs a start and an end and the end cannot be before the start.",
+	}
+}
+
+// ErrTaskAlreadyInSprint represents an error where a task is added to a sprint it already belongs to
+type ErrTaskAlreadyInSprint struct {
+	TaskID   int64
+	SprintID int64
+}
+
+// IsErrTaskAlreadyInSprint checks if an error is ErrTaskAlreadyInSprint.
+func IsErrTaskAlreadyInSprint(err error) bool {
+	_, ok := err.(*ErrTaskAlreadyInSprint)
+	return ok
+}
+
+func (err *ErrTaskAlreadyInSprint) Error() string {
+	return fmt.Sprintf("Task is already part of this sprint [TaskID: %d, SprintID: %d]", err.TaskID, err.SprintID)
+}
+
+// ErrCodeTaskAlreadyInSprint holds the unique world-error code of this error
+const ErrCodeTaskAlreadyInSprint = 22003
+
+// HTTPError holds the http error description
+func (err *ErrTaskAlreadyInSprint) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusConflict,
+		Code:     ErrCodeTaskAlreadyInSprint,
+		Message:  "This task is already part of this sprint.",
+	}
+}
+
+// ErrTaskNotInSprintProject represents an error where a task from another project is added to a sprint
+type ErrTaskNotInSprintProject struct {
+	TaskID    int64
+	ProjectID int64
+}
+
+// IsErrTaskNotInSprintProject checks if an error is ErrTaskNotInSprintProject.
+func IsErrTaskNotInSprintProject(err error) bool {
+	_, ok := err.(*ErrTaskNotInSprintProject)
+	return ok
+}
+
+func (err *ErrTaskNotInSprintProject) Error() string {
+	return fmt.Sprintf("Task does not belong to the project of the sprint [TaskID: %d, ProjectID: %d]", err.TaskID, err.ProjectID)
+}
+
+// ErrCodeTaskNotInSprintProject holds the unique world-error code of this error
+const ErrCodeTaskNotInSprintProject = 22004
+
+// HTTPError holds the http error description
+func (err *ErrTaskNotInSprintProject) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskNotInSprintProject,
+		Message:  "Only tasks of the sprint's project can be added to it.",
+	}
+}
+
+// ========================
+// Push subscription errors
+// ========================
+
+// ErrPushSubscriptionDoesNotExist represents an error where a push subscription does not exist
+type ErrPushSubscriptionDoesNotExist struct {
+	ID int64
+}
+
+// IsErrPushSubscriptionDoesNotExist checks if an error is ErrPushSubscriptionDoesNotExist.
+func IsErrPushSubscriptionDoesNotExist(err error) bool {
+	_, ok := err.(*ErrPushSubscriptionDoesNotExist)
+	return ok
+}
+
+func (err *ErrPushSubscriptionDoesNotExist) Error() string {
+	return fmt.Sprintf("Push subscription does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodePushSubscriptionDoesNotExist holds the unique world-error code of this error
+const ErrCodePushSubscriptionDoesNotExist = 23001
+
+// HTTPError holds the http error description
+func (err *ErrPushSubscriptionDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodePushSubscriptionDoesNotExist,
+		Message:  "This push subscription does not exist.",
+	}
+}
+
+// ErrWebPushDisabled represents an error where a user subscribes to push messages while web push is disabled
+type ErrWebPushDisabled struct{}
+
+// IsErrWebPushDisabled checks if an error is ErrWebPushDisabled.
+func IsErrWebPushDisabled(err error) bool {
+	_, ok := err.(*ErrWebPushDisabled)
+	return ok
+}
+
+func (err *ErrWebPushDisabled) Error() string {
+	return "Web push is disabled"
+}
+
+// ErrCodeWebPushDisabled holds the unique world-error code of this error
+const ErrCodeWebPushDisabled = 23002
+
+// HTTPError holds the http error description
+func (err *ErrWebPushDisabled) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeWebPushDisabled,
+		Message:  "Web push is not enabled on this instance.",
+	}
+}
+
+// ===================
+// Chat target errors
+// ===================
+
+// ErrChatTargetDoesNotExist represents an error where a chat target does not exist
+type ErrChatTargetDoesNotExist struct {
+	ID int64
+}
+
+// IsErrChatTargetDoesNotExist checks if an error is ErrChatTargetDoesNotExist.
+func IsErrChatTargetDoesNotExist(err error) bool {
+	_, ok := err.(*ErrChatTargetDoesNotExist)
+	return ok
+}
+
+func (err *ErrChatTargetDoesNotExist) Error() string {
+	return fmt.Sprintf("Chat target does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeChatTargetDoesNotExist holds the unique world-error code of this error
+const ErrCodeChatTargetDoesNotExist = 24001
+
+// HTTPError holds the http error description
+func (err *ErrChatTargetDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeChatTargetDoesNotExist,
+		Message:  "This chat target does not exist.",
+	}
+}
+
+// ErrChatNotificationsDisabled represents an error where a user adds a chat target while chat notifications are disabled
+type ErrChatNotificationsDisabled struct{}
+
+// IsErrChatNotificationsDisabled checks if an error is ErrChatNotificationsDisabled.
+func IsErrChatNotificationsDisabled(err error) bool {
+	_, ok := err.(*ErrChatNotificationsDisabled)
+	return ok
+}
+
+func (err *ErrChatNotificationsDisabled) Error() string {
+	return "Chat notifications are disabled"
+}
+
+// ErrCodeChatNotificationsDisabled holds the unique world-error code of this error
+const ErrCodeChatNotificationsDisabled = 24002
+
+// HTTPError holds the http error description
+func (err *ErrChatNotificationsDisabled) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeChatNotificationsDisabled,
+		Message:  "Chat notifications are not enabled on this instance.",
+	}
+}
+
+// ======================
+// Task comment reply errors
+// ======================
+
+// ErrTaskCommentsDisabled represents an error where a user replies to a notification mail while task comments are disabled
+type ErrTaskCommentsDisabled struct{}
+
+// IsErrTaskCommentsDisabled checks if an error is ErrTaskCommentsDisabled.
+func IsErrTaskCommentsDisabled(err error) bool {
+	_, ok := err.(*ErrTaskCommentsDisabled)
+	return ok
+}
+
+func (err *ErrTaskCommentsDisabled) Error() string {
+	return "Task comments are disabled"
+}
+
+// ErrCodeTaskCommentsDisabled holds the unique world-error code of this error
+const ErrCodeTaskCommentsDisabled = 25001
+
+// HTTPError holds the http error description
+func (err *ErrTaskCommentsDisabled) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskCommentsDisabled,
+		Message:  "Task comments are not enabled on this instance.",
+	}
+}
+
+// ErrInvalidTaskCommentReplyAddress represents an error where a mail was sent to a reply address without a valid signature
+type ErrInvalidTaskCommentReplyAddress struct {
+	Address string
+}
+
+// IsErrInvalidTaskCommentReplyAddress checks if an error is ErrInvalidTaskCommentReplyAddress.
+func IsErrInvalidTaskCommentReplyAddress(err error) bool {
+	_, ok := err.(*ErrInvalidTaskCommentReplyAddress)
+	return ok
+}
+
+func (err *ErrInvalidTaskCommentReplyAddress) Error() string {
+	return fmt.Sprintf("Invalid task comment reply address [Address: %s]", err.Address)
+}
+
+// ErrCodeInvalidTaskCommentReplyAddress holds the unique world-error code of this error
+const ErrCodeInvalidTaskCommentReplyAddress = 25002
+
+// HTTPError holds the http error description
+func (err *ErrInvalidTaskCommentReplyAddress) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeInvalidTaskCommentReplyAddress,
+		Message:  "This reply address does not exist.",
+	}
+}
+
+// ErrTaskCommentReplySenderMismatch represents an error where a reply was not sent by the user the reply address belongs to
+type ErrTaskCommentReplySenderMismatch struct{}
+
+// IsErrTaskCommentReplySenderMismatch checks if an error is ErrTaskCommentReplySenderMismatch.
+func IsErrTaskCommentReplySenderMismatch(err error) bool {
+	_, ok := err.(*ErrTaskCommentReplySenderMismatch)
+	return ok
+}
+
+func (err *ErrTaskCommentReplySenderMismatch) Error() string {
+	return "Task comment reply was sent by another user"
+}
+
+// ErrCodeTaskCommentReplySenderMismatch holds the unique world-error code of this error
+const ErrCodeTaskCommentReplySenderMismatch = 25003
+
+// HTTPError holds the http error description
+func (err *ErrTaskCommentReplySenderMismatch) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeTaskCommentReplySenderMismatch,
+		Message:  "Replies must be sent from the email address the notification was sent to.",
+	}
+}
+
+// ErrEmptyTaskCommentReply represents an error where a reply contains nothing but the quoted mail
+type ErrEmptyTaskCommentReply struct{}
+
+// IsErrEmptyTaskCommentReply checks if an error is ErrEmptyTaskCommentReply.
+func IsErrEmptyTaskCommentReply(err error) bool {
+	_, ok := err.(*ErrEmptyTaskCommentReply)
+	return ok
+}
+
+func (err *ErrEmptyTaskCommentReply) Error() string {
+	return "Task comment reply is empty"
+}
+
+// ErrCodeEmptyTaskCommentReply holds the unique world-error code of this error
+const ErrCodeEmptyTaskCommentReply = 25004
+
+// HTTPError holds the http error description
+func (err *ErrEmptyTaskCommentReply) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeEmptyTaskCommentReply,
+		Message:  "The reply is empty. Please write your comment above the quoted mail.",
+	}
+}
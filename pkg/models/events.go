@@ -58,6 +58,17 @@ func (t *TaskDeletedEvent) Name() string {
 	return "task.deleted"
 }
 
+// TaskRestoredEvent represents an event where a task has been restored from the trash
+type TaskRestoredEvent struct {
+	Task *Task      `json:"task"`
+	Doer *user.User `json:"doer"`
+}
+
+// Name defines the name for TaskRestoredEvent
+func (t *TaskRestoredEvent) Name() string {
+	return "task.restored"
+}
+
 // TaskAssigneeCreatedEvent represents an event where a task has been assigned to a user
 type TaskAssigneeCreatedEvent struct {
 	Task     *Task      `json:"task"`
@@ -166,6 +177,78 @@ func (t *TaskRelationDeletedEvent) Name() string {
 	return "task.relation.deleted"
 }
 
+// TaskTimeEntryCreatedEvent represents an event where time was tracked on a task
+type TaskTimeEntryCreatedEvent struct {
+	Task      *Task          `json:"task"`
+	TimeEntry *TaskTimeEntry `json:"time_entry"`
+	Doer      *user.User     `json:"doer"`
+}
+
+// Name defines the name for TaskTimeEntryCreatedEvent
+func (t *TaskTimeEntryCreatedEvent) Name() string {
+	return "task.time_entry.created"
+}
+
+// TaskTimeEntryUpdatedEvent represents a TaskTimeEntryUpdatedEvent event
+type TaskTimeEntryUpdatedEvent struct {
+	Task      *Task          `json:"task"`
+	TimeEntry *TaskTimeEntry `json:"time_entry"`
+	Doer      *user.User     `json:"doer"`
+}
+
+// Name defines the name for TaskTimeEntryUpdatedEvent
+func (t *TaskTimeEntryUpdatedEvent) Name() string {
+	return "task.time_entry.updated"
+}
+
+// TaskTimeEntryDeletedEvent represents a TaskTimeEntryDeletedEvent event
+type TaskTimeEntryDeletedEvent struct {
+	Task      *Task          `json:"task"`
+	TimeEntry *TaskTimeEntry `json:"time_entry"`
+	Doer      *user.User     `json:"doer"`
+}
+
+// Name defines the name for TaskTimeEntryDeletedEvent
+func (t *TaskTimeEntryDeletedEvent) Name() string {
+	return "task.time_entry.deleted"
+}
+
+// TaskChecklistItemCreatedEvent represents an event where an item was added to the checklist of a task
+type TaskChecklistItemCreatedEvent struct {
+	Task          *Task              `json:"task"`
+	ChecklistItem *TaskChecklistItem `json:"checklist_item"`
+	Doer          *user.User         `json:"doer"`
+}
+
+// Name defines the name for TaskChecklistItemCreatedEvent
+func (t *TaskChecklistItemCreatedEvent) Name() string {
+	return "task.checklist_item.created"
+}
+
+// TaskChecklistItemUpdatedEvent represents a TaskChecklistItemUpdatedEvent event
+type TaskChecklistItemUpdatedEvent struct {
+	Task          *Task              `json:"task"`
+	ChecklistItem *TaskChecklistItem `json:"checklist_item"`
+	Doer          *user.User         `json:"doer"`
+}
+
+// Name defines the name for TaskChecklistItemUpdatedEvent
+func (t *TaskChecklistItemUpdatedEvent) Name() string {
+	return "task.checklist_item.updated"
+}
+
+// TaskChecklistItemDeletedEvent represents a TaskChecklistItemDeletedEvent event
+type TaskChecklistItemDeletedEvent struct {
+	Task          *Task              `json:"task"`
+	ChecklistItem *TaskChecklistItem `json:"checklist_item"`
+	Doer          *user.User         `json:"doer"`
+}
+
+// Name defines the name for TaskChecklistItemDeletedEvent
+func (t *TaskChecklistItemDeletedEvent) Name() string {
+	return "task.checklist_item.deleted"
+}
+
 // TaskPositionsRecalculatedEvent represents a TaskPositionsRecalculatedEvent event
 type TaskPositionsRecalculatedEvent struct {
 	NewTaskPositions []*TaskPosition
@@ -213,6 +296,17 @@ func (p *ProjectDeletedEvent) Name() string {
 	return "project.deleted"
 }
 
+// ProjectRestoredEvent represents an event where a project has been restored from the trash
+type ProjectRestoredEvent struct {
+	Project *Project `json:"project"`
+	Doer    web.Auth `json:"doer"`
+}
+
+// Name defines the name for ProjectRestoredEvent
+func (p *ProjectRestoredEvent) Name() string {
+	return "project.restored"
+}
+
 ////////////////////
 // Sharing Events //
 ////////////////////
@@ -300,3 +394,18 @@ type UserDataExportRequestedEvent struct {
 func (t *UserDataExportRequestedEvent) Name() string {
 	return "user.export.requested"
 }
+
+////////////////////
+// Webhook Events //
+////////////////////
+
+// WebhookDisabledEvent represents an event where a webhook was disabled because too many requests to it failed
+type WebhookDisabledEvent struct {
+	Webhook *Webhook   `json:"webhook"`
+	Creator *user.User `json:"creator"`
+}
+
+// Name defines the name for WebhookDisabledEvent
+func (w *WebhookDisabledEvent) Name() string {
+	return "webhook.disabled"
+}
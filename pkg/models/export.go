@@ -75,6 +75,11 @@ func ExportUserData(s *xorm.Session, u *user.User) (err error) {
 	if err != nil {
 		return err
 	}
+	// Time entries
+	err = exportTimeEntries(s, u, dumpWriter)
+	if err != nil {
+		return err
+	}
 	// Background files
 	err = exportProjectBackgrounds(s, u, dumpWriter)
 	if err != nil {
@@ -378,6 +383,24 @@ func exportSavedFilters(s *xorm.Session, u *user.User, wr *zip.Writer) (err erro
 	return utils.WriteBytesToZip("filters.json", data, wr)
 }
 
+func exportTimeEntries(s *xorm.Session, u *user.User, wr *zip.Writer) (err error) {
+	entries := []*TaskTimeEntry{}
+	err = s.
+		Where("user_id = ?", u.ID).
+		OrderBy("start_time asc, id asc").
+		Find(&entries)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteBytesToZip("time_entries.json", data, wr)
+}
+
 func exportProjectBackgrounds(s *xorm.Session, u *user.User, wr *zip.Writer) (err error) {
 	projects, _, _, err := getRawProjectsForUser(
 		s,
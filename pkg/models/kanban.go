@@ -39,6 +39,8 @@ type Bucket struct {
 	ProjectViewID int64 `xorm:"bigint not null" json:"project_view_id" param:"view"`
 	// All tasks which belong to this bucket.
 	Tasks []*Task `xorm:"-" json:"tasks,omitempty"`
+	// The tasks of this bucket grouped by the swimlanes of the view. Only present if the view has swimlanes configured.
+	Swimlanes []*Swimlane `xorm:"-" json:"swimlanes,omitempty"`
 
 	// How many tasks can be at the same time on this board max
 	Limit int64 `xorm:"default 0" json:"limit" minimum:"0" valid:"range(0|9223372036854775807)"`
@@ -228,9 +230,22 @@ func GetTasksInBucketsForView(s *xorm.Session, view *ProjectView, projects []*Pr
 		}
 	}
 
+	var lanes []*Swimlane
+	if view.SwimlaneMode != SwimlaneModeNone {
+		projectIDs := make([]int64, 0, len(projects))
+		for _, p := range projects {
+			projectIDs = append(projectIDs, p.ID)
+		}
+		lanes, err = getSwimlanesForProjects(s, view, projectIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	originalFilter := opts.filter
 	for id, bucket := range bucketMap {
 
+		filterString := originalFilter
 		if !strings.Contains(originalFilter, taskPropertyBucketID) {
 
 			var bucketFilter = taskPropertyBucketID + " = " + strconv.FormatInt(id, 10)
@@ -245,7 +260,6 @@ func GetTasksInBucketsForView(s *xorm.Session, view *ProjectView, projects []*Pr
 				}
 			}
 
-			var filterString string
 			if originalFilter == "" {
 				filterString = bucketFilter
 			} else {
@@ -262,12 +276,20 @@ func GetTasksInBucketsForView(s *xorm.Session, view *ProjectView, projects []*Pr
 			return nil, err
 		}
 
+		bucket.Count = total
+
+		// Every lane is paginated on its own, the bucket holds the tasks of all of them
+		if view.SwimlaneMode != SwimlaneModeNone {
+			ts, err = addSwimlanesToBucket(s, auth, projects, view, opts, filterString, bucket, lanes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		for _, t := range ts {
 			t.BucketID = bucket.ID
 		}
 
-		bucket.Count = total
-
 		tasks = append(tasks, ts...)
 	}
 
@@ -293,6 +315,10 @@ func GetTasksInBucketsForView(s *xorm.Session, view *ProjectView, projects []*Pr
 		bucketMap[task.BucketID].Tasks = append(bucketMap[task.BucketID].Tasks, task)
 	}
 
+	if view.SwimlaneMode != SwimlaneModeNone {
+		alignSwimlanes(view, buckets, lanes)
+	}
+
 	return buckets, nil
 }
 
@@ -0,0 +1,517 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+type SwimlaneModeKind int
+
+// NOTE: When adding or changing enum values for SwimlaneModeKind,
+// make sure to update the corresponding `enums` tag in the ProjectView struct
+// to keep the OpenAPI documentation in sync.
+
+const (
+	SwimlaneModeNone SwimlaneModeKind = iota
+	SwimlaneModeAssignee
+	SwimlaneModeLabel
+	SwimlaneModePriority
+	SwimlaneModeParentTask
+	SwimlaneModeFilter
+)
+
+func (p *SwimlaneModeKind) MarshalJSON() ([]byte, error) {
+	switch *p {
+	case SwimlaneModeNone:
+		return []byte(`"none"`), nil
+	case SwimlaneModeAssignee:
+		return []byte(`"assignee"`), nil
+	case SwimlaneModeLabel:
+		return []byte(`"label"`), nil
+	case SwimlaneModePriority:
+		return []byte(`"priority"`), nil
+	case SwimlaneModeParentTask:
+		return []byte(`"parent_task"`), nil
+	case SwimlaneModeFilter:
+		return []byte(`"filter"`), nil
+	}
+
+	return []byte(`null`), nil
+}
+
+func (p *SwimlaneModeKind) UnmarshalJSON(bytes []byte) error {
+	var value string
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return err
+	}
+
+	switch value {
+	case "none":
+		*p = SwimlaneModeNone
+	case "assignee":
+		*p = SwimlaneModeAssignee
+	case "label":
+		*p = SwimlaneModeLabel
+	case "priority":
+		*p = SwimlaneModePriority
+	case "parent_task":
+		*p = SwimlaneModeParentTask
+	case "filter":
+		*p = SwimlaneModeFilter
+	default:
+		return fmt.Errorf("unknown swimlane mode kind: %s", value)
+	}
+
+	return nil
+}
+
+type ProjectViewSwimlaneConfiguration struct {
+	Title  string          `json:"title"`
+	Filter *TaskCollection `json:"filter"`
+}
+
+// Swimlane holds the tasks of one swimlane in a kanban bucket
+type Swimlane struct {
+	// Identifies the lane across all buckets of a view, for example `assignee:1`, `label:3`, `priority:2`, `parent_task:5` or `filter:0`.
+	// Tasks without an assignee, label or parent task are grouped in the lane with the id 0, for example `assignee:0`.
+	Key string `json:"key"`
+	// The title of this lane, for example the name of the assignee.
+	Title string `json:"title"`
+	// All tasks of the bucket which belong to this lane.
+	Tasks []*Task `json:"tasks"`
+	// The number of tasks of the bucket in this lane. Like the tasks of a bucket, the tasks of a lane are paginated,
+	// so this might be more than the number of tasks returned.
+	Count int64 `json:"count"`
+
+	id        int64
+	sortValue int64
+}
+
+func (pv *ProjectView) validateSwimlanes() error {
+	if pv.SwimlaneMode == SwimlaneModeNone {
+		return nil
+	}
+
+	if pv.ViewKind != ProjectViewKindKanban {
+		return InvalidFieldErrorWithMessage([]string{"swimlane_mode"}, "Swimlanes are only available for kanban views")
+	}
+
+	if pv.SwimlaneMode != SwimlaneModeFilter {
+		return nil
+	}
+
+	if len(pv.SwimlaneConfiguration) == 0 {
+		return InvalidFieldErrorWithMessage([]string{"swimlane_configuration"}, "Filter swimlanes need at least one lane")
+	}
+
+	for _, lane := range pv.SwimlaneConfiguration {
+		if lane.Filter == nil || lane.Filter.Filter == "" {
+			return InvalidFieldErrorWithMessage([]string{"swimlane_configuration"}, "Every filter swimlane needs a filter")
+		}
+		_, err := getTaskFiltersFromFilterString(lane.Filter.Filter, lane.Filter.FilterTimezone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func swimlaneKey(mode SwimlaneModeKind, id int64) string {
+	var prefix string
+	switch mode {
+	case SwimlaneModeAssignee:
+		prefix = "assignee"
+	case SwimlaneModeLabel:
+		prefix = "label"
+	case SwimlaneModePriority:
+		prefix = "priority"
+	case SwimlaneModeParentTask:
+		prefix = "parent_task"
+	case SwimlaneModeFilter:
+		prefix = "filter"
+	}
+	return prefix + ":" + strconv.FormatInt(id, 10)
+}
+
+func catchAllSwimlaneTitle(mode SwimlaneModeKind) string {
+	switch mode {
+	case SwimlaneModeAssignee:
+		return "No assignee"
+	case SwimlaneModeLabel:
+		return "No label"
+	case SwimlaneModeParentTask:
+		return "No parent task"
+	case SwimlaneModeNone, SwimlaneModePriority, SwimlaneModeFilter:
+	}
+	return ""
+}
+
+func sortSwimlanes(view *ProjectView, lanes []*Swimlane) {
+	sort.Slice(lanes, func(i, j int) bool {
+		// The catch-all lane always comes last
+		if (lanes[i].sortValue == 0) != (lanes[j].sortValue == 0) && view.SwimlaneMode != SwimlaneModeFilter {
+			return lanes[j].sortValue == 0
+		}
+		return lanes[i].sortValue < lanes[j].sortValue
+	})
+}
+
+// getSwimlanesForProjects returns all lanes the tasks of the given projects could be part of in a view, without
+// loading the tasks themselves. Lanes without any task in a bucket are removed again later.
+func getSwimlanesForProjects(s *xorm.Session, view *ProjectView, projectIDs []int64) (lanes []*Swimlane, err error) {
+	tasksInProjects := builder.
+		Select("id").
+		From("tasks").
+		Where(builder.And(builder.In("project_id", projectIDs), builder.IsNull{"deleted"}))
+
+	newLane := func(id int64, title string, sortValue int64) *Swimlane {
+		return &Swimlane{Key: swimlaneKey(view.SwimlaneMode, id), Title: title, id: id, sortValue: sortValue}
+	}
+
+	switch view.SwimlaneMode {
+	case SwimlaneModeNone:
+		return []*Swimlane{}, nil
+	case SwimlaneModeAssignee:
+		userIDs := []int64{}
+		err = s.
+			Table("task_assignees").
+			Distinct("user_id").
+			Where(builder.In("task_id", tasksInProjects)).
+			Find(&userIDs)
+		if err != nil {
+			return nil, err
+		}
+		users, err := user.GetUsersByIDs(s, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			lanes = append(lanes, newLane(u.ID, u.GetName(), u.ID))
+		}
+	case SwimlaneModeLabel:
+		labels := []*Label{}
+		err = s.
+			Where(builder.In("id", builder.
+				Select("label_id").
+				From("label_tasks").
+				Where(builder.In("task_id", tasksInProjects)))).
+			Find(&labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range labels {
+			lanes = append(lanes, newLane(l.ID, l.Title, l.ID))
+		}
+	case SwimlaneModePriority:
+		priorities := []int64{}
+		err = s.
+			Table("tasks").
+			Select("DISTINCT COALESCE(priority, 0)").
+			Where(builder.In("id", tasksInProjects)).
+			Find(&priorities)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range priorities {
+			// Higher priorities come first
+			lanes = append(lanes, newLane(p, strconv.FormatInt(p, 10), -p))
+		}
+	case SwimlaneModeParentTask:
+		parents := []*Task{}
+		err = s.
+			Where(builder.In("id", builder.
+				Select("other_task_id").
+				From("task_relations").
+				Where(builder.And(
+					builder.In("task_id", tasksInProjects),
+					builder.Eq{"relation_kind": RelationKindParenttask},
+				)))).
+			Find(&parents)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parents {
+			lanes = append(lanes, newLane(p.ID, p.Title, p.ID))
+		}
+	case SwimlaneModeFilter:
+		for i, lane := range view.SwimlaneConfiguration {
+			lanes = append(lanes, newLane(int64(i), lane.Title, int64(i)))
+		}
+	}
+
+	if view.SwimlaneMode != SwimlaneModeFilter && view.SwimlaneMode != SwimlaneModePriority {
+		lanes = append(lanes, newLane(0, catchAllSwimlaneTitle(view.SwimlaneMode), 0))
+	}
+
+	sortSwimlanes(view, lanes)
+	return lanes, nil
+}
+
+// getSwimlaneCond returns the condition matching all tasks in a lane.
+func getSwimlaneCond(view *ProjectView, lane *Swimlane) (builder.Cond, error) {
+	taskIDsIn := func(table string, cond builder.Cond) builder.Cond {
+		return builder.In("tasks.id", builder.Select("task_id").From(table).Where(cond))
+	}
+	taskIDsNotIn := func(table string, cond builder.Cond) builder.Cond {
+		return builder.NotIn("tasks.id", builder.Select("task_id").From(table).Where(cond))
+	}
+
+	switch view.SwimlaneMode {
+	case SwimlaneModeAssignee:
+		if lane.id == 0 {
+			return taskIDsNotIn("task_assignees", builder.Expr("1 = 1")), nil
+		}
+		return taskIDsIn("task_assignees", builder.Eq{"user_id": lane.id}), nil
+	case SwimlaneModeLabel:
+		if lane.id == 0 {
+			return taskIDsNotIn("label_tasks", builder.Expr("1 = 1")), nil
+		}
+		return taskIDsIn("label_tasks", builder.Eq{"label_id": lane.id}), nil
+	case SwimlaneModePriority:
+		if lane.id == 0 {
+			return builder.Or(builder.Eq{"tasks.priority": 0}, builder.IsNull{"tasks.priority"}), nil
+		}
+		return builder.Eq{"tasks.priority": lane.id}, nil
+	case SwimlaneModeParentTask:
+		if lane.id == 0 {
+			return taskIDsNotIn("task_relations", builder.Eq{"relation_kind": RelationKindParenttask}), nil
+		}
+		return taskIDsIn("task_relations", builder.Eq{"relation_kind": RelationKindParenttask, "other_task_id": lane.id}), nil
+	case SwimlaneModeFilter:
+		if lane.id < 0 || lane.id >= int64(len(view.SwimlaneConfiguration)) || view.SwimlaneConfiguration[lane.id].Filter == nil {
+			return builder.Expr("1 = 0"), nil
+		}
+		filter := view.SwimlaneConfiguration[lane.id].Filter
+		filters, err := getTaskFiltersFromFilterString(filter.Filter, filter.FilterTimezone)
+		if err != nil {
+			return nil, err
+		}
+		return convertFiltersToDBFilterCond(filters, filter.FilterIncludeNulls)
+	case SwimlaneModeNone:
+	}
+
+	return nil, nil
+}
+
+// addSwimlanesToBucket fetches the tasks of every lane in a bucket. Each lane is paginated on its own, the same
+// way the tasks of a bucket are. It returns the tasks of all lanes, every task only once.
+func addSwimlanesToBucket(s *xorm.Session, a web.Auth, projects []*Project, view *ProjectView, opts *taskSearchOptions, filter string, bucket *Bucket, lanes []*Swimlane) (tasks []*Task, err error) {
+	seen := make(map[int64]*Task)
+	bucket.Swimlanes = make([]*Swimlane, 0, len(lanes))
+
+	for _, lane := range lanes {
+		laneOpts := *opts
+		laneOpts.swimlaneCond, err = getSwimlaneCond(view, lane)
+		if err != nil {
+			return nil, err
+		}
+		// Building the query changes the parsed filters, every lane needs its own
+		laneOpts.parsedFilters, err = getTaskFiltersFromFilterString(filter, opts.filterTimezone)
+		if err != nil {
+			return nil, err
+		}
+
+		ts, _, total, err := getRawTasksForProjects(s, projects, a, &laneOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		bl := &Swimlane{
+			Key:   lane.Key,
+			Title: lane.Title,
+			Tasks: make([]*Task, 0, len(ts)),
+			Count: total,
+			id:    lane.id,
+		}
+		for _, t := range ts {
+			// A task can be part of more than one lane, it should be the same task everywhere
+			if existing, has := seen[t.ID]; has {
+				t = existing
+			} else {
+				seen[t.ID] = t
+				tasks = append(tasks, t)
+			}
+			bl.Tasks = append(bl.Tasks, t)
+		}
+		bucket.Swimlanes = append(bucket.Swimlanes, bl)
+	}
+
+	return tasks, nil
+}
+
+// alignSwimlanes makes sure all buckets have the same lanes in the same order. Lanes without tasks in any
+// bucket are removed, except for filter lanes which are configured explicitly.
+func alignSwimlanes(view *ProjectView, buckets []*Bucket, lanes []*Swimlane) {
+	used := make(map[string]bool, len(lanes))
+	for _, b := range buckets {
+		for _, lane := range b.Swimlanes {
+			if lane.Count > 0 {
+				used[lane.Key] = true
+			}
+		}
+	}
+
+	for _, b := range buckets {
+		bucketLanes := make(map[string]*Swimlane, len(b.Swimlanes))
+		for _, lane := range b.Swimlanes {
+			bucketLanes[lane.Key] = lane
+		}
+
+		b.Swimlanes = make([]*Swimlane, 0, len(lanes))
+		for _, lane := range lanes {
+			if !used[lane.Key] && view.SwimlaneMode != SwimlaneModeFilter {
+				continue
+			}
+			bl, has := bucketLanes[lane.Key]
+			if !has {
+				// Buckets which were not requested
+				bl = &Swimlane{Key: lane.Key, Title: lane.Title, Tasks: []*Task{}, id: lane.id}
+			}
+			b.Swimlanes = append(b.Swimlanes, bl)
+		}
+	}
+}
+
+// getSwimlaneIDsForTask returns the ids of all lanes a task belongs to. Assignees, labels, the priority and
+// the parent task are taken from the task passed in when it has them, so that a task which is about to be
+// created or changed is checked against the lanes it will end up in. Filter lanes need the stored task.
+func getSwimlaneIDsForTask(s *xorm.Session, view *ProjectView, t *Task) (ids []int64, err error) {
+	switch view.SwimlaneMode {
+	case SwimlaneModeNone:
+		return nil, nil
+	case SwimlaneModeAssignee:
+		if t.Assignees == nil {
+			assignees, err := getRawTaskAssigneesForTasks(s, []int64{t.ID})
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range assignees {
+				ids = append(ids, a.User.ID)
+			}
+			break
+		}
+		for _, a := range t.Assignees {
+			ids = append(ids, a.ID)
+		}
+	case SwimlaneModeLabel:
+		if t.Labels == nil {
+			err = s.
+				Table("label_tasks").
+				Cols("label_id").
+				Where("task_id = ?", t.ID).
+				Find(&ids)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		for _, l := range t.Labels {
+			ids = append(ids, l.ID)
+		}
+	case SwimlaneModePriority:
+		return []int64{t.Priority}, nil
+	case SwimlaneModeParentTask:
+		if t.RelatedTasks == nil {
+			err = s.
+				Table("task_relations").
+				Cols("other_task_id").
+				Where("task_id = ? AND relation_kind = ?", t.ID, RelationKindParenttask).
+				Find(&ids)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		for _, p := range t.RelatedTasks[RelationKindParenttask] {
+			ids = append(ids, p.ID)
+		}
+	case SwimlaneModeFilter:
+		for i := range view.SwimlaneConfiguration {
+			cond, err := getSwimlaneCond(view, &Swimlane{id: int64(i)})
+			if err != nil {
+				return nil, err
+			}
+			matches, err := s.
+				Table("tasks").
+				Join("LEFT", "task_buckets", "task_buckets.task_id = tasks.id AND task_buckets.project_view_id = ?", view.ID).
+				Where(builder.And(builder.Eq{"tasks.id": t.ID}, cond)).
+				Exist()
+			if err != nil {
+				return nil, err
+			}
+			if matches {
+				ids = append(ids, int64(i))
+			}
+		}
+		return ids, nil
+	}
+
+	// Tasks without an assignee, label or parent task are part of the catch-all lane
+	if len(ids) == 0 {
+		ids = []int64{0}
+	}
+
+	return ids, nil
+}
+
+// checkSwimlaneLimit enforces the limit of a bucket per swimlane: every lane the task belongs to may hold
+// at most as many tasks in the bucket as the bucket's limit.
+func checkSwimlaneLimit(s *xorm.Session, view *ProjectView, t *Task, bucket *Bucket) error {
+	laneIDs, err := getSwimlaneIDsForTask(s, view, t)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range laneIDs {
+		laneCond, err := getSwimlaneCond(view, &Swimlane{id: id})
+		if err != nil {
+			return err
+		}
+
+		// Tasks in the trash keep their bucket, but they must not count towards the limit.
+		count, err := s.
+			Table("tasks").
+			Join("INNER", "task_buckets", "task_buckets.task_id = tasks.id AND task_buckets.project_view_id = ?", view.ID).
+			Where(builder.And(
+				builder.Eq{"task_buckets.bucket_id": bucket.ID},
+				builder.Neq{"tasks.id": t.ID},
+				builder.IsNull{"tasks.deleted"},
+				laneCond,
+			)).
+			Count()
+		if err != nil {
+			return err
+		}
+
+		if count >= bucket.Limit {
+			return ErrBucketLimitExceeded{TaskID: t.ID, BucketID: bucket.ID, Limit: bucket.Limit}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func setSwimlanes(t *testing.T, s *xorm.Session, mode SwimlaneModeKind, config []*ProjectViewSwimlaneConfiguration) {
+	_, err := s.ID(4).
+		Cols("swimlane_mode", "swimlane_configuration").
+		Update(&ProjectView{SwimlaneMode: mode, SwimlaneConfiguration: config})
+	require.NoError(t, err)
+}
+
+func getSwimlane(bucket *Bucket, key string) *Swimlane {
+	for _, lane := range bucket.Swimlanes {
+		if lane.Key == key {
+			return lane
+		}
+	}
+	return nil
+}
+
+func TestGetTasksInBucketsForView_Swimlanes(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("by assignee", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModeAssignee, nil)
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 4}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		buckets := result.([]*Bucket)
+		require.Len(t, buckets, 3)
+
+		// Every bucket has the same lanes, the lane for tasks without an assignee comes last
+		for _, b := range buckets {
+			require.Len(t, b.Swimlanes, 3)
+			assert.Equal(t, "assignee:1", b.Swimlanes[0].Key)
+			assert.Equal(t, "assignee:2", b.Swimlanes[1].Key)
+			assert.Equal(t, "assignee:0", b.Swimlanes[2].Key)
+		}
+
+		// Task 30 is assigned to user 1 and 2 and therefore part of both lanes
+		lane := getSwimlane(buckets[0], "assignee:1")
+		require.Len(t, lane.Tasks, 1)
+		assert.Equal(t, int64(30), lane.Tasks[0].ID)
+		assert.Equal(t, int64(1), getSwimlane(buckets[0], "assignee:2").Count)
+		assert.Equal(t, int64(len(buckets[0].Tasks)-1), getSwimlane(buckets[0], "assignee:0").Count)
+		assert.Equal(t, int64(0), getSwimlane(buckets[1], "assignee:1").Count)
+		assert.Equal(t, int64(3), getSwimlane(buckets[1], "assignee:0").Count)
+	})
+	t.Run("by filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModeFilter, []*ProjectViewSwimlaneConfiguration{
+			{Title: "Done", Filter: &TaskCollection{Filter: "done = true"}},
+			{Title: "Open", Filter: &TaskCollection{Filter: "done = false"}},
+		})
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 4}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		buckets := result.([]*Bucket)
+		require.Len(t, buckets, 3)
+
+		doneBucket := buckets[2]
+		require.Len(t, doneBucket.Swimlanes, 2)
+		assert.Equal(t, "Done", doneBucket.Swimlanes[0].Title)
+		require.Len(t, doneBucket.Swimlanes[0].Tasks, 1)
+		assert.Equal(t, int64(2), doneBucket.Swimlanes[0].Tasks[0].ID)
+		assert.Equal(t, int64(3), doneBucket.Swimlanes[1].Count)
+	})
+	t.Run("by priority", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModePriority, nil)
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 4}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		buckets := result.([]*Bucket)
+		require.Len(t, buckets, 3)
+
+		// Higher priorities come first, tasks without a priority last
+		lanes := buckets[1].Swimlanes
+		require.NotEmpty(t, lanes)
+		assert.Equal(t, "priority:100", lanes[0].Key)
+		require.Len(t, lanes[0].Tasks, 1)
+		assert.Equal(t, int64(3), lanes[0].Tasks[0].ID)
+		assert.Equal(t, "priority:0", lanes[len(lanes)-1].Key)
+		assert.Equal(t, int64(1), lanes[len(lanes)-1].Count)
+	})
+	t.Run("lanes are paginated on their own", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModeAssignee, nil)
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 4}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 1)
+		require.NoError(t, err)
+		buckets := result.([]*Bucket)
+		require.Len(t, buckets, 3)
+
+		lane := getSwimlane(buckets[1], "assignee:0")
+		require.NotNil(t, lane)
+		assert.Len(t, lane.Tasks, 1)
+		assert.Equal(t, int64(3), lane.Count)
+		assert.Equal(t, int64(3), buckets[1].Count)
+
+		// The first page of every lane is part of the bucket
+		lane = getSwimlane(buckets[0], "assignee:1")
+		require.Len(t, lane.Tasks, 1)
+		assert.Equal(t, int64(30), lane.Tasks[0].ID)
+		assert.Len(t, getSwimlane(buckets[0], "assignee:0").Tasks, 1)
+		assert.Len(t, buckets[0].Tasks, 2)
+	})
+	t.Run("no swimlanes", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 4}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		for _, b := range result.([]*Bucket) {
+			assert.Nil(t, b.Swimlanes)
+		}
+	})
+}
+
+func TestSwimlaneLimit(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("allows moving into a full bucket when the lane has room", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModeAssignee, nil)
+
+		// Bucket 2 has a limit of 3 and holds three tasks without an assignee
+		tb := &TaskBucket{TaskID: 30, BucketID: 2, ProjectViewID: 4, ProjectID: 1}
+		err := tb.Update(s, u)
+		require.NoError(t, err)
+	})
+	t.Run("rejects moving into a full lane", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModeAssignee, nil)
+
+		tb := &TaskBucket{TaskID: 1, BucketID: 2, ProjectViewID: 4, ProjectID: 1}
+		err := tb.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrBucketLimitExceeded(err))
+	})
+	t.Run("uses the assignees of a new task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModeAssignee, nil)
+
+		// The lane without an assignee is full, the one of user 1 is not
+		task := &Task{Title: "Lane", ProjectID: 1, BucketID: 2, Assignees: []*user.User{{ID: 1}}}
+		err := task.Create(s, u)
+		require.NoError(t, err)
+
+		task = &Task{Title: "Lane", ProjectID: 1, BucketID: 2}
+		err = task.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrBucketLimitExceeded(err))
+	})
+	t.Run("uses the new priority of an updated task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setSwimlanes(t, s, SwimlaneModePriority, nil)
+		// The done bucket holds task 2 which has no priority
+		_, err := s.ID(3).Cols("limit").Update(&Bucket{Limit: 1})
+		require.NoError(t, err)
+
+		task := &Task{ID: 1, Title: "task #1", ProjectID: 1, Done: true}
+		err = task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrBucketLimitExceeded(err))
+
+		task = &Task{ID: 1, Title: "task #1", ProjectID: 1, Done: true, Priority: 3}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+	})
+}
+
+func TestProjectView_ValidateSwimlanes(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("only on kanban views", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		view := &ProjectView{ID: 1, ProjectID: 1, Title: "List", ViewKind: ProjectViewKindList, SwimlaneMode: SwimlaneModeAssignee}
+		err := view.Update(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("filter lanes need a filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		view := &ProjectView{
+			ProjectID:             1,
+			Title:                 "Lanes",
+			ViewKind:              ProjectViewKindKanban,
+			SwimlaneMode:          SwimlaneModeFilter,
+			SwimlaneConfiguration: []*ProjectViewSwimlaneConfiguration{{Title: "Empty"}},
+		}
+		err := view.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
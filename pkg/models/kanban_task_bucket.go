@@ -41,6 +41,10 @@ type TaskBucket struct {
 	ProjectID     int64 `xorm:"-" json:"-" param:"project"`
 	Task          *Task `xorm:"-" json:"task"`
 
+	// The task as it will be once the current update is saved. Bucket and lane limits
+	// are checked against it instead of the stored task.
+	incomingTask *Task
+
 	web.Permissions `xorm:"-" json:"-"`
 	web.CRUDable    `xorm:"-" json:"-"`
 }
@@ -125,11 +129,28 @@ func updateTaskBucket(s *xorm.Session, a web.Auth, b *TaskBucket) (err error) {
 	// Check the bucket limit
 	// Only check the bucket limit if the task is being moved between buckets, allow reordering the task within a bucket
 	if b.BucketID != 0 && b.BucketID != oldTaskBucket.BucketID {
-		taskCount, err := checkBucketLimit(s, a, task, bucket)
+		limitTask := task
+		if b.incomingTask != nil {
+			limitTask = b.incomingTask
+		}
+		taskCount, err := checkBucketLimit(s, a, limitTask, bucket)
 		if err != nil {
 			return err
 		}
 		bucket.Count = taskCount
+
+		// Moving a task out of the done bucket marks it undone, so it counts towards the limits from now on
+		willBeDone := task.Done && (view.DoneBucketID == 0 || oldTaskBucket.BucketID != view.DoneBucketID)
+		if !willBeDone && b.BucketID != view.DoneBucketID {
+			assigneeIDs := make([]int64, 0, len(task.Assignees))
+			for _, assignee := range task.Assignees {
+				assigneeIDs = append(assigneeIDs, assignee.ID)
+			}
+			task.WIPLimitWarnings, err = checkWIPLimits(s, task.ID, []*Bucket{bucket}, assigneeIDs)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	var updateBucket = true
@@ -139,6 +160,11 @@ func updateTaskBucket(s *xorm.Session, a web.Auth, b *TaskBucket) (err error) {
 	var doneChanged bool
 	if view.DoneBucketID != 0 {
 		if view.DoneBucketID == b.BucketID && !task.Done {
+			err = checkTaskCanBeCompleted(s, task)
+			if err != nil {
+				return err
+			}
+
 			doneChanged = true
 			task.Done = true
 			if task.isRepeating() {
@@ -180,6 +206,11 @@ func updateTaskBucket(s *xorm.Session, a web.Auth, b *TaskBucket) (err error) {
 			return
 		}
 
+		err = rollupSubtasksIntoParents(s, a, task.ID, nil, nil)
+		if err != nil {
+			return
+		}
+
 		// Since the done state of the task was changed, we need to move the task into all done buckets everywhere
 		if task.Done {
 			viewsWithDoneBucket := []*ProjectView{}
@@ -45,6 +45,23 @@ func TestTaskBucket_Update(t *testing.T) {
 		require.Error(t, err)
 		assert.True(t, IsErrBucketLimitExceeded(err))
 	})
+	t.Run("tasks in the trash do not count towards the limit", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := moveTaskToTrash(s, u, &Task{ID: 3, ProjectID: 1})
+		require.NoError(t, err)
+
+		tb := &TaskBucket{
+			TaskID:        1,
+			BucketID:      2, // Bucket 2 has 3 tasks and a limit of 3, but one of them is in the trash
+			ProjectViewID: 4,
+			ProjectID:     1, // In actual web requests set via the url
+		}
+		err = tb.Update(s, u)
+		require.NoError(t, err)
+	})
 	t.Run("full bucket but not changing the bucket", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
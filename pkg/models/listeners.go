@@ -43,6 +43,8 @@ func RegisterListeners() {
 		events.RegisterListener((&ProjectDeletedEvent{}).Name(), &DecreaseProjectCounter{})
 		events.RegisterListener((&TaskCreatedEvent{}).Name(), &IncreaseTaskCounter{})
 		events.RegisterListener((&TaskDeletedEvent{}).Name(), &DecreaseTaskCounter{})
+		events.RegisterListener((&TaskRestoredEvent{}).Name(), &IncreaseTaskCounter{})
+		events.RegisterListener((&ProjectRestoredEvent{}).Name(), &IncreaseProjectCounter{})
 		events.RegisterListener((&TeamDeletedEvent{}).Name(), &DecreaseTeamCounter{})
 		events.RegisterListener((&TeamCreatedEvent{}).Name(), &IncreaseTeamCounter{})
 		events.RegisterListener((&TaskAttachmentCreatedEvent{}).Name(), &IncreaseAttachmentCounter{})
@@ -67,19 +69,42 @@ func RegisterListeners() {
 	events.RegisterListener((&TaskAttachmentDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskRelationCreatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskRelationDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskTimeEntryCreatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskTimeEntryUpdatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskTimeEntryDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskChecklistItemCreatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskChecklistItemUpdatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskChecklistItemDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskCreatedEvent{}).Name(), &UpdateTaskInSavedFilterViews{})
 	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &UpdateTaskInSavedFilterViews{})
 	events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &MarkTaskUnreadOnComment{})
 	if config.TypesenseEnabled.GetBool() {
 		events.RegisterListener((&TaskDeletedEvent{}).Name(), &RemoveTaskFromTypesense{})
 		events.RegisterListener((&TaskCreatedEvent{}).Name(), &AddTaskToTypesense{})
+		events.RegisterListener((&TaskRestoredEvent{}).Name(), &AddTaskToTypesense{})
 		events.RegisterListener((&TaskUpdatedEvent{}).Name(), &UpdateTaskInTypesense{})
 		events.RegisterListener((&TaskPositionsRecalculatedEvent{}).Name(), &UpdateTaskPositionsInTypesense{})
-	}
+		events.RegisterListener((&TaskTimeEntryCreatedEvent{}).Name(), &UpdateTaskInTypesense{})
+		events.RegisterListener((&TaskTimeEntryUpdatedEvent{}).Name(), &UpdateTaskInTypesense{})
+		events.RegisterListener((&TaskTimeEntryDeletedEvent{}).Name(), &UpdateTaskInTypesense{})
+		events.RegisterListener((&TaskChecklistItemCreatedEvent{}).Name(), &UpdateTaskInTypesense{})
+		events.RegisterListener((&TaskChecklistItemUpdatedEvent{}).Name(), &UpdateTaskInTypesense{})
+		events.RegisterListener((&TaskChecklistItemDeletedEvent{}).Name(), &UpdateTaskInTypesense{})
+	}
+	RegisterEventForAutomation(&TaskCreatedEvent{})
+	RegisterEventForAutomation(&TaskUpdatedEvent{})
+	RegisterEventForAutomation(&TaskRestoredEvent{})
+	RegisterEventForAutomation(&TaskAssigneeCreatedEvent{})
+	RegisterEventForAutomation(&TaskAssigneeDeletedEvent{})
+	RegisterEventForAutomation(&TaskCommentCreatedEvent{})
+	RegisterEventForAutomation(&TaskAttachmentCreatedEvent{})
+	RegisterEventForAutomation(&TaskRelationCreatedEvent{})
+	RegisterEventForAutomation(&TaskRelationDeletedEvent{})
 	if config.WebhooksEnabled.GetBool() {
 		RegisterEventForWebhook(&TaskCreatedEvent{})
 		RegisterEventForWebhook(&TaskUpdatedEvent{})
 		RegisterEventForWebhook(&TaskDeletedEvent{})
+		RegisterEventForWebhook(&TaskRestoredEvent{})
 		RegisterEventForWebhook(&TaskAssigneeCreatedEvent{})
 		RegisterEventForWebhook(&TaskAssigneeDeletedEvent{})
 		RegisterEventForWebhook(&TaskCommentCreatedEvent{})
@@ -89,10 +114,22 @@ func RegisterListeners() {
 		RegisterEventForWebhook(&TaskAttachmentDeletedEvent{})
 		RegisterEventForWebhook(&TaskRelationCreatedEvent{})
 		RegisterEventForWebhook(&TaskRelationDeletedEvent{})
+		RegisterEventForWebhook(&TaskTimeEntryCreatedEvent{})
+		RegisterEventForWebhook(&TaskTimeEntryUpdatedEvent{})
+		RegisterEventForWebhook(&TaskTimeEntryDeletedEvent{})
+		RegisterEventForWebhook(&TaskChecklistItemCreatedEvent{})
+		RegisterEventForWebhook(&TaskChecklistItemUpdatedEvent{})
+		RegisterEventForWebhook(&TaskChecklistItemDeletedEvent{})
 		RegisterEventForWebhook(&ProjectUpdatedEvent{})
 		RegisterEventForWebhook(&ProjectDeletedEvent{})
+		RegisterEventForWebhook(&ProjectRestoredEvent{})
 		RegisterEventForWebhook(&ProjectSharedWithUserEvent{})
 		RegisterEventForWebhook(&ProjectSharedWithTeamEvent{})
+		RegisterEventForWebhook(&TeamCreatedEvent{})
+		RegisterEventForWebhook(&TeamMemberAddedEvent{})
+		RegisterEventForWebhook(&TeamMemberRemovedEvent{})
+
+		events.RegisterListener((&WebhookDisabledEvent{}).Name(), &SendWebhookDisabledNotification{})
 	}
 }
 
@@ -825,6 +862,56 @@ func (s *SendProjectCreatedNotification) Handle(msg *message.Message) (err error
 	return nil
 }
 
+// SendWebhookDisabledNotification  represents a listener
+type SendWebhookDisabledNotification struct {
+}
+
+// Name defines the name for the SendWebhookDisabledNotification listener
+func (s *SendWebhookDisabledNotification) Name() string {
+	return "send.webhook.disabled.notification"
+}
+
+// Handle is executed when the event SendWebhookDisabledNotification listens on is fired
+func (s *SendWebhookDisabledNotification) Handle(msg *message.Message) (err error) {
+	event := &WebhookDisabledEvent{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	creator, err := user.GetUserByID(sess, event.Creator.ID)
+	if err != nil {
+		if user.IsErrUserDoesNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	n := &WebhookDisabledNotification{
+		User:    creator,
+		Webhook: event.Webhook,
+	}
+
+	if event.Webhook.ProjectID != 0 {
+		n.Project, err = GetProjectSimpleByID(sess, event.Webhook.ProjectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if event.Webhook.TeamID != 0 {
+		n.Team, err = GetTeamByID(sess, event.Webhook.TeamID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return notifications.Notify(creator, n)
+}
+
 // WebhookListener represents a listener
 type WebhookListener struct {
 	EventName string
@@ -896,6 +983,17 @@ func getProjectIDFromAnyEvent(eventPayload map[string]interface{}) int64 {
 	return 0
 }
 
+func getTeamIDFromAnyEvent(eventPayload map[string]interface{}) int64 {
+	if team, has := eventPayload["team"]; has {
+		t, ok := team.(map[string]interface{})
+		if ok {
+			return getIDAsInt64(t["id"])
+		}
+	}
+
+	return 0
+}
+
 func reloadEventData(s *xorm.Session, event map[string]interface{}, projectID int64) (eventWithData map[string]interface{}, doerID int64, err error) {
 	// Load event data again so that it is always populated in the webhook payload
 	if doer, has := event["doer"]; has && doer != nil {
@@ -969,45 +1067,22 @@ func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
 	}
 
 	projectID := getProjectIDFromAnyEvent(event)
-	if projectID == 0 {
-		log.Debugf("event %s does not contain a project id, not handling webhook", wl.EventName)
+	teamID := getTeamIDFromAnyEvent(event)
+	if projectID == 0 && teamID == 0 {
+		log.Debugf("event %s does not contain a project or team id, not handling webhook", wl.EventName)
 		return nil
 	}
 
 	s := db.NewSession()
 	defer s.Close()
 
-	parents, err := GetAllParentProjects(s, projectID)
-	if err != nil {
-		return err
-	}
-
-	projectIDs := make([]int64, 0, len(parents)+1)
-	projectIDs = append(projectIDs, projectID)
-
-	for _, p := range parents {
-		projectIDs = append(projectIDs, p.ID)
-	}
-
-	ws := []*Webhook{}
-	err = s.In("project_id", projectIDs).
-		Find(&ws)
+	matchingWebhooks, err := getWebhooksForEvent(s, wl.EventName, projectID, teamID)
 	if err != nil {
 		return err
 	}
 
-	matchingWebhooks := []*Webhook{}
-	for _, w := range ws {
-		for _, e := range w.Events {
-			if e == wl.EventName {
-				matchingWebhooks = append(matchingWebhooks, w)
-				break
-			}
-		}
-	}
-
 	if len(matchingWebhooks) == 0 {
-		log.Debugf("Did not find any webhook for the %s event for project %d, not sending", wl.EventName, projectID)
+		log.Debugf("Did not find any webhook for the %s event for project %d or team %d, not sending", wl.EventName, projectID, teamID)
 		return nil
 	}
 
@@ -1019,8 +1094,13 @@ func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
 
 	for _, webhook := range matchingWebhooks {
 
-		if _, has := event["project"]; !has {
-			project, err := GetProjectSimpleByID(s, webhook.ProjectID)
+		webhookProjectID := webhook.ProjectID
+		if webhookProjectID == 0 {
+			webhookProjectID = projectID
+		}
+
+		if _, has := event["project"]; !has && webhookProjectID != 0 {
+			project, err := GetProjectSimpleByID(s, webhookProjectID)
 			if err != nil && !IsErrProjectDoesNotExist(err) {
 				log.Errorf("Could not load project for webhook %d: %s", webhook.ID, err)
 			}
@@ -1035,7 +1115,7 @@ func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
 			}
 		}
 
-		err = webhook.sendWebhookPayload(&WebhookPayload{
+		err = webhook.sendWebhookPayload(s, &WebhookPayload{
 			EventName: wl.EventName,
 			Time:      time.Now(),
 			Data:      event,
@@ -1048,6 +1128,56 @@ func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
 	return
 }
 
+// AutomationListener represents a listener
+type AutomationListener struct {
+	EventName string
+}
+
+// Name defines the name for the AutomationListener listener
+func (al *AutomationListener) Name() string {
+	return "automation.listener"
+}
+
+// Handle is executed when the event AutomationListener listens on is fired
+func (al *AutomationListener) Handle(msg *message.Message) (err error) {
+	event := map[string]interface{}{}
+	err = json.Unmarshal(msg.Payload, &event)
+	if err != nil {
+		return err
+	}
+
+	task, is := event["task"].(map[string]interface{})
+	if !is {
+		log.Debugf("event %s does not contain a task, not running automation rules", al.EventName)
+		return nil
+	}
+	taskID := getIDAsInt64(task["id"])
+	projectID := getIDAsInt64(task["project_id"])
+	if taskID == 0 || projectID == 0 {
+		return nil
+	}
+
+	s := db.NewSession()
+	rules := []*AutomationRule{}
+	err = s.
+		Where("project_id = ? AND event = ? AND is_disabled = ?", projectID, al.EventName, false).
+		OrderBy("id asc").
+		Find(&rules)
+	s.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		err = rule.run(al.EventName, taskID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 ///////
 // Team Events
 
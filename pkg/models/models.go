@@ -66,6 +66,20 @@ func GetTables() []interface{} {
 		&TaskPosition{},
 		&TaskBucket{},
 		&TaskUnreadStatus{},
+		&TaskTimeEntry{},
+		&TaskChecklistItem{},
+		&ProjectCustomField{},
+		&TaskCustomFieldValue{},
+		&AuditLogEntry{},
+		&TrashItem{},
+		&WebhookDelivery{},
+		&Template{},
+		&AutomationRule{},
+		&AutomationRuleExecution{},
+		&WIPLimit{},
+		&CalendarFeed{},
+		&Sprint{},
+		&SprintTask{},
 	}
 }
 
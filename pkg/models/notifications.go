@@ -26,11 +26,26 @@ import (
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/i18n"
+	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/notifications"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/utils"
 )
 
+func init() {
+	// Users can choose through which channels they want to get these, all others are always sent.
+	notifications.RegisterConfigurableNotification(
+		"task.reminder",
+		"task.comment",
+		"task.assigned",
+		"task.deleted",
+		"task.mentioned",
+		"task.undone.overdue",
+		"project.created",
+		"team.member.added",
+	)
+}
+
 // getThreadID generates a Message-ID format thread ID for a task
 func getThreadID(taskID int64) string {
 	domain := "vikunja"
@@ -43,6 +58,32 @@ func getThreadID(taskID int64) string {
 	return fmt.Sprintf("<task-%d@%s>", taskID, domain)
 }
 
+// getDigestEntryForTask returns where a notification about a task belongs in a digest mail.
+func getDigestEntryForTask(task *Task, summary string) *notifications.DigestEntry {
+	entry := &notifications.DigestEntry{
+		ProjectID: task.ProjectID,
+		Title:     task.Title,
+		URL:       task.GetFrontendURL(),
+		Summary:   summary,
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+	project, err := GetProjectSimpleByID(s, task.ProjectID)
+	if err != nil {
+		log.Errorf("Could not get project %d for digest entry of task %d: %s", task.ProjectID, task.ID, err)
+		return entry
+	}
+	entry.ProjectTitle = project.Title
+	return entry
+}
+
+// getPushTag returns the tag for push messages about a task. A newer message with the same tag replaces the
+// older one on the device, so reminders about the same task do not pile up.
+func getPushTag(taskID int64) string {
+	return "task-" + strconv.FormatInt(taskID, 10)
+}
+
 // ReminderDueNotification represents a ReminderDueNotification notification
 type ReminderDueNotification struct {
 	User    *user.User `json:"user,omitempty"`
@@ -52,6 +93,15 @@ type ReminderDueNotification struct {
 
 // ToMail returns the mail notification for ReminderDueNotification
 func (n *ReminderDueNotification) ToMail(lang string) *notifications.Mail {
+	// Users without reminders per mail only get reminders as push or chat messages
+	if !n.User.EmailRemindersEnabled {
+		return nil
+	}
+
+	return n.mail(lang)
+}
+
+func (n *ReminderDueNotification) mail(lang string) *notifications.Mail {
 	return notifications.NewMail().
 		IncludeLinkToSettings(lang).
 		To(n.User.Email).
@@ -80,6 +130,32 @@ func (n *ReminderDueNotification) ThreadID() string {
 	return getThreadID(n.Task.ID)
 }
 
+// ReplyTo returns the address the notifiable can reply to in order to comment on the task
+func (n *ReminderDueNotification) ReplyTo(notifiable notifications.Notifiable) string {
+	return getTaskReplyTo(notifiable, n.Task.ID)
+}
+
+// ProjectID returns the project this notification is about
+func (n *ReminderDueNotification) ProjectID() int64 {
+	return n.Task.ProjectID
+}
+
+// ToChat returns the chat message for ReminderDueNotification. Unlike the mail, it is sent regardless of whether
+// the user turned on reminders per mail.
+func (n *ReminderDueNotification) ToChat(lang string) *notifications.ChatMessage {
+	return notifications.NewChatMessage(n.mail(lang))
+}
+
+// ToPush returns the push message for ReminderDueNotification
+func (n *ReminderDueNotification) ToPush(lang string) *notifications.Push {
+	return &notifications.Push{
+		Title: i18n.T(lang, "notifications.task.reminder.subject", n.Task.Title, n.Project.Title),
+		Body:  i18n.T(lang, "notifications.task.reminder.message", n.Task.Title, n.Project.Title),
+		URL:   n.Task.GetFrontendURL(),
+		Tag:   getPushTag(n.Task.ID),
+	}
+}
+
 // TaskCommentNotification represents a TaskCommentNotification notification
 type TaskCommentNotification struct {
 	Doer      *user.User   `json:"doer"`
@@ -129,6 +205,39 @@ func (n *TaskCommentNotification) ThreadID() string {
 	return getThreadID(n.Task.ID)
 }
 
+// ReplyTo returns the address the notifiable can reply to in order to comment on the task
+func (n *TaskCommentNotification) ReplyTo(notifiable notifications.Notifiable) string {
+	return getTaskReplyTo(notifiable, n.Task.ID)
+}
+
+// ProjectID returns the project this notification is about
+func (n *TaskCommentNotification) ProjectID() int64 {
+	return n.Task.ProjectID
+}
+
+// ToDigest returns where this notification belongs in a digest mail
+func (n *TaskCommentNotification) ToDigest(lang string) *notifications.DigestEntry {
+	// Mentions already say who wrote the comment
+	if n.Mentioned {
+		return getDigestEntryForTask(n.Task, "")
+	}
+	return getDigestEntryForTask(n.Task, i18n.T(lang, "notifications.digest.comment", n.Doer.GetName()))
+}
+
+// ToPush returns the push message for TaskCommentNotification
+func (n *TaskCommentNotification) ToPush(lang string) *notifications.Push {
+	push := &notifications.Push{
+		Title: i18n.T(lang, "notifications.task.comment.subject", n.Task.Title),
+		Body:  n.Doer.GetName() + ": " + notifications.PlainText(n.Comment.Comment),
+		URL:   n.Task.GetFrontendURL(),
+	}
+	if n.Mentioned {
+		push.Title = i18n.T(lang, "notifications.task.comment.mentioned_subject", n.Doer.GetName(), n.Task.Title)
+		push.Body = notifications.PlainText(n.Comment.Comment)
+	}
+	return push
+}
+
 // TaskAssignedNotification represents a TaskAssignedNotification notification
 type TaskAssignedNotification struct {
 	Doer     *user.User `json:"doer"`
@@ -175,6 +284,40 @@ func (n *TaskAssignedNotification) ThreadID() string {
 	return getThreadID(n.Task.ID)
 }
 
+// ReplyTo returns the address the notifiable can reply to in order to comment on the task
+func (n *TaskAssignedNotification) ReplyTo(notifiable notifications.Notifiable) string {
+	return getTaskReplyTo(notifiable, n.Task.ID)
+}
+
+// ProjectID returns the project this notification is about
+func (n *TaskAssignedNotification) ProjectID() int64 {
+	return n.Task.ProjectID
+}
+
+// ToDigest returns where this notification belongs in a digest mail
+func (n *TaskAssignedNotification) ToDigest(_ string) *notifications.DigestEntry {
+	return getDigestEntryForTask(n.Task, "")
+}
+
+// ToPush returns the push message for TaskAssignedNotification
+func (n *TaskAssignedNotification) ToPush(lang string) *notifications.Push {
+	push := &notifications.Push{
+		Title: i18n.T(lang, "notifications.task.assigned.subject_to_others", n.Task.Title, n.Task.GetFullIdentifier(), n.Assignee.GetName()),
+		Body:  i18n.T(lang, "notifications.task.assigned.message_to_others", n.Doer.GetName(), n.Assignee.GetName()),
+		URL:   n.Task.GetFrontendURL(),
+	}
+	if n.Target.ID == n.Assignee.ID {
+		push.Title = i18n.T(lang, "notifications.task.assigned.subject_to_assignee", n.Task.Title, n.Task.GetFullIdentifier())
+		push.Body = i18n.T(lang, "notifications.task.assigned.message_to_assignee", n.Doer.GetName(), n.Task.Title)
+		return push
+	}
+	if n.Doer.ID == n.Assignee.ID {
+		push.Title = i18n.T(lang, "notifications.task.assigned.subject_to_others_self", n.Task.Title, n.Task.GetFullIdentifier(), n.Doer.GetName())
+		push.Body = i18n.T(lang, "notifications.task.assigned.message_to_others_self", n.Doer.GetName())
+	}
+	return push
+}
+
 // TaskDeletedNotification represents a TaskDeletedNotification notification
 type TaskDeletedNotification struct {
 	Doer *user.User `json:"doer"`
@@ -203,6 +346,19 @@ func (n *TaskDeletedNotification) ThreadID() string {
 	return getThreadID(n.Task.ID)
 }
 
+// ProjectID returns the project this notification is about
+func (n *TaskDeletedNotification) ProjectID() int64 {
+	return n.Task.ProjectID
+}
+
+// ToDigest returns where this notification belongs in a digest mail
+func (n *TaskDeletedNotification) ToDigest(_ string) *notifications.DigestEntry {
+	entry := getDigestEntryForTask(n.Task, "")
+	// The task cannot be opened anymore
+	entry.URL = ""
+	return entry
+}
+
 // ProjectCreatedNotification represents a ProjectCreatedNotification notification
 type ProjectCreatedNotification struct {
 	Doer    *user.User `json:"doer"`
@@ -227,6 +383,11 @@ func (n *ProjectCreatedNotification) Name() string {
 	return "project.created"
 }
 
+// ProjectID returns the project this notification is about
+func (n *ProjectCreatedNotification) ProjectID() int64 {
+	return n.Project.ID
+}
+
 // TeamMemberAddedNotification represents a TeamMemberAddedNotification notification
 type TeamMemberAddedNotification struct {
 	Member *user.User `json:"member"`
@@ -296,6 +457,27 @@ func (n *UndoneTaskOverdueNotification) ThreadID() string {
 	return getThreadID(n.Task.ID)
 }
 
+// ReplyTo returns the address the notifiable can reply to in order to comment on the task
+func (n *UndoneTaskOverdueNotification) ReplyTo(notifiable notifications.Notifiable) string {
+	return getTaskReplyTo(notifiable, n.Task.ID)
+}
+
+// ProjectID returns the project this notification is about
+func (n *UndoneTaskOverdueNotification) ProjectID() int64 {
+	return n.Task.ProjectID
+}
+
+// ToPush returns the push message for UndoneTaskOverdueNotification
+func (n *UndoneTaskOverdueNotification) ToPush(lang string) *notifications.Push {
+	until := time.Until(n.Task.DueDate).Round(1*time.Hour) * -1
+	return &notifications.Push{
+		Title: i18n.T(lang, "notifications.task.overdue.subject", n.Task.Title, n.Project.Title),
+		Body:  i18n.T(lang, "notifications.task.overdue.message", n.Task.Title, n.Project.Title, getOverdueSinceString(until, n.User.Language)),
+		URL:   n.Task.GetFrontendURL(),
+		Tag:   getPushTag(n.Task.ID),
+	}
+}
+
 // UndoneTasksOverdueNotification represents a UndoneTasksOverdueNotification notification
 type UndoneTasksOverdueNotification struct {
 	User     *user.User
@@ -390,6 +572,34 @@ func (n *UserMentionedInTaskNotification) ThreadID() string {
 	return getThreadID(n.Task.ID)
 }
 
+// ReplyTo returns the address the notifiable can reply to in order to comment on the task
+func (n *UserMentionedInTaskNotification) ReplyTo(notifiable notifications.Notifiable) string {
+	return getTaskReplyTo(notifiable, n.Task.ID)
+}
+
+// ProjectID returns the project this notification is about
+func (n *UserMentionedInTaskNotification) ProjectID() int64 {
+	return n.Task.ProjectID
+}
+
+// ToDigest returns where this notification belongs in a digest mail
+func (n *UserMentionedInTaskNotification) ToDigest(_ string) *notifications.DigestEntry {
+	return getDigestEntryForTask(n.Task, "")
+}
+
+// ToPush returns the push message for UserMentionedInTaskNotification
+func (n *UserMentionedInTaskNotification) ToPush(lang string) *notifications.Push {
+	title := i18n.T(lang, "notifications.task.mentioned.subject", n.Doer.GetName(), n.Task.Title)
+	if n.IsNew {
+		title = i18n.T(lang, "notifications.task.mentioned.subject_new", n.Doer.GetName(), n.Task.Title)
+	}
+	return &notifications.Push{
+		Title: title,
+		Body:  notifications.PlainText(n.Task.Description),
+		URL:   n.Task.GetFrontendURL(),
+	}
+}
+
 // DataExportReadyNotification represents a DataExportReadyNotification notification
 type DataExportReadyNotification struct {
 	User *user.User `json:"user"`
@@ -415,3 +625,47 @@ func (n *DataExportReadyNotification) ToDB() interface{} {
 func (n *DataExportReadyNotification) Name() string {
 	return "data.export.ready"
 }
+
+// WebhookDisabledNotification represents a WebhookDisabledNotification notification
+type WebhookDisabledNotification struct {
+	User    *user.User `json:"-"`
+	Webhook *Webhook   `json:"webhook"`
+	Project *Project   `json:"project,omitempty"`
+	Team    *Team      `json:"team,omitempty"`
+}
+
+// ToMail returns the mail notification for WebhookDisabledNotification
+func (n *WebhookDisabledNotification) ToMail(lang string) *notifications.Mail {
+	mail := notifications.NewMail().
+		Subject(i18n.T(lang, "notifications.webhook.disabled.subject", n.Webhook.TargetURL)).
+		Greeting(i18n.T(lang, "notifications.greeting", n.User.GetName()))
+
+	failures := strconv.FormatInt(n.Webhook.ConsecutiveFailures, 10)
+	switch {
+	case n.Project != nil:
+		return mail.
+			Line(i18n.T(lang, "notifications.webhook.disabled.message_project", n.Webhook.TargetURL, n.Project.Title, failures)).
+			Line(i18n.T(lang, "notifications.webhook.disabled.enable")).
+			Action(i18n.T(lang, "notifications.common.actions.open_project"), config.ServicePublicURL.GetString()+"projects/"+strconv.FormatInt(n.Project.ID, 10)+"/settings/webhooks")
+	case n.Team != nil:
+		return mail.
+			Line(i18n.T(lang, "notifications.webhook.disabled.message_team", n.Webhook.TargetURL, n.Team.Name, failures)).
+			Line(i18n.T(lang, "notifications.webhook.disabled.enable")).
+			Action(i18n.T(lang, "notifications.common.actions.open_team"), config.ServicePublicURL.GetString()+"teams/"+strconv.FormatInt(n.Team.ID, 10)+"/edit")
+	default:
+		return mail.
+			Line(i18n.T(lang, "notifications.webhook.disabled.message", n.Webhook.TargetURL, failures)).
+			Line(i18n.T(lang, "notifications.webhook.disabled.enable")).
+			Action(i18n.T(lang, "notifications.common.actions.go_to_settings"), config.ServicePublicURL.GetString()+"user/settings/webhooks")
+	}
+}
+
+// ToDB returns the WebhookDisabledNotification notification in a format which can be saved in the db
+func (n *WebhookDisabledNotification) ToDB() interface{} {
+	return n
+}
+
+// Name returns the name of the notification
+func (n *WebhookDisabledNotification) Name() string {
+	return "webhook.disabled"
+}
@@ -75,6 +75,14 @@ type Project struct {
 	// The position this project has when querying all projects. See the tasks.position property on how to use this.
 	Position float64 `xorm:"double null" json:"position"`
 
+	// If true, tasks in this project cannot be marked done (or moved into a done bucket) while they are blocked by tasks which are not done yet.
+	PreventCompletingBlockedTasks bool `xorm:"not null default false" json:"prevent_completing_blocked_tasks"`
+
+	// If true, the percent done, start and end date and the estimates of tasks with subtasks in this project are computed from their subtasks, recursively.
+	SubtaskRollup bool `xorm:"not null default false" json:"subtask_rollup"`
+	// If true, tasks in this project are marked done automatically once all of their subtasks are done.
+	AutoCompleteParentTasks bool `xorm:"not null default false" json:"auto_complete_parent_tasks"`
+
 	Views []*ProjectView `xorm:"-" json:"views"`
 
 	Expand        ProjectExpandable `xorm:"-" json:"-" query:"expand"`
@@ -85,6 +93,10 @@ type Project struct {
 	// A timestamp when this project was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
 
+	// Set when the project was moved to the trash, either on its own or together with its parent.
+	Deleted time.Time `xorm:"deleted" json:"-"`
+	TrashID int64     `xorm:"bigint null INDEX" json:"-"`
+
 	web.CRUDable    `xorm:"-" json:"-"`
 	web.Permissions `xorm:"-" json:"-"`
 }
@@ -458,6 +470,7 @@ func getUserProjectsStatement(userID int64, search string, getArchived bool) *bu
 			builder.Eq{"ul.user_id": userID},
 			builder.Eq{"l.owner_id": userID},
 		),
+		builder.IsNull{"l.deleted"},
 	}
 
 	ids := []int64{}
@@ -538,7 +551,8 @@ func getAllProjectsForUser(s *xorm.Session, userID int64, opts *projectOptions)
 	baseQuery := querySQLString + `
 UNION ALL
 SELECT p.* FROM projects p
-INNER JOIN all_projects ap ON p.parent_project_id = ap.id`
+INNER JOIN all_projects ap ON p.parent_project_id = ap.id
+WHERE p.deleted IS NULL`
 
 	columnStr := strings.Join([]string{
 		"all_projects.id",
@@ -971,6 +985,9 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 		"position",
 		"done_bucket_id",
 		"default_bucket_id",
+		"prevent_completing_blocked_tasks",
+		"subtask_rollup",
+		"auto_complete_parent_tasks",
 	}
 	if project.Description != "" {
 		colsToUpdate = append(colsToUpdate, "description")
@@ -1005,6 +1022,11 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 
 	project.HexColor = utils.NormalizeHex(project.HexColor)
 
+	oldProject, err := GetProjectSimpleByID(s, project.ID)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		ID(project.ID).
 		Cols(colsToUpdate...).
@@ -1013,6 +1035,13 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 		return err
 	}
 
+	if project.SubtaskRollup && !oldProject.SubtaskRollup {
+		err = rollupSubtasksInProject(s, auth, project.ID)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = events.Dispatch(&ProjectUpdatedEvent{
 		Project: project,
 		Doer:    auth,
@@ -1146,7 +1175,7 @@ func (p *Project) isDefaultProject(s *xorm.Session) (is bool, err error) {
 
 // Delete implements the delete method of CRUDable
 // @Summary Deletes a project
-// @Description Delets a project
+// @Description Delets a project. If the trash is enabled, the project, all of its child projects and their tasks are moved to the trash of the user deleting it and can be restored from there until they are purged.
 // @tags project
 // @Produce json
 // @Security JWTKeyAuth
@@ -1157,6 +1186,10 @@ func (p *Project) isDefaultProject(s *xorm.Session) (is bool, err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{id} [delete]
 func (p *Project) Delete(s *xorm.Session, a web.Auth) (err error) {
+	return p.delete(s, a, !isTrashEnabled())
+}
+
+func (p *Project) delete(s *xorm.Session, a web.Auth, permanently bool) (err error) {
 
 	isDefaultProject, err := p.isDefaultProject(s)
 	if err != nil {
@@ -1167,42 +1200,126 @@ func (p *Project) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return &ErrCannotDeleteDefaultProject{ProjectID: p.ID}
 	}
 
-	// Delete all tasks on that project
-	// Using the loop to make sure all related entities to all tasks are properly deleted as well.
-	tasks, _, _, err := getRawTasksForProjects(s, []*Project{p}, a, &taskSearchOptions{})
+	fullProject, err := GetProjectSimpleByID(s, p.ID)
 	if err != nil {
 		return
 	}
 
-	for _, task := range tasks {
-		err = task.Delete(s, a)
+	// Collect everything below the project so that we can send the events for it afterward.
+	// Projects and tasks already in the trash are not included here.
+	descendantIDs, err := getDescendantProjectIDs(s, p.ID)
+	if err != nil {
+		return
+	}
+	projects := []*Project{fullProject}
+	if len(descendantIDs) > 0 {
+		descendants := []*Project{}
+		err = s.In("id", descendantIDs).Find(&descendants)
 		if err != nil {
-			return err
+			return
 		}
+		projects = append(projects, descendants...)
 	}
 
-	fullProject, err := GetProjectSimpleByID(s, p.ID)
+	projectIDs := make([]int64, 0, len(projects))
+	for _, project := range projects {
+		projectIDs = append(projectIDs, project.ID)
+	}
+
+	tasks := []*Task{}
+	err = s.In("project_id", projectIDs).Find(&tasks)
 	if err != nil {
 		return
 	}
 
-	err = fullProject.DeleteBackgroundFileIfExists()
+	if permanently {
+		err = deleteProjectPermanently(s, p.ID)
+	} else {
+		err = moveProjectToTrash(s, a, fullProject, projectIDs)
+	}
 	if err != nil {
 		return
 	}
 
 	// If we're deleting a default project, remove it as default
-	if isDefaultProject {
-		_, err = s.Where("default_project_id = ?", p.ID).
-			Cols("default_project_id").
-			Update(&user.User{DefaultProjectID: 0})
+	_, err = s.In("default_project_id", projectIDs).
+		Cols("default_project_id").
+		Update(&user.User{DefaultProjectID: 0})
+	if err != nil {
+		return
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	for _, task := range tasks {
+		err = events.Dispatch(&TaskDeletedEvent{
+			Task: task,
+			Doer: doer,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	for _, project := range projects {
+		err = events.Dispatch(&ProjectDeletedEvent{
+			Project: project,
+			Doer:    a,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// deleteProjectPermanently removes a project, all of its child projects and everything belonging to them,
+// including the ones in the trash. It does not check any permissions and does not dispatch any events.
+func deleteProjectPermanently(s *xorm.Session, projectID int64) (err error) {
+	childProjects := []*Project{}
+	err = s.Unscoped().Where("parent_project_id = ?", projectID).Find(&childProjects)
+	if err != nil {
+		return
+	}
+
+	for _, child := range childProjects {
+		err = deleteProjectPermanently(s, child.ID)
 		if err != nil {
 			return
 		}
 	}
 
+	// Delete all tasks on that project
+	// Using the loop to make sure all related entities to all tasks are properly deleted as well.
+	tasks := []*Task{}
+	err = s.Unscoped().Where("project_id = ?", projectID).Find(&tasks)
+	if err != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		err = deleteTaskPermanently(s, task.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	project := &Project{}
+	has, err := s.Unscoped().Where("id = ?", projectID).Get(project)
+	if err != nil {
+		return
+	}
+	if !has {
+		return ErrProjectDoesNotExist{ID: projectID}
+	}
+
+	err = project.DeleteBackgroundFileIfExists()
+	if err != nil {
+		return
+	}
+
 	// Delete related project entities
-	views, err := getViewsForProject(s, p.ID)
+	views, err := getViewsForProject(s, projectID)
 	if err != nil {
 		return
 	}
@@ -1221,53 +1338,53 @@ func (p *Project) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
-	err = removeFromFavorite(s, p.ID, a, FavoriteKindProject)
+	_, err = s.Where("project_id = ?", projectID).Delete(&ProjectCustomField{})
 	if err != nil {
 		return
 	}
 
-	_, err = s.Where("project_id = ?", p.ID).Delete(&LinkSharing{})
+	err = deleteAutomationRules(s, builder.Eq{"project_id": projectID})
 	if err != nil {
 		return
 	}
 
-	_, err = s.Where("project_id = ?", p.ID).Delete(&ProjectUser{})
+	err = deleteSprints(s, builder.Eq{"project_id": projectID})
 	if err != nil {
 		return
 	}
 
-	_, err = s.Where("project_id = ?", p.ID).Delete(&TeamProject{})
+	err = user.DeleteNotificationPreferencesForProject(s, projectID)
 	if err != nil {
 		return
 	}
 
-	// Delete the project
-	_, err = s.ID(p.ID).Delete(&Project{})
+	_, err = s.Where("entity_id = ? AND kind = ?", projectID, FavoriteKindProject).Delete(&Favorite{})
 	if err != nil {
 		return
 	}
 
-	err = events.Dispatch(&ProjectDeletedEvent{
-		Project: fullProject,
-		Doer:    a,
-	})
+	_, err = s.Where("project_id = ?", projectID).Delete(&LinkSharing{})
 	if err != nil {
 		return
 	}
 
-	childProjects := []*Project{}
-	err = s.Where("parent_project_id = ?", fullProject.ID).Find(&childProjects)
+	_, err = s.Where("project_id = ?", projectID).Delete(&ProjectUser{})
 	if err != nil {
 		return
 	}
 
-	for _, child := range childProjects {
-		err = child.Delete(s, a)
-		if err != nil {
-			return
-		}
+	_, err = s.Where("project_id = ?", projectID).Delete(&TeamProject{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("kind = ? AND entity_id = ?", TrashItemKindProject, projectID).Delete(&TrashItem{})
+	if err != nil {
+		return
 	}
 
+	// Delete the project
+	_, err = s.Unscoped().ID(projectID).Delete(&Project{})
 	return
 }
 
@@ -1305,9 +1422,9 @@ func SetProjectBackground(s *xorm.Session, projectID int64, background *files.Fi
 }
 
 // setArchiveStateForProjectDescendants uses a recursive CTE to find and set the archived status of all descendant projects.
-func setArchiveStateForProjectDescendants(s *xorm.Session, parentProjectID int64, shouldBeArchived bool) error {
-	var descendantIDs []int64
-	err := s.SQL(
+func getDescendantProjectIDs(s *xorm.Session, parentProjectID int64) (descendantIDs []int64, err error) {
+	descendantIDs = []int64{}
+	err = s.SQL(
 		`
 WITH RECURSIVE descendant_ids (id) AS (
     SELECT id
@@ -1321,6 +1438,11 @@ WITH RECURSIVE descendant_ids (id) AS (
 SELECT id FROM descendant_ids`,
 		parentProjectID,
 	).Find(&descendantIDs)
+	return
+}
+
+func setArchiveStateForProjectDescendants(s *xorm.Session, parentProjectID int64, shouldBeArchived bool) error {
+	descendantIDs, err := getDescendantProjectIDs(s, parentProjectID)
 	if err != nil {
 		log.Errorf("Error finding descendant projects for parent ID %d: %v", parentProjectID, err)
 		return fmt.Errorf("failed to find descendant projects for parent ID %d: %w", parentProjectID, err)
@@ -0,0 +1,358 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+type CustomFieldType int
+
+// NOTE: When adding or changing enum values for CustomFieldType,
+// make sure to update the corresponding `enums` tag in the ProjectCustomField struct
+// to keep the OpenAPI documentation in sync.
+
+const (
+	CustomFieldTypeText CustomFieldType = iota
+	CustomFieldTypeNumber
+	CustomFieldTypeDate
+	CustomFieldTypeSelect
+	CustomFieldTypeMultiSelect
+	CustomFieldTypeUser
+)
+
+func (c *CustomFieldType) MarshalJSON() ([]byte, error) {
+	switch *c {
+	case CustomFieldTypeText:
+		return []byte(`"text"`), nil
+	case CustomFieldTypeNumber:
+		return []byte(`"number"`), nil
+	case CustomFieldTypeDate:
+		return []byte(`"date"`), nil
+	case CustomFieldTypeSelect:
+		return []byte(`"select"`), nil
+	case CustomFieldTypeMultiSelect:
+		return []byte(`"multiselect"`), nil
+	case CustomFieldTypeUser:
+		return []byte(`"user"`), nil
+	}
+
+	return []byte(`null`), nil
+}
+
+func (c *CustomFieldType) UnmarshalJSON(bytes []byte) error {
+	var value string
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return err
+	}
+
+	switch value {
+	case "text":
+		*c = CustomFieldTypeText
+	case "number":
+		*c = CustomFieldTypeNumber
+	case "date":
+		*c = CustomFieldTypeDate
+	case "select":
+		*c = CustomFieldTypeSelect
+	case "multiselect":
+		*c = CustomFieldTypeMultiSelect
+	case "user":
+		*c = CustomFieldTypeUser
+	default:
+		return fmt.Errorf("unknown custom field type: %s", value)
+	}
+
+	return nil
+}
+
+// ProjectCustomField defines an additional, typed property all tasks of a project can have.
+type ProjectCustomField struct {
+	// The unique numeric id of this custom field.
+	ID int64 `xorm:"autoincr not null unique pk" json:"id" param:"customfield"`
+	// The project this custom field belongs to.
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// The title of this custom field.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)"`
+	// The type of this custom field. Can be `text`, `number`, `date`, `select`, `multiselect` or `user`. The type cannot be changed once the field was created.
+	FieldType CustomFieldType `xorm:"not null default 0" json:"field_type" swaggertype:"string" enums:"text,number,date,select,multiselect,user"`
+	// The values a user can choose from. Only used for `select` and `multiselect` fields.
+	Options []string `xorm:"json null" json:"options"`
+	// The position of this field in the list. The list of all custom fields will be sorted by this parameter.
+	Position float64 `xorm:"double null" json:"position"`
+
+	// A timestamp when this custom field was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this custom field was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+func (*ProjectCustomField) TableName() string {
+	return "project_custom_fields"
+}
+
+func (cf *ProjectCustomField) hasOption(option string) bool {
+	for _, o := range cf.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (cf *ProjectCustomField) validate() error {
+	if (cf.FieldType == CustomFieldTypeSelect || cf.FieldType == CustomFieldTypeMultiSelect) && len(cf.Options) == 0 {
+		return &ErrCustomFieldNeedsOptions{Title: cf.Title}
+	}
+
+	if cf.FieldType != CustomFieldTypeSelect && cf.FieldType != CustomFieldTypeMultiSelect {
+		cf.Options = nil
+	}
+
+	// Multiple options are passed as a comma separated list, both as a value and in filters
+	for _, option := range cf.Options {
+		if strings.Contains(option, ",") {
+			return InvalidFieldErrorWithMessage([]string{"options"}, "Options must not contain a comma")
+		}
+	}
+
+	return nil
+}
+
+func getCustomFieldByIDAndProject(s *xorm.Session, id, projectID int64) (cf *ProjectCustomField, err error) {
+	cf = &ProjectCustomField{}
+	exists, err := s.
+		Where("id = ? AND project_id = ?", id, projectID).
+		Get(cf)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCustomFieldDoesNotExist{ID: id}
+	}
+	return
+}
+
+func getCustomFieldsForProjects(s *xorm.Session, projectIDs []int64) (fields map[int64]*ProjectCustomField, err error) {
+	fields = make(map[int64]*ProjectCustomField)
+	if len(projectIDs) == 0 {
+		return
+	}
+
+	err = s.In("project_id", projectIDs).Find(&fields)
+	return
+}
+
+// ReadAll returns all custom fields of a project
+// @Summary Get all custom fields of a project
+// @Description Returns all custom fields defined for a project.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Success 200 {array} models.ProjectCustomField "The custom fields"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields [get]
+func (cf *ProjectCustomField) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: cf.ProjectID}
+	can, _, err := p.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	fields := []*ProjectCustomField{}
+	err = s.
+		Where("project_id = ?", cf.ProjectID).
+		OrderBy("position asc, id asc").
+		Find(&fields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return fields, len(fields), int64(len(fields)), nil
+}
+
+// ReadOne returns a single custom field
+// @Summary Get one custom field
+// @Description Returns a custom field of a project by its ID.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param customfield path int true "Custom field ID"
+// @Success 200 {object} models.ProjectCustomField "The custom field"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields/{customfield} [get]
+func (cf *ProjectCustomField) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	field, err := getCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	*cf = *field
+	return
+}
+
+// Create adds a new custom field to a project
+// @Summary Create a custom field
+// @Description Creates a new custom field in a project. All tasks of that project can then have a value for that field.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param field body models.ProjectCustomField true "The custom field you want to create."
+// @Success 201 {object} models.ProjectCustomField "The created custom field"
+// @Failure 400 {object} web.HTTPError "Invalid custom field provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields [put]
+func (cf *ProjectCustomField) Create(s *xorm.Session, _ web.Auth) (err error) {
+	err = cf.validate()
+	if err != nil {
+		return
+	}
+
+	cf.ID = 0
+	_, err = s.Insert(cf)
+	if err != nil {
+		return
+	}
+
+	if cf.Position == 0 {
+		cf.Position = calculateDefaultPosition(cf.ID, cf.Position)
+		_, err = s.Where("id = ?", cf.ID).Cols("position").Update(cf)
+	}
+	return
+}
+
+// Update changes a custom field
+// @Summary Update a custom field
+// @Description Updates the title, options or position of a custom field. The type of a field cannot be changed.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param customfield path int true "Custom field ID"
+// @Param field body models.ProjectCustomField true "The custom field with updated values."
+// @Success 200 {object} models.ProjectCustomField "The updated custom field"
+// @Failure 400 {object} web.HTTPError "Invalid custom field provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields/{customfield} [post]
+func (cf *ProjectCustomField) Update(s *xorm.Session, _ web.Auth) (err error) {
+	stored, err := getCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	cf.FieldType = stored.FieldType
+	err = cf.validate()
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("id = ? AND project_id = ?", cf.ID, cf.ProjectID).
+		Cols("title", "options", "position").
+		Update(cf)
+	if err != nil {
+		return
+	}
+
+	// Remove all values which are not a valid option anymore
+	if cf.FieldType == CustomFieldTypeSelect || cf.FieldType == CustomFieldTypeMultiSelect {
+		_, err = s.
+			Where("field_id = ?", cf.ID).
+			NotIn("text_value", cf.Options).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return
+		}
+	}
+
+	return cf.ReadOne(s, nil)
+}
+
+// Delete removes a custom field and all its values
+// @Summary Delete a custom field
+// @Description Deletes a custom field and the values all tasks had for it.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param customfield path int true "Custom field ID"
+// @Success 200 {object} models.Message "The custom field was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields/{customfield} [delete]
+func (cf *ProjectCustomField) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = getCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("field_id = ?", cf.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("id = ?", cf.ID).Delete(&ProjectCustomField{})
+	return
+}
+
+func duplicateCustomFields(s *xorm.Session, pd *ProjectDuplicate) (fieldMap map[int64]int64, err error) {
+	fields := []*ProjectCustomField{}
+	err = s.Where("project_id = ?", pd.ProjectID).Find(&fields)
+	if err != nil {
+		return
+	}
+
+	fieldMap = make(map[int64]int64, len(fields))
+	for _, field := range fields {
+		oldID := field.ID
+		field.ID = 0
+		field.ProjectID = pd.Project.ID
+		_, err = s.Insert(field)
+		if err != nil {
+			return nil, err
+		}
+		fieldMap[oldID] = field.ID
+	}
+
+	return
+}
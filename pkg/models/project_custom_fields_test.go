@@ -0,0 +1,216 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectCustomField_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 1,
+			Title:     "Environment",
+			FieldType: CustomFieldTypeSelect,
+			Options:   []string{"staging", "production"},
+		}
+		can, err := cf.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = cf.Create(s, u)
+		require.NoError(t, err)
+		assert.NotZero(t, cf.Position)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "project_custom_fields", map[string]interface{}{
+			"id":         cf.ID,
+			"project_id": 1,
+			"title":      "Environment",
+			"field_type": CustomFieldTypeSelect,
+		}, false)
+	})
+	t.Run("select without options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 1,
+			Title:     "Environment",
+			FieldType: CustomFieldTypeMultiSelect,
+		}
+		err := cf.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrCustomFieldNeedsOptions(err))
+	})
+	t.Run("option with a comma", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 1,
+			Title:     "Environment",
+			FieldType: CustomFieldTypeMultiSelect,
+			Options:   []string{"staging", "production, eu"},
+		}
+		err := cf.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("no admin permission", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 3, // user 1 has only read access to project 3
+		}
+		can, err := cf.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestProjectCustomField_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	cf := &ProjectCustomField{ProjectID: 1}
+	result, resultCount, _, err := cf.ReadAll(s, u, "", 0, 50)
+	require.NoError(t, err)
+	fields := result.([]*ProjectCustomField)
+	assert.Equal(t, 6, resultCount)
+	assert.Equal(t, int64(1), fields[0].ID)
+	assert.Equal(t, []string{"low", "high"}, fields[3].Options)
+}
+
+func TestProjectCustomField_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ID: 2, ProjectID: 1}
+		can, _, err := cf.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = cf.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, "Story points", cf.Title)
+		assert.Equal(t, CustomFieldTypeNumber, cf.FieldType)
+	})
+	t.Run("field of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ID: 7, ProjectID: 1}
+		err := cf.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+}
+
+func TestProjectCustomField_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("type cannot be changed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "Client",
+			FieldType: CustomFieldTypeNumber,
+		}
+		err := cf.Update(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, CustomFieldTypeText, cf.FieldType)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "project_custom_fields", map[string]interface{}{
+			"id":         1,
+			"title":      "Client",
+			"field_type": CustomFieldTypeText,
+		}, false)
+	})
+	t.Run("removing an option removes its values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ID:        5,
+			ProjectID: 1,
+			Title:     "Platforms",
+			Options:   []string{"web", "android"},
+		}
+		err := cf.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"id":         3,
+			"text_value": "web",
+		}, false)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"id": 4,
+		})
+	})
+}
+
+func TestProjectCustomField_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	cf := &ProjectCustomField{ID: 2, ProjectID: 1}
+	can, err := cf.CanDelete(s, u)
+	require.NoError(t, err)
+	assert.True(t, can)
+	err = cf.Delete(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertMissing(t, "project_custom_fields", map[string]interface{}{
+		"id": 2,
+	})
+	db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+		"field_id": 2,
+	})
+}
@@ -95,7 +95,14 @@ func (pd *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 
 	log.Debugf("Duplicated project %d into new project %d", pd.ProjectID, pd.Project.ID)
 
-	newTaskIDs, err := duplicateTasks(s, doer, pd)
+	customFieldIDs, err := duplicateCustomFields(s, pd)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("Duplicated all custom fields from project %d into %d", pd.ProjectID, pd.Project.ID)
+
+	newTaskIDs, err := duplicateTasks(s, doer, pd, customFieldIDs)
 	if err != nil {
 		return
 	}
@@ -328,7 +335,7 @@ func duplicateProjectBackground(s *xorm.Session, pd *ProjectDuplicate, doer web.
 	return nil
 }
 
-func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate) (newTaskIDs map[int64]int64, err error) {
+func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, customFieldIDs map[int64]int64) (newTaskIDs map[int64]int64, err error) {
 	// Get all tasks + all task details
 	tasks, _, _, err := getTasksForProjects(s, []*Project{{ID: ld.ProjectID}}, doer, &taskSearchOptions{}, nil)
 	if err != nil {
@@ -349,6 +356,14 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate) (newTa
 		t.ID = 0
 		t.ProjectID = ld.Project.ID
 		t.UID = ""
+		// Custom field values are saved with the task, but need to point to the duplicated fields
+		if t.CustomFields != nil {
+			customFields := make(map[int64]interface{}, len(t.CustomFields))
+			for fieldID, value := range t.CustomFields {
+				customFields[customFieldIDs[fieldID]] = value
+			}
+			t.CustomFields = customFields
+		}
 		err = createTask(s, t, doer, false, false)
 		if err != nil {
 			return nil, err
@@ -76,6 +76,25 @@ func testProjectDuplicate(t *testing.T, projectID int64, userID int64) {
 	require.NoError(t, err)
 	assert.Equal(t, numberOfOriginalTasks, numberOfDuplicatedTasks, "duplicated project does not have the same amount of tasks as the original one")
 
+	// Check that the custom fields and all their values were duplicated
+	numberOfOriginalFields, err := s.Where("project_id = ?", originalProjectID).Count(&ProjectCustomField{})
+	require.NoError(t, err)
+	numberOfDuplicatedFields, err := s.Where("project_id = ?", duplicatedProjectID).Count(&ProjectCustomField{})
+	require.NoError(t, err)
+	assert.Equal(t, numberOfOriginalFields, numberOfDuplicatedFields, "duplicated project does not have the same amount of custom fields as the original one")
+	numberOfOriginalValues, err := s.
+		Join("INNER", "project_custom_fields", "project_custom_fields.id = task_custom_field_values.field_id").
+		Where("project_custom_fields.project_id = ?", originalProjectID).
+		Count(&TaskCustomFieldValue{})
+	require.NoError(t, err)
+	numberOfDuplicatedValues, err := s.
+		Join("INNER", "project_custom_fields", "project_custom_fields.id = task_custom_field_values.field_id").
+		Join("INNER", "tasks", "tasks.id = task_custom_field_values.task_id").
+		Where("project_custom_fields.project_id = ? AND tasks.project_id = ?", duplicatedProjectID, duplicatedProjectID).
+		Count(&TaskCustomFieldValue{})
+	require.NoError(t, err)
+	assert.Equal(t, numberOfOriginalValues, numberOfDuplicatedValues, "duplicated project does not have the same amount of custom field values as the original one")
+
 	// Check that each view has the same number of task positions between original and duplicated project
 	var originalViews []*ProjectView
 	err = s.Where("project_id = ?", originalProjectID).
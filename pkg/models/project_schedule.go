@@ -0,0 +1,435 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// ProjectSchedule holds the dependency-aware schedule of all tasks in a project view.
+type ProjectSchedule struct {
+	ProjectID     int64 `json:"project_id" param:"project"`
+	ProjectViewID int64 `json:"project_view_id" param:"view"`
+
+	// The earliest start of all scheduled tasks.
+	Start time.Time `json:"start"`
+	// The latest finish of all scheduled tasks.
+	Finish time.Time `json:"finish"`
+	// The ids of all tasks without slack, ordered by their earliest start.
+	CriticalPath []int64 `json:"critical_path"`
+	// The schedule of every task in the view which has at least one date set.
+	Tasks []*TaskSchedule `json:"tasks"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+// TaskSchedule holds the computed schedule of a single task.
+type TaskSchedule struct {
+	TaskID int64 `json:"task_id"`
+	// The planned duration of the task in seconds.
+	Duration       int64     `json:"duration"`
+	EarliestStart  time.Time `json:"earliest_start"`
+	EarliestFinish time.Time `json:"earliest_finish"`
+	LatestStart    time.Time `json:"latest_start"`
+	LatestFinish   time.Time `json:"latest_finish"`
+	// How many seconds the task can slip without delaying the project.
+	Slack      int64 `json:"slack"`
+	IsCritical bool  `json:"is_critical"`
+	// The ids of the tasks which precede or block this task.
+	Predecessors []int64 `json:"predecessors"`
+}
+
+// getTaskPlannedDates returns when a task is planned to start and finish. The finish is the end date or,
+// if the task has none, the due date. Tasks with only one date set have no duration.
+func getTaskPlannedDates(t *Task) (start, finish time.Time) {
+	start = t.StartDate
+	finish = t.EndDate
+	if finish.IsZero() {
+		finish = t.DueDate
+	}
+	if start.IsZero() {
+		start = finish
+	}
+	if finish.IsZero() || finish.Before(start) {
+		finish = start
+	}
+	return
+}
+
+// getTaskSuccessors returns all tasks the given tasks precede or block, keyed by the predecessor.
+func getTaskSuccessors(s *xorm.Session, taskIDs []int64) (successors map[int64][]int64, err error) {
+	successors = make(map[int64][]int64)
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	relations := []*TaskRelation{}
+	err = s.
+		In("task_id", taskIDs).
+		In("relation_kind", RelationKindPreceeds, RelationKindBlocking).
+		OrderBy("id asc").
+		Find(&relations)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[[2]int64]bool, len(relations))
+	for _, r := range relations {
+		edge := [2]int64{r.TaskID, r.OtherTaskID}
+		if seen[edge] || r.TaskID == r.OtherTaskID {
+			continue
+		}
+		seen[edge] = true
+		successors[r.TaskID] = append(successors[r.TaskID], r.OtherTaskID)
+	}
+
+	return
+}
+
+// sortTasksTopologically orders the task ids so that every task comes after all of its predecessors.
+// Tasks which are part of a cycle (or depend on one) are returned separately.
+func sortTasksTopologically(taskIDs []int64, successors map[int64][]int64) (sorted []int64, cyclic []int64) {
+	inDegree := make(map[int64]int, len(taskIDs))
+	for _, id := range taskIDs {
+		inDegree[id] = 0
+	}
+	for _, id := range taskIDs {
+		for _, succ := range successors[id] {
+			if _, has := inDegree[succ]; has {
+				inDegree[succ]++
+			}
+		}
+	}
+
+	queue := []int64{}
+	for _, id := range taskIDs {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, id)
+		for _, succ := range successors[id] {
+			if _, has := inDegree[succ]; !has {
+				continue
+			}
+			inDegree[succ]--
+			if inDegree[succ] == 0 {
+				queue = append(queue, succ)
+			}
+		}
+	}
+
+	for _, id := range taskIDs {
+		if inDegree[id] > 0 {
+			cyclic = append(cyclic, id)
+		}
+	}
+
+	return
+}
+
+func (ps *ProjectSchedule) getTasks(s *xorm.Session, a web.Auth) (tasks []*Task, err error) {
+	tc := &TaskCollection{
+		ProjectID:     ps.ProjectID,
+		ProjectViewID: ps.ProjectViewID,
+	}
+	result, _, _, err := tc.ReadAll(s, a, "", 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r := result.(type) {
+	case []*Task:
+		tasks = r
+	case []*Bucket:
+		for _, b := range r {
+			tasks = append(tasks, b.Tasks...)
+		}
+	}
+
+	return
+}
+
+// ReadOne computes the schedule of a project view
+// @Summary Get the schedule of a project view
+// @Description Computes earliest and latest start and finish, the slack of every task and the critical path of a project view. Dependencies are taken from `precedes` and `blocking` relations between tasks of the view. Tasks without any dates are not scheduled.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param view path int true "Project View ID"
+// @Success 200 {object} models.ProjectSchedule "The schedule"
+// @Failure 400 {object} web.HTTPError "The dependencies of the tasks contain a cycle."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project view"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{view}/schedule [get]
+func (ps *ProjectSchedule) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	tasks, err := ps.getTasks(s, a)
+	if err != nil {
+		return err
+	}
+
+	taskIDs := []int64{}
+	schedules := make(map[int64]*TaskSchedule, len(tasks))
+	for _, t := range tasks {
+		start, finish := getTaskPlannedDates(t)
+		if start.IsZero() {
+			continue
+		}
+		taskIDs = append(taskIDs, t.ID)
+		schedules[t.ID] = &TaskSchedule{
+			TaskID:         t.ID,
+			Duration:       int64(finish.Sub(start).Seconds()),
+			EarliestStart:  start,
+			EarliestFinish: finish,
+			Predecessors:   []int64{},
+		}
+	}
+
+	allSuccessors, err := getTaskSuccessors(s, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	// Only dependencies between tasks of this view are relevant for the schedule
+	successors := make(map[int64][]int64, len(allSuccessors))
+	for id, succs := range allSuccessors {
+		for _, succ := range succs {
+			if _, has := schedules[succ]; has {
+				successors[id] = append(successors[id], succ)
+				schedules[succ].Predecessors = append(schedules[succ].Predecessors, id)
+			}
+		}
+	}
+
+	sorted, cyclic := sortTasksTopologically(taskIDs, successors)
+	if len(cyclic) > 0 {
+		return &ErrTaskDependencyCycle{TaskIDs: cyclic}
+	}
+
+	ps.Tasks = make([]*TaskSchedule, 0, len(sorted))
+	ps.CriticalPath = []int64{}
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	// Forward pass: a task can start as soon as all of its predecessors are finished
+	for _, id := range sorted {
+		ts := schedules[id]
+		if len(ts.Predecessors) > 0 {
+			ts.EarliestStart = time.Time{}
+			for _, pred := range ts.Predecessors {
+				if schedules[pred].EarliestFinish.After(ts.EarliestStart) {
+					ts.EarliestStart = schedules[pred].EarliestFinish
+				}
+			}
+		}
+		ts.EarliestFinish = ts.EarliestStart.Add(time.Duration(ts.Duration) * time.Second)
+
+		if ps.Start.IsZero() || ts.EarliestStart.Before(ps.Start) {
+			ps.Start = ts.EarliestStart
+		}
+		if ts.EarliestFinish.After(ps.Finish) {
+			ps.Finish = ts.EarliestFinish
+		}
+	}
+
+	// Backward pass: a task must be finished before the first of its successors has to start
+	for i := len(sorted) - 1; i >= 0; i-- {
+		ts := schedules[sorted[i]]
+		ts.LatestFinish = ps.Finish
+		for _, succ := range successors[ts.TaskID] {
+			if schedules[succ].LatestStart.Before(ts.LatestFinish) {
+				ts.LatestFinish = schedules[succ].LatestStart
+			}
+		}
+		ts.LatestStart = ts.LatestFinish.Add(-time.Duration(ts.Duration) * time.Second)
+		ts.Slack = int64(ts.LatestStart.Sub(ts.EarliestStart).Seconds())
+		ts.IsCritical = ts.Slack == 0
+	}
+
+	for _, id := range sorted {
+		ps.Tasks = append(ps.Tasks, schedules[id])
+		if schedules[id].IsCritical {
+			ps.CriticalPath = append(ps.CriticalPath, id)
+		}
+	}
+
+	sort.SliceStable(ps.CriticalPath, func(i, j int) bool {
+		return schedules[ps.CriticalPath[i]].EarliestStart.Before(schedules[ps.CriticalPath[j]].EarliestStart)
+	})
+
+	return nil
+}
+
+// shiftSuccessors moves all successors of a task which would now start before one of their
+// predecessors is finished. Only successors in the same project are shifted, and only if that project
+// has a gantt view with shifting enabled. Start, end and due date of a successor are moved by the same
+// amount so its duration stays the same.
+func shiftSuccessors(s *xorm.Session, a web.Auth, task *Task) (err error) {
+	enabled, err := s.
+		Where("project_id = ? AND view_kind = ? AND shift_successors = ?", task.ProjectID, ProjectViewKindGantt, true).
+		Exist(&ProjectView{})
+	if err != nil || !enabled {
+		return err
+	}
+
+	// Find all tasks which directly or transitively follow the task
+	tasks := map[int64]*Task{task.ID: task}
+	successors := make(map[int64][]int64)
+	frontier := []int64{task.ID}
+	for len(frontier) > 0 {
+		edges, err := getTaskSuccessors(s, frontier)
+		if err != nil {
+			return err
+		}
+
+		newIDs := []int64{}
+		for id, succs := range edges {
+			successors[id] = succs
+			for _, succ := range succs {
+				if _, has := tasks[succ]; !has {
+					newIDs = append(newIDs, succ)
+				}
+			}
+		}
+
+		frontier = []int64{}
+		if len(newIDs) == 0 {
+			break
+		}
+
+		newTasks, err := GetTasksSimpleByIDs(s, newIDs)
+		if err != nil {
+			return err
+		}
+		for _, t := range newTasks {
+			if _, has := tasks[t.ID]; has || t.ProjectID != task.ProjectID {
+				continue
+			}
+			tasks[t.ID] = t
+			frontier = append(frontier, t.ID)
+		}
+	}
+
+	if len(tasks) == 1 {
+		return nil
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	predecessors := make(map[int64][]int64)
+	for id := range tasks {
+		taskIDs = append(taskIDs, id)
+		for _, succ := range successors[id] {
+			predecessors[succ] = append(predecessors[succ], id)
+		}
+	}
+	sort.Slice(taskIDs, func(i, j int) bool { return taskIDs[i] < taskIDs[j] })
+
+	// Tasks in a dependency cycle cannot be scheduled and are left as they are
+	sorted, _ := sortTasksTopologically(taskIDs, successors)
+
+	doer, _ := user.GetFromAuth(a)
+	finishes := make(map[int64]time.Time, len(sorted))
+	for _, id := range sorted {
+		t := tasks[id]
+		start, finish := getTaskPlannedDates(t)
+
+		var required time.Time
+		if id != task.ID {
+			for _, pred := range predecessors[id] {
+				if finishes[pred].After(required) {
+					required = finishes[pred]
+				}
+			}
+		}
+
+		if !start.IsZero() && start.Before(required) {
+			err = shiftTaskDates(s, t, required.Sub(start))
+			if err != nil {
+				return err
+			}
+			err = events.Dispatch(&TaskUpdatedEvent{
+				Task: t,
+				Doer: doer,
+			})
+			if err != nil {
+				return err
+			}
+			_, finish = getTaskPlannedDates(t)
+		}
+
+		finishes[id] = finish
+	}
+
+	return nil
+}
+
+func shiftTaskDates(s *xorm.Session, t *Task, by time.Duration) (err error) {
+	if !t.StartDate.IsZero() {
+		t.StartDate = t.StartDate.Add(by)
+	}
+	if !t.EndDate.IsZero() {
+		t.EndDate = t.EndDate.Add(by)
+	}
+	if !t.DueDate.IsZero() {
+		t.DueDate = t.DueDate.Add(by)
+	}
+
+	_, err = s.
+		ID(t.ID).
+		Cols("start_date", "end_date", "due_date").
+		Update(t)
+	if err != nil {
+		return err
+	}
+
+	reminders, err := getRemindersForTasks(s, []int64{t.ID})
+	if err != nil {
+		return err
+	}
+	t.Reminders = reminders
+
+	err = updateRelativeReminderDates(t)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range t.Reminders {
+		if r.RelativeTo == "" {
+			continue
+		}
+		_, err = s.ID(r.ID).Cols("reminder").Update(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+// setupScheduleTasks plans three tasks in project 2: task 13 (two days) precedes task 37 (three days),
+// a new one-day task blocks task 37 as well.
+func setupScheduleTasks(t *testing.T, s *xorm.Session) (day0 time.Time, extraID int64) {
+	day0 = time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	extra := &Task{Title: "Extra", ProjectID: 2, CreatedByID: 3, Index: 3, StartDate: day0, EndDate: day0.Add(day)}
+	_, err := s.Insert(extra)
+	require.NoError(t, err)
+
+	_, err = s.ID(13).Cols("start_date", "end_date").Update(&Task{StartDate: day0, EndDate: day0.Add(2 * day)})
+	require.NoError(t, err)
+	_, err = s.ID(37).Cols("start_date", "end_date").Update(&Task{StartDate: day0.Add(2 * day), EndDate: day0.Add(5 * day)})
+	require.NoError(t, err)
+
+	_, err = s.Insert(&TaskRelation{TaskID: 13, OtherTaskID: 37, RelationKind: RelationKindPreceeds, CreatedByID: 3})
+	require.NoError(t, err)
+	_, err = s.Insert(&TaskRelation{TaskID: extra.ID, OtherTaskID: 37, RelationKind: RelationKindBlocking, CreatedByID: 3})
+	require.NoError(t, err)
+
+	return day0, extra.ID
+}
+
+func TestProjectSchedule_ReadOne(t *testing.T) {
+	u := &user.User{ID: 3}
+
+	t.Run("critical path", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		day0, extraID := setupScheduleTasks(t, s)
+
+		ps := &ProjectSchedule{ProjectID: 2, ProjectViewID: 6}
+		can, _, err := ps.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = ps.ReadOne(s, u)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{13, 37}, ps.CriticalPath)
+		assert.Equal(t, day0.Unix(), ps.Start.Unix())
+		assert.Equal(t, day0.Add(5*24*time.Hour).Unix(), ps.Finish.Unix())
+		require.Len(t, ps.Tasks, 3)
+
+		schedules := map[int64]*TaskSchedule{}
+		for _, ts := range ps.Tasks {
+			schedules[ts.TaskID] = ts
+		}
+		assert.Equal(t, int64(0), schedules[13].Slack)
+		assert.Equal(t, int64(24*60*60), schedules[extraID].Slack)
+		assert.False(t, schedules[extraID].IsCritical)
+		assert.ElementsMatch(t, []int64{13, extraID}, schedules[37].Predecessors)
+		assert.Equal(t, day0.Add(2*24*time.Hour).Unix(), schedules[37].EarliestStart.Unix())
+		assert.Equal(t, int64(3*24*60*60), schedules[37].Duration)
+	})
+	t.Run("cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setupScheduleTasks(t, s)
+		_, err := s.Insert(&TaskRelation{TaskID: 37, OtherTaskID: 13, RelationKind: RelationKindPreceeds, CreatedByID: 3})
+		require.NoError(t, err)
+
+		ps := &ProjectSchedule{ProjectID: 2, ProjectViewID: 6}
+		err = ps.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskDependencyCycle(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ps := &ProjectSchedule{ProjectID: 2, ProjectViewID: 6}
+		can, _, err := ps.CanRead(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("view of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ps := &ProjectSchedule{ProjectID: 2, ProjectViewID: 2}
+		_, _, err := ps.CanRead(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrProjectViewDoesNotExist(err))
+	})
+}
+
+func TestShiftSuccessors(t *testing.T) {
+	u := &user.User{ID: 3}
+	day := 24 * time.Hour
+
+	t.Run("shifts successors when enabled", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		day0, extraID := setupScheduleTasks(t, s)
+		_, err := s.ID(6).Cols("shift_successors").Update(&ProjectView{ShiftSuccessors: true})
+		require.NoError(t, err)
+
+		task := &Task{ID: 13, Title: "task #13 basic other project", StartDate: day0, EndDate: day0.Add(4 * day)}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		successor, err := GetTaskByIDSimple(s, 37)
+		require.NoError(t, err)
+		assert.Equal(t, day0.Add(4*day).Unix(), successor.StartDate.Unix())
+		assert.Equal(t, day0.Add(7*day).Unix(), successor.EndDate.Unix())
+
+		unrelated, err := GetTaskByIDSimple(s, extraID)
+		require.NoError(t, err)
+		assert.Equal(t, day0.Unix(), unrelated.StartDate.Unix())
+	})
+	t.Run("does not shift when disabled", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		day0, _ := setupScheduleTasks(t, s)
+
+		task := &Task{ID: 13, Title: "task #13 basic other project", StartDate: day0, EndDate: day0.Add(4 * day)}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+
+		successor, err := GetTaskByIDSimple(s, 37)
+		require.NoError(t, err)
+		assert.Equal(t, day0.Add(2*day).Unix(), successor.StartDate.Unix())
+	})
+	t.Run("only allowed on gantt views", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		view := &ProjectView{ID: 5, ProjectID: 2, Title: "List", ViewKind: ProjectViewKindList, ShiftSuccessors: true}
+		err := view.Update(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
@@ -20,12 +20,14 @@ import (
 	"reflect"
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
 )
 
 func TestProject_CreateOrUpdate(t *testing.T) {
@@ -351,6 +353,42 @@ func TestProject_Delete(t *testing.T) {
 		require.NoError(t, err)
 		err = s.Commit()
 		require.NoError(t, err)
+		db.AssertCount(t, "projects", builder.Eq{"id": 1}.And(builder.IsNull{"deleted"}), 0)
+		db.AssertCount(t, "tasks", builder.Eq{"id": 1}.And(builder.IsNull{"deleted"}), 0)
+		db.AssertExists(t, "trash", map[string]interface{}{
+			"kind":          TrashItemKindProject,
+			"entity_id":     1,
+			"deleted_by_id": 1,
+		}, false)
+	})
+	t.Run("with child projects", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		project := Project{
+			ID: 12,
+		}
+		err := project.Delete(s, &user.User{ID: 6})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+		for _, id := range []int64{12, 25, 26} {
+			db.AssertCount(t, "projects", builder.Eq{"id": id}.And(builder.IsNull{"deleted"}), 0)
+		}
+		db.AssertCount(t, "projects", builder.Eq{"id": 27}.And(builder.IsNull{"deleted"}), 1)
+		db.AssertCount(t, "trash", builder.Eq{"kind": TrashItemKindProject}, 1)
+	})
+	t.Run("permanently without trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		config.ServiceTrashRetentionDays.Set(0)
+		defer config.ServiceTrashRetentionDays.Set(30)
+		project := Project{
+			ID: 1,
+		}
+		err := project.Delete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
 		db.AssertMissing(t, "projects", map[string]interface{}{
 			"id": 1,
 		})
@@ -362,6 +400,8 @@ func TestProject_Delete(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		files.InitTestFileFixtures(t)
 		s := db.NewSession()
+		config.ServiceTrashRetentionDays.Set(0)
+		defer config.ServiceTrashRetentionDays.Set(30)
 		project := Project{
 			ID: 35,
 		}
@@ -38,6 +38,8 @@ func (p *ProjectViewKind) MarshalJSON() ([]byte, error) {
 		return []byte(`"table"`), nil
 	case ProjectViewKindKanban:
 		return []byte(`"kanban"`), nil
+	case ProjectViewKindCalendar:
+		return []byte(`"calendar"`), nil
 	}
 
 	return []byte(`null`), nil
@@ -59,6 +61,8 @@ func (p *ProjectViewKind) UnmarshalJSON(bytes []byte) error {
 		*p = ProjectViewKindTable
 	case "kanban":
 		*p = ProjectViewKindKanban
+	case "calendar":
+		*p = ProjectViewKindCalendar
 	default:
 		return fmt.Errorf("unknown project view kind: %s", value)
 	}
@@ -75,6 +79,7 @@ const (
 	ProjectViewKindGantt
 	ProjectViewKindTable
 	ProjectViewKindKanban
+	ProjectViewKindCalendar
 )
 
 type BucketConfigurationModeKind int
@@ -135,8 +140,8 @@ type ProjectView struct {
 	Title string `xorm:"varchar(255) not null" json:"title" valid:"required,runelength(1|250)"`
 	// The project this view belongs to
 	ProjectID int64 `xorm:"not null index" json:"project_id" param:"project"`
-	// The kind of this view. Can be `list`, `gantt`, `table` or `kanban`.
-	ViewKind ProjectViewKind `xorm:"not null" json:"view_kind" swaggertype:"string" enums:"list,gantt,table,kanban"`
+	// The kind of this view. Can be `list`, `gantt`, `table`, `kanban` or `calendar`.
+	ViewKind ProjectViewKind `xorm:"not null" json:"view_kind" swaggertype:"string" enums:"list,gantt,table,kanban,calendar"`
 
 	// The filter query to match tasks by. Check out https://vikunja.io/docs/filters for a full explanation.
 	Filter *TaskCollection `xorm:"json null default null" query:"filter" json:"filter"`
@@ -151,6 +156,12 @@ type ProjectView struct {
 	DefaultBucketID int64 `xorm:"bigint INDEX null" json:"default_bucket_id"`
 	// If tasks are moved to the done bucket, they are marked as done. If they are marked as done individually, they are moved into the done bucket.
 	DoneBucketID int64 `xorm:"bigint INDEX null" json:"done_bucket_id"`
+	// Only for kanban views. Groups the tasks of every bucket into swimlanes. Can be `none`, `assignee`, `label`, `priority`, `parent_task` or `filter`.
+	SwimlaneMode SwimlaneModeKind `xorm:"default 0" json:"swimlane_mode" swaggertype:"string" enums:"none,assignee,label,priority,parent_task,filter"`
+	// When the swimlane mode is `filter`, this field holds the title and filter of each lane.
+	SwimlaneConfiguration []*ProjectViewSwimlaneConfiguration `xorm:"json" json:"swimlane_configuration"`
+	// Only for gantt views. If enabled, moving the end date of a task past the start of its successors (tasks it precedes or blocks) shifts those successors by the same amount.
+	ShiftSuccessors bool `xorm:"not null default false" json:"shift_successors"`
 
 	// A timestamp when this view was updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
@@ -165,6 +176,13 @@ func (pv *ProjectView) TableName() string {
 	return "project_views"
 }
 
+func (pv *ProjectView) validateShiftSuccessors() error {
+	if pv.ShiftSuccessors && pv.ViewKind != ProjectViewKindGantt {
+		return InvalidFieldErrorWithMessage([]string{"shift_successors"}, "Shifting successors is only available for gantt views")
+	}
+	return nil
+}
+
 func getViewsForProject(s *xorm.Session, projectID int64) (views []*ProjectView, err error) {
 	views = []*ProjectView{}
 	err = s.
@@ -261,6 +279,11 @@ func (pv *ProjectView) Delete(s *xorm.Session, _ web.Auth) (err error) {
 	}
 
 	_, err = s.Where("project_view_id = ?", pv.ID).Delete(&TaskPosition{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("project_view_id = ?", pv.ID).Delete(&CalendarFeed{})
 	return
 }
 
@@ -300,6 +323,16 @@ func createProjectView(s *xorm.Session, p *ProjectView, a web.Auth, createBacklo
 		}
 	}
 
+	err = p.validateShiftSuccessors()
+	if err != nil {
+		return
+	}
+
+	err = p.validateSwimlanes()
+	if err != nil {
+		return
+	}
+
 	p.ID = 0
 	_, err = s.Insert(p)
 	if err != nil {
@@ -411,6 +444,16 @@ func (pv *ProjectView) Update(s *xorm.Session, _ web.Auth) (err error) {
 		}
 	}
 
+	err = pv.validateShiftSuccessors()
+	if err != nil {
+		return
+	}
+
+	err = pv.validateSwimlanes()
+	if err != nil {
+		return
+	}
+
 	// Check if the project view exists
 	_, err = GetProjectViewByIDAndProject(s, pv.ID, pv.ProjectID)
 	if err != nil {
@@ -428,6 +471,9 @@ func (pv *ProjectView) Update(s *xorm.Session, _ web.Auth) (err error) {
 			"bucket_configuration",
 			"default_bucket_id",
 			"done_bucket_id",
+			"shift_successors",
+			"swimlane_mode",
+			"swimlane_configuration",
 		).
 		Update(pv)
 	return
@@ -0,0 +1,134 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CalendarGranularity defines how a calendar view groups its tasks.
+type CalendarGranularity string
+
+const (
+	CalendarGranularityDay  CalendarGranularity = `day`
+	CalendarGranularityWeek CalendarGranularity = `week`
+)
+
+// maxCalendarPeriods is the maximum number of days or weeks a calendar view returns at once.
+const maxCalendarPeriods = 366
+
+// CalendarPeriod is a day or week of a calendar view with all tasks happening in it.
+type CalendarPeriod struct {
+	// The start of this period.
+	Start time.Time `json:"start"`
+	// The end of this period, exclusive.
+	End time.Time `json:"end"`
+	// All tasks whose start, end or due date falls into this period, sorted by date. Tasks spanning multiple
+	// periods show up in all of them. Future occurrences of repeating tasks are included as virtual tasks.
+	Tasks []*Task `json:"tasks"`
+}
+
+// taskOverlapsRange checks if the planned dates of a task touch the range between from and to.
+func taskOverlapsRange(t *Task, from, to time.Time) bool {
+	start, finish := getTaskPlannedDates(t)
+	if start.IsZero() {
+		return false
+	}
+	return start.Before(to) && (finish.After(from) || !start.Before(from))
+}
+
+func getCalendarPeriods(opts *taskSearchOptions) (periods []*CalendarPeriod, err error) {
+	if opts.dateFrom.IsZero() || opts.dateTo.IsZero() {
+		return nil, InvalidFieldErrorWithMessage([]string{"date_from", "date_to"}, "Calendar views need a date range.")
+	}
+
+	days := 1
+	switch opts.granularity {
+	case "", CalendarGranularityDay:
+	case CalendarGranularityWeek:
+		days = 7
+	default:
+		return nil, InvalidFieldErrorWithMessage([]string{"granularity"}, "The granularity must be either day or week.")
+	}
+
+	loc, err := getFilterTimezoneLocation(opts.filterTimezone)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = config.GetTimeZone()
+	}
+
+	from := opts.dateFrom.In(loc)
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	for start.Before(opts.dateTo) {
+		if len(periods) == maxCalendarPeriods {
+			return nil, InvalidFieldErrorWithMessage([]string{"date_to"}, "The date range is too long for a calendar view.")
+		}
+		end := start.AddDate(0, 0, days)
+		periods = append(periods, &CalendarPeriod{
+			Start: start,
+			End:   end,
+			Tasks: []*Task{},
+		})
+		start = end
+	}
+
+	return
+}
+
+// getTasksInCalendarForView returns the tasks of a calendar view in the requested date range, grouped by day or week.
+func getTasksInCalendarForView(s *xorm.Session, a web.Auth, projects []*Project, view *ProjectView, opts *taskSearchOptions) (periods []*CalendarPeriod, err error) {
+	periods, err = getCalendarPeriods(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// A calendar shows everything in its range at once, including future occurrences of repeating tasks
+	opts.page = 0
+	opts.dateFrom = periods[0].Start
+	opts.dateTo = periods[len(periods)-1].End
+	if !opts.expandsOccurrences() {
+		opts.expand = append(opts.expand, TaskCollectionExpandOccurrences)
+	}
+	entries, _, _, err := getTasksForProjects(s, projects, a, opts, view)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		startI, _ := getTaskPlannedDates(entries[i])
+		startJ, _ := getTaskPlannedDates(entries[j])
+		return startI.Before(startJ)
+	})
+
+	for _, period := range periods {
+		for _, t := range entries {
+			if taskOverlapsRange(t, period.Start, period.End) {
+				period.Tasks = append(period.Tasks, t)
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,143 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getCalendarPeriodTaskIDs(period *CalendarPeriod) (ids []int64) {
+	for _, t := range period.Tasks {
+		ids = append(ids, t.ID)
+	}
+	return
+}
+
+func TestTaskCollection_ReadAll_Calendar(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	createCalendarView := func(t *testing.T) *ProjectView {
+		s := db.NewSession()
+		defer s.Close()
+
+		view := &ProjectView{ProjectID: 1, Title: "Calendar", ViewKind: ProjectViewKindCalendar}
+		_, err := s.Insert(view)
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+		return view
+	}
+
+	t.Run("by day", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		view := createCalendarView(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: view.ID,
+			DateFrom:      "2018-11-30",
+			DateTo:        "2018-12-14",
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		periods := result.([]*CalendarPeriod)
+		require.Len(t, periods, 14)
+		assert.Equal(t, 30, periods[0].Start.Day())
+		assert.Equal(t, periods[0].End, periods[1].Start)
+
+		assert.ElementsMatch(t, []int64{6, 27, 28}, getCalendarPeriodTaskIDs(periods[0]))
+		assert.ElementsMatch(t, []int64{5, 28}, getCalendarPeriodTaskIDs(periods[1]))
+		assert.ElementsMatch(t, []int64{7, 9, 28}, getCalendarPeriodTaskIDs(periods[12]))
+		assert.ElementsMatch(t, []int64{8, 9, 28}, getCalendarPeriodTaskIDs(periods[13]))
+	})
+	t.Run("by week", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		view := createCalendarView(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: view.ID,
+			DateFrom:      "2018-11-30",
+			DateTo:        "2018-12-14",
+			Granularity:   CalendarGranularityWeek,
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		periods := result.([]*CalendarPeriod)
+		require.Len(t, periods, 2)
+		assert.ElementsMatch(t, []int64{5, 6, 27, 28}, getCalendarPeriodTaskIDs(periods[0]))
+		assert.ElementsMatch(t, []int64{7, 8, 9, 28}, getCalendarPeriodTaskIDs(periods[1]))
+		// Sorted by date
+		assert.Equal(t, int64(28), periods[1].Tasks[0].ID)
+	})
+	t.Run("expands repeating tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		view := createCalendarView(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tomorrow := time.Now().Add(24 * time.Hour)
+		_, err := s.ID(5).Cols("due_date", "repeat_after").Update(&Task{DueDate: tomorrow, RepeatAfter: 7 * 24 * 60 * 60})
+		require.NoError(t, err)
+		// Task 28 repeats every hour
+		_, err = s.ID(28).Cols("repeat_after").Update(&Task{RepeatAfter: 0})
+		require.NoError(t, err)
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: view.ID,
+			DateFrom:      tomorrow.Format("2006-01-02"),
+			DateTo:        tomorrow.Add(21 * 24 * time.Hour).Format("2006-01-02"),
+			Granularity:   CalendarGranularityWeek,
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		periods := result.([]*CalendarPeriod)
+		require.Len(t, periods, 3)
+		for i, period := range periods {
+			require.Len(t, period.Tasks, 1)
+			assert.Equal(t, int64(5), period.Tasks[0].ID)
+			assert.Equal(t, i > 0, period.Tasks[0].IsVirtual)
+		}
+	})
+	t.Run("needs a date range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		view := createCalendarView(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: view.ID}
+		_, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+
+		tc = &TaskCollection{ProjectID: 1, ProjectViewID: view.ID, DateFrom: "2018-12-14", DateTo: "2018-11-30"}
+		_, _, _, err = tc.ReadAll(s, u, "", 1, 50)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
@@ -0,0 +1,95 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// PushSubscription is a wrapper around the crud operations that come with a push subscription.
+type PushSubscription struct {
+	notifications.PushSubscription
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// Create subscribes a browser or device to push messages
+// @Summary Subscribe to push messages
+// @Description Saves the push subscription of a browser or device, as returned by `PushManager.subscribe()` with the vapid public key from `/info`. If the browser subscribed before, the old subscription is replaced.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param subscription body models.PushSubscription true "The push subscription of the browser"
+// @Success 201 {object} models.PushSubscription "The created subscription."
+// @Failure 400 {object} web.HTTPError "Invalid subscription object provided."
+// @Failure 403 {object} web.HTTPError "Link shares cannot subscribe to push messages."
+// @Failure 412 {object} web.HTTPError "Web push is not enabled on this instance."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /push-subscriptions [put]
+func (p *PushSubscription) Create(s *xorm.Session, a web.Auth) (err error) {
+	if !notifications.IsWebPushEnabled() {
+		return &ErrWebPushDisabled{}
+	}
+
+	p.NotifiableID = a.GetID()
+	return notifications.AddPushSubscription(s, &p.PushSubscription)
+}
+
+// ReadAll returns all push subscriptions of the current user
+// @Summary Get all push subscriptions of the current user
+// @Description Returns all browsers and devices of the current user which get push messages.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} models.PushSubscription "The subscriptions"
+// @Failure 403 {object} web.HTTPError "Link shares cannot have push subscriptions."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /push-subscriptions [get]
+func (p *PushSubscription) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	subscriptions, err := notifications.GetPushSubscriptionsForNotifiable(s, a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return subscriptions, len(subscriptions), int64(len(subscriptions)), nil
+}
+
+// Delete unsubscribes a browser or device from push messages
+// @Summary Delete a push subscription
+// @Description Stops sending push messages to a browser or device of the current user.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param subscription path int true "Subscription ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 403 {object} web.HTTPError "The subscription belongs to another user."
+// @Failure 404 {object} web.HTTPError "The subscription does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /push-subscriptions/{subscription} [delete]
+func (p *PushSubscription) Delete(s *xorm.Session, a web.Auth) (err error) {
+	return notifications.DeletePushSubscription(s, p.ID, a.GetID())
+}
@@ -0,0 +1,47 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can subscribe to push messages. Only users can.
+func (p *PushSubscription) CanCreate(_ *xorm.Session, a web.Auth) (bool, error) {
+	_, isLinkShare := a.(*LinkSharing)
+	return !isLinkShare, nil
+}
+
+// CanDelete checks if a user can delete a push subscription. Only the user it belongs to can.
+func (p *PushSubscription) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	subscription, exists, err := notifications.GetPushSubscriptionByID(s, p.ID)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, &ErrPushSubscriptionDoesNotExist{ID: p.ID}
+	}
+
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	return subscription.NotifiableID == a.GetID(), nil
+}
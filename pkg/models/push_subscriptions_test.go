@@ -0,0 +1,185 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushSubscription_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	p := &PushSubscription{}
+	result, count, total, err := p.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.NoError(t, err)
+	subscriptions := result.([]*notifications.PushSubscription)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int64(1), subscriptions[0].ID)
+	assert.Equal(t, "BTBZMqHH6r4Tts7J_aSIgg", subscriptions[0].Keys.Auth)
+}
+
+func TestPushSubscription_Create(t *testing.T) {
+	privateKey, _, err := notifications.GenerateVAPIDKeys()
+	require.NoError(t, err)
+
+	t.Run("normal", func(t *testing.T) {
+		config.WebPushEnabled.Set(true)
+		config.WebPushVAPIDPrivateKey.Set(privateKey)
+		defer config.WebPushEnabled.Set(false)
+
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.Endpoint = "https://push.example.com/send/user1-phone"
+		p.Keys.P256dh = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+		p.Keys.Auth = "BTBZMqHH6r4Tts7J_aSIgg"
+		err := p.Create(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertExists(t, "push_subscriptions", map[string]interface{}{
+			"id":            p.ID,
+			"notifiable_id": 1,
+			"endpoint":      "https://push.example.com/send/user1-phone",
+		}, false)
+	})
+	t.Run("resubscribing replaces the old subscription", func(t *testing.T) {
+		config.WebPushEnabled.Set(true)
+		config.WebPushVAPIDPrivateKey.Set(privateKey)
+		defer config.WebPushEnabled.Set(false)
+
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.Endpoint = "https://push.example.com/send/user1-laptop"
+		p.Keys.P256dh = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+		p.Keys.Auth = "new-auth"
+		err := p.Create(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertMissing(t, "push_subscriptions", map[string]interface{}{"id": 1})
+		db.AssertExists(t, "push_subscriptions", map[string]interface{}{
+			"notifiable_id": 1,
+			"endpoint":      "https://push.example.com/send/user1-laptop",
+			"auth":          "new-auth",
+		}, false)
+	})
+	t.Run("subscribing with the endpoint of another user keeps their subscription", func(t *testing.T) {
+		config.WebPushEnabled.Set(true)
+		config.WebPushVAPIDPrivateKey.Set(privateKey)
+		defer config.WebPushEnabled.Set(false)
+
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.Endpoint = "https://push.example.com/send/user2-phone"
+		p.Keys.P256dh = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+		p.Keys.Auth = "new-auth"
+		err := p.Create(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertExists(t, "push_subscriptions", map[string]interface{}{
+			"id":            2,
+			"notifiable_id": 2,
+		}, false)
+		db.AssertExists(t, "push_subscriptions", map[string]interface{}{
+			"notifiable_id": 1,
+			"endpoint":      "https://push.example.com/send/user2-phone",
+		}, false)
+	})
+	t.Run("web push disabled", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.Endpoint = "https://push.example.com/send/user1-phone"
+		err := p.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrWebPushDisabled(err))
+	})
+}
+
+func TestPushSubscription_CanDelete(t *testing.T) {
+	t.Run("own subscription", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.ID = 1
+		can, err := p.CanDelete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("subscription of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.ID = 2
+		can, err := p.CanDelete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		p := &PushSubscription{}
+		p.ID = 9999
+		_, err := p.CanDelete(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrPushSubscriptionDoesNotExist(err))
+	})
+}
+
+func TestPushSubscription_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	p := &PushSubscription{}
+	p.ID = 1
+	err := p.Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	require.NoError(t, s.Commit())
+
+	db.AssertMissing(t, "push_subscriptions", map[string]interface{}{"id": 1})
+}
@@ -64,6 +64,9 @@ func SetupTests() {
 		"teams",
 		"users",
 		"user_tokens",
+		"user_notification_preferences",
+		"push_subscriptions",
+		"chat_notification_targets",
 		"users_projects",
 		"buckets",
 		"saved_filters",
@@ -74,6 +77,20 @@ func SetupTests() {
 		"project_views",
 		"task_positions",
 		"task_buckets",
+		"task_time_entries",
+		"task_checklist_items",
+		"sprints",
+		"sprint_tasks",
+		"project_custom_fields",
+		"task_custom_field_values",
+		"audit_log",
+		"trash",
+		"webhooks",
+		"webhook_deliveries",
+		"templates",
+		"automation_rules",
+		"automation_rule_executions",
+		"wip_limits",
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,153 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+const (
+	SprintMetricStoryPoints = "story_points"
+	SprintMetricEstimate    = "estimate"
+	SprintMetricTasks       = "tasks"
+)
+
+// SprintBurndown holds the data for burndown and burnup charts of a sprint.
+type SprintBurndown struct {
+	SprintID  int64 `json:"sprint_id" param:"sprint"`
+	ProjectID int64 `json:"-" param:"project"`
+	// What the chart sums up. Can be `story_points` (the default), `estimate` (in seconds) or `tasks`.
+	Metric string `json:"metric" query:"metric"`
+	// One point per day of the sprint.
+	Points []*SprintBurndownPoint `json:"points"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+// SprintBurndownPoint holds the values of a sprint at the end of a day.
+type SprintBurndownPoint struct {
+	// The start of the day this point is for.
+	Date time.Time `json:"date"`
+	// All tasks which were in the sprint at the end of the day. Use this as the total line of a burnup chart.
+	Scope float64 `json:"scope"`
+	// The tasks done at the end of the day. Null for days which are still to come.
+	Completed *float64 `json:"completed"`
+	// The tasks still open at the end of the day. Null for days which are still to come.
+	Remaining *float64 `json:"remaining"`
+	// Where the remaining work should be if it is done at a constant pace, starting from the scope of the first day.
+	Ideal float64 `json:"ideal"`
+}
+
+func (b *SprintBurndown) valueOf(t *Task) float64 {
+	if b.Metric == SprintMetricTasks {
+		return 1
+	}
+	if b.Metric == SprintMetricEstimate {
+		return float64(t.Estimate)
+	}
+	return t.StoryPoints
+}
+
+// ReadOne returns the burndown data of a sprint
+// @Summary Get the burndown of a sprint
+// @Description Returns the scope, completed and remaining work of a sprint for every day between its start and end, computed from when tasks were added to the sprint and when they were done.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint path int true "Sprint ID"
+// @Param metric query string false "What to sum up. Can be `story_points` (the default), `estimate` or `tasks`."
+// @Success 200 {object} models.SprintBurndown "The burndown data"
+// @Failure 400 {object} web.HTTPError "Invalid metric provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The sprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints/{sprint}/burndown [get]
+func (b *SprintBurndown) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	if b.Metric == "" {
+		b.Metric = SprintMetricStoryPoints
+	}
+	if b.Metric != SprintMetricStoryPoints && b.Metric != SprintMetricEstimate && b.Metric != SprintMetricTasks {
+		return InvalidFieldErrorWithMessage([]string{"metric"}, "The metric must be one of story_points, estimate or tasks.")
+	}
+
+	sp, err := getSprintByIDAndProject(s, b.SprintID, b.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	tasks, addedAt, err := getTasksInSprint(s, sp.ID)
+	if err != nil {
+		return err
+	}
+
+	loc := config.GetTimeZone()
+	start := sp.StartDate.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	now := time.Now()
+
+	b.Points = []*SprintBurndownPoint{}
+	for !day.After(sp.EndDate) && len(b.Points) < maxCalendarPeriods {
+		end := day.AddDate(0, 0, 1)
+		if end.After(sp.EndDate) {
+			end = sp.EndDate
+		}
+
+		point := &SprintBurndownPoint{Date: day}
+		var completed float64
+		for _, t := range tasks {
+			if addedAt[t.ID].After(end) {
+				continue
+			}
+			value := b.valueOf(t)
+			point.Scope += value
+			if isDoneInSprint(t, end) {
+				completed += value
+			}
+		}
+
+		if !day.After(now) {
+			remaining := point.Scope - completed
+			point.Completed = &completed
+			point.Remaining = &remaining
+		}
+
+		b.Points = append(b.Points, point)
+		day = day.AddDate(0, 0, 1)
+	}
+
+	if len(b.Points) == 0 {
+		return nil
+	}
+
+	initial := b.Points[0].Scope
+	last := float64(len(b.Points) - 1)
+	for i, point := range b.Points {
+		if last == 0 {
+			continue
+		}
+		point.Ideal = initial * (1 - float64(i)/last)
+	}
+
+	return nil
+}
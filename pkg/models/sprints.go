@@ -0,0 +1,465 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// Sprint is a fixed period of time in a project in which a set of tasks should get done.
+type Sprint struct {
+	// The unique, numeric id of this sprint.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"sprint"`
+	// The project this sprint belongs to.
+	ProjectID int64 `xorm:"bigint not null INDEX" json:"project_id" param:"project"`
+	// The title of this sprint, for example `Sprint 12`.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// What the team wants to achieve in this sprint.
+	Goal string `xorm:"longtext null" json:"goal"`
+	// When this sprint starts.
+	StartDate time.Time `xorm:"DATETIME not null 'start_date'" json:"start_date"`
+	// When this sprint ends. Cannot be before the start.
+	EndDate time.Time `xorm:"DATETIME not null 'end_date'" json:"end_date"`
+
+	// The tasks in this sprint. Only returned when retrieving a single sprint, use the tasks endpoint to add or remove tasks.
+	Tasks []*Task `xorm:"-" json:"tasks"`
+	// The totals of all tasks in this sprint.
+	Committed *SprintTotals `xorm:"-" json:"committed"`
+	// The totals of all tasks in this sprint which were done before it ended.
+	Completed *SprintTotals `xorm:"-" json:"completed"`
+
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+
+	// A timestamp when this sprint was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this sprint was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// SprintTotals holds the summed up effort of a set of tasks in a sprint.
+type SprintTotals struct {
+	Tasks       int64   `json:"tasks"`
+	StoryPoints float64 `json:"story_points"`
+	// The summed up estimates in seconds.
+	Estimate int64 `json:"estimate"`
+}
+
+func (st *SprintTotals) add(t *Task) {
+	st.Tasks++
+	st.StoryPoints += t.StoryPoints
+	st.Estimate += t.Estimate
+}
+
+// TableName returns the table name for sprints
+func (*Sprint) TableName() string {
+	return "sprints"
+}
+
+// SprintTask is the membership of a task in a sprint.
+type SprintTask struct {
+	ID        int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	SprintID  int64 `xorm:"bigint not null INDEX" json:"-" param:"sprint"`
+	ProjectID int64 `xorm:"-" json:"-" param:"project"`
+	// The task to add to the sprint. It needs to belong to the same project as the sprint.
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id" param:"task"`
+
+	// A timestamp when this task was added to the sprint. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for the tasks in sprints
+func (*SprintTask) TableName() string {
+	return "sprint_tasks"
+}
+
+func (sp *Sprint) validate() error {
+	if sp.StartDate.IsZero() || sp.EndDate.IsZero() || sp.EndDate.Before(sp.StartDate) {
+		return &ErrSprintEndBeforeStart{Start: sp.StartDate, End: sp.EndDate}
+	}
+	return nil
+}
+
+func getSprintByIDAndProject(s *xorm.Session, id, projectID int64) (sp *Sprint, err error) {
+	sp = &Sprint{}
+	exists, err := s.
+		Where("id = ? AND project_id = ?", id, projectID).
+		Get(sp)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrSprintDoesNotExist{ID: id}
+	}
+	return
+}
+
+// getTasksInSprint returns all tasks of a sprint together with the time they were added to it.
+func getTasksInSprint(s *xorm.Session, sprintID int64) (tasks []*Task, addedAt map[int64]time.Time, err error) {
+	memberships := []*SprintTask{}
+	err = s.Where("sprint_id = ?", sprintID).Find(&memberships)
+	if err != nil {
+		return
+	}
+
+	addedAt = make(map[int64]time.Time, len(memberships))
+	taskIDs := make([]int64, 0, len(memberships))
+	for _, m := range memberships {
+		addedAt[m.TaskID] = m.Created
+		taskIDs = append(taskIDs, m.TaskID)
+	}
+
+	tasks = []*Task{}
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	err = s.In("id", taskIDs).OrderBy("id asc").Find(&tasks)
+	return
+}
+
+// isDoneInSprint reports whether a task was done by the given time.
+// Tasks done before done timestamps were recorded count as done at any time.
+func isDoneInSprint(t *Task, by time.Time) bool {
+	return t.Done && (t.DoneAt.IsZero() || !t.DoneAt.After(by))
+}
+
+func (sp *Sprint) setTotals(tasks []*Task) {
+	sp.Committed = &SprintTotals{}
+	sp.Completed = &SprintTotals{}
+	for _, t := range tasks {
+		sp.Committed.add(t)
+		if isDoneInSprint(t, sp.EndDate) {
+			sp.Completed.add(t)
+		}
+	}
+}
+
+func addTotalsToSprints(s *xorm.Session, sprints []*Sprint) (err error) {
+	if len(sprints) == 0 {
+		return nil
+	}
+
+	sprintIDs := make([]int64, 0, len(sprints))
+	userIDs := make([]int64, 0, len(sprints))
+	for _, sp := range sprints {
+		sprintIDs = append(sprintIDs, sp.ID)
+		userIDs = append(userIDs, sp.CreatedByID)
+	}
+
+	memberships := []*SprintTask{}
+	err = s.In("sprint_id", sprintIDs).Find(&memberships)
+	if err != nil {
+		return
+	}
+
+	taskIDs := make([]int64, 0, len(memberships))
+	for _, m := range memberships {
+		taskIDs = append(taskIDs, m.TaskID)
+	}
+
+	tasks := make(map[int64]*Task, len(taskIDs))
+	if len(taskIDs) > 0 {
+		err = s.In("id", taskIDs).Find(&tasks)
+		if err != nil {
+			return
+		}
+	}
+
+	tasksBySprint := make(map[int64][]*Task, len(sprints))
+	for _, m := range memberships {
+		if t, has := tasks[m.TaskID]; has {
+			tasksBySprint[m.SprintID] = append(tasksBySprint[m.SprintID], t)
+		}
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return
+	}
+
+	for _, sp := range sprints {
+		sp.setTotals(tasksBySprint[sp.ID])
+		sp.CreatedBy = users[sp.CreatedByID]
+	}
+
+	return
+}
+
+// ReadAll returns all sprints of a project
+// @Summary Get all sprints of a project
+// @Description Returns all sprints of a project with the committed and completed totals of their tasks, sorted by their start.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Success 200 {array} models.Sprint "The sprints"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints [get]
+func (sp *Sprint) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: sp.ProjectID}
+	can, _, err := p.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	sprints := []*Sprint{}
+	err = s.
+		Where("project_id = ?", sp.ProjectID).
+		OrderBy("start_date asc, id asc").
+		Find(&sprints)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addTotalsToSprints(s, sprints)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return sprints, len(sprints), int64(len(sprints)), nil
+}
+
+// ReadOne returns a single sprint with its tasks
+// @Summary Get one sprint
+// @Description Returns a sprint of a project with all of its tasks and the committed and completed totals.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint path int true "Sprint ID"
+// @Success 200 {object} models.Sprint "The sprint"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The sprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints/{sprint} [get]
+func (sp *Sprint) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	stored, err := getSprintByIDAndProject(s, sp.ID, sp.ProjectID)
+	if err != nil {
+		return err
+	}
+	*sp = *stored
+
+	tasks, _, err := getTasksInSprint(s, sp.ID)
+	if err != nil {
+		return err
+	}
+
+	taskMap := make(map[int64]*Task, len(tasks))
+	for _, t := range tasks {
+		taskMap[t.ID] = t
+	}
+	err = addMoreInfoToTasks(s, taskMap, a, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	sp.Tasks = tasks
+	sp.setTotals(tasks)
+	sp.CreatedBy, err = user.GetUserByID(s, sp.CreatedByID)
+	return
+}
+
+// Create creates a new sprint
+// @Summary Create a sprint
+// @Description Creates a new sprint in a project. Tasks can be added to it afterwards.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint body models.Sprint true "The sprint you want to create."
+// @Success 201 {object} models.Sprint "The created sprint"
+// @Failure 400 {object} web.HTTPError "Invalid sprint provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints [put]
+func (sp *Sprint) Create(s *xorm.Session, a web.Auth) (err error) {
+	err = sp.validate()
+	if err != nil {
+		return
+	}
+
+	sp.ID = 0
+	sp.CreatedByID = a.GetID()
+	_, err = s.Insert(sp)
+	if err != nil {
+		return
+	}
+
+	return sp.ReadOne(s, a)
+}
+
+// Update changes a sprint
+// @Summary Update a sprint
+// @Description Updates the title, goal, start or end of a sprint.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint path int true "Sprint ID"
+// @Param body body models.Sprint true "The sprint with updated values."
+// @Success 200 {object} models.Sprint "The updated sprint"
+// @Failure 400 {object} web.HTTPError "Invalid sprint provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The sprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints/{sprint} [post]
+func (sp *Sprint) Update(s *xorm.Session, a web.Auth) (err error) {
+	_, err = getSprintByIDAndProject(s, sp.ID, sp.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	err = sp.validate()
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("id = ? AND project_id = ?", sp.ID, sp.ProjectID).
+		Cols("title", "goal", "start_date", "end_date").
+		Update(sp)
+	if err != nil {
+		return
+	}
+
+	return sp.ReadOne(s, a)
+}
+
+// Delete removes a sprint
+// @Summary Delete a sprint
+// @Description Deletes a sprint. Its tasks stay in the project.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint path int true "Sprint ID"
+// @Success 200 {object} models.Message "The sprint was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The sprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints/{sprint} [delete]
+func (sp *Sprint) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = getSprintByIDAndProject(s, sp.ID, sp.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	return deleteSprints(s, builder.Eq{"id": sp.ID})
+}
+
+func deleteSprints(s *xorm.Session, cond builder.Cond) (err error) {
+	_, err = s.
+		In("sprint_id", builder.Select("id").From("sprints").Where(cond)).
+		Delete(&SprintTask{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where(cond).Delete(&Sprint{})
+	return
+}
+
+// Create adds a task to a sprint
+// @Summary Add a task to a sprint
+// @Description Adds a task to a sprint. The task needs to belong to the project of the sprint.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint path int true "Sprint ID"
+// @Param task body models.SprintTask true "The task to add."
+// @Success 201 {object} models.SprintTask "The task was added to the sprint."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The sprint or task does not exist."
+// @Failure 409 {object} web.HTTPError "The task is already part of the sprint."
+// @Failure 412 {object} web.HTTPError "The task belongs to another project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints/{sprint}/tasks [put]
+func (st *SprintTask) Create(s *xorm.Session, _ web.Auth) (err error) {
+	sp, err := getSprintByIDAndProject(s, st.SprintID, st.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	t, err := GetTaskByIDSimple(s, st.TaskID)
+	if err != nil {
+		return err
+	}
+	if t.ProjectID != sp.ProjectID {
+		return &ErrTaskNotInSprintProject{TaskID: t.ID, ProjectID: sp.ProjectID}
+	}
+
+	exists, err := s.
+		Where("sprint_id = ? AND task_id = ?", sp.ID, t.ID).
+		Exist(&SprintTask{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &ErrTaskAlreadyInSprint{TaskID: t.ID, SprintID: sp.ID}
+	}
+
+	st.ID = 0
+	_, err = s.Insert(st)
+	return
+}
+
+// Delete removes a task from a sprint
+// @Summary Remove a task from a sprint
+// @Description Removes a task from a sprint. The task itself is not deleted.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param sprint path int true "Sprint ID"
+// @Param task path int true "Task ID"
+// @Success 200 {object} models.Message "The task was removed from the sprint."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 404 {object} web.HTTPError "The sprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/sprints/{sprint}/tasks/{task} [delete]
+func (st *SprintTask) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = getSprintByIDAndProject(s, st.SprintID, st.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("sprint_id = ? AND task_id = ?", st.SprintID, st.TaskID).
+		Delete(&SprintTask{})
+	return
+}
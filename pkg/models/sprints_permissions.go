@@ -0,0 +1,64 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can see a sprint
+func (sp *Sprint) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: sp.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// CanCreate checks if a user can add sprints to a project
+func (sp *Sprint) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	p := &Project{ID: sp.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can change a sprint
+func (sp *Sprint) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	p := &Project{ID: sp.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// CanDelete checks if a user can delete a sprint
+func (sp *Sprint) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	p := &Project{ID: sp.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// CanCreate checks if a user can add tasks to a sprint
+func (st *SprintTask) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	p := &Project{ID: st.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// CanDelete checks if a user can remove tasks from a sprint
+func (st *SprintTask) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	p := &Project{ID: st.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// CanRead checks if a user can see the burndown of a sprint
+func (b *SprintBurndown) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: b.ProjectID}
+	return p.CanRead(s, a)
+}
@@ -0,0 +1,430 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSprint_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		sp := &Sprint{
+			ProjectID: 1,
+			Title:     "Sprint 3",
+			StartDate: start,
+			EndDate:   start.AddDate(0, 0, 14),
+		}
+		can, err := sp.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = sp.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), sp.CreatedBy.ID)
+		assert.Empty(t, sp.Tasks)
+		assert.Equal(t, int64(0), sp.Committed.Tasks)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "sprints", map[string]interface{}{
+			"id":            sp.ID,
+			"project_id":    1,
+			"title":         "Sprint 3",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		sp := &Sprint{
+			ProjectID: 1,
+			Title:     "Sprint 3",
+			StartDate: start,
+			EndDate:   start.AddDate(0, 0, -1),
+		}
+		err := sp.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrSprintEndBeforeStart(err))
+	})
+	t.Run("no dates", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		sp := &Sprint{ProjectID: 1, Title: "Sprint 3"}
+		err := sp.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrSprintEndBeforeStart(err))
+	})
+	t.Run("read only project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		sp := &Sprint{ProjectID: 3}
+		can, err := sp.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestSprint_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	sp := &Sprint{ProjectID: 1}
+	res, _, total, err := sp.ReadAll(s, &user.User{ID: 1}, "", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	sprints := res.([]*Sprint)
+	assert.Equal(t, int64(1), sprints[0].ID)
+	assert.Equal(t, &SprintTotals{Tasks: 3}, sprints[0].Committed)
+	assert.Equal(t, &SprintTotals{Tasks: 1}, sprints[0].Completed)
+	assert.Equal(t, int64(1), sprints[0].CreatedBy.ID)
+	assert.Equal(t, &SprintTotals{}, sprints[1].Committed)
+
+	t.Run("no access to the project", func(t *testing.T) {
+		sp := &Sprint{ProjectID: 2}
+		_, _, _, err := sp.ReadAll(s, &user.User{ID: 1}, "", 0, 0)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrGenericForbidden{})
+	})
+}
+
+func TestSprint_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.In("id", []int64{1, 2}).Cols("story_points", "estimate").Update(&Task{StoryPoints: 2.5, Estimate: 3600})
+		require.NoError(t, err)
+
+		sp := &Sprint{ID: 1, ProjectID: 1}
+		can, _, err := sp.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = sp.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, "Sprint 1", sp.Title)
+		require.Len(t, sp.Tasks, 3)
+		assert.Equal(t, &SprintTotals{Tasks: 3, StoryPoints: 5, Estimate: 7200}, sp.Committed)
+		assert.Equal(t, &SprintTotals{Tasks: 1, StoryPoints: 2.5, Estimate: 3600}, sp.Completed)
+	})
+	t.Run("done after the sprint ended", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(1).Cols("done", "done_at").Update(&Task{Done: true, DoneAt: time.Date(2018, 12, 10, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+
+		sp := &Sprint{ID: 1, ProjectID: 1}
+		err = sp.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), sp.Completed.Tasks)
+	})
+	t.Run("sprint of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		sp := &Sprint{ID: 3, ProjectID: 1}
+		err := sp.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrSprintDoesNotExist(err))
+	})
+}
+
+func TestSprint_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	start := time.Date(2018, 11, 26, 0, 0, 0, 0, time.UTC)
+	sp := &Sprint{
+		ID:        1,
+		ProjectID: 1,
+		Title:     "Sprint 1 (extended)",
+		StartDate: start,
+		EndDate:   start.AddDate(0, 0, 14),
+	}
+	err := sp.Update(s, u)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), sp.CreatedByID)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertExists(t, "sprints", map[string]interface{}{
+		"id":    1,
+		"title": "Sprint 1 (extended)",
+	}, false)
+
+	t.Run("read only project", func(t *testing.T) {
+		sp := &Sprint{ID: 3, ProjectID: 3}
+		can, err := sp.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestSprint_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	sp := &Sprint{ID: 1, ProjectID: 1}
+	err := sp.Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertMissing(t, "sprints", map[string]interface{}{"id": 1})
+	db.AssertMissing(t, "sprint_tasks", map[string]interface{}{"sprint_id": 1})
+	db.AssertExists(t, "tasks", map[string]interface{}{"id": 1}, false)
+}
+
+func TestSprintTask_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		st := &SprintTask{SprintID: 1, ProjectID: 1, TaskID: 4}
+		can, err := st.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = st.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "sprint_tasks", map[string]interface{}{
+			"sprint_id": 1,
+			"task_id":   4,
+		}, false)
+	})
+	t.Run("already in the sprint", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		st := &SprintTask{SprintID: 1, ProjectID: 1, TaskID: 1}
+		err := st.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskAlreadyInSprint(err))
+	})
+	t.Run("task of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		st := &SprintTask{SprintID: 1, ProjectID: 1, TaskID: 13}
+		err := st.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskNotInSprintProject(err))
+	})
+	t.Run("sprint of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		st := &SprintTask{SprintID: 3, ProjectID: 1, TaskID: 4}
+		err := st.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrSprintDoesNotExist(err))
+	})
+	t.Run("moving the task removes it from the sprint", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "task #1", ProjectID: 2}
+		err := task.Update(s, &user.User{ID: 3})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "sprint_tasks", map[string]interface{}{"task_id": 1})
+	})
+}
+
+func TestSprintTask_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	st := &SprintTask{SprintID: 1, ProjectID: 1, TaskID: 3}
+	err := st.Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertMissing(t, "sprint_tasks", map[string]interface{}{"sprint_id": 1, "task_id": 3})
+	db.AssertExists(t, "sprint_tasks", map[string]interface{}{"sprint_id": 1, "task_id": 1}, false)
+}
+
+func TestSprintBurndown_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(1).Cols("done", "done_at").Update(&Task{Done: true, DoneAt: time.Date(2018, 11, 30, 12, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+
+		b := &SprintBurndown{SprintID: 1, ProjectID: 1, Metric: SprintMetricTasks}
+		err = b.ReadOne(s, u)
+		require.NoError(t, err)
+		require.Len(t, b.Points, 10)
+
+		first := b.Points[0]
+		assert.Equal(t, 26, first.Date.Day())
+		assert.InDelta(t, 2.0, first.Scope, 0)
+		assert.InDelta(t, 1.0, *first.Completed, 0)
+		assert.InDelta(t, 1.0, *first.Remaining, 0)
+		assert.InDelta(t, 2.0, first.Ideal, 0)
+
+		// Task 3 was added on the third day
+		assert.InDelta(t, 2.0, b.Points[1].Scope, 0)
+		assert.InDelta(t, 3.0, b.Points[2].Scope, 0)
+		assert.InDelta(t, 2.0, *b.Points[2].Remaining, 0)
+
+		// Task 1 was done on the fifth day
+		assert.InDelta(t, 2.0, *b.Points[4].Completed, 0)
+		assert.InDelta(t, 1.0, *b.Points[4].Remaining, 0)
+
+		last := b.Points[9]
+		assert.InDelta(t, 3.0, last.Scope, 0)
+		assert.InDelta(t, 0.0, last.Ideal, 0)
+	})
+	t.Run("story points", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(2).Cols("story_points").Update(&Task{StoryPoints: 5})
+		require.NoError(t, err)
+		_, err = s.ID(3).Cols("story_points").Update(&Task{StoryPoints: 8})
+		require.NoError(t, err)
+
+		b := &SprintBurndown{SprintID: 1, ProjectID: 1}
+		err = b.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, SprintMetricStoryPoints, b.Metric)
+		assert.InDelta(t, 5.0, b.Points[0].Scope, 0)
+		assert.InDelta(t, 0.0, *b.Points[0].Remaining, 0)
+		assert.InDelta(t, 13.0, b.Points[9].Scope, 0)
+		assert.InDelta(t, 8.0, *b.Points[9].Remaining, 0)
+	})
+	t.Run("future days have no values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Now().AddDate(0, 0, -1)
+		sp := &Sprint{ProjectID: 1, Title: "Current", StartDate: start, EndDate: start.AddDate(0, 0, 7)}
+		err := sp.Create(s, u)
+		require.NoError(t, err)
+
+		b := &SprintBurndown{SprintID: sp.ID, ProjectID: 1, Metric: SprintMetricTasks}
+		err = b.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.NotNil(t, b.Points[0].Remaining)
+		assert.Nil(t, b.Points[len(b.Points)-1].Remaining)
+		assert.Nil(t, b.Points[len(b.Points)-1].Completed)
+	})
+	t.Run("invalid metric", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &SprintBurndown{SprintID: 1, ProjectID: 1, Metric: "hours"}
+		err := b.ReadOne(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
+
+func TestTask_Estimates(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("filter by estimate", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(3).Cols("estimate").Update(&Task{Estimate: 7200})
+		require.NoError(t, err)
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    "estimate > 1h",
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, int64(3), tasks[0].ID)
+	})
+	t.Run("filter and sort by story points", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(3).Cols("story_points").Update(&Task{StoryPoints: 3})
+		require.NoError(t, err)
+		_, err = s.ID(4).Cols("story_points").Update(&Task{StoryPoints: 8})
+		require.NoError(t, err)
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    "story_points >= 3",
+			SortBy:    []string{"story_points"},
+			OrderBy:   []string{"desc"},
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, int64(4), tasks[0].ID)
+		assert.Equal(t, int64(3), tasks[1].ID)
+	})
+}
@@ -253,6 +253,12 @@ func (t *Task) addNewAssigneeByID(s *xorm.Session, newAssigneeID int64, project
 		}
 	}
 
+	warnings, err := checkWIPLimitsForNewAssignee(s, t.ID, newAssigneeID)
+	if err != nil {
+		return err
+	}
+	t.WIPLimitWarnings = append(t.WIPLimitWarnings, warnings...)
+
 	_, err = s.Insert(&TaskAssginee{
 		TaskID: t.ID,
 		UserID: newAssigneeID,
@@ -0,0 +1,137 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// getOpenBlockersForTasks returns all tasks which block one of the given tasks and are not done yet,
+// keyed by the id of the blocked task.
+func getOpenBlockersForTasks(s *xorm.Session, taskIDs []int64) (blockers map[int64][]*Task, err error) {
+	blockers = make(map[int64][]*Task)
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	relations := []*TaskRelation{}
+	err = s.
+		In("task_id", taskIDs).
+		And("relation_kind = ?", RelationKindBlocked).
+		Find(&relations)
+	if err != nil || len(relations) == 0 {
+		return
+	}
+
+	blockerIDs := make([]int64, 0, len(relations))
+	for _, r := range relations {
+		blockerIDs = append(blockerIDs, r.OtherTaskID)
+	}
+
+	openTasks := make(map[int64]*Task)
+	err = s.
+		In("id", blockerIDs).
+		And("done = ?", false).
+		OrderBy("id asc").
+		Find(&openTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range relations {
+		if blocker, has := openTasks[r.OtherTaskID]; has {
+			blockers[r.TaskID] = append(blockers[r.TaskID], blocker)
+		}
+	}
+
+	return
+}
+
+func addIsBlockedToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	blockers, err := getOpenBlockersForTasks(s, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	for taskID := range blockers {
+		if task, has := taskMap[taskID]; has {
+			task.IsBlocked = true
+		}
+	}
+
+	return nil
+}
+
+// checkTaskCanBeCompleted returns an error if the project of the task does not allow completing blocked
+// tasks and the task is still blocked by tasks which are not done.
+func checkTaskCanBeCompleted(s *xorm.Session, task *Task) error {
+	project, err := GetProjectSimpleByID(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if !project.PreventCompletingBlockedTasks {
+		return nil
+	}
+
+	blockers, err := getOpenBlockersForTasks(s, []int64{task.ID})
+	if err != nil {
+		return err
+	}
+
+	if len(blockers[task.ID]) > 0 {
+		return &ErrTaskIsBlocked{
+			TaskID:   task.ID,
+			Blockers: blockers[task.ID],
+		}
+	}
+
+	return nil
+}
+
+func getIsBlockedFilterCond(f *taskFilter) (builder.Cond, error) {
+	isBlocked, ok := f.value.(bool)
+	if !ok {
+		return nil, ErrInvalidTaskFilterValue{Value: f.value, Field: taskPropertyIsBlocked}
+	}
+
+	switch f.comparator {
+	case taskFilterComparatorEquals:
+	case taskFilterComparatorNotEquals:
+		isBlocked = !isBlocked
+	default:
+		return nil, ErrInvalidTaskFilterComparator{Comparator: f.comparator}
+	}
+
+	// Blockers in the trash do not block anything, the same as in getOpenBlockersForTasks
+	openBlockers := builder.
+		Select("1").
+		From("task_relations").
+		Join("INNER", "tasks AS blockers", "blockers.id = task_relations.other_task_id").
+		Where(builder.Expr("task_relations.task_id = tasks.id")).
+		And(builder.Eq{
+			"task_relations.relation_kind": RelationKindBlocked,
+			"blockers.done":                false,
+		}).
+		And(builder.IsNull{"blockers.deleted"})
+
+	if isBlocked {
+		return builder.Exists(openBlockers), nil
+	}
+	return builder.NotExists(openBlockers), nil
+}
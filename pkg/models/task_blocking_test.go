@@ -0,0 +1,167 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+// blockTask1 makes task 1 blocked by the open task 3 and the done task 2.
+func blockTask1(t *testing.T, s *xorm.Session, preventCompletion bool) {
+	_, err := s.Insert(&TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked, CreatedByID: 1})
+	require.NoError(t, err)
+	_, err = s.Insert(&TaskRelation{TaskID: 1, OtherTaskID: 2, RelationKind: RelationKindBlocked, CreatedByID: 1})
+	require.NoError(t, err)
+	_, err = s.ID(1).Cols("prevent_completing_blocked_tasks").Update(&Project{PreventCompletingBlockedTasks: preventCompletion})
+	require.NoError(t, err)
+}
+
+func TestTask_CompleteBlocked(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("rejected when blocked", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, true)
+
+		task := &Task{ID: 1, Title: "task #1", Done: true}
+		err := task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+		blocked := err.(*ErrTaskIsBlocked)
+		require.Len(t, blocked.Blockers, 1)
+		assert.Equal(t, int64(3), blocked.Blockers[0].ID)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":   1,
+			"done": false,
+		}, false)
+	})
+	t.Run("allowed without the project setting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, false)
+
+		task := &Task{ID: 1, Title: "task #1", Done: true}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		assert.True(t, task.Done)
+	})
+	t.Run("allowed once the blockers are done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, true)
+		_, err := s.ID(3).Cols("done").Update(&Task{Done: true})
+		require.NoError(t, err)
+
+		task := &Task{ID: 1, Title: "task #1", Done: true}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+	})
+	t.Run("rejected when moving into the done bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, true)
+
+		tb := &TaskBucket{TaskID: 1, BucketID: 3, ProjectViewID: 4, ProjectID: 1}
+		err := tb.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+	})
+}
+
+func TestTask_IsBlocked(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("computed flag", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, false)
+
+		task := &Task{ID: 1}
+		err := task.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.True(t, task.IsBlocked)
+
+		task = &Task{ID: 3}
+		err = task.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.False(t, task.IsBlocked)
+	})
+	t.Run("filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, false)
+
+		tc := &TaskCollection{ProjectID: 1, Filter: "is_blocked = true"}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, int64(1), tasks[0].ID)
+
+		tc = &TaskCollection{ProjectID: 1, Filter: "is_blocked = false"}
+		result, _, _, err = tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		for _, task := range result.([]*Task) {
+			assert.NotEqual(t, int64(1), task.ID)
+		}
+	})
+	t.Run("filter ignores blockers in the trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		blockTask1(t, s, false)
+		err := moveTaskToTrash(s, u, &Task{ID: 3, ProjectID: 1})
+		require.NoError(t, err)
+
+		tc := &TaskCollection{ProjectID: 1, Filter: "is_blocked = true"}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		assert.Empty(t, result.([]*Task))
+	})
+	t.Run("invalid comparator", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ProjectID: 1, Filter: "is_blocked > true"}
+		_, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterComparator(err))
+	})
+}
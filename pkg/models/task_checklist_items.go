@@ -0,0 +1,421 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskChecklistItem represents a single item of the checklist of a task
+type TaskChecklistItem struct {
+	// The unique, numeric id of this checklist item.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"checklistitem"`
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id" param:"task"`
+
+	// The title of this checklist item.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// Whether this checklist item is done.
+	Done bool `xorm:"INDEX null" json:"done"`
+	// The time when this checklist item was marked done.
+	DoneAt time.Time `xorm:"DATETIME null 'done_at'" json:"done_at"`
+
+	// The id of the user responsible for this checklist item. Must have access to the project of the task.
+	AssigneeID int64 `xorm:"bigint null INDEX" json:"assignee_id"`
+	// The user responsible for this checklist item. Read-only, set the assignee_id to change it.
+	Assignee *user.User `xorm:"-" json:"assignee" valid:"-"`
+
+	// An optional due date of this checklist item.
+	DueDate time.Time `xorm:"DATETIME INDEX null 'due_date'" json:"due_date"`
+	// The position of this checklist item in the checklist. Items are sorted by this value ascending.
+	Position float64 `xorm:"double null" json:"position"`
+
+	CreatedByID int64 `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this checklist item was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this checklist item was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for checklist items
+func (*TaskChecklistItem) TableName() string {
+	return "task_checklist_items"
+}
+
+// GetChecklistItemByID returns a single checklist item by its id
+func GetChecklistItemByID(s *xorm.Session, id int64) (item *TaskChecklistItem, err error) {
+	item = &TaskChecklistItem{}
+	exists, err := s.Where("id = ?", id).Get(item)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrChecklistItemDoesNotExist{ID: id}
+	}
+	return
+}
+
+func (ci *TaskChecklistItem) checkAssignee(s *xorm.Session) (err error) {
+	if ci.AssigneeID == 0 {
+		ci.Assignee = nil
+		return nil
+	}
+
+	assignee, err := user.GetUserByID(s, ci.AssigneeID)
+	if err != nil {
+		return err
+	}
+
+	project, err := GetProjectSimpleByTaskID(s, ci.TaskID)
+	if err != nil {
+		return err
+	}
+
+	canRead, _, err := project.CanRead(s, assignee)
+	if err != nil {
+		return err
+	}
+	if !canRead {
+		return ErrUserDoesNotHaveAccessToProject{project.ID, ci.AssigneeID}
+	}
+
+	ci.Assignee = assignee
+	return nil
+}
+
+func (ci *TaskChecklistItem) setDoneAt(wasDone bool) {
+	if !ci.Done {
+		ci.DoneAt = time.Time{}
+		return
+	}
+	if !wasDone || ci.DoneAt.IsZero() {
+		ci.DoneAt = time.Now()
+	}
+}
+
+// updateChecklistPercentDone rolls the completion of the checklist of a task up into its percent done.
+// Tasks without a checklist are left untouched.
+func updateChecklistPercentDone(s *xorm.Session, a web.Auth, taskID int64) (task *Task, err error) {
+	total, err := s.Where("task_id = ?", taskID).Count(&TaskChecklistItem{})
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		return nil, err
+	}
+	task = &t
+
+	if total == 0 {
+		return task, nil
+	}
+
+	done, err := s.Where("task_id = ? AND done = ?", taskID, true).Count(&TaskChecklistItem{})
+	if err != nil {
+		return nil, err
+	}
+
+	task.PercentDone = float64(done) / float64(total)
+	_, err = s.ID(taskID).Cols("percent_done").NoAutoTime().Update(task)
+	if err != nil {
+		return nil, err
+	}
+
+	// With the subtask roll-up enabled, subtasks take precedence over the checklist
+	err = applySubtaskRollup(s, a, task, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, rollupSubtasksIntoParents(s, a, taskID, nil, nil)
+}
+
+// Create adds a new item to the checklist of a task
+// @Summary Create a checklist item
+// @Description Adds a new item to the checklist of a task. The percent done of the task is updated from the checklist.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param item body models.TaskChecklistItem true "The checklist item"
+// @Success 201 {object} models.TaskChecklistItem "The created checklist item."
+// @Failure 400 {object} web.HTTPError "Invalid checklist item provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist [put]
+func (ci *TaskChecklistItem) Create(s *xorm.Session, a web.Auth) (err error) {
+	err = ci.checkAssignee(s)
+	if err != nil {
+		return err
+	}
+
+	doer, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	ci.ID = 0
+	ci.CreatedByID = doer.ID
+	ci.setDoneAt(false)
+
+	_, err = s.Insert(ci)
+	if err != nil {
+		return err
+	}
+
+	if ci.Position == 0 {
+		ci.Position = calculateDefaultPosition(ci.ID, ci.Position)
+		_, err = s.Where("id = ?", ci.ID).Cols("position").Update(ci)
+		if err != nil {
+			return err
+		}
+	}
+
+	task, err := updateChecklistPercentDone(s, a, ci.TaskID)
+	if err != nil {
+		return err
+	}
+
+	return events.Dispatch(&TaskChecklistItemCreatedEvent{
+		Task:          task,
+		ChecklistItem: ci,
+		Doer:          doer,
+	})
+}
+
+// ReadAll returns the checklist of a task
+// @Summary Get the checklist of a task
+// @Description Returns all checklist items of a task, ordered by their position.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 200 {array} models.TaskChecklistItem "The checklist items"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist [get]
+func (ci *TaskChecklistItem) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := ci.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	items, err := getChecklistItemsForTasks(s, []int64{ci.TaskID})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return items, len(items), int64(len(items)), nil
+}
+
+// Update changes a checklist item
+// @Summary Update a checklist item
+// @Description Updates the title, done state, assignee, due date and position of a checklist item. The percent done of the task is updated from the checklist.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param checklistitem path int true "Checklist item ID"
+// @Param item body models.TaskChecklistItem true "The checklist item"
+// @Success 200 {object} models.TaskChecklistItem "The updated checklist item."
+// @Failure 400 {object} web.HTTPError "Invalid checklist item provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist/{checklistitem} [post]
+func (ci *TaskChecklistItem) Update(s *xorm.Session, a web.Auth) (err error) {
+	stored, err := GetChecklistItemByID(s, ci.ID)
+	if err != nil {
+		return err
+	}
+
+	ci.TaskID = stored.TaskID
+	ci.CreatedByID = stored.CreatedByID
+	ci.Created = stored.Created
+	ci.DoneAt = stored.DoneAt
+	ci.setDoneAt(stored.Done)
+
+	err = ci.checkAssignee(s)
+	if err != nil {
+		return err
+	}
+
+	if ci.Position == 0 {
+		ci.Position = calculateDefaultPosition(ci.ID, ci.Position)
+	}
+
+	_, err = s.ID(ci.ID).
+		Cols("title", "done", "done_at", "assignee_id", "due_date", "position").
+		Update(ci)
+	if err != nil {
+		return err
+	}
+
+	task, err := updateChecklistPercentDone(s, a, ci.TaskID)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := GetUserOrLinkShareUser(s, a)
+	return events.Dispatch(&TaskChecklistItemUpdatedEvent{
+		Task:          task,
+		ChecklistItem: ci,
+		Doer:          doer,
+	})
+}
+
+// Delete removes a checklist item
+// @Summary Delete a checklist item
+// @Description Removes an item from the checklist of a task. The percent done of the task is updated from the remaining checklist.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param checklistitem path int true "Checklist item ID"
+// @Success 200 {object} models.Message "The checklist item was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist/{checklistitem} [delete]
+func (ci *TaskChecklistItem) Delete(s *xorm.Session, a web.Auth) (err error) {
+	stored, err := GetChecklistItemByID(s, ci.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ID(ci.ID).Delete(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+
+	task, err := updateChecklistPercentDone(s, a, stored.TaskID)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := GetUserOrLinkShareUser(s, a)
+	return events.Dispatch(&TaskChecklistItemDeletedEvent{
+		Task:          task,
+		ChecklistItem: stored,
+		Doer:          doer,
+	})
+}
+
+func getChecklistItemsForTasks(s *xorm.Session, taskIDs []int64) (items []*TaskChecklistItem, err error) {
+	items = []*TaskChecklistItem{}
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	err = s.
+		In("task_id", taskIDs).
+		OrderBy("position asc, id asc").
+		Find(&items)
+	if err != nil {
+		return nil, err
+	}
+
+	assigneeIDs := []int64{}
+	for _, item := range items {
+		if item.AssigneeID != 0 {
+			assigneeIDs = append(assigneeIDs, item.AssigneeID)
+		}
+	}
+	if len(assigneeIDs) == 0 {
+		return
+	}
+
+	assignees, err := user.GetUsersByIDs(s, assigneeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if assignee, has := assignees[item.AssigneeID]; has {
+			item.Assignee = assignee
+		}
+	}
+
+	return
+}
+
+func addChecklistItemsToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	items, err := getChecklistItemsForTasks(s, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	doneCount := make(map[int64]int, len(taskIDs))
+	for _, item := range items {
+		task, has := taskMap[item.TaskID]
+		if !has {
+			continue
+		}
+		task.ChecklistItems = append(task.ChecklistItems, item)
+		if item.Done {
+			doneCount[item.TaskID]++
+		}
+	}
+
+	for _, task := range taskMap {
+		if len(task.ChecklistItems) == 0 {
+			continue
+		}
+		percent := float64(doneCount[task.ID]) / float64(len(task.ChecklistItems)) * 100
+		task.ChecklistDone = &percent
+	}
+
+	return nil
+}
+
+// parseChecklistDoneFilterValue accepts a percentage like "50" or "50%" and returns it as number.
+func parseChecklistDoneFilterValue(rawValue string) (float64, error) {
+	rawValue = strings.TrimSuffix(strings.TrimSpace(rawValue), "%")
+	percent, err := strconv.ParseFloat(strings.TrimSpace(rawValue), 64)
+	if err != nil {
+		return 0, ErrInvalidTaskFilterValue{Value: rawValue, Field: taskPropertyChecklistDone}
+	}
+
+	return percent, nil
+}
+
+func getChecklistDoneFilterCond(f *taskFilter) (builder.Cond, error) {
+	return getFilterCond(&taskFilter{
+		field:      "(SELECT SUM(CASE WHEN task_checklist_items.done THEN 100.0 ELSE 0 END) / COUNT(*) FROM task_checklist_items WHERE task_checklist_items.task_id = tasks.id)",
+		value:      f.value,
+		comparator: f.comparator,
+		isNumeric:  true,
+	}, false)
+}
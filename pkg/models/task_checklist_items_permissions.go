@@ -0,0 +1,57 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read the checklist of a task
+func (ci *TaskChecklistItem) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	t := &Task{ID: ci.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can add items to the checklist of a task
+func (ci *TaskChecklistItem) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: ci.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can change a checklist item
+func (ci *TaskChecklistItem) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return ci.canModifyChecklistItem(s, a)
+}
+
+// CanDelete checks if a user can delete a checklist item
+func (ci *TaskChecklistItem) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return ci.canModifyChecklistItem(s, a)
+}
+
+func (ci *TaskChecklistItem) canModifyChecklistItem(s *xorm.Session, a web.Auth) (bool, error) {
+	stored, err := GetChecklistItemByID(s, ci.ID)
+	if err != nil {
+		return false, err
+	}
+	if ci.TaskID != 0 && stored.TaskID != ci.TaskID {
+		return false, &ErrChecklistItemDoesNotExist{ID: ci.ID}
+	}
+
+	t := &Task{ID: stored.TaskID}
+	return t.CanWrite(s, a)
+}
@@ -0,0 +1,254 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskChecklistItem_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID:     1,
+			Title:      "Buy milk",
+			AssigneeID: 1,
+		}
+		err := ci.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), ci.Assignee.ID)
+		assert.InDelta(t, calculateDefaultPosition(ci.ID, 0), ci.Position, 0)
+		err = s.Commit()
+		require.NoError(t, err)
+		events.AssertDispatched(t, &TaskChecklistItemCreatedEvent{})
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":          ci.ID,
+			"task_id":     1,
+			"title":       "Buy milk",
+			"assignee_id": 1,
+			"done":        false,
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 0,
+		}, false)
+	})
+	t.Run("done item rolls up", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID: 2,
+			Title:  "Publish",
+			Done:   true,
+		}
+		err := ci.Create(s, u)
+		require.NoError(t, err)
+		assert.False(t, ci.DoneAt.IsZero())
+		err = s.Commit()
+		require.NoError(t, err)
+
+		task, err := GetTaskByIDSimple(s, 2)
+		require.NoError(t, err)
+		assert.InDelta(t, 2.0/3.0, task.PercentDone, 0.0001)
+	})
+	t.Run("assignee without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID:     1,
+			Title:      "Buy milk",
+			AssigneeID: 2,
+		}
+		err := ci.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToProject(err))
+	})
+	t.Run("no permission", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID: 14, // belongs to project 5, which user 1 has no access to
+		}
+		can, err := ci.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskChecklistItem_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	ci := &TaskChecklistItem{TaskID: 2}
+	result, resultCount, _, err := ci.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	require.NoError(t, err)
+	items := result.([]*TaskChecklistItem)
+	assert.Equal(t, 2, resultCount)
+	assert.Equal(t, int64(1), items[0].ID)
+	assert.Equal(t, int64(2), items[1].ID)
+
+	t.Run("no permission", func(t *testing.T) {
+		ci := &TaskChecklistItem{TaskID: 14}
+		_, _, _, err := ci.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		require.Error(t, err)
+	})
+}
+
+func TestTaskChecklistItem_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("mark done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     2,
+			TaskID: 2,
+			Title:  "Review the draft",
+			Done:   true,
+		}
+		err := ci.Update(s, u)
+		require.NoError(t, err)
+		assert.False(t, ci.DoneAt.IsZero())
+		err = s.Commit()
+		require.NoError(t, err)
+		events.AssertDispatched(t, &TaskChecklistItemUpdatedEvent{})
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":   2,
+			"done": true,
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           2,
+			"percent_done": 1,
+		}, false)
+	})
+	t.Run("mark undone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     1,
+			TaskID: 2,
+			Title:  "Write the draft",
+		}
+		err := ci.Update(s, u)
+		require.NoError(t, err)
+		assert.True(t, ci.DoneAt.IsZero())
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           2,
+			"percent_done": 0,
+		}, false)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{ID: 9999, TaskID: 2}
+		_, err := ci.CanUpdate(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrChecklistItemDoesNotExist(err))
+	})
+	t.Run("item of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{ID: 1, TaskID: 1}
+		_, err := ci.CanUpdate(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrChecklistItemDoesNotExist(err))
+	})
+}
+
+func TestTaskChecklistItem_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	ci := &TaskChecklistItem{ID: 2, TaskID: 2}
+	err := ci.Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+	events.AssertDispatched(t, &TaskChecklistItemDeletedEvent{})
+
+	db.AssertMissing(t, "task_checklist_items", map[string]interface{}{
+		"id": 2,
+	})
+	db.AssertExists(t, "tasks", map[string]interface{}{
+		"id":           2,
+		"percent_done": 1,
+	}, false)
+}
+
+func TestTaskChecklistItem_Filter(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	readTaskIDs := func(t *testing.T, filter string) []int64 {
+		tc := &TaskCollection{ProjectID: 1, Filter: filter}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("not completed", func(t *testing.T) {
+		assert.Equal(t, []int64{2}, readTaskIDs(t, "checklist_done < 100%"))
+	})
+	t.Run("exact", func(t *testing.T) {
+		assert.Equal(t, []int64{2}, readTaskIDs(t, "checklist_done = 50"))
+	})
+	t.Run("completed", func(t *testing.T) {
+		assert.Empty(t, readTaskIDs(t, "checklist_done >= 100%"))
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		tc := &TaskCollection{ProjectID: 1, Filter: "checklist_done > half"}
+		_, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.Error(t, err)
+	})
+}
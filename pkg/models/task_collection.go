@@ -20,7 +20,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/web"
 
@@ -55,10 +57,20 @@ type TaskCollection struct {
 	// If set to `buckets`, the buckets of each task will be present in the response.
 	// If set to `reactions`, the reactions of each task will be present in the response.
 	// If set to `comments`, the first 50 comments of each task will be present in the response.
+	// If set to `occurrences`, only tasks in the date range are returned, together with virtual copies of
+	// repeating tasks for each of their future occurrences in it. This needs `date_from` and `date_to`.
 	// You can set this multiple times with different values.
 	Expand []TaskCollectionExpandable `query:"expand" json:"-"`
 
+	// The start and end of the date range to get tasks for. Calendar views require both.
+	DateFrom string `query:"date_from" json:"-"`
+	DateTo   string `query:"date_to" json:"-"`
+	// How calendar views group their tasks, either `day` or `week`. Defaults to `day`.
+	Granularity CalendarGranularity `query:"granularity" json:"-"`
+
 	isSavedFilter bool
+	// If set, the tasks are always returned as a plain list, regardless of the kind of the view.
+	asTaskList bool
 
 	web.CRUDable    `xorm:"-" json:"-"`
 	web.Permissions `xorm:"-" json:"-"`
@@ -72,6 +84,7 @@ const TaskCollectionExpandReactions TaskCollectionExpandable = `reactions`
 const TaskCollectionExpandComments TaskCollectionExpandable = `comments`
 const TaskCollectionExpandCommentCount TaskCollectionExpandable = `comment_count`
 const TaskCollectionExpandIsUnread TaskCollectionExpandable = `is_unread`
+const TaskCollectionExpandOccurrences TaskCollectionExpandable = `occurrences`
 
 // Validate validates if the TaskCollectionExpandable value is valid.
 func (t TaskCollectionExpandable) Validate() error {
@@ -88,9 +101,11 @@ func (t TaskCollectionExpandable) Validate() error {
 		return nil
 	case TaskCollectionExpandIsUnread:
 		return nil
+	case TaskCollectionExpandOccurrences:
+		return nil
 	}
 
-	return InvalidFieldErrorWithMessage([]string{"expand"}, "Expand must be one of the following values: subtasks, buckets, reactions, comments, comment_count, is_unread")
+	return InvalidFieldErrorWithMessage([]string{"expand"}, "Expand must be one of the following values: subtasks, buckets, reactions, comments, comment_count, is_unread, occurrences")
 }
 
 func validateTaskField(fieldName string) error {
@@ -98,7 +113,10 @@ func validateTaskField(fieldName string) error {
 	case
 		taskPropertyAssignees,
 		taskPropertyLabels,
-		taskPropertyReminders:
+		taskPropertyReminders,
+		taskPropertyTimeSpent,
+		taskPropertyIsBlocked,
+		taskPropertyChecklistDone:
 		return nil
 	}
 
@@ -157,10 +175,15 @@ func getTaskFilterOptsFromCollection(tf *TaskCollection, projectView *ProjectVie
 }
 
 func getTaskOrTasksInBuckets(s *xorm.Session, a web.Auth, projects []*Project, view *ProjectView, opts *taskSearchOptions, filteringForBucket bool) (tasks interface{}, resultCount int, totalItems int64, err error) {
-	if filteringForBucket {
+	if filteringForBucket || opts.asTaskList {
 		return getTasksForProjects(s, projects, a, opts, view)
 	}
 
+	if view != nil && view.ViewKind == ProjectViewKindCalendar {
+		periods, err := getTasksInCalendarForView(s, a, projects, view, opts)
+		return periods, len(periods), int64(len(periods)), err
+	}
+
 	if view != nil && !strings.Contains(opts.filter, taskPropertyBucketID) {
 		if view.BucketConfigurationMode != BucketConfigurationModeNone {
 			tasksInBuckets, err := GetTasksInBucketsForView(s, view, projects, opts, a)
@@ -171,6 +194,38 @@ func getTaskOrTasksInBuckets(s *xorm.Session, a web.Auth, projects []*Project, v
 	return getTasksForProjects(s, projects, a, opts, view)
 }
 
+// getDateRange parses the date range of the collection. Both dates are zero if no range was requested.
+func (tf *TaskCollection) getDateRange() (from, to time.Time, err error) {
+	if tf.DateFrom == "" && tf.DateTo == "" {
+		return
+	}
+	if tf.DateFrom == "" || tf.DateTo == "" {
+		return from, to, InvalidFieldErrorWithMessage([]string{"date_from", "date_to"}, "A date range needs both a start and an end.")
+	}
+
+	loc, err := getFilterTimezoneLocation(tf.FilterTimezone)
+	if err != nil {
+		return
+	}
+	if loc == nil {
+		loc = config.GetTimeZone()
+	}
+
+	from, err = parseTimeFromUserInput(tf.DateFrom, loc)
+	if err != nil {
+		return from, to, InvalidFieldErrorWithMessage([]string{"date_from"}, "The start of the date range is not a valid date.")
+	}
+	to, err = parseTimeFromUserInput(tf.DateTo, loc)
+	if err != nil {
+		return from, to, InvalidFieldErrorWithMessage([]string{"date_to"}, "The end of the date range is not a valid date.")
+	}
+	if !to.After(from) {
+		return from, to, InvalidFieldErrorWithMessage([]string{"date_to"}, "The end of the date range must be after its start.")
+	}
+
+	return
+}
+
 func getRelevantProjectsFromCollection(s *xorm.Session, a web.Auth, tf *TaskCollection) (projects []*Project, err error) {
 	if tf.ProjectID == 0 || tf.isSavedFilter {
 		projects, _, _, err = getRawProjectsForUser(
@@ -227,7 +282,7 @@ func getFilterValueForBucketFilter(filter string, view *ProjectView) (newFilter
 
 // ReadAll gets all tasks for a collection
 // @Summary Get tasks in a project
-// @Description Returns all tasks for the selected project. When the requested view is a kanban view, a list of buckets containing the tasks will be returned. Otherwise, a list of tasks will be returned.
+// @Description Returns all tasks for the selected project. When the requested view is a kanban view, a list of buckets containing the tasks will be returned. When it is a calendar view, a list of days or weeks in the requested date range containing the tasks and occurrences of repeating tasks in them will be returned. Otherwise, a list of tasks will be returned.
 // @tags task
 // @Accept json
 // @Produce json
@@ -236,12 +291,15 @@ func getFilterValueForBucketFilter(filter string, view *ProjectView) (newFilter
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `story_points`, `estimate`, `uid`, `created`, `updated`. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
 // @Param filter query string false "The filter query to match tasks by. Check out https://vikunja.io/docs/filters for a full explanation of the feature."
 // @Param filter_timezone query string false "The time zone which should be used for date match (statements like "now" resolve to different actual times)"
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
-// @Param expand query array false "If set to `subtasks`, Vikunja will fetch only tasks which do not have subtasks and then in a second step, will fetch all of these subtasks. This may result in more tasks than the pagination limit being returned, but all subtasks will be present in the response. If set to `buckets`, the buckets of each task will be present in the response. If set to `reactions`, the reactions of each task will be present in the response. If set to `comments`, the first 50 comments of each task will be present in the response. You can set this multiple times with different values."
+// @Param expand query array false "If set to `subtasks`, Vikunja will fetch only tasks which do not have subtasks and then in a second step, will fetch all of these subtasks. This may result in more tasks than the pagination limit being returned, but all subtasks will be present in the response. If set to `buckets`, the buckets of each task will be present in the response. If set to `reactions`, the reactions of each task will be present in the response. If set to `comments`, the first 50 comments of each task will be present in the response. If set to `occurrences`, only tasks in the date range are returned, each followed by virtual copies for the future occurrences of repeating tasks in it, flagged with `is_virtual`. Occurrences are paginated like tasks, every task has at most 500 of them. You can set this multiple times with different values."
+// @Param date_from query string false "The start of the date range to get tasks for. Required for calendar views and when expanding occurrences."
+// @Param date_to query string false "The end of the date range to get tasks for, exclusive. Required for calendar views and when expanding occurrences."
+// @Param granularity query string false "Only for calendar views. Whether to group the tasks by `day` or by `week`. Weeks start on the weekday of `date_from`. Defaults to `day`."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
 // @Failure 500 {object} models.Message "Internal error"
@@ -292,7 +350,11 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 		tc.ProjectViewID = tf.ProjectViewID
 		tc.ProjectID = tf.ProjectID
 		tc.isSavedFilter = true
+		tc.asTaskList = tf.asTaskList
 		tc.Expand = tf.Expand
+		tc.DateFrom = tf.DateFrom
+		tc.DateTo = tf.DateTo
+		tc.Granularity = tf.Granularity
 
 		if tf.Filter != "" {
 			if tc.Filter != "" {
@@ -357,6 +419,16 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 	opts.perPage = perPage
 	opts.expand = tf.Expand
 	opts.isSavedFilter = tf.isSavedFilter
+	opts.asTaskList = tf.asTaskList
+	opts.granularity = tf.Granularity
+
+	opts.dateFrom, opts.dateTo, err = tf.getDateRange()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if opts.expandsOccurrences() && opts.dateFrom.IsZero() {
+		return nil, 0, 0, InvalidFieldErrorWithMessage([]string{"date_from", "date_to"}, "Expanding occurrences of repeating tasks needs a date range.")
+	}
 
 	if view != nil {
 		var hasOrderByPosition bool
@@ -159,6 +159,22 @@ func parseFilterFromExpression(f fexpr.ExprGroup, loc *time.Location) (filter *t
 	return filter, nil
 }
 
+// getFilterTimezoneLocation loads the time zone used to parse dates in filters. It returns nil if none was provided.
+func getFilterTimezoneLocation(filterTimezone string) (loc *time.Location, err error) {
+	if filterTimezone == "" {
+		return nil, nil
+	}
+
+	loc, err = time.LoadLocation(filterTimezone)
+	if err != nil {
+		return nil, &ErrInvalidTimezone{
+			Name:      filterTimezone,
+			LoadError: err,
+		}
+	}
+	return
+}
+
 func getTaskFiltersFromFilterString(filter string, filterTimezone string) (filters []*taskFilter, err error) {
 
 	if filter == "" {
@@ -201,15 +217,9 @@ func getTaskFiltersFromFilterString(filter string, filterTimezone string) (filte
 		}
 	}
 
-	var loc *time.Location
-	if filterTimezone != "" {
-		loc, err = time.LoadLocation(filterTimezone)
-		if err != nil {
-			return nil, &ErrInvalidTimezone{
-				Name:      filterTimezone,
-				LoadError: err,
-			}
-		}
+	loc, err := getFilterTimezoneLocation(filterTimezone)
+	if err != nil {
+		return nil, err
 	}
 
 	filters = make([]*taskFilter, 0, len(parsedFilter))
@@ -335,6 +345,11 @@ func getValueForField(field reflect.StructField, rawValue string, loc *time.Loca
 
 func getNativeValueForTaskField(fieldName string, comparator taskFilterComparator, value string, loc *time.Location) (reflectField *reflect.StructField, nativeValue interface{}, err error) {
 
+	if _, is := getCustomFieldIDFromTaskProperty(fieldName); is {
+		nativeValue, err = getNativeValueForCustomFieldFilter(comparator, value, loc)
+		return nil, nativeValue, err
+	}
+
 	realFieldName := strings.ReplaceAll(strcase.ToCamel(fieldName), "Id", "ID")
 
 	if realFieldName == "Assignees" {
@@ -355,11 +370,23 @@ func getNativeValueForTaskField(fieldName string, comparator taskFilterComparato
 		}
 	}
 
+	getValue := getValueForField
+	if realFieldName == "TimeSpent" || realFieldName == "Estimate" {
+		getValue = func(_ reflect.StructField, rawValue string, _ *time.Location) (interface{}, error) {
+			return parseSecondsFilterValue(fieldName, rawValue)
+		}
+	}
+	if realFieldName == "ChecklistDone" {
+		getValue = func(_ reflect.StructField, rawValue string, _ *time.Location) (interface{}, error) {
+			return parseChecklistDoneFilterValue(rawValue)
+		}
+	}
+
 	if comparator == taskFilterComparatorIn || comparator == taskFilterComparatorNotIn {
 		vals := strings.Split(value, ",")
 		valueSlice := []interface{}{}
 		for _, val := range vals {
-			v, err := getValueForField(field, val, loc)
+			v, err := getValue(field, val, loc)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -368,6 +395,6 @@ func getNativeValueForTaskField(fieldName string, comparator taskFilterComparato
 		return nil, valueSlice, nil
 	}
 
-	val, err := getValueForField(field, value, loc)
+	val, err := getValue(field, value, loc)
 	return &field, val, err
 }
@@ -41,6 +41,8 @@ const (
 	taskPropertyEndDate       string = "end_date"
 	taskPropertyHexColor      string = "hex_color"
 	taskPropertyPercentDone   string = "percent_done"
+	taskPropertyStoryPoints   string = "story_points"
+	taskPropertyEstimate      string = "estimate"
 	taskPropertyUID           string = "uid"
 	taskPropertyCreated       string = "created"
 	taskPropertyUpdated       string = "updated"
@@ -51,6 +53,11 @@ const (
 	taskPropertyAssignees     string = "assignees"
 	taskPropertyLabels        string = "labels"
 	taskPropertyReminders     string = "reminders"
+	taskPropertyTimeSpent     string = "time_spent"
+	taskPropertyIsBlocked     string = "is_blocked"
+	taskPropertyChecklistDone string = "checklist_done"
+
+	taskPropertyCustomFieldPrefix string = "custom_fields."
 )
 
 const (
@@ -102,6 +109,8 @@ func validateTaskFieldForSorting(fieldName string) error {
 		taskPropertyEndDate,
 		taskPropertyHexColor,
 		taskPropertyPercentDone,
+		taskPropertyStoryPoints,
+		taskPropertyEstimate,
 		taskPropertyUID,
 		taskPropertyCreated,
 		taskPropertyUpdated,
@@ -110,5 +119,8 @@ func validateTaskFieldForSorting(fieldName string) error {
 		taskPropertyIndex:
 		return nil
 	}
+	if _, is := getCustomFieldIDFromTaskProperty(fieldName); is {
+		return nil
+	}
 	return ErrInvalidTaskField{TaskField: fieldName}
 }
@@ -173,6 +173,7 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 	task1WithReaction.Reactions = ReactionMap{
 		"👋": []*user.User{user1},
 	}
+	task2ChecklistDone := float64(50)
 	task2 := &Task{
 		ID:          2,
 		Title:       "task #2 done",
@@ -194,8 +195,32 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 				Created:  time.Unix(1543626724, 0).In(loc),
 			},
 		},
-		Created: time.Unix(1543626724, 0).In(loc),
-		Updated: time.Unix(1543626724, 0).In(loc),
+		Created:   time.Unix(1543626724, 0).In(loc),
+		Updated:   time.Unix(1543626724, 0).In(loc),
+		TimeSpent: 10800,
+		ChecklistItems: []*TaskChecklistItem{
+			{
+				ID:          1,
+				TaskID:      2,
+				Title:       "Write the draft",
+				Done:        true,
+				DoneAt:      time.Unix(1543658400, 0).In(loc),
+				Position:    65536,
+				CreatedByID: 1,
+				Created:     time.Unix(1543626724, 0).In(loc),
+				Updated:     time.Unix(1543658400, 0).In(loc),
+			},
+			{
+				ID:          2,
+				TaskID:      2,
+				Title:       "Review the draft",
+				Position:    131072,
+				CreatedByID: 1,
+				Created:     time.Unix(1543626724, 0).In(loc),
+				Updated:     time.Unix(1543626724, 0).In(loc),
+			},
+		},
+		ChecklistDone: &task2ChecklistDone,
 	}
 	task3 := &Task{
 		ID:           3,
@@ -209,6 +234,11 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Created:      time.Unix(1543626724, 0).In(loc),
 		Updated:      time.Unix(1543626724, 0).In(loc),
 		Priority:     100,
+		CustomFields: map[int64]interface{}{
+			2: float64(5),
+			4: "high",
+			5: []string{"web", "ios"},
+		},
 	}
 	task4 := &Task{
 		ID:           4,
@@ -222,6 +252,13 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Created:      time.Unix(1543626724, 0).In(loc),
 		Updated:      time.Unix(1543626724, 0).In(loc),
 		Priority:     1,
+		CustomFields: map[int64]interface{}{
+			1: "ACME",
+			2: float64(2),
+			3: time.Unix(1543665600, 0).In(loc),
+			4: "low",
+			6: int64(1),
+		},
 	}
 	task5 := &Task{
 		ID:           5,
@@ -0,0 +1,203 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+)
+
+// IsTaskCommentReplyEnabled checks if users can reply to notification mails to comment on a task.
+func IsTaskCommentReplyEnabled() bool {
+	return config.InboundMailEnabled.GetBool() &&
+		strings.Contains(config.InboundMailAddress.GetString(), "@") &&
+		config.ServiceEnableTaskComments.GetBool()
+}
+
+// getTaskCommentReplySignature signs the user and task of a reply address. Only the user the address was made
+// for can comment with it, and only on that task.
+func getTaskCommentReplySignature(userID, taskID int64) string {
+	mac := hmac.New(sha256.New, []byte(config.ServiceJWTSecret.GetString()))
+	mac.Write([]byte("task-comment-reply:" + strconv.FormatInt(userID, 10) + ":" + strconv.FormatInt(taskID, 10)))
+	// 96 bits are plenty and keep the address below the 64 characters a local part may have
+	return hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// GetTaskCommentReplyAddress returns the address a user can send mails to in order to comment on a task, like
+// reply+1-42-3f2a...@vikunja.example.com. Returns an empty string if replying per mail is not enabled.
+func GetTaskCommentReplyAddress(userID, taskID int64) string {
+	if !IsTaskCommentReplyEnabled() {
+		return ""
+	}
+
+	local, domain, _ := strings.Cut(config.InboundMailAddress.GetString(), "@")
+	return local + "+" +
+		strconv.FormatInt(userID, 10) + "-" +
+		strconv.FormatInt(taskID, 10) + "-" +
+		getTaskCommentReplySignature(userID, taskID) +
+		"@" + domain
+}
+
+// parseTaskCommentReplyAddress checks the signature of a reply address and returns the user and task it was made
+// for.
+func parseTaskCommentReplyAddress(address string) (userID, taskID int64, err error) {
+	invalid := &ErrInvalidTaskCommentReplyAddress{Address: address}
+
+	expectedLocal, expectedDomain, _ := strings.Cut(config.InboundMailAddress.GetString(), "@")
+	at := strings.LastIndex(address, "@")
+	if at == -1 || !strings.EqualFold(address[at+1:], expectedDomain) {
+		return 0, 0, invalid
+	}
+
+	local, token, hasToken := strings.Cut(address[:at], "+")
+	if !hasToken || !strings.EqualFold(local, expectedLocal) {
+		return 0, 0, invalid
+	}
+
+	parts := strings.Split(token, "-")
+	if len(parts) != 3 {
+		return 0, 0, invalid
+	}
+	userID, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, invalid
+	}
+	taskID, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, invalid
+	}
+
+	// Some mail servers change the case of addresses
+	if !hmac.Equal([]byte(strings.ToLower(parts[2])), []byte(getTaskCommentReplySignature(userID, taskID))) {
+		return 0, 0, invalid
+	}
+
+	return userID, taskID, nil
+}
+
+// getTaskReplyTo returns the reply address for a notification about a task.
+func getTaskReplyTo(notifiable notifications.Notifiable, taskID int64) string {
+	u, is := notifiable.(*user.User)
+	if !is {
+		return ""
+	}
+	return GetTaskCommentReplyAddress(u.ID, taskID)
+}
+
+// plainTextToCommentHTML converts the text of a mail to the html comments are saved as. Paragraphs are separated by
+// blank lines.
+func plainTextToCommentHTML(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return b.String()
+}
+
+// HandleTaskCommentReply creates a task comment from a reply to a notification mail. It is the handler for the
+// inbound mail server.
+func HandleTaskCommentReply(recipient string, msg *mail.InboundMessage) error {
+	err := createTaskCommentFromReply(recipient, msg)
+	if err == nil {
+		return nil
+	}
+
+	// Everything the sender did wrong will not get better when the mail is delivered again
+	var httpErr web.HTTPErrorProcessor
+	if errors.As(err, &httpErr) && httpErr.HTTPError().HTTPCode < 500 {
+		return &mail.RejectedError{Message: httpErr.HTTPError().Message}
+	}
+	return err
+}
+
+func createTaskCommentFromReply(recipient string, msg *mail.InboundMessage) error {
+	if !config.ServiceEnableTaskComments.GetBool() {
+		return &ErrTaskCommentsDisabled{}
+	}
+
+	userID, taskID, err := parseTaskCommentReplyAddress(recipient)
+	if err != nil {
+		return err
+	}
+
+	// Out of office replies and the like would otherwise end up as comments, and the notifications about them
+	// could start a mail loop.
+	if msg.AutoSubmitted {
+		log.Debugf("Ignoring automatic reply from %s to %s", msg.From, recipient)
+		return nil
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	u, err := user.GetUserWithEmail(s, &user.User{ID: userID})
+	if err != nil {
+		return err
+	}
+
+	// The signature proves the address was made for this user, the sender has to be them as well in case the
+	// address was leaked, for example by forwarding a notification.
+	if u.Status == user.StatusDisabled || !strings.EqualFold(u.Email, msg.From) {
+		return &ErrTaskCommentReplySenderMismatch{}
+	}
+
+	text := mail.StripReply(msg.Text)
+	if text == "" {
+		return &ErrEmptyTaskCommentReply{}
+	}
+
+	comment := &TaskComment{
+		TaskID:  taskID,
+		Comment: plainTextToCommentHTML(text),
+	}
+	can, err := comment.CanCreate(s, u)
+	if err != nil {
+		return err
+	}
+	if !can {
+		return ErrGenericForbidden{}
+	}
+
+	err = comment.Create(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	return s.Commit()
+}
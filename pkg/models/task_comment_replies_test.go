@@ -0,0 +1,174 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enableTaskCommentReplies(t *testing.T) {
+	config.InboundMailEnabled.Set(true)
+	config.InboundMailAddress.Set("reply@vikunja.example.com")
+	t.Cleanup(func() {
+		config.InboundMailEnabled.Set(false)
+		config.InboundMailAddress.Set("")
+	})
+}
+
+func TestTaskCommentReplyAddress(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		assert.Empty(t, GetTaskCommentReplyAddress(1, 1))
+	})
+	t.Run("round trip", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+
+		address := GetTaskCommentReplyAddress(1, 42)
+		assert.Regexp(t, `^reply\+1-42-[0-9a-f]{24}@vikunja\.example\.com$`, address)
+		local, _, _ := strings.Cut(address, "@")
+		assert.LessOrEqual(t, len(local), 64)
+
+		userID, taskID, err := parseTaskCommentReplyAddress(address)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), userID)
+		assert.Equal(t, int64(42), taskID)
+
+		// Some mail servers change the case of addresses
+		userID, taskID, err = parseTaskCommentReplyAddress(strings.ToUpper(address))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), userID)
+		assert.Equal(t, int64(42), taskID)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+
+		address := GetTaskCommentReplyAddress(1, 42)
+		for _, invalid := range []string{
+			strings.Replace(address, "+1-42-", "+1-43-", 1),
+			strings.Replace(address, "+1-42-", "+2-42-", 1),
+			strings.Replace(address, "@vikunja.example.com", "@example.com", 1),
+			strings.Replace(address, "reply+", "noreply+", 1),
+			"reply@vikunja.example.com",
+			"reply+1-42@vikunja.example.com",
+		} {
+			_, _, err := parseTaskCommentReplyAddress(invalid)
+			require.Error(t, err, invalid)
+			assert.True(t, IsErrInvalidTaskCommentReplyAddress(err), invalid)
+		}
+	})
+}
+
+func TestHandleTaskCommentReply(t *testing.T) {
+	reply := func(from string) *mail.InboundMessage {
+		return &mail.InboundMessage{
+			From: from,
+			Text: "Done <for real>.\nSee you.\n\nOn Mon, 1 Jan 2024 at 10:00, Vikunja <mail@vikunja> wrote:\n> Who does this?\n",
+		}
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		db.LoadAndAssertFixtures(t)
+
+		err := HandleTaskCommentReply(GetTaskCommentReplyAddress(1, 1), reply("User1@Example.com"))
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"task_id":   1,
+			"author_id": 1,
+			"comment":   "<p>Done &lt;for real&gt;.<br>See you.</p>",
+		}, false)
+	})
+	t.Run("other sender", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		db.LoadAndAssertFixtures(t)
+
+		err := HandleTaskCommentReply(GetTaskCommentReplyAddress(1, 1), reply("user2@example.com"))
+		require.Error(t, err)
+		assert.IsType(t, &mail.RejectedError{}, err)
+	})
+	t.Run("invalid address", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		db.LoadAndAssertFixtures(t)
+
+		err := HandleTaskCommentReply("reply+1-1-000000000000000000000000@vikunja.example.com", reply("user1@example.com"))
+		require.Error(t, err)
+		assert.IsType(t, &mail.RejectedError{}, err)
+	})
+	t.Run("no access to the task", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		db.LoadAndAssertFixtures(t)
+
+		err := HandleTaskCommentReply(GetTaskCommentReplyAddress(1, 14), reply("user1@example.com"))
+		require.Error(t, err)
+		assert.IsType(t, &mail.RejectedError{}, err)
+	})
+	t.Run("only the quoted mail", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		db.LoadAndAssertFixtures(t)
+
+		msg := reply("user1@example.com")
+		msg.Text = "On Mon, 1 Jan 2024 at 10:00, Vikunja <mail@vikunja> wrote:\n> Who does this?\n"
+		err := HandleTaskCommentReply(GetTaskCommentReplyAddress(1, 1), msg)
+		require.Error(t, err)
+		assert.IsType(t, &mail.RejectedError{}, err)
+	})
+	t.Run("automatic reply", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		db.LoadAndAssertFixtures(t)
+
+		msg := reply("user1@example.com")
+		msg.AutoSubmitted = true
+		err := HandleTaskCommentReply(GetTaskCommentReplyAddress(1, 1), msg)
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "task_comments", map[string]interface{}{
+			"comment": "<p>Done &lt;for real&gt;.<br>See you.</p>",
+		})
+	})
+	t.Run("task comments disabled", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		address := GetTaskCommentReplyAddress(1, 1)
+		config.ServiceEnableTaskComments.Set(false)
+		defer config.ServiceEnableTaskComments.Set(true)
+		db.LoadAndAssertFixtures(t)
+
+		err := HandleTaskCommentReply(address, reply("user1@example.com"))
+		require.Error(t, err)
+		assert.IsType(t, &mail.RejectedError{}, err)
+	})
+}
+
+func TestTaskCommentNotification_ReplyTo(t *testing.T) {
+	n := &TaskCommentNotification{Task: &Task{ID: 1}}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Empty(t, n.ReplyTo(&user.User{ID: 1}))
+	})
+	t.Run("enabled", func(t *testing.T) {
+		enableTaskCommentReplies(t)
+		assert.Equal(t, GetTaskCommentReplyAddress(1, 1), n.ReplyTo(&user.User{ID: 1}))
+	})
+}
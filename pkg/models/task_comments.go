@@ -41,6 +41,10 @@ type TaskComment struct {
 	Created time.Time `xorm:"created" json:"created"`
 	Updated time.Time `xorm:"updated" json:"updated"`
 
+	// Set when the comment was moved to the trash.
+	Deleted time.Time `xorm:"deleted" json:"-"`
+	TrashID int64     `xorm:"bigint null INDEX" json:"-"`
+
 	web.CRUDable    `xorm:"-" json:"-"`
 	web.Permissions `xorm:"-" json:"-"`
 }
@@ -106,7 +110,7 @@ func (tc *TaskComment) CreateWithTimestamps(s *xorm.Session, a web.Auth) (err er
 
 // Delete removes a task comment
 // @Summary Remove a task comment
-// @Description Remove a task comment. The user doing this need to have at least write access to the task this comment belongs to.
+// @Description Remove a task comment. The user doing this need to have at least write access to the task this comment belongs to. If the trash is enabled, the comment is moved to the trash of the user deleting it.
 // @tags task
 // @Accept json
 // @Produce json
@@ -118,20 +122,22 @@ func (tc *TaskComment) CreateWithTimestamps(s *xorm.Session, a web.Auth) (err er
 // @Failure 404 {object} web.HTTPError "The task comment was not found."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [delete]
-func (tc *TaskComment) Delete(s *xorm.Session, _ web.Auth) error {
-	deleted, err := s.
-		ID(tc.ID).
-		NoAutoCondition().
-		Delete(tc)
-	if deleted == 0 {
-		return ErrTaskCommentDoesNotExist{ID: tc.ID}
+func (tc *TaskComment) Delete(s *xorm.Session, a web.Auth) error {
+	err := getTaskCommentSimple(s, tc)
+	if err != nil {
+		return err
 	}
 
+	task, err := GetTaskByIDSimple(s, tc.TaskID)
 	if err != nil {
 		return err
 	}
 
-	task, err := GetTaskByIDSimple(s, tc.TaskID)
+	if isTrashEnabled() {
+		err = moveTaskCommentToTrash(s, a, tc, &task)
+	} else {
+		_, err = s.Unscoped().ID(tc.ID).Delete(&TaskComment{})
+	}
 	if err != nil {
 		return err
 	}
@@ -296,6 +302,7 @@ func addCommentCountToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]
 	if err := s.
 		Select("task_id, COUNT(*) as count").
 		Where(builder.In("task_id", taskIDs)).
+		And("deleted IS NULL").
 		GroupBy("task_id").
 		Table("task_comments").
 		Find(&counts); err != nil {
@@ -20,12 +20,14 @@ import (
 	"fmt"
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
 )
 
 func TestTaskComment_Create(t *testing.T) {
@@ -145,6 +147,30 @@ func TestTaskComment_Delete(t *testing.T) {
 		err = s.Commit()
 		require.NoError(t, err)
 
+		db.AssertCount(t, "task_comments", builder.Eq{"id": 1}.And(builder.IsNull{"deleted"}), 0)
+		db.AssertExists(t, "trash", map[string]interface{}{
+			"kind":          TrashItemKindTaskComment,
+			"entity_id":     1,
+			"task_id":       1,
+			"deleted_by_id": 1,
+		}, false)
+	})
+	t.Run("permanently without trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		config.ServiceTrashRetentionDays.Set(0)
+		defer config.ServiceTrashRetentionDays.Set(30)
+
+		tc := &TaskComment{
+			ID:     1,
+			TaskID: 1,
+		}
+		err := tc.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
 		db.AssertMissing(t, "task_comments", map[string]interface{}{
 			"id": 1,
 		})
@@ -0,0 +1,544 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskCustomFieldValue holds the value a task has for a custom field of its project.
+// Depending on the type of the field, only one of the value columns is used.
+// Multi-select fields store one row per selected option.
+type TaskCustomFieldValue struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	TaskID      int64     `xorm:"bigint not null INDEX"`
+	FieldID     int64     `xorm:"bigint not null INDEX"`
+	TextValue   string    `xorm:"text null"`
+	NumberValue *float64  `xorm:"double null"`
+	DateValue   time.Time `xorm:"DATETIME null"`
+}
+
+func (*TaskCustomFieldValue) TableName() string {
+	return "task_custom_field_values"
+}
+
+// getCustomFieldIDFromTaskProperty returns the id of the custom field if the
+// property is a custom field property like `custom_fields.12`.
+func getCustomFieldIDFromTaskProperty(property string) (fieldID int64, is bool) {
+	if !strings.HasPrefix(property, taskPropertyCustomFieldPrefix) {
+		return 0, false
+	}
+
+	fieldID, err := strconv.ParseInt(strings.TrimPrefix(property, taskPropertyCustomFieldPrefix), 10, 64)
+	if err != nil || fieldID <= 0 {
+		return 0, false
+	}
+
+	return fieldID, true
+}
+
+func customFieldValueToString(raw interface{}) (string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case []string:
+		return strings.Join(v, ","), true
+	case []interface{}:
+		parts, ok := customFieldValueToStrings(v)
+		return strings.Join(parts, ","), ok
+	}
+	return "", false
+}
+
+func customFieldValueToStrings(raw interface{}) ([]string, bool) {
+	switch v := raw.(type) {
+	case string:
+		parts := strings.Split(v, ",")
+		values := make([]string, 0, len(parts))
+		for _, part := range parts {
+			part = strings.TrimSpace(part)
+			if part != "" {
+				values = append(values, part)
+			}
+		}
+		return values, true
+	case []string:
+		return v, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			str, is := item.(string)
+			if !is {
+				return nil, false
+			}
+			values = append(values, str)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func customFieldValueToNumber(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func customFieldValueToTime(raw interface{}) (time.Time, bool) {
+	switch v := raw.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case string:
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// toCustomFieldValues converts a value as it is sent by a client to the rows stored in the database.
+// An empty value results in no rows, which removes the value from the task.
+func toCustomFieldValues(s *xorm.Session, field *ProjectCustomField, taskID int64, raw interface{}) (values []*TaskCustomFieldValue, err error) {
+	if raw == nil {
+		return
+	}
+
+	invalid := &ErrInvalidCustomFieldValue{FieldID: field.ID, Value: raw}
+	newValue := func() *TaskCustomFieldValue {
+		return &TaskCustomFieldValue{TaskID: taskID, FieldID: field.ID}
+	}
+
+	switch field.FieldType {
+	case CustomFieldTypeText:
+		str, ok := customFieldValueToString(raw)
+		if !ok {
+			return nil, invalid
+		}
+		if str == "" {
+			return
+		}
+		v := newValue()
+		v.TextValue = str
+		values = append(values, v)
+	case CustomFieldTypeNumber:
+		n, ok := customFieldValueToNumber(raw)
+		if !ok {
+			return nil, invalid
+		}
+		v := newValue()
+		v.NumberValue = &n
+		values = append(values, v)
+	case CustomFieldTypeDate:
+		t, ok := customFieldValueToTime(raw)
+		if !ok {
+			return nil, invalid
+		}
+		v := newValue()
+		v.DateValue = t.In(config.GetTimeZone())
+		values = append(values, v)
+	case CustomFieldTypeSelect:
+		str, ok := raw.(string)
+		if !ok || (str != "" && !field.hasOption(str)) {
+			return nil, invalid
+		}
+		if str == "" {
+			return
+		}
+		v := newValue()
+		v.TextValue = str
+		values = append(values, v)
+	case CustomFieldTypeMultiSelect:
+		options, ok := customFieldValueToStrings(raw)
+		if !ok {
+			return nil, invalid
+		}
+		seen := make(map[string]bool, len(options))
+		for _, option := range options {
+			if !field.hasOption(option) {
+				return nil, invalid
+			}
+			if seen[option] {
+				continue
+			}
+			seen[option] = true
+			v := newValue()
+			v.TextValue = option
+			values = append(values, v)
+		}
+	case CustomFieldTypeUser:
+		n, ok := customFieldValueToNumber(raw)
+		if !ok || n != float64(int64(n)) {
+			return nil, invalid
+		}
+		var u *user.User
+		u, err = user.GetUserByID(s, int64(n))
+		if err != nil {
+			if user.IsErrUserDoesNotExist(err) {
+				return nil, invalid
+			}
+			return nil, err
+		}
+		// Only users who can see the task can be picked
+		var canRead bool
+		canRead, _, err = (&Project{ID: field.ProjectID}).CanRead(s, u)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, invalid
+		}
+		v := newValue()
+		v.NumberValue = &n
+		values = append(values, v)
+	}
+
+	return
+}
+
+// fromCustomFieldValues converts the stored rows of one field back to the value returned to clients.
+func fromCustomFieldValues(field *ProjectCustomField, values []*TaskCustomFieldValue) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch field.FieldType {
+	case CustomFieldTypeNumber:
+		if values[0].NumberValue == nil {
+			return nil
+		}
+		return *values[0].NumberValue
+	case CustomFieldTypeDate:
+		return values[0].DateValue.In(config.GetTimeZone())
+	case CustomFieldTypeMultiSelect:
+		selected := make(map[string]bool, len(values))
+		for _, v := range values {
+			selected[v.TextValue] = true
+		}
+		// Keep the order in which the options were defined
+		options := make([]string, 0, len(values))
+		for _, option := range field.Options {
+			if selected[option] {
+				options = append(options, option)
+			}
+		}
+		return options
+	case CustomFieldTypeUser:
+		if values[0].NumberValue == nil {
+			return nil
+		}
+		return int64(*values[0].NumberValue)
+	default:
+		return values[0].TextValue
+	}
+}
+
+// setTaskCustomFieldValues saves all values in t.CustomFields. Fields which are not part of the map are left
+// untouched, a nil value removes the value of that field from the task.
+func setTaskCustomFieldValues(s *xorm.Session, t *Task) (err error) {
+	if len(t.CustomFields) == 0 {
+		return nil
+	}
+
+	fields, err := getCustomFieldsForProjects(s, []int64{t.ProjectID})
+	if err != nil {
+		return err
+	}
+
+	for fieldID, raw := range t.CustomFields {
+		field, has := fields[fieldID]
+		if !has {
+			return &ErrCustomFieldDoesNotExist{ID: fieldID}
+		}
+
+		values, err := toCustomFieldValues(s, field, t.ID, raw)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.
+			Where("task_id = ? AND field_id = ?", t.ID, fieldID).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return err
+		}
+
+		if len(values) > 0 {
+			_, err = s.Insert(&values)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeCustomFieldValuesOfOtherProjects removes all values of custom fields which do not belong to
+// the project. Used when a task is moved to another project.
+func removeCustomFieldValuesOfOtherProjects(s *xorm.Session, taskID, projectID int64) (err error) {
+	_, err = s.
+		Where("task_id = ?", taskID).
+		NotIn("field_id", builder.Select("id").From("project_custom_fields").Where(builder.Eq{"project_id": projectID})).
+		Delete(&TaskCustomFieldValue{})
+	return
+}
+
+func addCustomFieldValuesToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) (err error) {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	values := []*TaskCustomFieldValue{}
+	err = s.
+		In("task_id", taskIDs).
+		OrderBy("id asc").
+		Find(&values)
+	if err != nil {
+		return err
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	valuesByTaskAndField := make(map[int64]map[int64][]*TaskCustomFieldValue)
+	for _, v := range values {
+		if _, has := valuesByTaskAndField[v.TaskID]; !has {
+			valuesByTaskAndField[v.TaskID] = make(map[int64][]*TaskCustomFieldValue)
+		}
+		valuesByTaskAndField[v.TaskID][v.FieldID] = append(valuesByTaskAndField[v.TaskID][v.FieldID], v)
+		fieldIDs = append(fieldIDs, v.FieldID)
+	}
+
+	fields := make(map[int64]*ProjectCustomField)
+	err = s.In("id", fieldIDs).Find(&fields)
+	if err != nil {
+		return err
+	}
+
+	for taskID, valuesByField := range valuesByTaskAndField {
+		task, has := taskMap[taskID]
+		if !has {
+			continue
+		}
+
+		for fieldID, fieldValues := range valuesByField {
+			field, has := fields[fieldID]
+			if !has || field.ProjectID != task.ProjectID {
+				continue
+			}
+			if task.CustomFields == nil {
+				task.CustomFields = make(map[int64]interface{})
+			}
+			task.CustomFields[fieldID] = fromCustomFieldValues(field, fieldValues)
+		}
+	}
+
+	return nil
+}
+
+// getNativeValueForCustomFieldFilter guesses the type of a filter value since the type of the field is not
+// known when parsing the filter. Numbers are compared with number and user fields, dates with date fields
+// and everything else with text and select fields.
+func getNativeValueForCustomFieldFilter(comparator taskFilterComparator, value string, loc *time.Location) (interface{}, error) {
+	dateField, _ := reflect.TypeOf(TaskCustomFieldValue{}).FieldByName("DateValue")
+
+	parse := func(raw string) interface{} {
+		raw = strings.TrimSpace(raw)
+		if comparator == taskFilterComparatorLike {
+			return raw
+		}
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+		if t, err := getValueForField(dateField, raw, loc); err == nil {
+			return t
+		}
+		return raw
+	}
+
+	if comparator == taskFilterComparatorIn || comparator == taskFilterComparatorNotIn {
+		vals := strings.Split(value, ",")
+		valueSlice := make([]interface{}, 0, len(vals))
+		for _, val := range vals {
+			valueSlice = append(valueSlice, parse(val))
+		}
+		return valueSlice, nil
+	}
+
+	return parse(value), nil
+}
+
+func getCustomFieldFilterCond(f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
+	fieldID, _ := getCustomFieldIDFromTaskProperty(f.field)
+
+	sample := f.value
+	if values, is := f.value.([]interface{}); is && len(values) > 0 {
+		sample = values[0]
+	}
+
+	column := "task_custom_field_values.text_value"
+	switch sample.(type) {
+	case float64:
+		column = "task_custom_field_values.number_value"
+	case time.Time:
+		column = "task_custom_field_values.date_value"
+	}
+
+	// Negative comparisons check that no value matching the positive comparison exists.
+	comparator := f.comparator
+	negate := comparator == taskFilterComparatorNotEquals || comparator == taskFilterComparatorNotIn
+	switch comparator {
+	case taskFilterComparatorNotEquals:
+		comparator = taskFilterComparatorEquals
+	case taskFilterComparatorNotIn:
+		comparator = taskFilterComparatorIn
+	}
+
+	valueCond, err := getFilterCond(&taskFilter{
+		field:      column,
+		value:      f.value,
+		comparator: comparator,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Select options may look like numbers, so we need to check the text value as well.
+	if _, is := sample.(float64); is && (comparator == taskFilterComparatorEquals || comparator == taskFilterComparatorIn) {
+		var textValue interface{}
+		if values, is := f.value.([]interface{}); is {
+			textValues := make([]interface{}, 0, len(values))
+			for _, v := range values {
+				str, _ := customFieldValueToString(v)
+				textValues = append(textValues, str)
+			}
+			textValue = textValues
+		} else {
+			textValue, _ = customFieldValueToString(f.value)
+		}
+		textCond, err := getFilterCond(&taskFilter{
+			field:      "task_custom_field_values.text_value",
+			value:      textValue,
+			comparator: comparator,
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+		valueCond = builder.Or(valueCond, textCond)
+	}
+
+	baseCond := func() *builder.Builder {
+		return builder.
+			Select("1").
+			From("task_custom_field_values").
+			Where(builder.And(
+				builder.Expr("task_custom_field_values.task_id = tasks.id"),
+				builder.Eq{"task_custom_field_values.field_id": fieldID},
+			))
+	}
+
+	if negate {
+		cond = builder.NotExists(baseCond().And(valueCond))
+	} else {
+		cond = builder.Exists(baseCond().And(valueCond))
+	}
+
+	if includeNulls {
+		cond = builder.Or(cond, builder.NotExists(baseCond()))
+	}
+
+	return cond, nil
+}
+
+func getCustomFieldSortAlias(fieldID int64) string {
+	return "custom_field_sort_" + strconv.FormatInt(fieldID, 10)
+}
+
+// getCustomFieldSortColumns returns the columns to sort by for a custom field. Only one of them
+// will contain values, depending on the type of the field.
+func getCustomFieldSortColumns(fieldID int64) []string {
+	alias := getCustomFieldSortAlias(fieldID)
+	return []string{
+		alias + ".number_value",
+		alias + ".date_value",
+		alias + ".text_value",
+	}
+}
+
+// getCustomFieldSortSelect returns the columns which need to be selected when sorting by custom fields.
+func getCustomFieldSortSelect(sortby []*sortParam) (sel string) {
+	for _, param := range sortby {
+		fieldID, is := getCustomFieldIDFromTaskProperty(param.sortBy)
+		if !is {
+			continue
+		}
+		sel += ", " + strings.Join(getCustomFieldSortColumns(fieldID), ", ")
+	}
+	return
+}
+
+// joinCustomFieldSortValues joins one row per task with the value of each custom field the tasks are sorted by.
+// Multi-select fields are sorted by their first selected option.
+func joinCustomFieldSortValues(query *xorm.Session, sortby []*sortParam) *xorm.Session {
+	for _, param := range sortby {
+		fieldID, is := getCustomFieldIDFromTaskProperty(param.sortBy)
+		if !is {
+			continue
+		}
+		alias := getCustomFieldSortAlias(fieldID)
+		query = query.Join(
+			"LEFT",
+			"(SELECT task_id, MIN(number_value) AS number_value, MIN(date_value) AS date_value, MIN(text_value) AS text_value "+
+				"FROM task_custom_field_values WHERE field_id = "+strconv.FormatInt(fieldID, 10)+" GROUP BY task_id) "+alias,
+			alias+".task_id = tasks.id",
+		)
+	}
+	return query
+}
+
+func getCustomFieldOrderBy(fieldID int64, order sortOrder) string {
+	orderby := []string{}
+	for _, column := range getCustomFieldSortColumns(fieldID) {
+		orderby = append(orderby, getNullsLastOrderBy(column, order))
+	}
+	return strings.Join(orderby, ", ")
+}
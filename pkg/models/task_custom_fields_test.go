@@ -0,0 +1,226 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTask_CustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("set values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				1: "Globex",
+				2: 8,
+				5: []interface{}{"android", "web"},
+				6: 1,
+			},
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Equal(t, "Globex", task.CustomFields[1])
+		assert.InDelta(t, float64(8), task.CustomFields[2], 0)
+		// Multi select values are returned in the order of the options
+		assert.Equal(t, []string{"web", "android"}, task.CustomFields[5])
+		assert.Equal(t, int64(1), task.CustomFields[6])
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    1,
+			"field_id":   1,
+			"text_value": "Globex",
+		}, false)
+	})
+	t.Run("only given values are changed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        4,
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				1: nil,
+				4: "high",
+			},
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.NotContains(t, task.CustomFields, int64(1))
+		assert.Equal(t, "high", task.CustomFields[4])
+		assert.Equal(t, int64(1), task.CustomFields[6])
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"id": 5,
+		})
+	})
+	t.Run("invalid option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				4: "critical",
+			},
+		}
+		err := task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("nonexisting user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				6: 9999,
+			},
+		}
+		err := task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("user without access to the project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				6: 2,
+			},
+		}
+		err := task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("field of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				7: "lorem",
+			},
+		}
+		err := task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+	t.Run("create with values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ProjectID: 1,
+			Title:     "test",
+			CustomFields: map[int64]interface{}{
+				3: "2024-01-02T10:00:00Z",
+			},
+		}
+		err := task.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  task.ID,
+			"field_id": 3,
+		}, false)
+	})
+}
+
+func TestTaskCollection_CustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+	getTaskIDs := func(t *testing.T, tc *TaskCollection) []int64 {
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("filter by number", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := getTaskIDs(t, &TaskCollection{ProjectID: 1, Filter: "custom_fields.2 > 3"})
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("filter by select", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := getTaskIDs(t, &TaskCollection{ProjectID: 1, Filter: "custom_fields.4 = low"})
+		assert.Equal(t, []int64{4}, ids)
+	})
+	t.Run("filter by multi select", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := getTaskIDs(t, &TaskCollection{ProjectID: 1, Filter: "custom_fields.5 in ios"})
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("filter by date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := getTaskIDs(t, &TaskCollection{ProjectID: 1, Filter: "custom_fields.3 < 2019-01-01"})
+		assert.Equal(t, []int64{4}, ids)
+	})
+	t.Run("sort by number", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := getTaskIDs(t, &TaskCollection{
+			ProjectID: 1,
+			Filter:    "custom_fields.2 > 0",
+			SortBy:    []string{"custom_fields.2"},
+			OrderBy:   []string{"desc"},
+		})
+		assert.Equal(t, []int64{3, 4}, ids)
+	})
+}
@@ -0,0 +1,172 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"slices"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+
+	"xorm.io/builder"
+)
+
+// maxTaskOccurrences is the maximum number of virtual occurrences computed for a single repeating task in one range.
+const maxTaskOccurrences = 500
+
+// getTaskRepeatAnchor returns the date the repeat settings of a task are based on: its due date, or its start
+// or end date if it has none.
+func getTaskRepeatAnchor(t *Task) time.Time {
+	switch {
+	case !t.DueDate.IsZero():
+		return t.DueDate
+	case !t.StartDate.IsZero():
+		return t.StartDate
+	default:
+		return t.EndDate
+	}
+}
+
+// iterateTaskOccurrences calls fn with the offset of every future occurrence of a repeating task to its current
+// occurrence, in chronological order, starting with the first one after notBefore, until fn returns false or the
+// series ends. Occurrences in the past are skipped because marking the task as done jumps over them as well,
+// except for monthly repeating tasks which always move by exactly one month.
+func iterateTaskOccurrences(t *Task, notBefore time.Time, fn func(offset time.Duration) bool) {
+	anchor := getTaskRepeatAnchor(t)
+	if t.Done || !t.isRepeating() || anchor.IsZero() {
+		return
+	}
+
+	monthly := t.RepeatRule == "" && t.RepeatMode == TaskRepeatModeMonth
+	if now := time.Now(); !monthly && notBefore.Before(now) {
+		notBefore = now
+	}
+	if notBefore.Before(anchor) {
+		notBefore = anchor
+	}
+
+	switch {
+	case t.RepeatRule != "":
+		rule, err := ParseRepeatRule(t.RepeatRule)
+		if err != nil {
+			return
+		}
+		rule.iterate(anchor, notBefore, func(occurrence time.Time) bool {
+			if !occurrence.After(notBefore) {
+				return true
+			}
+			return fn(occurrence.Sub(anchor))
+		})
+	case monthly:
+		for months := 1; ; months++ {
+			occurrence := time.Date(anchor.Year(), anchor.Month()+time.Month(months), anchor.Day(), anchor.Hour(), anchor.Minute(), anchor.Second(), anchor.Nanosecond(), config.GetTimeZone())
+			if !occurrence.After(notBefore) {
+				continue
+			}
+			if !fn(occurrence.Sub(anchor)) {
+				return
+			}
+		}
+	case t.RepeatAfter > 0:
+		interval := time.Duration(t.RepeatAfter) * time.Second
+		for step := int64(notBefore.Sub(anchor)/interval) + 1; ; step++ {
+			if !fn(time.Duration(step) * interval) {
+				return
+			}
+		}
+	}
+}
+
+// getTaskOccurrences returns virtual copies of a repeating task for each of its future occurrences overlapping
+// with the range between from and to.
+func getTaskOccurrences(t *Task, from, to time.Time) (occurrences []*Task) {
+	start, finish := getTaskPlannedDates(t)
+	// An occurrence overlaps with the range as long as it does not finish before it starts
+	notBefore := from.Add(-finish.Sub(getTaskRepeatAnchor(t)))
+
+	iterateTaskOccurrences(t, notBefore, func(offset time.Duration) bool {
+		if !start.Add(offset).Before(to) || len(occurrences) >= maxTaskOccurrences {
+			return false
+		}
+		occurrences = append(occurrences, t.virtualOccurrence(offset))
+		return true
+	})
+
+	return
+}
+
+// virtualOccurrence returns a copy of a repeating task with all dates moved by offset.
+func (t *Task) virtualOccurrence(offset time.Duration) *Task {
+	occurrence := *t
+	occurrence.IsVirtual = true
+
+	shift := func(date time.Time) time.Time {
+		if date.IsZero() {
+			return date
+		}
+		return date.Add(offset)
+	}
+	occurrence.DueDate = shift(t.DueDate)
+	occurrence.StartDate = shift(t.StartDate)
+	occurrence.EndDate = shift(t.EndDate)
+
+	occurrence.Reminders = make([]*TaskReminder, 0, len(t.Reminders))
+	for _, r := range t.Reminders {
+		reminder := *r
+		reminder.Reminder = shift(r.Reminder)
+		occurrence.Reminders = append(occurrence.Reminders, &reminder)
+	}
+
+	return &occurrence
+}
+
+// expandsOccurrences checks if virtual occurrences of repeating tasks were requested.
+func (opts *taskSearchOptions) expandsOccurrences() bool {
+	return slices.Contains(opts.expand, TaskCollectionExpandOccurrences)
+}
+
+// getTaskOccurrencesRangeCond matches all tasks with dates in the range between from and to, and all repeating
+// tasks, since they might have future occurrences in it.
+func getTaskOccurrencesRangeCond(from, to time.Time) builder.Cond {
+	inRange := func(column string) builder.Cond {
+		return builder.And(builder.Gte{column: from}, builder.Lt{column: to})
+	}
+
+	return builder.Or(
+		inRange("tasks.due_date"),
+		inRange("tasks.start_date"),
+		inRange("tasks.end_date"),
+		builder.And(builder.Lt{"tasks.start_date": from}, builder.Gte{"tasks.end_date": to}),
+		builder.And(builder.Lt{"tasks.start_date": from}, builder.Gte{"tasks.due_date": to}),
+		builder.Gt{"tasks.repeat_after": 0},
+		builder.Eq{"tasks.repeat_mode": TaskRepeatModeMonth},
+		builder.And(builder.NotNull{"tasks.repeat_rule"}, builder.Neq{"tasks.repeat_rule": ""}),
+	)
+}
+
+// expandTaskOccurrences returns all tasks in the range between from and to, each followed by its virtual
+// occurrences in that range.
+func expandTaskOccurrences(tasks []*Task, from, to time.Time) (expanded []*Task) {
+	expanded = make([]*Task, 0, len(tasks))
+	for _, t := range tasks {
+		if taskOverlapsRange(t, from, to) {
+			expanded = append(expanded, t)
+		}
+		expanded = append(expanded, getTaskOccurrences(t, from, to)...)
+	}
+	return
+}
@@ -0,0 +1,192 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTaskOccurrences(t *testing.T) {
+	tomorrow := time.Now().In(config.GetTimeZone()).Truncate(time.Second).Add(24 * time.Hour)
+	from := tomorrow.Add(-time.Hour)
+	to := tomorrow.Add(30 * 24 * time.Hour)
+
+	t.Run("repeat after", func(t *testing.T) {
+		task := &Task{
+			ID:          1,
+			DueDate:     tomorrow,
+			StartDate:   tomorrow.Add(-time.Hour),
+			RepeatAfter: 7 * 24 * 60 * 60,
+			Reminders:   []*TaskReminder{{Reminder: tomorrow.Add(-2 * time.Hour)}},
+		}
+		occurrences := getTaskOccurrences(task, from, to)
+		require.Len(t, occurrences, 4)
+		for i, o := range occurrences {
+			offset := time.Duration(i+1) * 7 * 24 * time.Hour
+			assert.True(t, o.IsVirtual)
+			assert.Equal(t, int64(1), o.ID)
+			assert.Equal(t, tomorrow.Add(offset), o.DueDate)
+			assert.Equal(t, tomorrow.Add(offset-time.Hour), o.StartDate)
+			assert.True(t, o.EndDate.IsZero())
+			assert.Equal(t, tomorrow.Add(offset-2*time.Hour), o.Reminders[0].Reminder)
+		}
+		assert.False(t, task.IsVirtual)
+		assert.Equal(t, tomorrow.Add(-2*time.Hour), task.Reminders[0].Reminder)
+	})
+	t.Run("monthly", func(t *testing.T) {
+		task := &Task{
+			DueDate:    time.Date(2018, 1, 15, 10, 0, 0, 0, config.GetTimeZone()),
+			RepeatMode: TaskRepeatModeMonth,
+		}
+		occurrences := getTaskOccurrences(task, time.Date(2018, 3, 1, 0, 0, 0, 0, config.GetTimeZone()), time.Date(2018, 5, 1, 0, 0, 0, 0, config.GetTimeZone()))
+		require.Len(t, occurrences, 2)
+		assert.Equal(t, time.Date(2018, 3, 15, 10, 0, 0, 0, config.GetTimeZone()), occurrences[0].DueDate)
+		assert.Equal(t, time.Date(2018, 4, 15, 10, 0, 0, 0, config.GetTimeZone()), occurrences[1].DueDate)
+	})
+	t.Run("repeat rule", func(t *testing.T) {
+		task := &Task{
+			DueDate:    tomorrow,
+			RepeatRule: "FREQ=DAILY;COUNT=3",
+		}
+		occurrences := getTaskOccurrences(task, from, to)
+		require.Len(t, occurrences, 2)
+		assert.Equal(t, tomorrow.Add(24*time.Hour), occurrences[0].DueDate)
+		assert.Equal(t, tomorrow.Add(48*time.Hour), occurrences[1].DueDate)
+	})
+	t.Run("skips occurrences in the past", func(t *testing.T) {
+		task := &Task{
+			DueDate:     time.Date(2018, 1, 15, 10, 0, 0, 0, config.GetTimeZone()),
+			RepeatAfter: 24 * 60 * 60,
+		}
+		occurrences := getTaskOccurrences(task, time.Date(2018, 1, 1, 0, 0, 0, 0, config.GetTimeZone()), time.Date(2018, 2, 1, 0, 0, 0, 0, config.GetTimeZone()))
+		assert.Empty(t, occurrences)
+
+		occurrences = getTaskOccurrences(task, from, from.Add(48*time.Hour))
+		require.Len(t, occurrences, 2)
+		assert.True(t, occurrences[0].DueDate.After(time.Now()))
+	})
+	t.Run("includes occurrences spanning the start of the range", func(t *testing.T) {
+		task := &Task{
+			StartDate:   tomorrow,
+			EndDate:     tomorrow.Add(3 * 24 * time.Hour),
+			RepeatAfter: 7 * 24 * 60 * 60,
+		}
+		occurrences := getTaskOccurrences(task, tomorrow.Add(9*24*time.Hour), tomorrow.Add(10*24*time.Hour))
+		require.Len(t, occurrences, 1)
+		assert.Equal(t, tomorrow.Add(7*24*time.Hour), occurrences[0].StartDate)
+	})
+	t.Run("limited per task", func(t *testing.T) {
+		hourly := &Task{DueDate: tomorrow, RepeatAfter: 3600}
+		assert.Len(t, getTaskOccurrences(hourly, from, from.Add(365*24*time.Hour)), maxTaskOccurrences)
+	})
+	t.Run("done or not repeating", func(t *testing.T) {
+		assert.Empty(t, getTaskOccurrences(&Task{DueDate: tomorrow}, from, to))
+		assert.Empty(t, getTaskOccurrences(&Task{DueDate: tomorrow, RepeatAfter: 3600, Done: true}, from, to))
+		assert.Empty(t, getTaskOccurrences(&Task{RepeatAfter: 3600}, from, to))
+	})
+}
+
+func TestTaskCollection_ReadAll_Occurrences(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tomorrow := time.Now().Add(24 * time.Hour)
+		_, err := s.ID(5).Cols("due_date", "repeat_after").Update(&Task{DueDate: tomorrow, RepeatAfter: 7 * 24 * 60 * 60})
+		require.NoError(t, err)
+		// Task 28 repeats every hour
+		_, err = s.ID(28).Cols("repeat_after").Update(&Task{RepeatAfter: 0})
+		require.NoError(t, err)
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: 1,
+			DateFrom:      tomorrow.Add(-time.Hour).Format(time.RFC3339),
+			DateTo:        tomorrow.Add(15 * 24 * time.Hour).Format(time.RFC3339),
+			Expand:        []TaskCollectionExpandable{TaskCollectionExpandOccurrences},
+		}
+		result, count, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 3)
+		assert.Equal(t, 3, count)
+		for i, task := range tasks {
+			assert.Equal(t, int64(5), task.ID)
+			assert.Equal(t, i > 0, task.IsVirtual)
+			assert.Equal(t, tomorrow.Add(time.Duration(i)*7*24*time.Hour).Unix(), task.DueDate.Unix())
+		}
+	})
+	t.Run("paginates occurrences", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tomorrow := time.Now().Add(24 * time.Hour)
+		_, err := s.ID(5).Cols("due_date", "repeat_after").Update(&Task{DueDate: tomorrow, RepeatAfter: 7 * 24 * 60 * 60})
+		require.NoError(t, err)
+		_, err = s.ID(28).Cols("repeat_after").Update(&Task{RepeatAfter: 0})
+		require.NoError(t, err)
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: 1,
+			DateFrom:      tomorrow.Add(-time.Hour).Format(time.RFC3339),
+			DateTo:        tomorrow.Add(15 * 24 * time.Hour).Format(time.RFC3339),
+			Expand:        []TaskCollectionExpandable{TaskCollectionExpandOccurrences},
+		}
+		result, count, total, err := tc.ReadAll(s, u, "", 1, 2)
+		require.NoError(t, err)
+		require.Len(t, result.([]*Task), 2)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(3), total)
+		assert.False(t, result.([]*Task)[0].IsVirtual)
+
+		result, count, total, err = tc.ReadAll(s, u, "", 2, 2)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(3), total)
+		assert.True(t, tasks[0].IsVirtual)
+		assert.Equal(t, tomorrow.Add(14*24*time.Hour).Unix(), tasks[0].DueDate.Unix())
+	})
+	t.Run("needs a date range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: 1,
+			Expand:        []TaskCollectionExpandable{TaskCollectionExpandOccurrences},
+		}
+		_, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
@@ -257,6 +257,11 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	err = rel.rollupIntoParentTask(s, a)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	task, err := GetTaskByIDSimple(s, rel.TaskID)
 	if err != nil {
@@ -322,6 +327,11 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	err = rel.rollupIntoParentTask(s, a)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	task, err := GetTaskByIDSimple(s, rel.TaskID)
 	if err != nil {
@@ -254,6 +254,7 @@ func getTasksWithRemindersDueAndTheirUsers(s *xorm.Session, now time.Time) (remi
 		// All reminders from -12h to +14h to include all time zones
 		Where("reminder >= ? and reminder < ?", now.Add(time.Hour*-12).Format(dbTimeFormat), nextMinute.Add(time.Hour*14).Format(dbTimeFormat)).
 		And("tasks.done = false").
+		And("tasks.deleted IS NULL").
 		Find(&reminders)
 	if err != nil {
 		return
@@ -274,7 +275,13 @@ func getTasksWithRemindersDueAndTheirUsers(s *xorm.Session, now time.Time) (remi
 		return
 	}
 
-	usersWithReminders, err := getTaskUsersForTasks(s, taskIDs, builder.Eq{"users.email_reminders_enabled": true})
+	// Users who turned off reminders per mail still get them as push or chat messages
+	var usersCond builder.Cond = builder.Eq{"users.email_reminders_enabled": true}
+	if notifications.IsWebPushEnabled() || notifications.IsChatEnabled() {
+		usersCond = nil
+	}
+
+	usersWithReminders, err := getTaskUsersForTasks(s, taskIDs, usersCond)
 	if err != nil {
 		return
 	}
@@ -344,8 +351,8 @@ func RegisterReminderCron() {
 		return
 	}
 
-	if !config.MailerEnabled.GetBool() {
-		log.Info("Mailer is disabled, not sending reminders per mail")
+	if !config.MailerEnabled.GetBool() && !notifications.IsWebPushEnabled() && !notifications.IsChatEnabled() {
+		log.Info("Mailer, web push and chat notifications are disabled, not sending reminders")
 		return
 	}
 
@@ -377,7 +384,7 @@ func RegisterReminderCron() {
 				return
 			}
 
-			log.Debugf("[Task Reminder Cron] Sent reminder email for task %d to user %d", n.Task.ID, n.User.ID)
+			log.Debugf("[Task Reminder Cron] Sent reminder for task %d to user %d", n.Task.ID, n.User.ID)
 		}
 	})
 	if err != nil {
@@ -20,7 +20,10 @@ import (
 	"testing"
 	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/notifications"
+	"code.vikunja.io/api/pkg/user"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,6 +42,54 @@ func TestReminderGetTasksInTheNextMinute(t *testing.T) {
 		assert.Len(t, notifications, 1)
 		assert.Equal(t, int64(27), notifications[0].Task.ID)
 	})
+	t.Run("Users without email reminders get push reminders", func(t *testing.T) {
+		config.ChatNotificationsEnabled.Set(false)
+		defer config.ChatNotificationsEnabled.Set(true)
+
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("email_reminders_enabled").Update(&user.User{EmailRemindersEnabled: false})
+		require.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339Nano, "2018-12-01T01:12:00Z")
+		require.NoError(t, err)
+		reminders, err := getTasksWithRemindersDueAndTheirUsers(s, now)
+		require.NoError(t, err)
+		assert.Empty(t, reminders)
+
+		privateKey, _, err := notifications.GenerateVAPIDKeys()
+		require.NoError(t, err)
+		config.WebPushEnabled.Set(true)
+		config.WebPushVAPIDPrivateKey.Set(privateKey)
+		defer config.WebPushEnabled.Set(false)
+
+		reminders, err = getTasksWithRemindersDueAndTheirUsers(s, now)
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		assert.Equal(t, int64(27), reminders[0].Task.ID)
+		assert.Nil(t, reminders[0].ToMail("en"))
+		assert.NotNil(t, reminders[0].ToPush("en"))
+	})
+	t.Run("Users without email reminders get chat reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("email_reminders_enabled").Update(&user.User{EmailRemindersEnabled: false})
+		require.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339Nano, "2018-12-01T01:12:00Z")
+		require.NoError(t, err)
+		reminders, err := getTasksWithRemindersDueAndTheirUsers(s, now)
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		assert.Equal(t, int64(27), reminders[0].Task.ID)
+		assert.Nil(t, reminders[0].ToMail("en"))
+		require.NotNil(t, reminders[0].ToChat("en"))
+		assert.NotEmpty(t, reminders[0].ToChat("en").Subject)
+	})
 	t.Run("Found No Tasks", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
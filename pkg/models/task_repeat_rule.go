@@ -0,0 +1,707 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+// RepeatFrequency is the FREQ part of a repeat rule
+type RepeatFrequency string
+
+const (
+	RepeatFrequencySecondly RepeatFrequency = "SECONDLY"
+	RepeatFrequencyMinutely RepeatFrequency = "MINUTELY"
+	RepeatFrequencyHourly   RepeatFrequency = "HOURLY"
+	RepeatFrequencyDaily    RepeatFrequency = "DAILY"
+	RepeatFrequencyWeekly   RepeatFrequency = "WEEKLY"
+	RepeatFrequencyMonthly  RepeatFrequency = "MONTHLY"
+	RepeatFrequencyYearly   RepeatFrequency = "YEARLY"
+)
+
+// RepeatWeekday is a single entry of the BYDAY part of a repeat rule, like MO or -1FR.
+type RepeatWeekday struct {
+	Weekday time.Weekday
+	// The nth occurrence of the weekday in the month or year, negative values count from the end.
+	// 0 matches every occurrence.
+	N int
+}
+
+// RepeatRule is a recurrence rule as defined in RFC 5545, section 3.3.10.
+type RepeatRule struct {
+	Freq       RepeatFrequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	BySecond   []int
+	ByMinute   []int
+	ByHour     []int
+	ByDay      []RepeatWeekday
+	ByMonthDay []int
+	ByYearDay  []int
+	ByWeekNo   []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+
+	// All parts of the rule in their original order, to serialize the rule without losing anything
+	// like x-name parts other clients put in there.
+	parts []string
+}
+
+var repeatWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var repeatByDayRegex = regexp.MustCompile(`^([+-]?[0-9]{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+const (
+	// If this many periods in a row don't produce a single occurrence, the rule is considered to never match again.
+	maxEmptyRepeatPeriods = 1000
+	// The most work a single iteration over a series may do, counted in days checked for daily and longer
+	// frequencies and in periods for shorter ones. Rules which need more than that to find their next occurrence
+	// are treated as if the series ended, so no rule can keep the server busy.
+	maxRepeatRuleSteps = 50000
+)
+
+// ParseRepeatRule parses the value of an RRULE property like "FREQ=MONTHLY;BYDAY=-1FR".
+//
+//nolint:gocyclo
+func ParseRepeatRule(rule string) (r *RepeatRule, err error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("the rule is empty")
+	}
+
+	r = &RepeatRule{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		name, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("the rule part %q is invalid", part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return nil, fmt.Errorf("the rule part %s is used more than once", name)
+		}
+		seen[name] = true
+
+		if !strings.HasPrefix(name, "X-") {
+			value = strings.ToUpper(value)
+		}
+		r.parts = append(r.parts, name+"="+value)
+
+		switch name {
+		case "FREQ":
+			r.Freq = RepeatFrequency(value)
+			switch r.Freq {
+			case RepeatFrequencySecondly, RepeatFrequencyMinutely, RepeatFrequencyHourly,
+				RepeatFrequencyDaily, RepeatFrequencyWeekly, RepeatFrequencyMonthly, RepeatFrequencyYearly:
+			default:
+				err = errors.New("unknown frequency")
+			}
+		case "INTERVAL":
+			r.Interval, err = parseRepeatRuleInt(value, 1, math.MaxInt32, false)
+		case "COUNT":
+			r.Count, err = parseRepeatRuleInt(value, 1, math.MaxInt32, false)
+		case "UNTIL":
+			r.Until, err = parseRepeatRuleUntil(value)
+		case "BYSECOND":
+			r.BySecond, err = parseRepeatRuleIntList(value, 0, 60, false)
+		case "BYMINUTE":
+			r.ByMinute, err = parseRepeatRuleIntList(value, 0, 59, false)
+		case "BYHOUR":
+			r.ByHour, err = parseRepeatRuleIntList(value, 0, 23, false)
+		case "BYDAY":
+			r.ByDay, err = parseRepeatRuleByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRepeatRuleIntList(value, 1, 31, true)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseRepeatRuleIntList(value, 1, 366, true)
+		case "BYWEEKNO":
+			r.ByWeekNo, err = parseRepeatRuleIntList(value, 1, 53, true)
+		case "BYMONTH":
+			r.ByMonth, err = parseRepeatRuleIntList(value, 1, 12, false)
+		case "BYSETPOS":
+			r.BySetPos, err = parseRepeatRuleIntList(value, 1, 366, true)
+		case "WKST":
+			var has bool
+			r.WeekStart, has = repeatWeekdays[value]
+			if !has {
+				err = errors.New("unknown weekday")
+			}
+		default:
+			// Clients may add their own x-name parts, they are kept but don't change the recurrence.
+			if !strings.HasPrefix(name, "X-") {
+				return nil, fmt.Errorf("the rule part %s is not supported", name)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("the rule needs a FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	if len(r.ByWeekNo) > 0 && r.Freq != RepeatFrequencyYearly {
+		return nil, errors.New("BYWEEKNO can only be used with a yearly frequency")
+	}
+	if len(r.ByYearDay) > 0 && (r.Freq == RepeatFrequencyDaily || r.Freq == RepeatFrequencyWeekly || r.Freq == RepeatFrequencyMonthly) {
+		return nil, errors.New("BYYEARDAY cannot be used with a daily, weekly or monthly frequency")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == RepeatFrequencyWeekly {
+		return nil, errors.New("BYMONTHDAY cannot be used with a weekly frequency")
+	}
+	for _, day := range r.ByDay {
+		if day.N == 0 {
+			continue
+		}
+		if r.Freq != RepeatFrequencyMonthly && r.Freq != RepeatFrequencyYearly {
+			return nil, errors.New("numbered weekdays in BYDAY can only be used with a monthly or yearly frequency")
+		}
+		if len(r.ByWeekNo) > 0 {
+			return nil, errors.New("numbered weekdays in BYDAY cannot be used together with BYWEEKNO")
+		}
+	}
+	if err := r.checkCanMatch(); err != nil {
+		return nil, err
+	}
+	if len(r.BySetPos) > 0 && len(r.BySecond) == 0 && len(r.ByMinute) == 0 && len(r.ByHour) == 0 &&
+		len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByYearDay) == 0 && len(r.ByWeekNo) == 0 && len(r.ByMonth) == 0 {
+		return nil, errors.New("BYSETPOS needs another BYxxx rule part")
+	}
+
+	return r, nil
+}
+
+// repeatRuleMaxDaysInMonth holds the most days each month can have, in leap years.
+var repeatRuleMaxDaysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// checkCanMatch rejects rules whose parts contradict each other so they can never produce a single occurrence
+// after the start, like the 30th of February.
+func (r *RepeatRule) checkCanMatch() error {
+	months := r.ByMonth
+	if len(months) == 0 {
+		months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	}
+
+	if len(r.ByMonthDay) > 0 && !slices.ContainsFunc(months, func(month int) bool {
+		return slices.ContainsFunc(r.ByMonthDay, func(day int) bool {
+			return max(day, -day) <= repeatRuleMaxDaysInMonth[month]
+		})
+	}) {
+		return errors.New("none of the days in BYMONTHDAY exist in the months of the rule")
+	}
+
+	if len(r.ByYearDay) > 0 && len(r.ByMonth) > 0 && !slices.ContainsFunc(r.ByYearDay, func(yearDay int) bool {
+		// 2023 is a common year, 2024 a leap year
+		for _, year := range []int{2023, 2024} {
+			day := time.Date(year, 1, yearDay, 0, 0, 0, 0, time.UTC)
+			if yearDay < 0 {
+				day = time.Date(year+1, 1, yearDay+1, 0, 0, 0, 0, time.UTC)
+			}
+			if slices.Contains(r.ByMonth, int(day.Month())) {
+				return true
+			}
+		}
+		return false
+	}) {
+		return errors.New("none of the days in BYYEARDAY are in the months of BYMONTH")
+	}
+
+	inMonth := r.Freq == RepeatFrequencyMonthly || (r.Freq == RepeatFrequencyYearly && len(r.ByMonth) > 0)
+	if inMonth && len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(day RepeatWeekday) bool {
+		return max(day.N, -day.N) <= 5
+	}) {
+		return errors.New("a month has at most five of each weekday")
+	}
+
+	return nil
+}
+
+func parseRepeatRuleInt(value string, minimum, maximum int, allowNegative bool) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	abs := i
+	if allowNegative && i < 0 {
+		abs = -i
+	}
+	if abs < minimum || abs > maximum {
+		return 0, fmt.Errorf("%d is out of range", i)
+	}
+	return i, nil
+}
+
+func parseRepeatRuleIntList(value string, minimum, maximum int, allowNegative bool) (list []int, err error) {
+	for _, v := range strings.Split(value, ",") {
+		i, err := parseRepeatRuleInt(v, minimum, maximum, allowNegative)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	return
+}
+
+func parseRepeatRuleByDay(value string) (days []RepeatWeekday, err error) {
+	for _, v := range strings.Split(value, ",") {
+		matches := repeatByDayRegex.FindStringSubmatch(v)
+		if matches == nil {
+			return nil, fmt.Errorf("%s is not a weekday", v)
+		}
+		day := RepeatWeekday{Weekday: repeatWeekdays[matches[2]]}
+		if matches[1] != "" {
+			day.N, err = parseRepeatRuleInt(matches[1], 1, 53, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		days = append(days, day)
+	}
+	return
+}
+
+func parseRepeatRuleUntil(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("20060102T150405Z", value, time.UTC); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, config.GetTimeZone()); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", value, config.GetTimeZone())
+	if err != nil {
+		return time.Time{}, errors.New("not a date or date-time")
+	}
+	// A date includes all occurrences on that day
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// String returns the rule as it is used as the value of an RRULE property.
+func (r *RepeatRule) String() string {
+	parts := make([]string, 0, len(r.parts))
+	for _, part := range r.parts {
+		if strings.HasPrefix(part, "COUNT=") {
+			part = "COUNT=" + strconv.Itoa(r.Count)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ";")
+}
+
+// FixedInterval returns the interval in seconds if the rule does nothing more than repeating in fixed steps,
+// which is what repeat_after can express as well.
+func (r *RepeatRule) FixedInterval() (seconds int64, ok bool) {
+	for _, part := range r.parts {
+		if !strings.HasPrefix(part, "FREQ=") && !strings.HasPrefix(part, "INTERVAL=") {
+			return 0, false
+		}
+	}
+
+	var unit time.Duration
+	switch r.Freq {
+	case RepeatFrequencySecondly:
+		unit = time.Second
+	case RepeatFrequencyMinutely:
+		unit = time.Minute
+	case RepeatFrequencyHourly:
+		unit = time.Hour
+	case RepeatFrequencyDaily:
+		unit = 24 * time.Hour
+	case RepeatFrequencyWeekly:
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+
+	return int64(unit.Seconds()) * int64(r.Interval), true
+}
+
+// Iterate calls fn with all occurrences of a series starting at start, in chronological order, until fn returns
+// false or the series ends. Like in RFC 5545, the start itself always is the first occurrence.
+func (r *RepeatRule) Iterate(start time.Time, fn func(occurrence time.Time) bool) {
+	r.iterate(start, time.Time{}, fn)
+}
+
+// iterate works like Iterate, but may skip occurrences before notBefore if the rule has no COUNT, which would need
+// all of them to be counted. Callers must not rely on fn only being called with occurrences after notBefore.
+func (r *RepeatRule) iterate(start, notBefore time.Time, fn func(occurrence time.Time) bool) {
+	start = start.In(config.GetTimeZone()).Truncate(time.Second)
+	expanded := r.withDefaults(start)
+
+	emitted := 0
+	emit := func(occurrence time.Time) bool {
+		if !r.Until.IsZero() && occurrence.After(r.Until) {
+			return false
+		}
+		emitted++
+		if !fn(occurrence) {
+			return false
+		}
+		return r.Count == 0 || emitted < r.Count
+	}
+
+	if !emit(start) {
+		return
+	}
+
+	period := 0
+	if r.Count == 0 && notBefore.After(start) {
+		period = expanded.periodsBefore(start, notBefore)
+	}
+
+	stepsPerPeriod := map[RepeatFrequency]int{
+		RepeatFrequencyYearly:  366,
+		RepeatFrequencyMonthly: 31,
+		RepeatFrequencyWeekly:  7,
+	}[r.Freq]
+	stepsPerPeriod = max(stepsPerPeriod, 1)
+
+	empty := 0
+	for steps := 0; empty < maxEmptyRepeatPeriods && steps < maxRepeatRuleSteps; steps += stepsPerPeriod {
+		var occurrences []time.Time
+		occurrences, period = expanded.occurrencesInPeriod(start, period)
+		if len(occurrences) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+
+		for _, occurrence := range occurrences {
+			if !occurrence.After(start) {
+				continue
+			}
+			if !emit(occurrence) {
+				return
+			}
+		}
+	}
+}
+
+// After returns the first occurrence after a point in time of a series starting at start, together with the
+// number of occurrences before it. It returns false if the series ends before that.
+func (r *RepeatRule) After(start, after time.Time) (next time.Time, index int, ok bool) {
+	r.iterate(start, after, func(occurrence time.Time) bool {
+		if occurrence.After(after) {
+			next = occurrence
+			ok = true
+			return false
+		}
+		index++
+		return true
+	})
+	return
+}
+
+// periodsBefore returns the number of whole periods between start and notBefore, minus one to be on the safe side
+// with daylight saving time and months of different lengths. Iterating can start there without missing an
+// occurrence after notBefore.
+func (r *RepeatRule) periodsBefore(start, notBefore time.Time) int {
+	var periods int
+	switch r.Freq {
+	case RepeatFrequencyYearly:
+		periods = notBefore.Year() - start.Year()
+	case RepeatFrequencyMonthly:
+		periods = (notBefore.Year()-start.Year())*12 + int(notBefore.Month()) - int(start.Month())
+	default:
+		unit := map[RepeatFrequency]time.Duration{
+			RepeatFrequencyWeekly:   7 * 24 * time.Hour,
+			RepeatFrequencyDaily:    24 * time.Hour,
+			RepeatFrequencyHourly:   time.Hour,
+			RepeatFrequencyMinutely: time.Minute,
+			RepeatFrequencySecondly: time.Second,
+		}[r.Freq]
+		periods = int(notBefore.Sub(start) / unit)
+	}
+
+	return max(periods/r.Interval-1, 0)
+}
+
+// withDefaults returns a copy of the rule with the parts RFC 5545 derives from the start of the series when
+// they are missing, for example the day of the month for a monthly rule.
+func (r *RepeatRule) withDefaults(start time.Time) *RepeatRule {
+	e := *r
+
+	switch e.Freq {
+	case RepeatFrequencyYearly:
+		if len(e.ByWeekNo) == 0 && len(e.ByYearDay) == 0 && len(e.ByMonthDay) == 0 && len(e.ByDay) == 0 {
+			if len(e.ByMonth) == 0 {
+				e.ByMonth = []int{int(start.Month())}
+			}
+			e.ByMonthDay = []int{start.Day()}
+		}
+		if len(e.ByWeekNo) > 0 && len(e.ByYearDay) == 0 && len(e.ByMonthDay) == 0 && len(e.ByDay) == 0 {
+			e.ByDay = []RepeatWeekday{{Weekday: start.Weekday()}}
+		}
+	case RepeatFrequencyMonthly:
+		if len(e.ByMonthDay) == 0 && len(e.ByDay) == 0 {
+			e.ByMonthDay = []int{start.Day()}
+		}
+	case RepeatFrequencyWeekly:
+		if len(e.ByDay) == 0 {
+			e.ByDay = []RepeatWeekday{{Weekday: start.Weekday()}}
+		}
+	}
+
+	return &e
+}
+
+// occurrencesInPeriod returns all occurrences in the nth period (year, month, week, day, ...) after the one
+// containing start, sorted. It also returns the next period worth looking at.
+func (r *RepeatRule) occurrencesInPeriod(start time.Time, period int) (occurrences []time.Time, next int) {
+	loc := start.Location()
+	step := period * r.Interval
+
+	var unit time.Duration
+	switch r.Freq {
+	case RepeatFrequencyYearly:
+		first := time.Date(start.Year()+step, 1, 1, 0, 0, 0, 0, loc)
+		occurrences = r.occurrencesOnDays(start, first, first.AddDate(1, 0, 0))
+		return r.applySetPos(occurrences), period + 1
+	case RepeatFrequencyMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, loc)
+		occurrences = r.occurrencesOnDays(start, first, first.AddDate(0, 1, 0))
+		return r.applySetPos(occurrences), period + 1
+	case RepeatFrequencyWeekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		first := time.Date(start.Year(), start.Month(), start.Day()-offset+7*step, 0, 0, 0, 0, loc)
+		occurrences = r.occurrencesOnDays(start, first, first.AddDate(0, 0, 7))
+		return r.applySetPos(occurrences), period + 1
+	case RepeatFrequencyDaily:
+		first := time.Date(start.Year(), start.Month(), start.Day()+step, 0, 0, 0, 0, loc)
+		occurrences = r.occurrencesOnDays(start, first, first.AddDate(0, 0, 1))
+		return r.applySetPos(occurrences), period + 1
+	case RepeatFrequencyHourly:
+		unit = time.Hour
+	case RepeatFrequencyMinutely:
+		unit = time.Minute
+	case RepeatFrequencySecondly:
+		unit = time.Second
+	}
+
+	var base time.Time
+	switch r.Freq {
+	case RepeatFrequencyHourly:
+		base = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, loc)
+	case RepeatFrequencyMinutely:
+		base = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+	default:
+		base = start
+	}
+	stepDuration := unit * time.Duration(r.Interval)
+	current := base.Add(stepDuration * time.Duration(period))
+
+	// Skip whole days, hours or minutes which can't match instead of going through all of their periods
+	skipUntil := func(next time.Time) int {
+		skip := int((next.Sub(base) + stepDuration - 1) / stepDuration)
+		return max(skip, period+1)
+	}
+	if !r.matchesDay(current) {
+		return nil, skipUntil(time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, loc))
+	}
+	if len(r.ByHour) > 0 && !slices.Contains(r.ByHour, current.Hour()) {
+		return nil, skipUntil(time.Date(current.Year(), current.Month(), current.Day(), current.Hour()+1, 0, 0, 0, loc))
+	}
+	if r.Freq != RepeatFrequencyHourly && len(r.ByMinute) > 0 && !slices.Contains(r.ByMinute, current.Minute()) {
+		return nil, skipUntil(time.Date(current.Year(), current.Month(), current.Day(), current.Hour(), current.Minute()+1, 0, 0, loc))
+	}
+	if r.Freq == RepeatFrequencySecondly {
+		if len(r.BySecond) > 0 && !slices.Contains(r.BySecond, current.Second()) {
+			return nil, period + 1
+		}
+		return []time.Time{current}, period + 1
+	}
+
+	minutes := []int{current.Minute()}
+	if r.Freq == RepeatFrequencyHourly {
+		minutes = repeatRuleValuesOr(r.ByMinute, start.Minute())
+	}
+	for _, minute := range minutes {
+		for _, second := range repeatRuleValuesOr(r.BySecond, start.Second()) {
+			occurrences = append(occurrences, time.Date(current.Year(), current.Month(), current.Day(), current.Hour(), minute, second, 0, loc))
+		}
+	}
+	slices.SortFunc(occurrences, func(a, b time.Time) int { return a.Compare(b) })
+
+	return r.applySetPos(occurrences), period + 1
+}
+
+// occurrencesOnDays returns all occurrences on the matching days between from (inclusive) and to (exclusive).
+func (r *RepeatRule) occurrencesOnDays(start, from, to time.Time) (occurrences []time.Time) {
+	hours := repeatRuleValuesOr(r.ByHour, start.Hour())
+	minutes := repeatRuleValuesOr(r.ByMinute, start.Minute())
+	seconds := repeatRuleValuesOr(r.BySecond, start.Second())
+
+	for day := from; day.Before(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location()) {
+		if !r.matchesDay(day) {
+			continue
+		}
+		for _, hour := range hours {
+			for _, minute := range minutes {
+				for _, second := range seconds {
+					occurrences = append(occurrences, time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, day.Location()))
+				}
+			}
+		}
+	}
+	slices.SortFunc(occurrences, func(a, b time.Time) int { return a.Compare(b) })
+
+	return
+}
+
+func (r *RepeatRule) matchesDay(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, int(day.Month())) {
+		return false
+	}
+
+	if len(r.ByWeekNo) > 0 {
+		week, weeksInYear := repeatRuleWeekNumber(day, r.WeekStart)
+		if !slices.Contains(r.ByWeekNo, week) && !slices.Contains(r.ByWeekNo, week-weeksInYear-1) {
+			return false
+		}
+	}
+
+	daysInYear := repeatRuleDaysBetween(time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.UTC), time.Date(day.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(r.ByYearDay) > 0 && !slices.Contains(r.ByYearDay, day.YearDay()) && !slices.Contains(r.ByYearDay, day.YearDay()-daysInYear-1) {
+		return false
+	}
+
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(r.ByMonthDay) > 0 && !slices.Contains(r.ByMonthDay, day.Day()) && !slices.Contains(r.ByMonthDay, day.Day()-daysInMonth-1) {
+		return false
+	}
+
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	// Numbered weekdays count within the month for monthly rules or yearly rules limited to some months,
+	// otherwise within the year.
+	inMonth := r.Freq == RepeatFrequencyMonthly || (r.Freq == RepeatFrequencyYearly && len(r.ByMonth) > 0)
+	for _, wd := range r.ByDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		if wd.N == 0 {
+			return true
+		}
+
+		var nth, nthFromEnd int
+		if inMonth {
+			nth = (day.Day()-1)/7 + 1
+			nthFromEnd = (daysInMonth-day.Day())/7 + 1
+		} else {
+			nth = (day.YearDay()-1)/7 + 1
+			nthFromEnd = (daysInYear-day.YearDay())/7 + 1
+		}
+		if wd.N == nth || wd.N == -nthFromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *RepeatRule) applySetPos(occurrences []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(occurrences) == 0 {
+		return occurrences
+	}
+
+	var selected []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(occurrences) + pos
+		}
+		if i >= 0 && i < len(occurrences) && !slices.ContainsFunc(selected, occurrences[i].Equal) {
+			selected = append(selected, occurrences[i])
+		}
+	}
+	slices.SortFunc(selected, func(a, b time.Time) int { return a.Compare(b) })
+
+	return selected
+}
+
+func repeatRuleValuesOr(values []int, fallback int) []int {
+	if len(values) == 0 {
+		return []int{fallback}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
+}
+
+func repeatRuleDaysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// repeatRuleFirstWeekStart returns the first day of week 1 of a year. Week 1 is the first week with at least
+// four days in that year.
+func repeatRuleFirstWeekStart(year int, weekStart time.Weekday) time.Time {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan1.Weekday()) - int(weekStart) + 7) % 7
+	if offset <= 3 {
+		return jan1.AddDate(0, 0, -offset)
+	}
+	return jan1.AddDate(0, 0, 7-offset)
+}
+
+// repeatRuleWeekNumber returns the week number of a day and how many weeks the year of that week has.
+func repeatRuleWeekNumber(day time.Time, weekStart time.Weekday) (week, weeksInYear int) {
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	year := date.Year()
+	first := repeatRuleFirstWeekStart(year, weekStart)
+	if date.Before(first) {
+		year--
+		first = repeatRuleFirstWeekStart(year, weekStart)
+	} else if next := repeatRuleFirstWeekStart(year+1, weekStart); !date.Before(next) {
+		year++
+		first = next
+	}
+
+	week = repeatRuleDaysBetween(first, date)/7 + 1
+	weeksInYear = repeatRuleDaysBetween(first, repeatRuleFirstWeekStart(year+1, weekStart)) / 7
+	return
+}
@@ -0,0 +1,245 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepeatRule(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		r, err := ParseRepeatRule("RRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=MO,-1FR;COUNT=4;WKST=SU")
+		require.NoError(t, err)
+		assert.Equal(t, RepeatFrequencyMonthly, r.Freq)
+		assert.Equal(t, 2, r.Interval)
+		assert.Equal(t, 4, r.Count)
+		assert.Equal(t, []RepeatWeekday{{Weekday: time.Monday}, {Weekday: time.Friday, N: -1}}, r.ByDay)
+		assert.Equal(t, time.Sunday, r.WeekStart)
+		assert.Equal(t, "FREQ=MONTHLY;INTERVAL=2;BYDAY=MO,-1FR;COUNT=4;WKST=SU", r.String())
+	})
+	t.Run("keeps x-name parts", func(t *testing.T) {
+		r, err := ParseRepeatRule("freq=daily;X-Client-Thing=Foo")
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=DAILY;X-CLIENT-THING=Foo", r.String())
+	})
+	t.Run("until", func(t *testing.T) {
+		r, err := ParseRepeatRule("FREQ=DAILY;UNTIL=20191231T100000Z")
+		require.NoError(t, err)
+		assert.True(t, r.Until.Equal(time.Date(2019, 12, 31, 10, 0, 0, 0, time.UTC)))
+
+		r, err = ParseRepeatRule("FREQ=DAILY;UNTIL=20191231")
+		require.NoError(t, err)
+		assert.True(t, r.Until.Equal(time.Date(2019, 12, 31, 23, 59, 59, 0, config.GetTimeZone())))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, rule := range []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=SOMETIMES",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=2;UNTIL=20191231",
+			"FREQ=DAILY;FREQ=WEEKLY",
+			"FREQ=DAILY;BYDAY=XY",
+			"FREQ=DAILY;BYDAY=1MO",
+			"FREQ=MONTHLY;BYWEEKNO=1",
+			"FREQ=WEEKLY;BYMONTHDAY=1",
+			"FREQ=MONTHLY;BYMONTHDAY=32",
+			"FREQ=MONTHLY;BYSETPOS=1",
+			"FREQ=DAILY;RSCALE=GREGORIAN",
+			"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			"FREQ=MONTHLY;BYMONTH=4,6;BYMONTHDAY=-31",
+			"FREQ=YEARLY;BYMONTH=1;BYYEARDAY=100",
+			"FREQ=MONTHLY;BYDAY=6MO",
+			"FREQ",
+		} {
+			_, err := ParseRepeatRule(rule)
+			assert.Error(t, err, rule)
+		}
+	})
+}
+
+func TestRepeatRule_FixedInterval(t *testing.T) {
+	r, err := ParseRepeatRule("FREQ=WEEKLY;INTERVAL=2")
+	require.NoError(t, err)
+	seconds, ok := r.FixedInterval()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1209600), seconds)
+
+	r, err = ParseRepeatRule("FREQ=WEEKLY;BYDAY=MO")
+	require.NoError(t, err)
+	_, ok = r.FixedInterval()
+	assert.False(t, ok)
+
+	r, err = ParseRepeatRule("FREQ=MONTHLY")
+	require.NoError(t, err)
+	_, ok = r.FixedInterval()
+	assert.False(t, ok)
+}
+
+func getRepeatRuleOccurrences(t *testing.T, rule string, start time.Time, limit int) (occurrences []string) {
+	r, err := ParseRepeatRule(rule)
+	require.NoError(t, err)
+	r.Iterate(start, func(occurrence time.Time) bool {
+		occurrences = append(occurrences, occurrence.Format("2006-01-02 15:04"))
+		return len(occurrences) < limit
+	})
+	return
+}
+
+func TestRepeatRule_Iterate(t *testing.T) {
+	// Most of these are the examples from RFC 5545
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, config.GetTimeZone())
+	}
+
+	t.Run("daily with count", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-02 09:00", "1997-09-03 09:00", "1997-09-04 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=DAILY;COUNT=3", date(1997, 9, 2, 9), 10))
+	})
+	t.Run("weekly on tuesday and thursday until", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-02 09:00", "1997-09-04 09:00", "1997-09-09 09:00", "1997-09-11 09:00",
+			"1997-09-16 09:00", "1997-09-18 09:00", "1997-09-23 09:00", "1997-09-25 09:00",
+			"1997-09-30 09:00", "1997-10-02 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=WEEKLY;UNTIL=19971007T000000;WKST=SU;BYDAY=TU,TH", date(1997, 9, 2, 9), 20))
+	})
+	t.Run("every other week", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-02 09:00", "1997-09-16 09:00", "1997-09-30 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=WEEKLY;INTERVAL=2", date(1997, 9, 2, 9), 3))
+	})
+	t.Run("last friday of the month", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-26 09:00", "1997-10-31 09:00", "1997-11-28 09:00", "1997-12-26 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MONTHLY;BYDAY=-1FR", date(1997, 9, 26, 9), 4))
+	})
+	t.Run("first and last sunday of the month", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-07 09:00", "1997-09-28 09:00", "1997-10-05 09:00", "1997-10-26 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MONTHLY;BYDAY=1SU,-1SU", date(1997, 9, 7, 9), 4))
+	})
+	t.Run("third to last day of the month", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-28 09:00", "1997-10-29 09:00", "1997-11-28 09:00", "1997-12-29 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MONTHLY;BYMONTHDAY=-3", date(1997, 9, 28, 9), 4))
+	})
+	t.Run("last work day of the month", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-30 09:00", "1997-10-31 09:00", "1997-11-28 09:00", "1997-12-31 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", date(1997, 9, 30, 9), 4))
+	})
+	t.Run("friday the 13th", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1998-02-13 09:00", "1998-03-13 09:00", "1998-11-13 09:00", "1999-08-13 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", date(1998, 2, 13, 9), 4))
+	})
+	t.Run("monday of week 20", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-05-12 09:00", "1998-05-11 09:00", "1999-05-17 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO", date(1997, 5, 12, 9), 3))
+	})
+	t.Run("thursdays in march", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-03-13 09:00", "1997-03-20 09:00", "1997-03-27 09:00", "1998-03-05 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=YEARLY;BYMONTH=3;BYDAY=TH", date(1997, 3, 13, 9), 4))
+	})
+	t.Run("us presidential election day", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1996-11-05 09:00", "2000-11-07 09:00", "2004-11-02 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=YEARLY;INTERVAL=4;BYMONTH=11;BYDAY=TU;BYMONTHDAY=2,3,4,5,6,7,8", date(1996, 11, 5, 9), 3))
+	})
+	t.Run("yearly on a leap day", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"2024-02-29 09:00", "2028-02-29 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=YEARLY", date(2024, 2, 29, 9), 2))
+	})
+	t.Run("monthly skips months without the day", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"2019-01-31 09:00", "2019-03-31 09:00", "2019-05-31 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MONTHLY", date(2019, 1, 31, 9), 3))
+	})
+	t.Run("every three hours", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-02 09:00", "1997-09-02 12:00", "1997-09-02 15:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=HOURLY;INTERVAL=3;UNTIL=19970902T170000", date(1997, 9, 2, 9), 10))
+	})
+	t.Run("every 20 minutes during work hours", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"1997-09-02 09:00", "1997-09-02 09:20", "1997-09-02 09:40", "1997-09-02 10:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=DAILY;BYHOUR=9,10;BYMINUTE=0,20,40", date(1997, 9, 2, 9), 4))
+		assert.Equal(t, []string{
+			"1997-09-02 16:40", "1997-09-03 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10,11,12,13,14,15,16", date(1997, 9, 2, 16).Add(40*time.Minute), 2))
+	})
+	t.Run("never matches again", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"2019-01-01 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=MINUTELY;INTERVAL=2;BYMINUTE=1", date(2019, 1, 1, 9), 2))
+	})
+	t.Run("leap days", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"2019-01-01 09:00", "2020-02-29 09:00", "2024-02-29 09:00",
+		}, getRepeatRuleOccurrences(t, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", date(2019, 1, 1, 9), 3))
+	})
+}
+
+func TestRepeatRule_After(t *testing.T) {
+	r, err := ParseRepeatRule("FREQ=WEEKLY;BYDAY=MO;COUNT=3")
+	require.NoError(t, err)
+	start := time.Date(2019, 1, 7, 9, 0, 0, 0, config.GetTimeZone())
+
+	next, index, ok := r.After(start, start.AddDate(0, 0, 8))
+	assert.True(t, ok)
+	assert.Equal(t, 2, index)
+	assert.True(t, next.Equal(start.AddDate(0, 0, 14)))
+
+	_, _, ok = r.After(start, start.AddDate(0, 0, 14))
+	assert.False(t, ok)
+}
+
+func TestRepeatRule_After_Bounded(t *testing.T) {
+	now := time.Now().In(config.GetTimeZone()).Truncate(time.Second)
+
+	t.Run("high frequency from long ago", func(t *testing.T) {
+		r, err := ParseRepeatRule("FREQ=SECONDLY;BYMINUTE=0")
+		require.NoError(t, err)
+
+		began := time.Now()
+		next, _, ok := r.After(now.AddDate(-1, 0, 0), now)
+		assert.Less(t, time.Since(began), time.Second)
+		require.True(t, ok)
+		assert.True(t, next.After(now))
+		assert.Equal(t, 0, next.Minute())
+	})
+	t.Run("too expensive", func(t *testing.T) {
+		// Valid, but with COUNT every occurrence since the start has to be counted
+		r, err := ParseRepeatRule("FREQ=SECONDLY;COUNT=100000000")
+		require.NoError(t, err)
+
+		began := time.Now()
+		_, _, ok := r.After(now.AddDate(-1, 0, 0), now)
+		assert.Less(t, time.Since(began), time.Second)
+		assert.False(t, ok)
+	})
+}
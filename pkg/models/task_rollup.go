@@ -0,0 +1,343 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// subtaskRollup holds the values of a task computed from its subtasks.
+type subtaskRollup struct {
+	percentDone float64
+	startDate   time.Time
+	endDate     time.Time
+	storyPoints float64
+	estimate    int64
+}
+
+func getRelatedTaskIDs(s *xorm.Session, taskID int64, kind RelationKind) (ids []int64, err error) {
+	ids = []int64{}
+	err = s.
+		Table("task_relations").
+		Cols("other_task_id").
+		Where("task_id = ? AND relation_kind = ?", taskID, kind).
+		OrderBy("other_task_id asc").
+		Find(&ids)
+	return
+}
+
+func getSubtasks(s *xorm.Session, taskID int64) (subtasks []*Task, err error) {
+	ids, err := getRelatedTaskIDs(s, taskID, RelationKindSubtask)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	return GetTasksSimpleByIDs(s, ids)
+}
+
+// getSubtaskRollup computes the progress, the date span and the estimate totals of a task from all of its
+// subtasks, recursively.
+// A done subtask counts as finished, no matter its own progress. Tasks without subtasks contribute their own
+// values. Like checkTaskRelationCycle, this keeps track of the current path to stay safe from cycles.
+func getSubtaskRollup(s *xorm.Session, task *Task, visited map[int64]*subtaskRollup, currentPath map[int64]bool) (rollup *subtaskRollup, err error) {
+	if r, has := visited[task.ID]; has {
+		return r, nil
+	}
+
+	own := &subtaskRollup{
+		percentDone: task.PercentDone,
+		startDate:   task.StartDate,
+		endDate:     task.EndDate,
+		storyPoints: task.StoryPoints,
+		estimate:    task.Estimate,
+	}
+	if currentPath[task.ID] {
+		return own, nil
+	}
+
+	currentPath[task.ID] = true
+	defer delete(currentPath, task.ID)
+
+	subtasks, err := getSubtasks(s, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(subtasks) == 0 {
+		visited[task.ID] = own
+		return own, nil
+	}
+
+	rollup = &subtaskRollup{}
+	for _, subtask := range subtasks {
+		r, err := getSubtaskRollup(s, subtask, visited, currentPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if subtask.Done {
+			rollup.percentDone++
+		} else {
+			rollup.percentDone += r.percentDone
+		}
+		if !r.startDate.IsZero() && (rollup.startDate.IsZero() || r.startDate.Before(rollup.startDate)) {
+			rollup.startDate = r.startDate
+		}
+		if r.endDate.After(rollup.endDate) {
+			rollup.endDate = r.endDate
+		}
+		rollup.storyPoints += r.storyPoints
+		rollup.estimate += r.estimate
+	}
+	rollup.percentDone /= float64(len(subtasks))
+
+	visited[task.ID] = rollup
+	return rollup, nil
+}
+
+// applySubtaskRollup updates a task with the values computed from its subtasks, if its project is configured to
+// do so. Only parent tasks of a changed task are completed automatically and get an update event, the task
+// which was changed itself is left to its caller. Parents can live in other projects, those the user can't write
+// to are left untouched.
+func applySubtaskRollup(s *xorm.Session, a web.Auth, task *Task, isParent bool) (err error) {
+	if isParent {
+		canWrite, err := (&Task{ID: task.ID}).CanWrite(s, a)
+		if err != nil {
+			return err
+		}
+		if !canWrite {
+			return nil
+		}
+	}
+
+	project, err := GetProjectSimpleByID(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	autoComplete := isParent && project.AutoCompleteParentTasks
+	if !project.SubtaskRollup && !autoComplete {
+		return nil
+	}
+
+	subtasks, err := getSubtasks(s, task.ID)
+	if err != nil || len(subtasks) == 0 {
+		return err
+	}
+
+	cols := []string{}
+	if project.SubtaskRollup {
+		rollup, err := getSubtaskRollup(s, task, make(map[int64]*subtaskRollup), make(map[int64]bool))
+		if err != nil {
+			return err
+		}
+
+		if rollup.percentDone != task.PercentDone {
+			task.PercentDone = rollup.percentDone
+			cols = append(cols, "percent_done")
+		}
+		if !rollup.startDate.IsZero() && !rollup.startDate.Equal(task.StartDate) {
+			task.StartDate = rollup.startDate
+			cols = append(cols, "start_date")
+		}
+		if !rollup.endDate.IsZero() && !rollup.endDate.Equal(task.EndDate) {
+			task.EndDate = rollup.endDate
+			cols = append(cols, "end_date")
+		}
+		if rollup.storyPoints != 0 && rollup.storyPoints != task.StoryPoints {
+			task.StoryPoints = rollup.storyPoints
+			cols = append(cols, "story_points")
+		}
+		if rollup.estimate != 0 && rollup.estimate != task.Estimate {
+			task.Estimate = rollup.estimate
+			cols = append(cols, "estimate")
+		}
+	}
+
+	// Repeating tasks would only move on to their next occurrence, that's not what completing them here should do.
+	completed := false
+	if autoComplete && !task.Done && !task.isRepeating() {
+		completed = true
+		for _, subtask := range subtasks {
+			if !subtask.Done {
+				completed = false
+				break
+			}
+		}
+
+		if completed {
+			err = checkTaskCanBeCompleted(s, task)
+			if IsErrTaskIsBlocked(err) {
+				completed = false
+				err = nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if completed {
+			task.Done = true
+			task.DoneAt = time.Now()
+			cols = append(cols, "done", "done_at")
+		}
+	}
+
+	if len(cols) == 0 {
+		return nil
+	}
+
+	_, err = s.ID(task.ID).Cols(cols...).Update(task)
+	if err != nil {
+		return err
+	}
+
+	if completed {
+		views := []*ProjectView{}
+		err = s.
+			Where("project_id = ? AND view_kind = ? AND bucket_configuration_mode = ?",
+				task.ProjectID, ProjectViewKindKanban, BucketConfigurationModeManual).
+			Find(&views)
+		if err != nil {
+			return err
+		}
+
+		err = task.moveTaskToDoneBuckets(s, a, views)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !isParent {
+		return nil
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	return events.Dispatch(&TaskUpdatedEvent{
+		Task: task,
+		Doer: doer,
+	})
+}
+
+// rollupSubtasksIntoParents updates all parent tasks of a task with the values of their subtasks, walking up
+// the task hierarchy the same way checkTaskRelationCycle does.
+func rollupSubtasksIntoParents(s *xorm.Session, a web.Auth, taskID int64, visited map[int64]bool, currentPath map[int64]bool) (err error) {
+	if visited == nil {
+		visited = make(map[int64]bool)
+	}
+
+	if currentPath == nil {
+		currentPath = make(map[int64]bool)
+	}
+
+	if visited[taskID] || currentPath[taskID] {
+		return nil
+	}
+
+	visited[taskID] = true
+	currentPath[taskID] = true
+
+	parentIDs, err := getRelatedTaskIDs(s, taskID, RelationKindParenttask)
+	if err != nil {
+		return err
+	}
+
+	for _, parentID := range parentIDs {
+		if currentPath[parentID] {
+			continue
+		}
+
+		parent, err := GetTaskByIDSimple(s, parentID)
+		if err != nil {
+			if IsErrTaskDoesNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		err = applySubtaskRollup(s, a, &parent, true)
+		if err != nil {
+			return err
+		}
+
+		err = rollupSubtasksIntoParents(s, a, parentID, visited, currentPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the current node from the currentPath to avoid false positives
+	delete(currentPath, taskID)
+
+	return nil
+}
+
+// rollupSubtasksIntoTaskAndParents updates a task and all of its parents after its subtasks changed.
+func rollupSubtasksIntoTaskAndParents(s *xorm.Session, a web.Auth, taskID int64) error {
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		if IsErrTaskDoesNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = applySubtaskRollup(s, a, &task, true)
+	if err != nil {
+		return err
+	}
+
+	return rollupSubtasksIntoParents(s, a, taskID, nil, nil)
+}
+
+// rollupSubtasksInProject updates all tasks with subtasks in a project, used when the roll-up is enabled for it.
+func rollupSubtasksInProject(s *xorm.Session, a web.Auth, projectID int64) error {
+	parents := []*Task{}
+	err := s.
+		Where("project_id = ?", projectID).
+		And("id IN (SELECT task_id FROM task_relations WHERE relation_kind = ?)", RelationKindSubtask).
+		OrderBy("id asc").
+		Find(&parents)
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range parents {
+		err = applySubtaskRollup(s, a, parent, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollupIntoParentTask updates the parent task of a subtask relation after it was created or removed.
+func (rel *TaskRelation) rollupIntoParentTask(s *xorm.Session, a web.Auth) error {
+	if rel.RelationKind == RelationKindSubtask {
+		return rollupSubtasksIntoTaskAndParents(s, a, rel.TaskID)
+	}
+	if rel.RelationKind == RelationKindParenttask {
+		return rollupSubtasksIntoTaskAndParents(s, a, rel.OtherTaskID)
+	}
+	return nil
+}
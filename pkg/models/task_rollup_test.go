@@ -0,0 +1,248 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+// buildSubtaskHierarchy makes task 3 a second subtask of task 1 (next to task 29 from the fixtures) and task 4
+// a subtask of task 3.
+func buildSubtaskHierarchy(t *testing.T, s *xorm.Session, rollup, autoComplete bool) {
+	for _, rel := range []*TaskRelation{
+		{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindSubtask, CreatedByID: 1},
+		{TaskID: 3, OtherTaskID: 1, RelationKind: RelationKindParenttask, CreatedByID: 1},
+		{TaskID: 3, OtherTaskID: 4, RelationKind: RelationKindSubtask, CreatedByID: 1},
+		{TaskID: 4, OtherTaskID: 3, RelationKind: RelationKindParenttask, CreatedByID: 1},
+	} {
+		_, err := s.Insert(rel)
+		require.NoError(t, err)
+	}
+	_, err := s.ID(1).
+		Cols("subtask_rollup", "auto_complete_parent_tasks").
+		Update(&Project{SubtaskRollup: rollup, AutoCompleteParentTasks: autoComplete})
+	require.NoError(t, err)
+}
+
+func TestTask_SubtaskRollup(t *testing.T) {
+	u := &user.User{ID: 1}
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 8, 17, 0, 0, 0, time.UTC)
+
+	t.Run("progress and dates", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, true, false)
+
+		task := &Task{ID: 4, Title: "task #4 low prio", PercentDone: 0.5, StartDate: start, EndDate: end}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		task = &Task{ID: 29, Title: "task #29 with parent task (1)", Done: true, StartDate: start.Add(-24 * time.Hour)}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		parent, err := GetTaskByIDSimple(s, 3)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, parent.PercentDone, 0.0001)
+		assert.Equal(t, start.Unix(), parent.StartDate.Unix())
+		assert.Equal(t, end.Unix(), parent.EndDate.Unix())
+		assert.False(t, parent.Done)
+
+		root, err := GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.75, root.PercentDone, 0.0001)
+		assert.Equal(t, start.Add(-24*time.Hour).Unix(), root.StartDate.Unix())
+		assert.Equal(t, end.Unix(), root.EndDate.Unix())
+		assert.False(t, root.Done)
+		events.AssertDispatched(t, &TaskUpdatedEvent{})
+	})
+	t.Run("estimates", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, true, false)
+
+		task := &Task{ID: 4, Title: "task #4 low prio", StoryPoints: 3, Estimate: 3600}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		task = &Task{ID: 29, Title: "task #29 with parent task (1)", StoryPoints: 2, Estimate: 1800}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		parent, err := GetTaskByIDSimple(s, 3)
+		require.NoError(t, err)
+		assert.InDelta(t, 3.0, parent.StoryPoints, 0)
+		assert.Equal(t, int64(3600), parent.Estimate)
+
+		root, err := GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 5.0, root.StoryPoints, 0)
+		assert.Equal(t, int64(5400), root.Estimate)
+	})
+	t.Run("progress of parents is always computed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, true, false)
+
+		task := &Task{ID: 3, Title: "task #3 high prio", PercentDone: 0.9}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.0, task.PercentDone, 0.0001)
+	})
+	t.Run("nothing happens without the project setting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, false, false)
+
+		task := &Task{ID: 4, Title: "task #4 low prio", PercentDone: 0.5, Done: true}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+
+		parent, err := GetTaskByIDSimple(s, 3)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.0, parent.PercentDone, 0.0001)
+		assert.False(t, parent.Done)
+	})
+	t.Run("auto complete parents", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, false, true)
+
+		task := &Task{ID: 29, Title: "task #29 with parent task (1)", Done: true}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+
+		root, err := GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.False(t, root.Done)
+
+		task = &Task{ID: 4, Title: "task #4 low prio", Done: true}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		parent, err := GetTaskByIDSimple(s, 3)
+		require.NoError(t, err)
+		assert.True(t, parent.Done)
+		assert.False(t, parent.DoneAt.IsZero())
+
+		root, err = GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.True(t, root.Done)
+	})
+	t.Run("blocked parents are not completed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, false, true)
+		_, err := s.Insert(&TaskRelation{TaskID: 3, OtherTaskID: 5, RelationKind: RelationKindBlocked, CreatedByID: 1})
+		require.NoError(t, err)
+		_, err = s.ID(1).Cols("prevent_completing_blocked_tasks").Update(&Project{PreventCompletingBlockedTasks: true})
+		require.NoError(t, err)
+
+		task := &Task{ID: 4, Title: "task #4 low prio", Done: true}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		parent, err := GetTaskByIDSimple(s, 3)
+		require.NoError(t, err)
+		assert.False(t, parent.Done)
+	})
+	t.Run("removing a subtask", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, true, false)
+		_, err := s.ID(29).Cols("done").Update(&Task{Done: true})
+		require.NoError(t, err)
+
+		rel := &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindSubtask}
+		err = rel.Delete(s, u)
+		require.NoError(t, err)
+
+		root, err := GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, root.PercentDone, 0.0001)
+	})
+	t.Run("enabling the setting rolls up existing tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		buildSubtaskHierarchy(t, s, false, false)
+		_, err := s.ID(29).Cols("done").Update(&Task{Done: true})
+		require.NoError(t, err)
+
+		project := &Project{ID: 1}
+		err = project.ReadOne(s, u)
+		require.NoError(t, err)
+		project.SubtaskRollup = true
+		err = project.Update(s, u)
+		require.NoError(t, err)
+
+		root, err := GetTaskByIDSimple(s, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, root.PercentDone, 0.0001)
+	})
+	t.Run("parents in projects without write access are left alone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Task 18 lives in project 9 which user 1 can only read
+		for _, rel := range []*TaskRelation{
+			{TaskID: 18, OtherTaskID: 4, RelationKind: RelationKindSubtask, CreatedByID: 1},
+			{TaskID: 4, OtherTaskID: 18, RelationKind: RelationKindParenttask, CreatedByID: 1},
+		} {
+			_, err := s.Insert(rel)
+			require.NoError(t, err)
+		}
+		_, err := s.ID(9).
+			Cols("subtask_rollup", "auto_complete_parent_tasks").
+			Update(&Project{SubtaskRollup: true, AutoCompleteParentTasks: true})
+		require.NoError(t, err)
+
+		task := &Task{ID: 4, Title: "task #4 low prio", PercentDone: 0.5, StartDate: start, Done: true}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		parent, err := GetTaskByIDSimple(s, 18)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.0, parent.PercentDone, 0.0001)
+		assert.True(t, parent.StartDate.IsZero())
+		assert.False(t, parent.Done)
+	})
+}
@@ -123,29 +123,20 @@ func getOrderByDBStatement(opts *taskSearchOptions) (orderby string, err error)
 			return "", err
 		}
 
-		var prefix string
-		switch param.sortBy {
-		case taskPropertyPosition:
-			prefix = "task_positions."
-		case taskPropertyBucketID:
-			prefix = "task_buckets."
-		default:
-			prefix = "tasks."
-		}
-
-		// Mysql sorts columns with null values before ones without null value.
-		// Because it does not have support for NULLS FIRST or NULLS LAST we work around this by
-		// first sorting for null (or not null) values and then the order we actually want to.
-		if db.Type() == schemas.MYSQL {
-			orderby += prefix + "`" + param.sortBy + "` IS NULL, "
-		}
-
-		orderby += prefix + "`" + param.sortBy + "` " + param.orderBy.String()
+		if fieldID, is := getCustomFieldIDFromTaskProperty(param.sortBy); is {
+			orderby += getCustomFieldOrderBy(fieldID, param.orderBy)
+		} else {
+			var prefix string
+			switch param.sortBy {
+			case taskPropertyPosition:
+				prefix = "task_positions."
+			case taskPropertyBucketID:
+				prefix = "task_buckets."
+			default:
+				prefix = "tasks."
+			}
 
-		// Postgres and sqlite allow us to control how columns with null values are sorted.
-		// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
-		if db.Type() == schemas.POSTGRES || db.Type() == schemas.SQLITE {
-			orderby += " NULLS LAST"
+			orderby += getNullsLastOrderBy(prefix+"`"+param.sortBy+"`", param.orderBy)
 		}
 
 		if (i + 1) < len(opts.sortby) {
@@ -156,6 +147,27 @@ func getOrderByDBStatement(opts *taskSearchOptions) (orderby string, err error)
 	return
 }
 
+// getNullsLastOrderBy returns an order by statement for a column which sorts null values last in every
+// database we support.
+func getNullsLastOrderBy(column string, order sortOrder) (orderby string) {
+	// Mysql sorts columns with null values before ones without null value.
+	// Because it does not have support for NULLS FIRST or NULLS LAST we work around this by
+	// first sorting for null (or not null) values and then the order we actually want to.
+	if db.Type() == schemas.MYSQL {
+		orderby += column + " IS NULL, "
+	}
+
+	orderby += column + " " + order.String()
+
+	// Postgres and sqlite allow us to control how columns with null values are sorted.
+	// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
+	if db.Type() == schemas.POSTGRES || db.Type() == schemas.SQLITE {
+		orderby += " NULLS LAST"
+	}
+
+	return
+}
+
 func convertFiltersToDBFilterCond(rawFilters []*taskFilter, includeNulls bool) (filterCond builder.Cond, err error) {
 
 	var dbFilters = make([]builder.Cond, 0, len(rawFilters))
@@ -213,6 +225,42 @@ func convertFiltersToDBFilterCond(rawFilters []*taskFilter, includeNulls bool) (
 			continue
 		}
 
+		if _, is := getCustomFieldIDFromTaskProperty(f.field); is {
+			filter, err := getCustomFieldFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			dbFilters = append(dbFilters, filter)
+			continue
+		}
+
+		if f.field == taskPropertyTimeSpent {
+			filter, err := getTimeSpentFilterCond(f)
+			if err != nil {
+				return nil, err
+			}
+			dbFilters = append(dbFilters, filter)
+			continue
+		}
+
+		if f.field == taskPropertyChecklistDone {
+			filter, err := getChecklistDoneFilterCond(f)
+			if err != nil {
+				return nil, err
+			}
+			dbFilters = append(dbFilters, filter)
+			continue
+		}
+
+		if f.field == taskPropertyIsBlocked {
+			filter, err := getIsBlockedFilterCond(f)
+			if err != nil {
+				return nil, err
+			}
+			dbFilters = append(dbFilters, filter)
+			continue
+		}
+
 		if f.field == taskPropertyBucketID {
 			f.field = "task_buckets.`bucket_id`"
 		} else {
@@ -261,6 +309,20 @@ func hasBucketIDInParsedFilter(filters []*taskFilter) bool {
 	return false
 }
 
+// hasPropertyInParsedFilter checks whether any of the filters, including nested ones, filters by the given task property.
+func hasPropertyInParsedFilter(filters []*taskFilter, property string) bool {
+	for _, filter := range filters {
+		if subfilters, is := filter.value.([]*taskFilter); is && hasPropertyInParsedFilter(subfilters, property) {
+			return true
+		}
+		if filter.field == property {
+			return true
+		}
+	}
+
+	return false
+}
+
 //nolint:gocyclo
 func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCount int64, err error) {
 
@@ -315,6 +377,7 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 	if strings.Contains(orderby, "task_positions.") {
 		distinct += ", task_positions.position"
 	}
+	distinct += getCustomFieldSortSelect(opts.sortby)
 
 	var expandSubtasks = false
 	for _, expandable := range opts.expand {
@@ -331,6 +394,14 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 		))
 	}
 
+	if opts.expandsOccurrences() {
+		cond = builder.And(cond, getTaskOccurrencesRangeCond(opts.dateFrom, opts.dateTo))
+	}
+
+	if opts.swimlaneCond != nil {
+		cond = builder.And(cond, opts.swimlaneCond)
+	}
+
 	query := d.s.
 		Distinct(distinct).
 		Where(cond)
@@ -344,6 +415,7 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 			break
 		}
 	}
+	query = joinCustomFieldSortValues(query, opts.sortby)
 
 	if joinTaskBuckets {
 		joinCond := "task_buckets.task_id = tasks.id"
@@ -410,7 +482,7 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 		sub_tasks st ON tr.task_id = st.other_task_id
 		WHERE tr.relation_kind = '`+string(RelationKindSubtask)+`')
 		SELECT other_task_id
-		FROM sub_tasks) AND id NOT IN (`+notIn+`)`, allArgs...).Find(&subtasks)
+		FROM sub_tasks) AND id NOT IN (`+notIn+`) AND deleted IS NULL`, allArgs...).Find(&subtasks)
 		if err != nil {
 			return nil, totalCount, err
 		}
@@ -473,6 +545,8 @@ func convertFilterValues(value interface{}) string {
 		return strconv.Itoa(v)
 	case int64:
 		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
 	case bool:
 		if v {
 			return "true"
@@ -525,6 +599,10 @@ func convertParsedFilterToTypesense(rawFilters []*taskFilter) (filterBy string,
 			f.field = "buckets"
 		}
 
+		if fieldID, is := getCustomFieldIDFromTaskProperty(f.field); is {
+			f.field = getTypesenseCustomFieldName(fieldID)
+		}
+
 		filter := f.field
 
 		switch f.comparator {
@@ -623,6 +701,10 @@ func (t *typesenseTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task,
 			sortBy = "positions.view_" + strconv.FormatInt(param.projectViewID, 10)
 		}
 
+		if fieldID, is := getCustomFieldIDFromTaskProperty(param.sortBy); is {
+			sortBy = getTypesenseCustomFieldName(fieldID)
+		}
+
 		sortbyFields = append(sortbyFields, sortBy+"(missing_values:last):"+param.orderBy.String())
 
 		if usedParams == 2 {
@@ -691,6 +773,7 @@ func (t *typesenseTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task,
 	if strings.Contains(orderby, "task_positions.") {
 		distinct += ", task_positions.position"
 	}
+	distinct += getCustomFieldSortSelect(opts.sortby)
 
 	query := t.s.
 		Distinct(distinct).
@@ -703,6 +786,7 @@ func (t *typesenseTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task,
 			break
 		}
 	}
+	query = joinCustomFieldSortValues(query, opts.sortby)
 
 	err = query.Find(&tasks)
 	return tasks, int64(*result.Found), err
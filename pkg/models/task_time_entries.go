@@ -0,0 +1,531 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskTimeEntry represents a span of time a user spent working on a task
+type TaskTimeEntry struct {
+	// The unique, numeric id of this time entry.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"timeentry"`
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id" param:"task"`
+
+	// The user who tracked this time entry.
+	User   *user.User `xorm:"-" json:"user" valid:"-"`
+	UserID int64      `xorm:"bigint not null INDEX" json:"-"`
+
+	// When the tracked time span started.
+	Start time.Time `xorm:"DATETIME not null 'start_time'" json:"start"`
+	// When the tracked time span ended. If this is null, the time entry is a currently running timer.
+	End time.Time `xorm:"DATETIME null 'end_time'" json:"end"`
+	// The duration of this entry in seconds. Calculated from start and end, you cannot set this value.
+	Duration int64 `xorm:"bigint not null default 0" json:"duration"`
+	// An optional note describing what was done during that time.
+	Note string `xorm:"text null" json:"note"`
+
+	// A timestamp when this time entry was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this time entry was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for time entries
+func (*TaskTimeEntry) TableName() string {
+	return "task_time_entries"
+}
+
+// IsRunning returns true if the time entry does not have an end yet.
+func (te *TaskTimeEntry) IsRunning() bool {
+	return te.End.IsZero()
+}
+
+func (te *TaskTimeEntry) calculateDuration() error {
+	if te.IsRunning() {
+		te.Duration = 0
+		return nil
+	}
+
+	if te.End.Before(te.Start) {
+		return &ErrTimeEntryEndBeforeStart{Start: te.Start, End: te.End}
+	}
+
+	te.Duration = int64(te.End.Sub(te.Start).Seconds())
+	return nil
+}
+
+func getTimeEntryByID(s *xorm.Session, id int64) (te *TaskTimeEntry, err error) {
+	te = &TaskTimeEntry{}
+	exists, err := s.Where("id = ?", id).Get(te)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTimeEntryDoesNotExist{ID: id}
+	}
+	return
+}
+
+// stopRunningTimersForUser ends all timers a user has currently running.
+func stopRunningTimersForUser(s *xorm.Session, userID int64, end time.Time) (stopped []*TaskTimeEntry, err error) {
+	stopped = []*TaskTimeEntry{}
+	err = s.
+		Where("user_id = ?", userID).
+		And(builder.IsNull{"end_time"}).
+		Find(&stopped)
+	if err != nil {
+		return
+	}
+
+	for _, te := range stopped {
+		if end.Before(te.Start) {
+			end = te.Start
+		}
+		te.End = end
+		err = te.calculateDuration()
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.ID(te.ID).Cols("end_time", "duration").Update(te)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Create adds a new time entry to a task
+// @Summary Create a time entry
+// @Description Tracks time spent on a task. If no end is provided, the entry is a running timer. A user can only have one timer running at a time, all other running timers of that user will be stopped.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param entry body models.TaskTimeEntry true "The time entry"
+// @Success 201 {object} models.TaskTimeEntry "The created time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries [put]
+func (te *TaskTimeEntry) Create(s *xorm.Session, a web.Auth) (err error) {
+	task, err := GetTaskByIDSimple(s, te.TaskID)
+	if err != nil {
+		return err
+	}
+
+	te.User, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	te.ID = 0
+	te.UserID = te.User.ID
+
+	if te.Start.IsZero() {
+		te.Start = time.Now()
+	}
+
+	err = te.calculateDuration()
+	if err != nil {
+		return err
+	}
+
+	if te.IsRunning() {
+		_, err = stopRunningTimersForUser(s, te.UserID, te.Start)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Insert(te)
+	if err != nil {
+		return err
+	}
+
+	return events.Dispatch(&TaskTimeEntryCreatedEvent{
+		Task:      &task,
+		TimeEntry: te,
+		Doer:      te.User,
+	})
+}
+
+// ReadOne returns a single time entry
+// @Summary Get one time entry
+// @Description Returns a single time entry of a task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timeentry path int true "Time entry ID"
+// @Success 200 {object} models.TaskTimeEntry "The time entry"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries/{timeentry} [get]
+func (te *TaskTimeEntry) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	stored, err := getTimeEntryByID(s, te.ID)
+	if err != nil {
+		return err
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, []int64{stored.UserID})
+	if err != nil {
+		return err
+	}
+
+	*te = *stored
+	te.User = users[te.UserID]
+	return nil
+}
+
+// ReadAll returns all time entries of a task
+// @Summary Get all time entries of a task
+// @Description Returns all time entries of all users on a task, newest first.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskTimeEntry "The time entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries [get]
+func (te *TaskTimeEntry) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := te.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	entries := []*TaskTimeEntry{}
+	query := s.
+		Where("task_id = ?", te.TaskID).
+		OrderBy("start_time desc, id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	userIDs := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, entry := range entries {
+		entry.User = users[entry.UserID]
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", te.TaskID).
+		Count(&TaskTimeEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+// Update changes a time entry
+// @Summary Update a time entry
+// @Description Updates the start, end and note of a time entry. Only the user who tracked the time can change it. Setting an end stops a running timer.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timeentry path int true "Time entry ID"
+// @Param entry body models.TaskTimeEntry true "The time entry"
+// @Success 200 {object} models.TaskTimeEntry "The updated time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries/{timeentry} [post]
+func (te *TaskTimeEntry) Update(s *xorm.Session, a web.Auth) (err error) {
+	stored, err := getTimeEntryByID(s, te.ID)
+	if err != nil {
+		return err
+	}
+
+	te.TaskID = stored.TaskID
+	te.UserID = stored.UserID
+	te.Created = stored.Created
+	if te.Start.IsZero() {
+		te.Start = stored.Start
+	}
+	if te.End.IsZero() {
+		te.End = stored.End
+	}
+
+	err = te.calculateDuration()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ID(te.ID).
+		Cols("start_time", "end_time", "duration", "note").
+		Update(te)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, te.TaskID)
+	if err != nil {
+		return err
+	}
+
+	// The entry still belongs to the user who tracked it, no matter who changed it
+	users, err := getUsersOrLinkSharesFromIDs(s, []int64{te.UserID})
+	if err != nil {
+		return err
+	}
+	te.User = users[te.UserID]
+
+	doer, _ := GetUserOrLinkShareUser(s, a)
+	return events.Dispatch(&TaskTimeEntryUpdatedEvent{
+		Task:      &task,
+		TimeEntry: te,
+		Doer:      doer,
+	})
+}
+
+// Delete removes a time entry
+// @Summary Delete a time entry
+// @Description Removes a time entry. Only the user who tracked the time can delete it.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timeentry path int true "Time entry ID"
+// @Success 200 {object} models.Message "The time entry was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries/{timeentry} [delete]
+func (te *TaskTimeEntry) Delete(s *xorm.Session, a web.Auth) (err error) {
+	stored, err := getTimeEntryByID(s, te.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ID(te.ID).Delete(&TaskTimeEntry{})
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, stored.TaskID)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := GetUserOrLinkShareUser(s, a)
+	return events.Dispatch(&TaskTimeEntryDeletedEvent{
+		Task:      &task,
+		TimeEntry: stored,
+		Doer:      doer,
+	})
+}
+
+// TaskTimer represents the currently running timer of a user on a task.
+type TaskTimer struct {
+	TaskID int64 `json:"-" param:"task"`
+
+	// The time entry of the running timer. When stopping a timer, this holds the now finished time entry.
+	TimeEntry *TaskTimeEntry `json:"time_entry"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+func getRunningTimeEntry(s *xorm.Session, taskID, userID int64) (te *TaskTimeEntry, exists bool, err error) {
+	te = &TaskTimeEntry{}
+	exists, err = s.
+		Where("task_id = ? AND user_id = ?", taskID, userID).
+		And(builder.IsNull{"end_time"}).
+		Get(te)
+	return
+}
+
+// ReadOne returns the running timer of the current user on a task
+// @Summary Get the running timer
+// @Description Returns the currently running timer of the current user on a task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 200 {object} models.TaskTimer "The running timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "There is no timer running on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/timer [get]
+func (tt *TaskTimer) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	u, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	te, exists, err := getRunningTimeEntry(s, tt.TaskID, u.ID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &ErrNoRunningTimer{TaskID: tt.TaskID}
+	}
+
+	te.User = u
+	tt.TimeEntry = te
+	return nil
+}
+
+// Create starts a timer on a task
+// @Summary Start a timer
+// @Description Starts tracking time on a task now. Any other timer the current user has running will be stopped.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 201 {object} models.TaskTimer "The started timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/timer [put]
+func (tt *TaskTimer) Create(s *xorm.Session, a web.Auth) (err error) {
+	tt.TimeEntry = &TaskTimeEntry{
+		TaskID: tt.TaskID,
+		Start:  time.Now(),
+	}
+	return tt.TimeEntry.Create(s, a)
+}
+
+// Update stops the running timer on a task
+// @Summary Stop a timer
+// @Description Stops the timer the current user has running on a task and returns the resulting time entry.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 200 {object} models.TaskTimer "The stopped timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "There is no timer running on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/timer [post]
+func (tt *TaskTimer) Update(s *xorm.Session, a web.Auth) (err error) {
+	err = tt.ReadOne(s, a)
+	if err != nil {
+		return err
+	}
+
+	tt.TimeEntry.End = time.Now()
+	if tt.TimeEntry.End.Before(tt.TimeEntry.Start) {
+		tt.TimeEntry.End = tt.TimeEntry.Start
+	}
+	return tt.TimeEntry.Update(s, a)
+}
+
+// getTimeSpentForTasks returns the sum of all finished time entries per task, in seconds.
+func getTimeSpentForTasks(s *xorm.Session, taskIDs []int64) (timeSpent map[int64]int64, err error) {
+	timeSpent = make(map[int64]int64, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	type timeSpentSum struct {
+		TaskID int64 `xorm:"task_id"`
+		Sum    int64 `xorm:"sum"`
+	}
+
+	sums := []*timeSpentSum{}
+	err = s.
+		Select("task_id, SUM(duration) AS sum").
+		Table("task_time_entries").
+		In("task_id", taskIDs).
+		GroupBy("task_id").
+		Find(&sums)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sum := range sums {
+		timeSpent[sum.TaskID] = sum.Sum
+	}
+
+	return
+}
+
+func addTimeSpentToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	timeSpent, err := getTimeSpentForTasks(s, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	for taskID, spent := range timeSpent {
+		if task, has := taskMap[taskID]; has {
+			task.TimeSpent = spent
+		}
+	}
+
+	return nil
+}
+
+// parseSecondsFilterValue accepts either a plain number of seconds or a
+// duration like "2h" or "1h30m" and returns the number of seconds.
+func parseSecondsFilterValue(field, rawValue string) (int64, error) {
+	rawValue = strings.TrimSpace(rawValue)
+	seconds, err := strconv.ParseInt(rawValue, 10, 64)
+	if err == nil {
+		return seconds, nil
+	}
+
+	duration, err := time.ParseDuration(rawValue)
+	if err != nil {
+		return 0, ErrInvalidTaskFilterValue{Value: rawValue, Field: field}
+	}
+
+	return int64(duration.Seconds()), nil
+}
+
+func getTimeSpentFilterCond(f *taskFilter) (builder.Cond, error) {
+	return getFilterCond(&taskFilter{
+		field:      "(SELECT COALESCE(SUM(task_time_entries.duration), 0) FROM task_time_entries WHERE task_time_entries.task_id = tasks.id)",
+		value:      f.value,
+		comparator: f.comparator,
+		isNumeric:  true,
+	}, false)
+}
@@ -0,0 +1,98 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read the time entries of a task
+func (te *TaskTimeEntry) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	if te.ID != 0 {
+		stored, err := getTimeEntryByID(s, te.ID)
+		if err != nil {
+			return false, 0, err
+		}
+		if te.TaskID != 0 && stored.TaskID != te.TaskID {
+			return false, 0, &ErrTimeEntryDoesNotExist{ID: te.ID}
+		}
+		te.TaskID = stored.TaskID
+	}
+
+	t := &Task{ID: te.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can track time on a task
+func (te *TaskTimeEntry) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: te.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can change a time entry
+func (te *TaskTimeEntry) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return te.canModifyTimeEntry(s, a)
+}
+
+// CanDelete checks if a user can delete a time entry
+func (te *TaskTimeEntry) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return te.canModifyTimeEntry(s, a)
+}
+
+// Only the user who tracked a time entry can modify it and only as long as they
+// still have write access to the task.
+func (te *TaskTimeEntry) canModifyTimeEntry(s *xorm.Session, a web.Auth) (bool, error) {
+	stored, err := getTimeEntryByID(s, te.ID)
+	if err != nil {
+		return false, err
+	}
+	if te.TaskID != 0 && stored.TaskID != te.TaskID {
+		return false, &ErrTimeEntryDoesNotExist{ID: te.ID}
+	}
+
+	t := &Task{ID: stored.TaskID}
+	canWrite, err := t.CanWrite(s, a)
+	if err != nil || !canWrite {
+		return false, err
+	}
+
+	shareAuth, is := a.(*LinkSharing)
+	if is {
+		return shareAuth.getUserID() == stored.UserID, nil
+	}
+
+	return a.GetID() == stored.UserID, nil
+}
+
+// CanRead checks if a user can see their running timer on a task
+func (tt *TaskTimer) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	t := &Task{ID: tt.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can start a timer on a task
+func (tt *TaskTimer) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: tt.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can stop a timer on a task
+func (tt *TaskTimer) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: tt.TaskID}
+	return t.CanWrite(s, a)
+}
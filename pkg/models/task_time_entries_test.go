@@ -0,0 +1,316 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskTimeEntry_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("finished entry", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(90 * time.Minute),
+			Note:   "test",
+		}
+		err := te.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5400), te.Duration)
+		assert.Equal(t, int64(1), te.User.ID)
+		err = s.Commit()
+		require.NoError(t, err)
+		events.AssertDispatched(t, &TaskTimeEntryCreatedEvent{})
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       te.ID,
+			"task_id":  1,
+			"user_id":  1,
+			"duration": 5400,
+			"note":     "test",
+		}, false)
+
+		// The running timer from the fixtures must not be touched by a finished entry
+		running, err := getTimeEntryByID(s, 3)
+		require.NoError(t, err)
+		assert.True(t, running.IsRunning())
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(-time.Hour),
+		}
+		err := te.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTimeEntryEndBeforeStart(err))
+	})
+	t.Run("nonexisting task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 99999,
+		}
+		err := te.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+	})
+	t.Run("no permission", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 14, // belongs to project 5, which user 1 has no access to
+		}
+		can, err := te.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTimeEntry_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	te := &TaskTimeEntry{TaskID: 2}
+	result, resultCount, total, err := te.ReadAll(s, u, "", 0, 50)
+	require.NoError(t, err)
+	entries := result.([]*TaskTimeEntry)
+	assert.Equal(t, 3, resultCount)
+	assert.Equal(t, int64(3), total)
+	// Newest first
+	assert.Equal(t, int64(4), entries[0].ID)
+	assert.Equal(t, int64(2), entries[1].ID)
+	assert.Equal(t, int64(1), entries[2].ID)
+	assert.Equal(t, int64(2), entries[0].User.ID)
+}
+
+func TestTaskTimeEntry_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:     1,
+			TaskID: 2,
+			Note:   "changed",
+		}
+		can, err := te.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = te.Update(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3600), te.Duration)
+		require.NotNil(t, te.User)
+		assert.Equal(t, "user1", te.User.Username)
+		assert.False(t, te.Created.IsZero())
+		err = s.Commit()
+		require.NoError(t, err)
+		events.AssertDispatched(t, &TaskTimeEntryUpdatedEvent{})
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       1,
+			"note":     "changed",
+			"duration": 3600,
+		}, false)
+	})
+	t.Run("entry of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:     4,
+			TaskID: 2,
+		}
+		can, err := te.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("entry of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:     1,
+			TaskID: 3,
+		}
+		_, err := te.CanUpdate(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+}
+
+func TestTaskTimeEntry_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	te := &TaskTimeEntry{ID: 1, TaskID: 2}
+	can, err := te.CanDelete(s, u)
+	require.NoError(t, err)
+	assert.True(t, can)
+	err = te.Delete(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+	events.AssertDispatched(t, &TaskTimeEntryDeletedEvent{})
+
+	db.AssertMissing(t, "task_time_entries", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestTaskTimer(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("get running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 3}
+		err := tt.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), tt.TimeEntry.ID)
+	})
+	t.Run("no running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 2}
+		err := tt.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+	t.Run("starting a timer stops other running timers", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 1}
+		err := tt.Create(s, u)
+		require.NoError(t, err)
+		assert.True(t, tt.TimeEntry.IsRunning())
+		err = s.Commit()
+		require.NoError(t, err)
+
+		started, err := getTimeEntryByID(s, tt.TimeEntry.ID)
+		require.NoError(t, err)
+		assert.True(t, started.IsRunning())
+		stopped, err := getTimeEntryByID(s, 3)
+		require.NoError(t, err)
+		assert.False(t, stopped.IsRunning())
+		assert.Positive(t, stopped.Duration)
+	})
+	t.Run("stop a timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 3}
+		err := tt.Update(s, u)
+		require.NoError(t, err)
+		assert.False(t, tt.TimeEntry.IsRunning())
+		assert.Positive(t, tt.TimeEntry.Duration)
+	})
+}
+
+func TestTaskTimeEntry_TimeSpent(t *testing.T) {
+	u := &user.User{ID: 1}
+	t.Run("task time spent", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 2}
+		err := task.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10800), task.TimeSpent)
+	})
+	t.Run("filter by duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    "time_spent > 2h",
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, int64(2), tasks[0].ID)
+	})
+	t.Run("filter by seconds", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    "time_spent >= 10800",
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		tasks := result.([]*Task)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, int64(2), tasks[0].ID)
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    "time_spent > lorem",
+		}
+		_, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterValue(err))
+	})
+}
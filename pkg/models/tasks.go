@@ -27,6 +27,7 @@ import (
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/utils"
@@ -71,6 +72,8 @@ type Task struct {
 	RepeatAfter int64 `xorm:"bigint INDEX null" json:"repeat_after" valid:"range(0|9223372036854775807)"`
 	// Can have three possible values which will trigger when the task is marked as done: 0 = repeats after the amount specified in repeat_after, 1 = repeats all dates each months (ignoring repeat_after), 3 = repeats from the current date rather than the last set date.
 	RepeatMode TaskRepeatMode `xorm:"not null default 0" json:"repeat_mode"`
+	// An RFC 5545 recurrence rule like `FREQ=MONTHLY;BYDAY=-1FR` for "every last friday of the month". If this is set, it takes precedence over repeat_after and repeat_mode. The due date (or the start or end date if there is none) is the current occurrence of the series, when marking the task as done all dates move to the next occurrence after now. If the rule has a COUNT, it is reduced with every occurrence. Once the series ends, the task stays done.
+	RepeatRule string `xorm:"text null" json:"repeat_rule"`
 	// The task priority. Can be anything you want, it is possible to sort by this later.
 	Priority int64 `xorm:"bigint null" json:"priority"`
 	// When this task starts.
@@ -85,6 +88,10 @@ type Task struct {
 	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|7)" maxLength:"7"`
 	// Determines how far a task is left from being done
 	PercentDone float64 `xorm:"DOUBLE null" json:"percent_done"`
+	// The estimated effort of this task in story points.
+	StoryPoints float64 `xorm:"DOUBLE null" json:"story_points"`
+	// The estimated effort of this task in seconds.
+	Estimate int64 `xorm:"bigint null" json:"estimate"`
 
 	// The task identifier, based on the project identifier and the task's index
 	Identifier string `xorm:"-" json:"identifier"`
@@ -117,6 +124,10 @@ type Task struct {
 	// A timestamp when this task was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
 
+	// Set when the task was moved to the trash, either on its own or together with its project.
+	Deleted time.Time `xorm:"deleted" json:"-"`
+	TrashID int64     `xorm:"bigint null INDEX" json:"-"`
+
 	// The bucket id. Will only be populated when the task is accessed via a view with buckets.
 	// Can be used to move a task between buckets. In that case, the new bucket must be in the same view as the old one.
 	BucketID int64 `xorm:"-" json:"bucket_id"`
@@ -130,6 +141,28 @@ type Task struct {
 	// Comment count of this task. Only present when fetching tasks with the `expand` parameter set to `comment_count`.
 	CommentCount *int64 `xorm:"-" json:"comment_count,omitempty"`
 
+	// The total time in seconds tracked on this task by all users. This property is read-only, use the time entry endpoints to track time.
+	TimeSpent int64 `xorm:"-" json:"time_spent"`
+
+	// The checklist of this task, ordered by position. This property is read-only, use the checklist endpoints to change it.
+	ChecklistItems []*TaskChecklistItem `xorm:"-" json:"checklist_items"`
+
+	// The percentage (0-100) of checklist items which are done. Null if the task has no checklist.
+	ChecklistDone *float64 `xorm:"-" json:"checklist_done"`
+
+	// True if this task is blocked by at least one task which is not done yet. This property is read-only, use task relations of the kind `blocked` to change it.
+	IsBlocked bool `xorm:"-" json:"is_blocked"`
+
+	// True if this is a computed future occurrence of a repeating task with its dates moved accordingly. Virtual occurrences share the id of their task and do not exist on their own.
+	IsVirtual bool `xorm:"-" json:"is_virtual,omitempty"`
+
+	// Only present after a change which exceeded a soft work-in-progress limit of an assignee.
+	WIPLimitWarnings []*WIPLimitWarning `xorm:"-" json:"wip_limit_warnings,omitempty"`
+
+	// The values of the custom fields of this task's project, keyed by the id of the custom field. Text and select fields hold a string, number fields a number, date fields a date, multiselect fields a list of strings and user fields the id of a user.
+	// When updating a task, only the fields you pass are changed. Set a field to null to remove its value.
+	CustomFields map[int64]interface{} `xorm:"-" json:"custom_fields,omitempty"`
+
 	// Behaves exactly the same as with the TaskCollection.Expand parameter
 	Expand []TaskCollectionExpandable `xorm:"-" json:"-" query:"expand"`
 
@@ -176,9 +209,25 @@ func (t *Task) GetFrontendURL() string {
 	return config.ServicePublicURL.GetString() + "tasks/" + strconv.FormatInt(t.ID, 10)
 }
 
+// normalizeRepeatRule checks the repeat rule of a task and brings it into its canonical form.
+func (t *Task) normalizeRepeatRule() error {
+	if t.RepeatRule == "" {
+		return nil
+	}
+
+	rule, err := ParseRepeatRule(t.RepeatRule)
+	if err != nil {
+		return InvalidFieldErrorWithMessage([]string{"repeat_rule"}, "The repeat rule is invalid: "+err.Error())
+	}
+	t.RepeatRule = rule.String()
+
+	return nil
+}
+
 func (t *Task) isRepeating() bool {
 	return t.RepeatAfter > 0 ||
-		t.RepeatMode == TaskRepeatModeMonth
+		t.RepeatMode == TaskRepeatModeMonth ||
+		t.RepeatRule != ""
 }
 
 type taskFilterConcatinator string
@@ -201,6 +250,12 @@ type taskSearchOptions struct {
 	projectIDs         []int64
 	expand             []TaskCollectionExpandable
 	projectViewID      int64
+	asTaskList         bool
+	dateFrom           time.Time
+	dateTo             time.Time
+	granularity        CalendarGranularity
+	// Only used for kanban swimlanes, Typesense does not know about them.
+	swimlaneCond builder.Cond
 }
 
 // ReadAll is a dummy function to still have that endpoint documented
@@ -212,12 +267,14 @@ type taskSearchOptions struct {
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `story_points`, `estimate`, `uid`, `created`, `updated`. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
 // @Param filter query string false "The filter query to match tasks by. Check out https://vikunja.io/docs/filters for a full explanation of the feature."
 // @Param filter_timezone query string false "The time zone which should be used for date match (statements like "now" resolve to different actual times)"
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
-// @Param expand query []string false "If set to `subtasks`, Vikunja will fetch only tasks which do not have subtasks and then in a second step, will fetch all of these subtasks. This may result in more tasks than the pagination limit being returned, but all subtasks will be present in the response. If set to `buckets`, the buckets of each task will be present in the response. If set to `reactions`, the reactions of each task will be present in the response. If set to `comments`, the first 50 comments of each task will be present in the response. You can set this multiple times with different values."
+// @Param expand query []string false "If set to `subtasks`, Vikunja will fetch only tasks which do not have subtasks and then in a second step, will fetch all of these subtasks. This may result in more tasks than the pagination limit being returned, but all subtasks will be present in the response. If set to `buckets`, the buckets of each task will be present in the response. If set to `reactions`, the reactions of each task will be present in the response. If set to `comments`, the first 50 comments of each task will be present in the response. If set to `occurrences`, only tasks in the date range are returned, each followed by virtual copies for the future occurrences of repeating tasks in it, flagged with `is_virtual`. Occurrences are paginated like tasks, every task has at most 500 of them. You can set this multiple times with different values."
+// @Param date_from query string false "The start of the date range to get tasks for. Required when expanding occurrences."
+// @Param date_to query string false "The end of the date range to get tasks for, exclusive. Required when expanding occurrences."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
 // @Failure 500 {object} models.Message "Internal error"
@@ -309,7 +366,9 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		a:                   a,
 		hasFavoritesProject: hasFavoritesProject,
 	}
-	if config.TypesenseEnabled.GetBool() {
+	// Whether a task is blocked depends on the done state of other tasks, which is not part of the Typesense index.
+	// Typesense also cannot select the repeating tasks which have occurrences in a date range or the tasks of a swimlane.
+	if config.TypesenseEnabled.GetBool() && !hasPropertyInParsedFilter(opts.parsedFilters, taskPropertyIsBlocked) && !opts.expandsOccurrences() && opts.swimlaneCond == nil {
 		var tsSearcher taskSearcher = &typesenseTaskSearcher{
 			s: s,
 		}
@@ -330,6 +389,13 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 }
 
 func getTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, opts *taskSearchOptions, view *ProjectView) (tasks []*Task, resultCount int, totalItems int64, err error) {
+	// Occurrences are part of the result, they have to be expanded before paginating
+	page := opts.page
+	if opts.expandsOccurrences() {
+		opts.page = 0
+		defer func() { opts.page = page }()
+	}
+
 	tasks, resultCount, totalItems, err = getRawTasksForProjects(s, projects, a, opts)
 	if err != nil {
 		return nil, 0, 0, err
@@ -345,6 +411,18 @@ func getTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, opts
 		return nil, 0, 0, err
 	}
 
+	if opts.expandsOccurrences() {
+		tasks = expandTaskOccurrences(tasks, opts.dateFrom, opts.dateTo)
+		totalItems = int64(len(tasks))
+
+		limit, start := getLimitFromPageIndex(page, opts.perPage)
+		if limit > 0 {
+			start = min(start, len(tasks))
+			tasks = tasks[start:min(start+limit, len(tasks))]
+		}
+		resultCount = len(tasks)
+	}
+
 	return tasks, resultCount, totalItems, err
 }
 
@@ -651,6 +729,26 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth, vi
 		return
 	}
 
+	err = addTimeSpentToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = addIsBlockedToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = addChecklistItemsToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = addCustomFieldValuesToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
 	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
 	if err != nil {
 		return
@@ -748,6 +846,8 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth, vi
 				if err != nil {
 					return
 				}
+			case TaskCollectionExpandOccurrences:
+				// dealt with after all other information was added
 			}
 			expanded[expandable] = true
 		}
@@ -808,15 +908,23 @@ func checkBucketLimit(s *xorm.Session, a web.Auth, t *Task, bucket *Bucket) (tas
 			return 0, err
 		}
 	} else {
+		// Tasks in the trash keep their bucket so that they end up there again when restored,
+		// but they must not count towards the limit.
 		taskCount, err = s.
-			Where("bucket_id = ?", bucket.ID).
-			GroupBy("task_id").
+			Join("INNER", "tasks", "tasks.id = task_buckets.task_id").
+			Where("task_buckets.bucket_id = ? AND tasks.deleted IS NULL", bucket.ID).
+			GroupBy("task_buckets.task_id").
 			Count(&TaskBucket{})
 		if err != nil {
 			return 0, err
 		}
 	}
 
+	if bucket.Limit > 0 && view.SwimlaneMode != SwimlaneModeNone && view.ProjectID > 0 {
+		// With swimlanes, the limit applies to each lane separately
+		return taskCount, checkSwimlaneLimit(s, view, t, bucket)
+	}
+
 	if bucket.Limit > 0 && taskCount >= bucket.Limit {
 		return 0, ErrBucketLimitExceeded{TaskID: t.ID, BucketID: bucket.ID, Limit: bucket.Limit}
 	}
@@ -834,7 +942,9 @@ func calculateDefaultPosition(entityID int64, position float64) float64 {
 
 func calculateNextTaskIndex(s *xorm.Session, projectID int64) (nextIndex int64, err error) {
 	latestTask := &Task{}
+	// Tasks in the trash keep their index so that they can be restored without conflicts.
 	_, err = s.
+		Unscoped().
 		Where("project_id = ?", projectID).
 		OrderBy("`index` desc").
 		Get(latestTask)
@@ -853,7 +963,7 @@ func setNewTaskIndex(s *xorm.Session, t *Task) (err error) {
 	}
 
 	// Check if the provided index is already taken
-	exists, err := s.Where("project_id = ? AND `index` = ?", t.ProjectID, t.Index).Exist(&Task{})
+	exists, err := s.Unscoped().Where("project_id = ? AND `index` = ?", t.ProjectID, t.Index).Exist(&Task{})
 	if err != nil {
 		return err
 	}
@@ -919,6 +1029,11 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool, setB
 
 	t.HexColor = utils.NormalizeHex(t.HexColor)
 
+	err = t.normalizeRepeatRule()
+	if err != nil {
+		return err
+	}
+
 	_, err = s.Insert(t)
 	if err != nil {
 		return err
@@ -970,6 +1085,17 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool, setB
 		return err
 	}
 
+	// Update the custom fields
+	if t.CustomFields != nil {
+		if err := setTaskCustomFieldValues(s, t); err != nil {
+			return err
+		}
+		t.CustomFields = nil
+		if err := addCustomFieldValuesToTasks(s, []int64{t.ID}, map[int64]*Task{t.ID: t}); err != nil {
+			return err
+		}
+	}
+
 	t.setIdentifier(p)
 
 	if t.IsFavorite {
@@ -1087,6 +1213,8 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		return
 	}
 
+	_, oldFinish := getTaskPlannedDates(&ot)
+
 	if t.ProjectID == 0 {
 		t.ProjectID = ot.ProjectID
 	}
@@ -1105,6 +1233,16 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		return err
 	}
 
+	// Update the custom fields. Values of fields from the old project are removed when moving the task.
+	if t.ProjectID != ot.ProjectID {
+		if err := removeCustomFieldValuesOfOtherProjects(s, t.ID, t.ProjectID); err != nil {
+			return err
+		}
+	}
+	if err := setTaskCustomFieldValues(s, t); err != nil {
+		return err
+	}
+
 	// All columns to update in a separate variable to be able to add to them
 	colsToUpdate := []string{
 		"title",
@@ -1117,9 +1255,12 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		"end_date",
 		"hex_color",
 		"percent_done",
+		"story_points",
+		"estimate",
 		"project_id",
 		"bucket_id",
 		"repeat_mode",
+		"repeat_rule",
 		"cover_image_attachment_id",
 	}
 
@@ -1171,6 +1312,12 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		if !fieldSet["percent_done"] {
 			t.PercentDone = ot.PercentDone
 		}
+		if !fieldSet["story_points"] {
+			t.StoryPoints = ot.StoryPoints
+		}
+		if !fieldSet["estimate"] {
+			t.Estimate = ot.Estimate
+		}
 		if !fieldSet["project_id"] {
 			t.ProjectID = ot.ProjectID
 		}
@@ -1180,11 +1327,19 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		if !fieldSet["repeat_mode"] {
 			t.RepeatMode = ot.RepeatMode
 		}
+		if !fieldSet["repeat_rule"] {
+			t.RepeatRule = ot.RepeatRule
+		}
 		if !fieldSet["cover_image_attachment_id"] {
 			t.CoverImageAttachmentID = ot.CoverImageAttachmentID
 		}
 	}
 
+	err = t.normalizeRepeatRule()
+	if err != nil {
+		return err
+	}
+
 	// If the task is being moved between projects, make sure to move the bucket + index as well
 	if t.ProjectID != 0 && ot.ProjectID != t.ProjectID {
 		t.Index, err = calculateNextTaskIndex(s, t.ProjectID)
@@ -1195,6 +1350,13 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		colsToUpdate = append(colsToUpdate, "index")
 	}
 
+	if t.Done && !ot.Done {
+		err = checkTaskCanBeCompleted(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
 	views := []*ProjectView{}
 	if (!t.isRepeating() && t.Done != ot.Done) || t.ProjectID != ot.ProjectID {
 		err = s.
@@ -1216,6 +1378,11 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		if err != nil {
 			return err
 		}
+		// Sprints belong to a project, the task cannot stay in the ones of its old project
+		_, err = s.Where("task_id = ?", t.ID).Delete(&SprintTask{})
+		if err != nil {
+			return err
+		}
 
 		for _, view := range views {
 			var bucketID = view.DoneBucketID
@@ -1231,11 +1398,15 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 				TaskID:        t.ID,
 				ProjectViewID: view.ID,
 				ProjectID:     t.ProjectID,
+				incomingTask:  t,
 			}
 			err = updateTaskBucket(s, a, tb)
 			if err != nil {
 				return err
 			}
+			if tb.Task != nil {
+				t.WIPLimitWarnings = append(t.WIPLimitWarnings, tb.Task.WIPLimitWarnings...)
+			}
 
 			tp, err := calculateNewPositionForTask(s, a, t, view)
 			if err != nil {
@@ -1367,10 +1538,21 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 	if t.PercentDone == 0 {
 		ot.PercentDone = 0
 	}
+	// Estimates
+	if t.StoryPoints == 0 {
+		ot.StoryPoints = 0
+	}
+	if t.Estimate == 0 {
+		ot.Estimate = 0
+	}
 	// Repeat from current date
 	if t.RepeatMode == TaskRepeatModeDefault {
 		ot.RepeatMode = TaskRepeatModeDefault
 	}
+	// Repeat rule
+	if t.RepeatRule == "" {
+		ot.RepeatRule = ""
+	}
 	// Is Favorite
 	if !t.IsFavorite {
 		ot.IsFavorite = false
@@ -1382,12 +1564,17 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 
 	_, err = s.ID(t.ID).
 		Cols(colsToUpdate...).
-		Update(ot)
+		Update(&ot)
 	*t = ot
 	if err != nil {
 		return err
 	}
 
+	err = applySubtaskRollup(s, a, t, false)
+	if err != nil {
+		return err
+	}
+
 	// Get the task updated timestamp in a new struct - if we'd just try to put it into t which we already have, it
 	// would still contain the old updated date.
 	nt := &Task{}
@@ -1397,6 +1584,12 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 	}
 	t.Updated = nt.Updated
 
+	t.CustomFields = nil
+	err = addCustomFieldValuesToTasks(s, []int64{t.ID}, map[int64]*Task{t.ID: t})
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	err = events.Dispatch(&TaskUpdatedEvent{
 		Task: t,
@@ -1406,6 +1599,19 @@ func (t *Task) updateSingleTask(s *xorm.Session, a web.Auth, fields []string) (e
 		return err
 	}
 
+	// When the task now finishes later, its successors might need to move as well
+	if _, newFinish := getTaskPlannedDates(t); newFinish.After(oldFinish) {
+		err = shiftSuccessors(s, a, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rollupSubtasksIntoParents(s, a, t.ID, nil, nil)
+	if err != nil {
+		return err
+	}
+
 	return updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
 }
 
@@ -1462,11 +1668,15 @@ func (t *Task) moveTaskToDoneBuckets(s *xorm.Session, a web.Auth, views []*Proje
 			TaskID:        t.ID,
 			ProjectViewID: view.ID,
 			ProjectID:     t.ProjectID,
+			incomingTask:  t,
 		}
 		err = updateTaskBucket(s, a, tb)
 		if err != nil {
 			return err
 		}
+		if tb.Task != nil {
+			t.WIPLimitWarnings = append(t.WIPLimitWarnings, tb.Task.WIPLimitWarnings...)
+		}
 
 		tp := TaskPosition{
 			TaskID:        t.ID,
@@ -1629,6 +1839,65 @@ func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
 	newTask.Done = false
 }
 
+func setTaskDatesFromRepeatRule(oldTask, newTask *Task) {
+	rule, err := ParseRepeatRule(oldTask.RepeatRule)
+	if err != nil {
+		log.Errorf("Could not parse repeat rule %q of task %d: %s", oldTask.RepeatRule, oldTask.ID, err)
+		return
+	}
+
+	// Current time in an extra variable to base all calculations on the same time
+	now := time.Now()
+
+	// The current occurrence of the series is the due date, or any other date if the task does not have one.
+	current := oldTask.DueDate
+	if current.IsZero() {
+		current = oldTask.StartDate
+	}
+	if current.IsZero() {
+		current = oldTask.EndDate
+	}
+	if current.IsZero() {
+		current = now
+	}
+
+	after := now
+	if current.After(now) {
+		after = current
+	}
+
+	next, index, ok := rule.After(current, after)
+	if !ok {
+		// The series has ended, the task stays done.
+		return
+	}
+
+	// All dates keep their distance to the current occurrence
+	diff := next.Sub(current)
+	if !oldTask.DueDate.IsZero() {
+		newTask.DueDate = oldTask.DueDate.Add(diff)
+	}
+	if !oldTask.StartDate.IsZero() {
+		newTask.StartDate = oldTask.StartDate.Add(diff)
+	}
+	if !oldTask.EndDate.IsZero() {
+		newTask.EndDate = oldTask.EndDate.Add(diff)
+	}
+
+	newTask.Reminders = oldTask.Reminders
+	for in, r := range oldTask.Reminders {
+		newTask.Reminders[in].Reminder = r.Reminder.Add(diff)
+	}
+
+	// The next occurrence starts a new series with the occurrences which are left
+	if rule.Count > 0 {
+		rule.Count -= index
+		newTask.RepeatRule = rule.String()
+	}
+
+	newTask.Done = false
+}
+
 // This helper function updates the reminders, doneAt, start, end and due dates of the *old* task
 // and saves the new values in the newTask object.
 // We make a few assumptions here:
@@ -1639,12 +1908,14 @@ func updateDone(oldTask *Task, newTask *Task) (updateDoneAt bool) {
 	doneStatusChanged := oldTask.Done != newTask.Done
 
 	if !oldTask.Done && newTask.Done {
-		switch oldTask.RepeatMode {
-		case TaskRepeatModeMonth:
+		switch {
+		case oldTask.RepeatRule != "":
+			setTaskDatesFromRepeatRule(oldTask, newTask)
+		case oldTask.RepeatMode == TaskRepeatModeMonth:
 			setTaskDatesMonthRepeat(oldTask, newTask)
-		case TaskRepeatModeFromCurrentDate:
+		case oldTask.RepeatMode == TaskRepeatModeFromCurrentDate:
 			setTaskDatesFromCurrentDateRepeat(oldTask, newTask)
-		case TaskRepeatModeDefault:
+		case oldTask.RepeatMode == TaskRepeatModeDefault:
 			setTaskDatesDefault(oldTask, newTask)
 		}
 
@@ -1751,7 +2022,7 @@ func updateTaskLastUpdated(s *xorm.Session, task *Task) error {
 
 // Delete implements the delete method for a task
 // @Summary Delete a task
-// @Description Deletes a task from a project. This does not mean "mark it done".
+// @Description Deletes a task from a project. This does not mean "mark it done". If the trash is enabled, the task is moved to the trash of the user deleting it and can be restored from there until it is purged.
 // @tags task
 // @Produce json
 // @Security JWTKeyAuth
@@ -1770,89 +2041,145 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	if isTrashEnabled() {
+		err = moveTaskToTrash(s, a, fullTask)
+	} else {
+		err = deleteTaskPermanently(s, t.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range fullTask.RelatedTasks[RelationKindParenttask] {
+		err = rollupSubtasksIntoTaskAndParents(s, a, parent.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskDeletedEvent{
+		Task: fullTask,
+		Doer: doer,
+	})
+	if err != nil {
+		return
+	}
+
+	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
+	return
+}
+
+// deleteTaskPermanently removes a task and everything belonging to it, including the files of its attachments.
+// It does not check any permissions and does not dispatch any events.
+func deleteTaskPermanently(s *xorm.Session, taskID int64) (err error) {
 	// Delete assignees
-	if _, err = s.Where("task_id = ?", t.ID).Delete(&TaskAssginee{}); err != nil {
+	if _, err = s.Where("task_id = ?", taskID).Delete(&TaskAssginee{}); err != nil {
 		return err
 	}
 
 	// Delete Favorites
-	err = removeFromFavorite(s, t.ID, a, FavoriteKindTask)
+	_, err = s.Where("entity_id = ? AND kind = ?", taskID, FavoriteKindTask).Delete(&Favorite{})
 	if err != nil {
 		return
 	}
 
 	// Delete label associations
-	_, err = s.Where("task_id = ?", t.ID).Delete(&LabelTask{})
+	_, err = s.Where("task_id = ?", taskID).Delete(&LabelTask{})
 	if err != nil {
 		return
 	}
 
 	// Delete task attachments
-	attachments, err := getTaskAttachmentsByTaskIDs(s, []int64{t.ID})
+	attachments, err := getTaskAttachmentsByTaskIDs(s, []int64{taskID})
 	if err != nil {
 		return err
 	}
 	for _, attachment := range attachments {
-		// Using the attachment delete method here because that takes care of removing all files properly
-		err = attachment.Delete(s, a)
-		if err != nil && !IsErrTaskAttachmentDoesNotExist(err) {
+		_, err = s.Where("id = ?", attachment.ID).Delete(&TaskAttachment{})
+		if err != nil {
+			return err
+		}
+
+		file := &files.File{ID: attachment.FileID}
+		err = file.Delete(s)
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
 			return err
 		}
 	}
 
-	// Delete all comments
-	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskComment{})
+	// Delete all comments, including the ones in the trash
+	_, err = s.Unscoped().Where("task_id = ?", taskID).Delete(&TaskComment{})
 	if err != nil {
 		return
 	}
 
 	// Delete all task unread statuses
-	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskUnreadStatus{})
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskUnreadStatus{})
+	if err != nil {
+		return err
+	}
+
+	// Delete all time entries
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskTimeEntry{})
+	if err != nil {
+		return err
+	}
+
+	// Delete all checklist items
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+
+	// Delete sprint memberships
+	_, err = s.Where("task_id = ?", taskID).Delete(&SprintTask{})
+	if err != nil {
+		return err
+	}
+
+	// Delete all custom field values
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskCustomFieldValue{})
 	if err != nil {
 		return err
 	}
 
 	// Delete all relations
-	_, err = s.Where("task_id = ? OR other_task_id = ?", t.ID, t.ID).Delete(&TaskRelation{})
+	_, err = s.Where("task_id = ? OR other_task_id = ?", taskID, taskID).Delete(&TaskRelation{})
 	if err != nil {
 		return
 	}
 
 	// Delete all reminders
-	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskReminder{})
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskReminder{})
 	if err != nil {
 		return
 	}
 
 	// Delete all positions
-	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskPosition{})
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskPosition{})
 	if err != nil {
 		return
 	}
 
 	// Delete all bucket relations
-	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskBucket{})
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskBucket{})
 	if err != nil {
 		return
 	}
 
-	// Actually delete the task
-	_, err = s.ID(t.ID).Delete(Task{})
-	if err != nil {
-		return err
-	}
-
-	doer, _ := user.GetFromAuth(a)
-	err = events.Dispatch(&TaskDeletedEvent{
-		Task: fullTask,
-		Doer: doer,
-	})
+	// Delete the trash items of the task and its comments
+	_, err = s.Where(builder.Or(
+		builder.Eq{"kind": TrashItemKindTask, "entity_id": taskID},
+		builder.Eq{"kind": TrashItemKindTaskComment, "task_id": taskID},
+	)).Delete(&TrashItem{})
 	if err != nil {
 		return
 	}
 
-	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
-	return
+	// Actually delete the task
+	_, err = s.Unscoped().ID(taskID).Delete(&Task{})
+	return err
 }
 
 // ReadOne gets one task by its ID
@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
@@ -128,6 +129,38 @@ func TestTask_Create(t *testing.T) {
 		require.Error(t, err)
 		assert.True(t, IsErrTaskCannotBeEmpty(err))
 	})
+	t.Run("invalid repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:      "Lorem",
+			ProjectID:  1,
+			RepeatRule: "FREQ=SOMETIMES",
+		}
+		err := task.Create(s, usr)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("with repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:      "Lorem",
+			ProjectID:  1,
+			RepeatRule: "RRULE:freq=monthly;byday=-1fr",
+		}
+		err := task.Create(s, usr)
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=MONTHLY;BYDAY=-1FR", task.RepeatRule)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          task.ID,
+			"repeat_rule": "FREQ=MONTHLY;BYDAY=-1FR",
+		}, false)
+	})
 	t.Run("nonexistant project", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -355,6 +388,41 @@ func TestTask_Update(t *testing.T) {
 			"bucket_id": 1,
 		}, false)
 	})
+	t.Run("repeat rule count is reduced when marked done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		dueDate := time.Now().Add(time.Hour).Truncate(time.Second)
+		task := &Task{
+			ID:         1,
+			Title:      "test",
+			DueDate:    dueDate,
+			RepeatRule: "FREQ=DAILY;COUNT=3",
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+
+		task = &Task{
+			ID:         1,
+			Title:      "test",
+			Done:       true,
+			DueDate:    dueDate,
+			RepeatRule: "FREQ=DAILY;COUNT=3",
+		}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+		assert.False(t, task.Done)
+		assert.True(t, task.DueDate.Equal(dueDate.AddDate(0, 0, 1)))
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          1,
+			"done":        false,
+			"repeat_rule": "FREQ=DAILY;COUNT=2",
+		}, false)
+	})
 	t.Run("repeating tasks should set done_at when marked done", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -559,9 +627,43 @@ func TestTask_Delete(t *testing.T) {
 		err = s.Commit()
 		require.NoError(t, err)
 
+		db.AssertCount(t, "tasks", builder.Eq{"id": 1}.And(builder.IsNull{"deleted"}), 0)
+		db.AssertExists(t, "trash", map[string]interface{}{
+			"kind":          TrashItemKindTask,
+			"entity_id":     1,
+			"project_id":    1,
+			"deleted_by_id": 1,
+		}, false)
+		// Everything belonging to the task is kept so that it can be restored
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id": 1,
+		}, false)
+	})
+	t.Run("permanently without trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		config.ServiceTrashRetentionDays.Set(0)
+		defer config.ServiceTrashRetentionDays.Set(30)
+
+		task := &Task{
+			ID: 1,
+		}
+		err := task.Delete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
 		db.AssertMissing(t, "tasks", map[string]interface{}{
 			"id": 1,
 		})
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"task_id": 1,
+		})
+		db.AssertMissing(t, "trash", map[string]interface{}{
+			"kind":      TrashItemKindTask,
+			"entity_id": 1,
+		})
 	})
 }
 
@@ -936,6 +1038,102 @@ func TestUpdateDone(t *testing.T) {
 				assert.False(t, newTask.Done)
 			})
 		})
+		t.Run("repeat rule", func(t *testing.T) {
+			t.Run("last friday of the month", func(t *testing.T) {
+				oldTask := &Task{
+					Done:       false,
+					RepeatRule: "FREQ=MONTHLY;BYDAY=-1FR",
+					DueDate:    time.Date(2019, 1, 25, 9, 0, 0, 0, config.GetTimeZone()),
+					StartDate:  time.Date(2019, 1, 24, 9, 0, 0, 0, config.GetTimeZone()),
+				}
+				newTask := &Task{
+					Done: true,
+				}
+
+				updateDone(oldTask, newTask)
+
+				assert.True(t, newTask.DueDate.After(time.Now()))
+				assert.Equal(t, time.Friday, newTask.DueDate.Weekday())
+				assert.NotEqual(t, newTask.DueDate.Month(), newTask.DueDate.AddDate(0, 0, 7).Month())
+				assert.Equal(t, 9, newTask.DueDate.Hour())
+				assert.Equal(t, oldTask.DueDate.Sub(oldTask.StartDate), newTask.DueDate.Sub(newTask.StartDate))
+				assert.False(t, newTask.Done)
+			})
+			t.Run("count is reduced", func(t *testing.T) {
+				dueDate := time.Now().Add(time.Hour).Truncate(time.Second)
+				oldTask := &Task{
+					Done:       false,
+					RepeatRule: "FREQ=DAILY;COUNT=3",
+					DueDate:    dueDate,
+					Reminders: []*TaskReminder{
+						{
+							Reminder: dueDate.Add(-time.Hour),
+						},
+					},
+				}
+				newTask := &Task{
+					Done: true,
+				}
+
+				updateDone(oldTask, newTask)
+
+				assert.True(t, newTask.DueDate.Equal(dueDate.AddDate(0, 0, 1)))
+				assert.True(t, newTask.Reminders[0].Reminder.Equal(dueDate.AddDate(0, 0, 1).Add(-time.Hour)))
+				assert.Equal(t, "FREQ=DAILY;COUNT=2", newTask.RepeatRule)
+				assert.False(t, newTask.Done)
+			})
+			t.Run("series ended", func(t *testing.T) {
+				dueDate := time.Now().Add(time.Hour).Truncate(time.Second)
+				oldTask := &Task{
+					Done:       false,
+					RepeatRule: "FREQ=DAILY;COUNT=1",
+					DueDate:    dueDate,
+				}
+				newTask := &Task{
+					Done:    true,
+					DueDate: dueDate,
+				}
+
+				updateDone(oldTask, newTask)
+
+				assert.True(t, newTask.DueDate.Equal(dueDate))
+				assert.True(t, newTask.Done)
+			})
+			t.Run("until in the past", func(t *testing.T) {
+				dueDate := time.Unix(1550000000, 0)
+				oldTask := &Task{
+					Done:       false,
+					RepeatRule: "FREQ=WEEKLY;UNTIL=20190401T000000Z",
+					DueDate:    dueDate,
+				}
+				newTask := &Task{
+					Done:    true,
+					DueDate: dueDate,
+				}
+
+				updateDone(oldTask, newTask)
+
+				assert.True(t, newTask.DueDate.Equal(dueDate))
+				assert.True(t, newTask.Done)
+			})
+			t.Run("takes precedence over repeat after", func(t *testing.T) {
+				oldTask := &Task{
+					Done:        false,
+					RepeatAfter: 3600,
+					RepeatRule:  "FREQ=YEARLY",
+					DueDate:     time.Unix(1550000000, 0),
+				}
+				newTask := &Task{
+					Done: true,
+				}
+
+				updateDone(oldTask, newTask)
+
+				assert.Equal(t, oldTask.DueDate.YearDay(), newTask.DueDate.YearDay())
+				assert.True(t, newTask.DueDate.After(time.Now()))
+				assert.False(t, newTask.Done)
+			})
+		})
 	})
 }
 
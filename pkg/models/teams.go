@@ -352,6 +352,12 @@ func (t *Team) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	// Delete the team's webhooks
+	err = deleteWebhooks(s, builder.Eq{"team_id": t.ID})
+	if err != nil {
+		return
+	}
+
 	return events.Dispatch(&TeamDeletedEvent{
 		Team: t,
 		Doer: a,
@@ -0,0 +1,1047 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TemplateKind defines what a template creates
+type TemplateKind string
+
+const (
+	TemplateKindTask    TemplateKind = "task"
+	TemplateKindProject TemplateKind = "project"
+)
+
+// Template is a saved task or project which can be created again and again, with all dates relative to an anchor
+// date and placeholders like {{name}} in titles and descriptions.
+type Template struct {
+	// The unique, numeric id of this template.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"template"`
+	// The title of the template. Defaults to the title of the task or project it was created from.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// The description of the template.
+	Description string `xorm:"longtext null" json:"description"`
+	// Whether the template creates tasks in an existing project or a whole new project.
+	Kind TemplateKind `xorm:"varchar(10) not null" json:"kind"`
+	// Everything the template creates. All dates are saved as offsets in seconds from the anchor date.
+	// Not returned when listing templates.
+	Content *TemplateContent `xorm:"json not null" json:"content,omitempty"`
+	// All placeholders used in the titles and descriptions of the template. A value has to be provided for each of
+	// them when creating something from the template.
+	Placeholders []string `xorm:"-" json:"placeholders"`
+
+	// When creating a template: the task to save as a template, together with its subtasks in the same project.
+	TaskID int64 `xorm:"-" json:"task_id,omitempty"`
+	// When creating a template: the project to save as a template, with its views, buckets and tasks.
+	ProjectID int64 `xorm:"-" json:"project_id,omitempty"`
+	// When creating a template from a task or project: all dates are saved relative to this date.
+	// Defaults to the start of the day of the earliest date in the task or project.
+	AnchorDate time.Time `xorm:"-" json:"anchor_date"`
+
+	OwnerID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The user who owns this template.
+	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
+
+	// A timestamp when this template was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this template was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for templates
+func (*Template) TableName() string {
+	return "templates"
+}
+
+// TemplateContent holds everything a template creates.
+type TemplateContent struct {
+	// The project to create. Only set for project templates.
+	Project *TemplateProject `json:"project,omitempty"`
+	// The tasks to create. The first one is the main task of a task template, the others are its subtasks.
+	Tasks []*TemplateTask `json:"tasks"`
+}
+
+// TemplateProject is the project of a project template.
+type TemplateProject struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	HexColor    string          `json:"hex_color"`
+	Views       []*TemplateView `json:"views"`
+}
+
+// TemplateView is a view of a project template.
+type TemplateView struct {
+	Title                   string                              `json:"title"`
+	ViewKind                ProjectViewKind                     `json:"view_kind" swaggertype:"string" enums:"list,gantt,table,kanban,calendar"`
+	Filter                  *TaskCollection                     `json:"filter"`
+	Position                float64                             `json:"position"`
+	BucketConfigurationMode BucketConfigurationModeKind         `json:"bucket_configuration_mode" swaggertype:"string" enums:"none,manual,filter"`
+	BucketConfiguration     []*ProjectViewBucketConfiguration   `json:"bucket_configuration"`
+	Buckets                 []*TemplateBucket                   `json:"buckets"`
+	SwimlaneMode            SwimlaneModeKind                    `json:"swimlane_mode" swaggertype:"string" enums:"none,assignee,label,priority,parent_task,filter"`
+	SwimlaneConfiguration   []*ProjectViewSwimlaneConfiguration `json:"swimlane_configuration"`
+	// The index of the default bucket in buckets.
+	DefaultBucket *int `json:"default_bucket"`
+	// The index of the done bucket in buckets.
+	DoneBucket *int `json:"done_bucket"`
+}
+
+// TemplateBucket is a bucket of a manual kanban view of a project template.
+type TemplateBucket struct {
+	Title    string  `json:"title"`
+	Limit    int64   `json:"limit"`
+	Position float64 `json:"position"`
+}
+
+// TemplateTask is a task of a template. All dates are offsets in seconds from the anchor date.
+type TemplateTask struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Priority    int64               `json:"priority"`
+	HexColor    string              `json:"hex_color"`
+	PercentDone float64             `json:"percent_done"`
+	StoryPoints float64             `json:"story_points"`
+	Estimate    int64               `json:"estimate"`
+	RepeatAfter int64               `json:"repeat_after"`
+	RepeatMode  TaskRepeatMode      `json:"repeat_mode"`
+	RepeatRule  string              `json:"repeat_rule"`
+	DueOffset   *int64              `json:"due_offset"`
+	StartOffset *int64              `json:"start_offset"`
+	EndOffset   *int64              `json:"end_offset"`
+	Reminders   []*TemplateReminder `json:"reminders"`
+	LabelIDs    []int64             `json:"label_ids"`
+	Relations   []*TemplateRelation `json:"relations"`
+	// The bucket index the task is in, per view index. Only used for project templates.
+	Buckets map[int]int `json:"buckets"`
+	// The position of the task, per view index. Only used for project templates.
+	Positions map[int]float64 `json:"positions"`
+}
+
+// TemplateReminder is a reminder of a template task. Either the offset or the relative period and relation are set.
+type TemplateReminder struct {
+	Offset         *int64           `json:"offset"`
+	RelativePeriod int64            `json:"relative_period"`
+	RelativeTo     ReminderRelation `json:"relative_to"`
+}
+
+// TemplateRelation is a relation between two tasks of a template.
+type TemplateRelation struct {
+	// The index of the other task in the tasks of the template.
+	Task         int          `json:"task"`
+	RelationKind RelationKind `json:"relation_kind"`
+}
+
+var templatePlaceholderRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+func getTemplateByID(s *xorm.Session, id int64) (tpl *Template, err error) {
+	tpl = &Template{}
+	exists, err := s.Where("id = ?", id).Get(tpl)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTemplateDoesNotExist{ID: id}
+	}
+	return
+}
+
+// getPlaceholders returns all placeholders used in the template, sorted and without duplicates.
+func (tc *TemplateContent) getPlaceholders() (placeholders []string) {
+	texts := []string{}
+	if tc.Project != nil {
+		texts = append(texts, tc.Project.Title, tc.Project.Description)
+	}
+	for _, t := range tc.Tasks {
+		texts = append(texts, t.Title, t.Description)
+	}
+
+	for _, text := range texts {
+		for _, match := range templatePlaceholderRegex.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(placeholders, match[1]) {
+				placeholders = append(placeholders, match[1])
+			}
+		}
+	}
+	slices.Sort(placeholders)
+
+	return
+}
+
+//nolint:gocyclo
+func (tc *TemplateContent) validate(kind TemplateKind) error {
+	if kind != TemplateKindTask && kind != TemplateKindProject {
+		return InvalidFieldErrorWithMessage([]string{"kind"}, "The kind must be either task or project.")
+	}
+	if kind == TemplateKindTask && (tc.Project != nil || len(tc.Tasks) == 0) {
+		return InvalidFieldErrorWithMessage([]string{"content"}, "A task template needs at least one task and no project.")
+	}
+	if kind == TemplateKindProject && (tc.Project == nil || tc.Project.Title == "") {
+		return InvalidFieldErrorWithMessage([]string{"content"}, "A project template needs a project with a title.")
+	}
+
+	var views []*TemplateView
+	if tc.Project != nil {
+		views = tc.Project.Views
+	}
+	for _, view := range views {
+		if view.Title == "" {
+			return InvalidFieldErrorWithMessage([]string{"content"}, "Every view needs a title.")
+		}
+		for _, bucket := range []*int{view.DefaultBucket, view.DoneBucket} {
+			if bucket != nil && (*bucket < 0 || *bucket >= len(view.Buckets)) {
+				return InvalidFieldErrorWithMessage([]string{"content"}, "The default or done bucket of the view "+view.Title+" does not exist.")
+			}
+		}
+	}
+
+	for i, t := range tc.Tasks {
+		if t.Title == "" {
+			return InvalidFieldErrorWithMessage([]string{"content"}, "Every task needs a title.")
+		}
+		if t.RepeatRule != "" {
+			if _, err := ParseRepeatRule(t.RepeatRule); err != nil {
+				return InvalidFieldErrorWithMessage([]string{"content"}, "The repeat rule of the task "+t.Title+" is invalid: "+err.Error())
+			}
+		}
+		for _, r := range t.Relations {
+			if r.Task < 0 || r.Task >= len(tc.Tasks) || r.Task == i || !r.RelationKind.isValid() {
+				return InvalidFieldErrorWithMessage([]string{"content"}, "A relation of the task "+t.Title+" is invalid.")
+			}
+		}
+		for viewIndex, bucketIndex := range t.Buckets {
+			if viewIndex < 0 || viewIndex >= len(views) || bucketIndex < 0 || bucketIndex >= len(views[viewIndex].Buckets) {
+				return InvalidFieldErrorWithMessage([]string{"content"}, "A bucket of the task "+t.Title+" does not exist.")
+			}
+		}
+		for viewIndex := range t.Positions {
+			if viewIndex < 0 || viewIndex >= len(views) {
+				return InvalidFieldErrorWithMessage([]string{"content"}, "A position of the task "+t.Title+" belongs to a view which does not exist.")
+			}
+		}
+	}
+
+	return nil
+}
+
+// getTemplateOffset returns the distance of a date from the anchor in seconds. It is made up of whole days and the
+// difference in the time of day so that applying it keeps the time of day, even across daylight saving changes.
+func getTemplateOffset(anchor, date time.Time) *int64 {
+	if date.IsZero() {
+		return nil
+	}
+
+	anchor = anchor.In(config.GetTimeZone())
+	date = date.In(config.GetTimeZone())
+
+	days := repeatRuleDaysBetween(
+		time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, time.UTC),
+		time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+	)
+	secondsOfDay := func(t time.Time) int64 {
+		return int64(t.Hour()*3600 + t.Minute()*60 + t.Second())
+	}
+
+	offset := int64(days)*86400 + secondsOfDay(date) - secondsOfDay(anchor)
+	return &offset
+}
+
+func applyTemplateOffset(anchor time.Time, offset *int64) time.Time {
+	if offset == nil {
+		return time.Time{}
+	}
+
+	return anchor.
+		AddDate(0, 0, int(*offset/86400)).
+		Add(time.Duration(*offset%86400) * time.Second)
+}
+
+// getTemplateTaskIDs returns the ids of a task and all of its subtasks (and their subtasks) in the same project.
+func getTemplateTaskIDs(s *xorm.Session, task *Task) (taskIDs []int64, err error) {
+	taskIDs = []int64{task.ID}
+	current := []int64{task.ID}
+
+	for len(current) > 0 {
+		subtaskIDs := []int64{}
+		err = s.
+			Table("task_relations").
+			Join("INNER", "tasks", "tasks.id = task_relations.other_task_id").
+			Where(builder.And(
+				builder.In("task_relations.task_id", current),
+				builder.Eq{"task_relations.relation_kind": RelationKindSubtask},
+				builder.Eq{"tasks.project_id": task.ProjectID},
+				builder.NotIn("task_relations.other_task_id", taskIDs),
+			)).
+			Cols("task_relations.other_task_id").
+			Find(&subtaskIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		current = []int64{}
+		for _, id := range subtaskIDs {
+			if !slices.Contains(taskIDs, id) {
+				taskIDs = append(taskIDs, id)
+				current = append(current, id)
+			}
+		}
+	}
+
+	return
+}
+
+// buildTemplateTasks saves tasks as template tasks. For project templates, views contains the views of the project
+// to save the buckets and positions of the tasks in them.
+//
+//nolint:gocyclo
+func buildTemplateTasks(s *xorm.Session, taskIDs []int64, views []*ProjectView, anchor time.Time) (templateTasks []*TemplateTask, err error) {
+	taskMap := make(map[int64]*Task, len(taskIDs))
+	err = s.In("id", taskIDs).Find(&taskMap)
+	if err != nil {
+		return nil, err
+	}
+
+	reminders, err := getRemindersForTasks(s, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reminders {
+		if t, has := taskMap[r.TaskID]; has {
+			t.Reminders = append(t.Reminders, r)
+		}
+	}
+
+	// The anchor defaults to the start of the day of the earliest date
+	if anchor.IsZero() {
+		for _, t := range taskMap {
+			dates := []time.Time{t.DueDate, t.StartDate, t.EndDate}
+			for _, r := range t.Reminders {
+				if r.RelativeTo == "" {
+					dates = append(dates, r.Reminder)
+				}
+			}
+			for _, d := range dates {
+				if !d.IsZero() && (anchor.IsZero() || d.Before(anchor)) {
+					anchor = d
+				}
+			}
+		}
+		anchor = anchor.In(config.GetTimeZone())
+		anchor = time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, config.GetTimeZone())
+	}
+
+	// Keeps the order of the task ids, the first task is the main task of a task template
+	indexes := make(map[int64]int, len(taskIDs))
+	for _, id := range taskIDs {
+		t, has := taskMap[id]
+		if !has {
+			continue
+		}
+		indexes[id] = len(templateTasks)
+
+		tt := &TemplateTask{
+			Title:       t.Title,
+			Description: t.Description,
+			Priority:    t.Priority,
+			HexColor:    t.HexColor,
+			PercentDone: t.PercentDone,
+			StoryPoints: t.StoryPoints,
+			Estimate:    t.Estimate,
+			RepeatAfter: t.RepeatAfter,
+			RepeatMode:  t.RepeatMode,
+			RepeatRule:  t.RepeatRule,
+			DueOffset:   getTemplateOffset(anchor, t.DueDate),
+			StartOffset: getTemplateOffset(anchor, t.StartDate),
+			EndOffset:   getTemplateOffset(anchor, t.EndDate),
+		}
+		for _, r := range t.Reminders {
+			reminder := &TemplateReminder{
+				RelativePeriod: r.RelativePeriod,
+				RelativeTo:     r.RelativeTo,
+			}
+			if r.RelativeTo == "" {
+				reminder.Offset = getTemplateOffset(anchor, r.Reminder)
+			}
+			tt.Reminders = append(tt.Reminders, reminder)
+		}
+		templateTasks = append(templateTasks, tt)
+	}
+
+	labelTasks := []*LabelTask{}
+	err = s.In("task_id", taskIDs).OrderBy("id asc").Find(&labelTasks)
+	if err != nil {
+		return nil, err
+	}
+	for _, lt := range labelTasks {
+		if i, has := indexes[lt.TaskID]; has {
+			templateTasks[i].LabelIDs = append(templateTasks[i].LabelIDs, lt.LabelID)
+		}
+	}
+
+	// Only relations between the saved tasks are kept
+	relations := []*TaskRelation{}
+	err = s.
+		In("task_id", taskIDs).
+		In("other_task_id", taskIDs).
+		OrderBy("id asc").
+		Find(&relations)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range relations {
+		i, has := indexes[r.TaskID]
+		other, hasOther := indexes[r.OtherTaskID]
+		if !has || !hasOther {
+			continue
+		}
+		templateTasks[i].Relations = append(templateTasks[i].Relations, &TemplateRelation{
+			Task:         other,
+			RelationKind: r.RelationKind,
+		})
+	}
+
+	if len(views) == 0 {
+		return templateTasks, nil
+	}
+
+	viewIndexes := make(map[int64]int, len(views))
+	viewIDs := make([]int64, 0, len(views))
+	for i, v := range views {
+		viewIndexes[v.ID] = i
+		viewIDs = append(viewIDs, v.ID)
+	}
+
+	buckets := []*Bucket{}
+	err = s.In("project_view_id", viewIDs).OrderBy("position asc", "id asc").Find(&buckets)
+	if err != nil {
+		return nil, err
+	}
+	bucketIndexes := make(map[int64]int, len(buckets))
+	viewBucketCount := make(map[int64]int, len(views))
+	for _, b := range buckets {
+		bucketIndexes[b.ID] = viewBucketCount[b.ProjectViewID]
+		viewBucketCount[b.ProjectViewID]++
+	}
+
+	taskBuckets := []*TaskBucket{}
+	err = s.In("task_id", taskIDs).In("project_view_id", viewIDs).Find(&taskBuckets)
+	if err != nil {
+		return nil, err
+	}
+	for _, tb := range taskBuckets {
+		i, has := indexes[tb.TaskID]
+		bucketIndex, hasBucket := bucketIndexes[tb.BucketID]
+		if !has || !hasBucket {
+			continue
+		}
+		if templateTasks[i].Buckets == nil {
+			templateTasks[i].Buckets = make(map[int]int)
+		}
+		templateTasks[i].Buckets[viewIndexes[tb.ProjectViewID]] = bucketIndex
+	}
+
+	positions := []*TaskPosition{}
+	err = s.In("task_id", taskIDs).In("project_view_id", viewIDs).Find(&positions)
+	if err != nil {
+		return nil, err
+	}
+	for _, tp := range positions {
+		i, has := indexes[tp.TaskID]
+		if !has {
+			continue
+		}
+		if templateTasks[i].Positions == nil {
+			templateTasks[i].Positions = make(map[int]float64)
+		}
+		templateTasks[i].Positions[viewIndexes[tp.ProjectViewID]] = tp.Position
+	}
+
+	return templateTasks, nil
+}
+
+func buildTemplateProject(s *xorm.Session, projectID int64, anchor time.Time) (content *TemplateContent, err error) {
+	project, err := GetProjectSimpleByID(s, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := getViewsForProject(s, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	content = &TemplateContent{
+		Project: &TemplateProject{
+			Title:       project.Title,
+			Description: project.Description,
+			HexColor:    project.HexColor,
+			Views:       make([]*TemplateView, 0, len(views)),
+		},
+	}
+
+	for _, view := range views {
+		tv := &TemplateView{
+			Title:                   view.Title,
+			ViewKind:                view.ViewKind,
+			Filter:                  view.Filter,
+			Position:                view.Position,
+			BucketConfigurationMode: view.BucketConfigurationMode,
+			BucketConfiguration:     view.BucketConfiguration,
+			Buckets:                 []*TemplateBucket{},
+			SwimlaneMode:            view.SwimlaneMode,
+			SwimlaneConfiguration:   view.SwimlaneConfiguration,
+		}
+
+		buckets := []*Bucket{}
+		err = s.Where("project_view_id = ?", view.ID).OrderBy("position asc", "id asc").Find(&buckets)
+		if err != nil {
+			return nil, err
+		}
+		for i, b := range buckets {
+			if b.ID == view.DefaultBucketID {
+				tv.DefaultBucket = &i
+			}
+			if b.ID == view.DoneBucketID {
+				tv.DoneBucket = &i
+			}
+			tv.Buckets = append(tv.Buckets, &TemplateBucket{
+				Title:    b.Title,
+				Limit:    b.Limit,
+				Position: b.Position,
+			})
+		}
+
+		content.Project.Views = append(content.Project.Views, tv)
+	}
+
+	taskIDs := []int64{}
+	err = s.Table("tasks").Where("project_id = ?", project.ID).OrderBy("id asc").Cols("id").Find(&taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	content.Tasks, err = buildTemplateTasks(s, taskIDs, views, anchor)
+	return
+}
+
+// Create saves a task or project as a template
+// @Summary Create a template
+// @Description Saves a task (with its subtasks in the same project) or a project (with its views, buckets and tasks) as a template. Labels and relations between the saved tasks are kept, all dates are saved relative to the anchor date. Placeholders like {{name}} in titles and descriptions are filled when creating something from the template. Instead of a task or project, the content of the template can also be provided directly.
+// @tags templates
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template body models.Template true "The task or project to save as a template."
+// @Success 201 {object} models.Template "The created template."
+// @Failure 400 {object} web.HTTPError "Invalid template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task or project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates [put]
+func (tpl *Template) Create(s *xorm.Session, a web.Auth) (err error) {
+	tpl.ID = 0
+	tpl.OwnerID = a.GetID()
+
+	switch {
+	case tpl.TaskID != 0 && tpl.ProjectID != 0:
+		return InvalidFieldErrorWithMessage([]string{"task_id", "project_id"}, "A template can only be created from either a task or a project.")
+	case tpl.TaskID != 0:
+		task, err := GetTaskByIDSimple(s, tpl.TaskID)
+		if err != nil {
+			return err
+		}
+		taskIDs, err := getTemplateTaskIDs(s, &task)
+		if err != nil {
+			return err
+		}
+		tasks, err := buildTemplateTasks(s, taskIDs, nil, tpl.AnchorDate)
+		if err != nil {
+			return err
+		}
+		tpl.Kind = TemplateKindTask
+		tpl.Content = &TemplateContent{Tasks: tasks}
+		if tpl.Title == "" {
+			tpl.Title = task.Title
+		}
+	case tpl.ProjectID != 0:
+		tpl.Content, err = buildTemplateProject(s, tpl.ProjectID, tpl.AnchorDate)
+		if err != nil {
+			return err
+		}
+		tpl.Kind = TemplateKindProject
+		if tpl.Title == "" {
+			tpl.Title = tpl.Content.Project.Title
+		}
+	case tpl.Content == nil:
+		return InvalidFieldErrorWithMessage([]string{"task_id", "project_id", "content"}, "A template needs a task, a project or its content.")
+	}
+
+	if tpl.Content.Tasks == nil {
+		tpl.Content.Tasks = []*TemplateTask{}
+	}
+	err = tpl.Content.validate(tpl.Kind)
+	if err != nil {
+		return err
+	}
+	if tpl.Title == "" {
+		return InvalidFieldErrorWithMessage([]string{"title"}, "The template needs a title.")
+	}
+
+	_, err = s.Insert(tpl)
+	if err != nil {
+		return err
+	}
+
+	tpl.Placeholders = tpl.Content.getPlaceholders()
+	tpl.Owner, err = user.GetUserByID(s, a.GetID())
+	return
+}
+
+// ReadAll returns all templates of the current user
+// @Summary Get all templates
+// @Description Returns all templates of the current user. The content of the templates is not included.
+// @tags templates
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search templates by title."
+// @Success 200 {array} models.Template "The templates"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates [get]
+func (tpl *Template) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(
+		builder.Eq{"owner_id": a.GetID()},
+		db.ILIKE("title", search),
+	)
+
+	templates := []*Template{}
+	err = s.
+		Where(cond).
+		OrderBy("title asc", "id asc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&templates)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	total, err := s.Where(cond).Count(&Template{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	owner, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, t := range templates {
+		t.Placeholders = t.Content.getPlaceholders()
+		t.Content = nil
+		t.Owner = owner
+	}
+
+	return templates, len(templates), total, nil
+}
+
+// ReadOne returns a template
+// @Summary Get one template
+// @Description Returns a template with its content.
+// @tags templates
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Template "The template"
+// @Failure 403 {object} web.HTTPError "The user does not own the template."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{id} [get]
+func (tpl *Template) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	template, err := getTemplateByID(s, tpl.ID)
+	if err != nil {
+		return err
+	}
+	*tpl = *template
+
+	tpl.Placeholders = tpl.Content.getPlaceholders()
+	tpl.Owner, err = user.GetUserByID(s, tpl.OwnerID)
+	return
+}
+
+// Update changes a template
+// @Summary Update a template
+// @Description Changes the title, description or content of a template. The kind of a template cannot be changed.
+// @tags templates
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Template ID"
+// @Param template body models.Template true "The template with updated values."
+// @Success 200 {object} models.Template "The updated template."
+// @Failure 400 {object} web.HTTPError "Invalid template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not own the template."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{id} [post]
+func (tpl *Template) Update(s *xorm.Session, a web.Auth) (err error) {
+	old, err := getTemplateByID(s, tpl.ID)
+	if err != nil {
+		return err
+	}
+
+	tpl.Kind = old.Kind
+	if tpl.Content == nil {
+		tpl.Content = old.Content
+	}
+	if tpl.Content.Tasks == nil {
+		tpl.Content.Tasks = []*TemplateTask{}
+	}
+	err = tpl.Content.validate(tpl.Kind)
+	if err != nil {
+		return err
+	}
+	if tpl.Title == "" {
+		return InvalidFieldErrorWithMessage([]string{"title"}, "The template needs a title.")
+	}
+
+	_, err = s.
+		Where("id = ?", tpl.ID).
+		Cols("title", "description", "content").
+		Update(tpl)
+	if err != nil {
+		return err
+	}
+
+	return tpl.ReadOne(s, a)
+}
+
+// Delete removes a template
+// @Summary Delete a template
+// @Description Deletes a template. Tasks and projects created from it are not changed.
+// @tags templates
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Message "The template was deleted successfully."
+// @Failure 403 {object} web.HTTPError "The user does not own the template."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{id} [delete]
+func (tpl *Template) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", tpl.ID).Delete(&Template{})
+	return
+}
+
+// TemplateInstance creates a task or project from a template
+type TemplateInstance struct {
+	// The template to create something from.
+	TemplateID int64 `json:"-" param:"template"`
+	// For task templates the project to create the tasks in. For project templates the parent project of the
+	// new project, if any.
+	ProjectID int64 `json:"project_id"`
+	// All dates of the template are relative to this date. Defaults to the start of today.
+	AnchorDate time.Time `json:"anchor_date"`
+	// The values for the placeholders of the template.
+	Values map[string]string `json:"values"`
+
+	// The created project. Only set for project templates.
+	Project *Project `json:"project,omitempty"`
+	// The created tasks.
+	Tasks []*Task `json:"tasks"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+// fillTemplatePlaceholders replaces all placeholders in a text with their values. Values are escaped when the text
+// is html, like the descriptions of tasks and projects.
+func fillTemplatePlaceholders(text string, values map[string]string, isHTML bool) string {
+	return templatePlaceholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		value := values[templatePlaceholderRegex.FindStringSubmatch(placeholder)[1]]
+		if isHTML {
+			return html.EscapeString(value)
+		}
+		return value
+	})
+}
+
+// Create creates a task or project from a template
+// @Summary Create a task or project from a template
+// @Description Creates the tasks of a task template in a project or the project of a project template. All dates are moved relative to the anchor date and all placeholders are replaced with the provided values.
+// @tags templates
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Template ID"
+// @Param instance body models.TemplateInstance true "Where to create the template, the anchor date and the placeholder values."
+// @Success 201 {object} models.TemplateInstance "The created tasks and project."
+// @Failure 400 {object} web.HTTPError "A placeholder value is missing."
+// @Failure 403 {object} web.HTTPError "The user does not own the template or cannot create tasks or projects in the target project."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{id}/instantiate [put]
+//
+//nolint:gocyclo
+func (ti *TemplateInstance) Create(s *xorm.Session, a web.Auth) (err error) {
+	tpl, err := getTemplateByID(s, ti.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, placeholder := range tpl.Content.getPlaceholders() {
+		if _, has := ti.Values[placeholder]; !has {
+			missing = append(missing, placeholder)
+		}
+	}
+	if len(missing) > 0 {
+		return &ErrTemplatePlaceholderValueMissing{Placeholders: missing}
+	}
+
+	anchor := ti.AnchorDate.In(config.GetTimeZone())
+	if ti.AnchorDate.IsZero() {
+		now := time.Now().In(config.GetTimeZone())
+		anchor = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.GetTimeZone())
+	}
+
+	projectID := ti.ProjectID
+	if tpl.Kind == TemplateKindProject {
+		ti.Project = &Project{
+			Title:           fillTemplatePlaceholders(tpl.Content.Project.Title, ti.Values, false),
+			Description:     fillTemplatePlaceholders(tpl.Content.Project.Description, ti.Values, true),
+			HexColor:        tpl.Content.Project.HexColor,
+			ParentProjectID: ti.ProjectID,
+		}
+		if ti.Project.Title == "" {
+			ti.Project.Title = tpl.Title
+		}
+		err = CreateProject(s, ti.Project, a, false, false)
+		if err != nil {
+			return err
+		}
+		projectID = ti.Project.ID
+	}
+
+	ti.Tasks = make([]*Task, 0, len(tpl.Content.Tasks))
+	for _, tt := range tpl.Content.Tasks {
+		task := &Task{
+			Title:       fillTemplatePlaceholders(tt.Title, ti.Values, false),
+			Description: fillTemplatePlaceholders(tt.Description, ti.Values, true),
+			ProjectID:   projectID,
+			Priority:    tt.Priority,
+			HexColor:    tt.HexColor,
+			PercentDone: tt.PercentDone,
+			StoryPoints: tt.StoryPoints,
+			Estimate:    tt.Estimate,
+			RepeatAfter: tt.RepeatAfter,
+			RepeatMode:  tt.RepeatMode,
+			RepeatRule:  tt.RepeatRule,
+			DueDate:     applyTemplateOffset(anchor, tt.DueOffset),
+			StartDate:   applyTemplateOffset(anchor, tt.StartOffset),
+			EndDate:     applyTemplateOffset(anchor, tt.EndOffset),
+		}
+		if task.Title == "" {
+			task.Title = tt.Title
+		}
+		for _, r := range tt.Reminders {
+			task.Reminders = append(task.Reminders, &TaskReminder{
+				Reminder:       applyTemplateOffset(anchor, r.Offset),
+				RelativePeriod: r.RelativePeriod,
+				RelativeTo:     r.RelativeTo,
+			})
+		}
+
+		err = createTask(s, task, a, false, true)
+		if err != nil {
+			return err
+		}
+		ti.Tasks = append(ti.Tasks, task)
+	}
+
+	// Labels are only added if the user still has access to them
+	labelAccess := make(map[int64]bool)
+	for i, tt := range tpl.Content.Tasks {
+		for _, labelID := range tt.LabelIDs {
+			has, exists := labelAccess[labelID]
+			if !exists {
+				has, _, err = (&Label{ID: labelID}).hasAccessToLabel(s, a)
+				if err != nil {
+					return err
+				}
+				labelAccess[labelID] = has
+			}
+			if !has {
+				continue
+			}
+			_, err = s.Insert(&LabelTask{TaskID: ti.Tasks[i].ID, LabelID: labelID})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Relations are saved in both directions, no matter if the template has both of them
+	relations := []*TaskRelation{}
+	for i, tt := range tpl.Content.Tasks {
+		for _, r := range tt.Relations {
+			for _, rel := range []*TaskRelation{
+				{TaskID: ti.Tasks[i].ID, OtherTaskID: ti.Tasks[r.Task].ID, RelationKind: r.RelationKind},
+				{TaskID: ti.Tasks[r.Task].ID, OtherTaskID: ti.Tasks[i].ID, RelationKind: getInverseRelation(r.RelationKind)},
+			} {
+				if slices.ContainsFunc(relations, func(existing *TaskRelation) bool {
+					return existing.TaskID == rel.TaskID && existing.OtherTaskID == rel.OtherTaskID && existing.RelationKind == rel.RelationKind
+				}) {
+					continue
+				}
+				rel.CreatedByID = a.GetID()
+				relations = append(relations, rel)
+			}
+		}
+	}
+	if len(relations) > 0 {
+		_, err = s.Insert(&relations)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tpl.Kind == TemplateKindProject {
+		err = ti.createViews(s, a, tpl.Content)
+		if err != nil {
+			return err
+		}
+
+		err = ti.Project.ReadOne(s, a)
+	}
+
+	return
+}
+
+// createViews creates the views and buckets of a project template and puts the created tasks in them.
+func (ti *TemplateInstance) createViews(s *xorm.Session, a web.Auth, content *TemplateContent) (err error) {
+	for viewIndex, tv := range content.Project.Views {
+		view := &ProjectView{
+			Title:                   tv.Title,
+			ProjectID:               ti.Project.ID,
+			ViewKind:                tv.ViewKind,
+			Filter:                  tv.Filter,
+			Position:                tv.Position,
+			BucketConfigurationMode: tv.BucketConfigurationMode,
+			BucketConfiguration:     tv.BucketConfiguration,
+			SwimlaneMode:            tv.SwimlaneMode,
+			SwimlaneConfiguration:   tv.SwimlaneConfiguration,
+		}
+		err = createProjectView(s, view, a, false, false)
+		if err != nil {
+			return err
+		}
+
+		bucketIDs := make([]int64, 0, len(tv.Buckets))
+		for _, tb := range tv.Buckets {
+			bucket := &Bucket{
+				Title:         tb.Title,
+				Limit:         tb.Limit,
+				Position:      tb.Position,
+				ProjectID:     ti.Project.ID,
+				ProjectViewID: view.ID,
+			}
+			err = bucket.Create(s, a)
+			if err != nil {
+				return err
+			}
+			bucketIDs = append(bucketIDs, bucket.ID)
+		}
+
+		if tv.DefaultBucket != nil || tv.DoneBucket != nil {
+			if tv.DefaultBucket != nil {
+				view.DefaultBucketID = bucketIDs[*tv.DefaultBucket]
+			}
+			if tv.DoneBucket != nil {
+				view.DoneBucketID = bucketIDs[*tv.DoneBucket]
+			}
+			err = view.Update(s, a)
+			if err != nil {
+				return err
+			}
+		}
+
+		taskBuckets := []*TaskBucket{}
+		positions := []*TaskPosition{}
+		for i, tt := range content.Tasks {
+			task := ti.Tasks[i]
+
+			if view.ViewKind == ProjectViewKindKanban && view.BucketConfigurationMode == BucketConfigurationModeManual && len(bucketIDs) > 0 {
+				bucketID, has := int64(0), false
+				if bucketIndex, hasIndex := tt.Buckets[viewIndex]; hasIndex {
+					bucketID, has = bucketIDs[bucketIndex], true
+				}
+				if !has {
+					bucketID, err = getDefaultBucketID(s, view)
+					if err != nil {
+						return err
+					}
+				}
+				taskBuckets = append(taskBuckets, &TaskBucket{
+					BucketID:      bucketID,
+					TaskID:        task.ID,
+					ProjectViewID: view.ID,
+				})
+			}
+
+			positions = append(positions, &TaskPosition{
+				TaskID:        task.ID,
+				ProjectViewID: view.ID,
+				Position:      calculateDefaultPosition(task.Index, tt.Positions[viewIndex]),
+			})
+		}
+
+		if len(taskBuckets) > 0 {
+			_, err = s.Insert(&taskBuckets)
+			if err != nil {
+				return err
+			}
+		}
+		if len(positions) > 0 {
+			_, err = s.Insert(&positions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user has the permission to read a template
+func (tpl *Template) CanRead(s *xorm.Session, auth web.Auth) (bool, int, error) {
+	can, err := tpl.canDoTemplate(s, auth)
+	return can, int(PermissionAdmin), err
+}
+
+// CanDelete checks if a user has the permission to delete a template
+func (tpl *Template) CanDelete(s *xorm.Session, auth web.Auth) (bool, error) {
+	return tpl.canDoTemplate(s, auth)
+}
+
+// CanUpdate checks if a user has the permission to update a template
+func (tpl *Template) CanUpdate(s *xorm.Session, auth web.Auth) (bool, error) {
+	// A normal check would replace the passed struct which in our case would override the values we want to update.
+	t := &Template{ID: tpl.ID}
+	return t.canDoTemplate(s, auth)
+}
+
+// CanCreate checks if a user has the permission to create a template. Saving a task or project as a template
+// needs read access to it.
+func (tpl *Template) CanCreate(s *xorm.Session, auth web.Auth) (bool, error) {
+	if _, is := auth.(*LinkSharing); is {
+		return false, nil
+	}
+
+	if tpl.TaskID != 0 {
+		can, _, err := (&Task{ID: tpl.TaskID}).CanRead(s, auth)
+		if err != nil || !can {
+			return false, err
+		}
+	}
+
+	if tpl.ProjectID != 0 {
+		can, _, err := (&Project{ID: tpl.ProjectID}).CanRead(s, auth)
+		if err != nil || !can {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Only owners are allowed to do something with a template
+func (tpl *Template) canDoTemplate(s *xorm.Session, auth web.Auth) (can bool, err error) {
+	if _, is := auth.(*LinkSharing); is {
+		return false, nil
+	}
+
+	t, err := getTemplateByID(s, tpl.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if t.OwnerID != auth.GetID() {
+		return false, nil
+	}
+
+	*tpl = *t
+
+	return true, nil
+}
+
+// CanCreate checks if a user can create something from a template. They need to own the template and be able to
+// create tasks in the target project or, for project templates, be able to create a project there.
+func (ti *TemplateInstance) CanCreate(s *xorm.Session, auth web.Auth) (bool, error) {
+	tpl := &Template{ID: ti.TemplateID}
+	can, err := tpl.canDoTemplate(s, auth)
+	if err != nil || !can {
+		return false, err
+	}
+
+	if tpl.Kind == TemplateKindTask {
+		if ti.ProjectID == 0 {
+			return false, InvalidFieldErrorWithMessage([]string{"project_id"}, "A project is needed to create tasks from a task template.")
+		}
+		return (&Project{ID: ti.ProjectID}).CanWrite(s, auth)
+	}
+
+	return (&Project{ParentProjectID: ti.ProjectID}).CanCreate(s, auth)
+}
@@ -0,0 +1,396 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("from task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		anchor := time.Date(2025, 1, 6, 0, 0, 0, 0, config.GetTimeZone())
+		_, err := s.Where("id = ?", 1).Cols("due_date").Update(&Task{DueDate: anchor.AddDate(0, 0, 3).Add(10 * time.Hour)})
+		require.NoError(t, err)
+		_, err = s.Where("id = ?", 29).Cols("start_date").Update(&Task{StartDate: anchor.Add(9 * time.Hour)})
+		require.NoError(t, err)
+
+		tpl := &Template{TaskID: 1}
+		can, err := tpl.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = tpl.Create(s, u)
+		require.NoError(t, err)
+
+		assert.Equal(t, TemplateKindTask, tpl.Kind)
+		assert.Equal(t, "task #1", tpl.Title)
+		assert.Nil(t, tpl.Content.Project)
+		require.Len(t, tpl.Content.Tasks, 2)
+
+		// The anchor defaults to the start of the day of the earliest date
+		main := tpl.Content.Tasks[0]
+		assert.Equal(t, "task #1", main.Title)
+		require.NotNil(t, main.DueOffset)
+		assert.Equal(t, int64(3*86400+10*3600), *main.DueOffset)
+		assert.Nil(t, main.StartOffset)
+		assert.Equal(t, []int64{4}, main.LabelIDs)
+		// Relations to tasks which are not part of the template are not saved
+		require.Len(t, main.Relations, 1)
+		assert.Equal(t, 1, main.Relations[0].Task)
+		assert.Equal(t, RelationKindSubtask, main.Relations[0].RelationKind)
+
+		subtask := tpl.Content.Tasks[1]
+		assert.Equal(t, "task #29 with parent task (1)", subtask.Title)
+		require.NotNil(t, subtask.StartOffset)
+		assert.Equal(t, int64(9*3600), *subtask.StartOffset)
+
+		db.AssertExists(t, "templates", map[string]interface{}{
+			"id":       tpl.ID,
+			"title":    "task #1",
+			"kind":     "task",
+			"owner_id": 1,
+		}, false)
+	})
+	t.Run("from task with anchor", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		anchor := time.Date(2025, 1, 6, 0, 0, 0, 0, config.GetTimeZone())
+		_, err := s.Where("id = ?", 1).Cols("due_date").Update(&Task{DueDate: anchor.Add(-2 * time.Hour)})
+		require.NoError(t, err)
+
+		tpl := &Template{TaskID: 1, Title: "Onboarding", AnchorDate: anchor}
+		err = tpl.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, "Onboarding", tpl.Title)
+		require.NotNil(t, tpl.Content.Tasks[0].DueOffset)
+		assert.Equal(t, int64(-2*3600), *tpl.Content.Tasks[0].DueOffset)
+	})
+	t.Run("from project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{ProjectID: 1}
+		err := tpl.Create(s, u)
+		require.NoError(t, err)
+
+		assert.Equal(t, TemplateKindProject, tpl.Kind)
+		assert.Equal(t, "Test1", tpl.Title)
+		require.NotNil(t, tpl.Content.Project)
+		require.Len(t, tpl.Content.Project.Views, 4)
+
+		kanban := tpl.Content.Project.Views[3]
+		assert.Equal(t, ProjectViewKindKanban, kanban.ViewKind)
+		require.Len(t, kanban.Buckets, 3)
+		assert.Equal(t, "testbucket1", kanban.Buckets[0].Title)
+		require.NotNil(t, kanban.DefaultBucket)
+		assert.Equal(t, 0, *kanban.DefaultBucket)
+		require.NotNil(t, kanban.DoneBucket)
+		assert.Equal(t, 2, *kanban.DoneBucket)
+
+		assert.NotEmpty(t, tpl.Content.Tasks)
+		assert.Equal(t, "task #1", tpl.Content.Tasks[0].Title)
+		assert.Contains(t, tpl.Content.Tasks[0].Buckets, 3)
+	})
+	t.Run("from content", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{
+			Title: "Checklist",
+			Kind:  TemplateKindTask,
+			Content: &TemplateContent{Tasks: []*TemplateTask{
+				{Title: "Welcome {{name}}", Description: "Meet {{ buddy }} and {{name}}"},
+			}},
+		}
+		err := tpl.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"buddy", "name"}, tpl.Placeholders)
+	})
+	t.Run("task and project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{TaskID: 1, ProjectID: 1}
+		err := tpl.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("nothing to save", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{Title: "Empty"}
+		err := tpl.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("invalid relation", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{
+			Title: "Invalid",
+			Kind:  TemplateKindTask,
+			Content: &TemplateContent{Tasks: []*TemplateTask{
+				{Title: "Task", Relations: []*TemplateRelation{{Task: 1, RelationKind: RelationKindSubtask}}},
+			}},
+		}
+		err := tpl.Create(s, u)
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{TaskID: 14}
+		can, _ := tpl.CanCreate(s, u)
+		assert.False(t, can)
+	})
+}
+
+func TestTemplate_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tpl := &Template{}
+	result, _, total, err := tpl.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.NoError(t, err)
+	templates := result.([]*Template)
+	require.Len(t, templates, 1)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Onboarding", templates[0].Title)
+	assert.Nil(t, templates[0].Content)
+	assert.Equal(t, []string{"name", "team"}, templates[0].Placeholders)
+}
+
+func TestTemplate_Permissions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	can, _, err := (&Template{ID: 1}).CanRead(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	assert.True(t, can)
+
+	can, _, err = (&Template{ID: 1}).CanRead(s, &user.User{ID: 2})
+	require.NoError(t, err)
+	assert.False(t, can)
+
+	can, err = (&Template{ID: 1}).CanUpdate(s, &LinkSharing{ID: 1})
+	require.NoError(t, err)
+	assert.False(t, can)
+
+	_, _, err = (&Template{ID: 9999}).CanRead(s, &user.User{ID: 1})
+	require.Error(t, err)
+	assert.True(t, IsErrTemplateDoesNotExist(err))
+}
+
+func TestTemplate_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tpl := &Template{ID: 1, Title: "New hire", Kind: TemplateKindProject}
+	err := tpl.Update(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	// The kind and content are kept
+	assert.Equal(t, TemplateKindTask, tpl.Kind)
+	assert.Len(t, tpl.Content.Tasks, 2)
+	db.AssertExists(t, "templates", map[string]interface{}{
+		"id":    1,
+		"title": "New hire",
+		"kind":  "task",
+	}, false)
+}
+
+func TestTemplateInstance_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		anchor := time.Date(2025, 3, 3, 0, 0, 0, 0, config.GetTimeZone())
+		ti := &TemplateInstance{
+			TemplateID: 1,
+			ProjectID:  1,
+			AnchorDate: anchor,
+			Values:     map[string]string{"name": "Alice & Bob", "team": "Ops"},
+		}
+		can, err := ti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Create(s, u)
+		require.NoError(t, err)
+
+		assert.Nil(t, ti.Project)
+		require.Len(t, ti.Tasks, 2)
+
+		main := ti.Tasks[0]
+		assert.Equal(t, "Onboard Alice & Bob", main.Title)
+		assert.Equal(t, "<p>Welcome Alice &amp; Bob to Ops!</p>", main.Description)
+		assert.Equal(t, int64(1), main.ProjectID)
+		assert.True(t, anchor.AddDate(0, 0, 3).Equal(main.DueDate))
+		require.Len(t, main.Reminders, 2)
+		assert.True(t, anchor.AddDate(0, 0, 2).Equal(main.Reminders[0].Reminder))
+		assert.Equal(t, ReminderRelationDueDate, main.Reminders[1].RelativeTo)
+
+		subtask := ti.Tasks[1]
+		assert.Equal(t, "Set up a laptop for Alice & Bob", subtask.Title)
+		assert.True(t, anchor.Add(9*time.Hour).Equal(subtask.StartDate))
+		assert.True(t, subtask.DueDate.IsZero())
+
+		db.AssertExists(t, "label_tasks", map[string]interface{}{
+			"task_id":  main.ID,
+			"label_id": 1,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       main.ID,
+			"other_task_id": subtask.ID,
+			"relation_kind": RelationKindSubtask,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       subtask.ID,
+			"other_task_id": main.ID,
+			"relation_kind": RelationKindParenttask,
+		}, false)
+		count, err := s.Where("task_id = ? OR task_id = ?", main.ID, subtask.ID).Count(&TaskRelation{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+	t.Run("missing value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TemplateInstance{
+			TemplateID: 1,
+			ProjectID:  1,
+			Values:     map[string]string{"name": "Alice"},
+		}
+		err := ti.Create(s, u)
+		require.Error(t, err)
+		require.True(t, IsErrTemplatePlaceholderValueMissing(err))
+		assert.Equal(t, []string{"team"}, err.(*ErrTemplatePlaceholderValueMissing).Placeholders)
+	})
+	t.Run("default anchor", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TemplateInstance{
+			TemplateID: 1,
+			ProjectID:  1,
+			Values:     map[string]string{"name": "Alice", "team": "Ops"},
+		}
+		err := ti.Create(s, u)
+		require.NoError(t, err)
+
+		now := time.Now().In(config.GetTimeZone())
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.GetTimeZone())
+		assert.True(t, today.AddDate(0, 0, 3).Equal(ti.Tasks[0].DueDate))
+	})
+	t.Run("project template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		tpl := &Template{ProjectID: 1, Title: "Copy"}
+		err := tpl.Create(s, u)
+		require.NoError(t, err)
+		tpl.Content.Project.Title = "Project for {{client}}"
+		err = tpl.Update(s, u)
+		require.NoError(t, err)
+
+		ti := &TemplateInstance{
+			TemplateID: tpl.ID,
+			Values:     map[string]string{"client": "ACME"},
+		}
+		can, err := ti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Create(s, u)
+		require.NoError(t, err)
+
+		require.NotNil(t, ti.Project)
+		assert.Equal(t, "Project for ACME", ti.Project.Title)
+		assert.Len(t, ti.Tasks, len(tpl.Content.Tasks))
+
+		views, err := getViewsForProject(s, ti.Project.ID)
+		require.NoError(t, err)
+		require.Len(t, views, 4)
+		kanban := views[3]
+		buckets := []*Bucket{}
+		err = s.Where("project_view_id = ?", kanban.ID).OrderBy("position asc").Find(&buckets)
+		require.NoError(t, err)
+		require.Len(t, buckets, 3)
+		assert.Equal(t, buckets[0].ID, kanban.DefaultBucketID)
+		assert.Equal(t, buckets[2].ID, kanban.DoneBucketID)
+
+		count, err := s.Where("project_view_id = ?", kanban.ID).Count(&TaskBucket{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(ti.Tasks)), count)
+	})
+	t.Run("not the owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TemplateInstance{TemplateID: 2, ProjectID: 1}
+		can, err := ti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no write access to project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TemplateInstance{TemplateID: 1, ProjectID: 2}
+		can, _ := ti.CanCreate(s, u)
+		assert.False(t, can)
+	})
+}
@@ -0,0 +1,580 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TrashItemKind is the kind of entity a trash item holds
+type TrashItemKind string
+
+const (
+	TrashItemKindTask        TrashItemKind = "task"
+	TrashItemKindProject     TrashItemKind = "project"
+	TrashItemKindTaskComment TrashItemKind = "task_comment"
+)
+
+// TrashItem is a task, project or task comment which was deleted and can still be restored
+type TrashItem struct {
+	// The unique, numeric id of this trash item.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"trashitem"`
+	// The kind of the deleted entity. Can be `task`, `project` or `task_comment`.
+	Kind TrashItemKind `xorm:"varchar(20) not null INDEX" json:"kind"`
+	// The id of the deleted entity.
+	EntityID int64 `xorm:"bigint not null INDEX" json:"entity_id"`
+	// The title of the deleted task or project or the text of the deleted comment.
+	Title string `xorm:"text null" json:"title"`
+	// The project the deleted entity belonged to. For projects, this is the parent project.
+	ProjectID int64 `xorm:"bigint null INDEX" json:"project_id"`
+	// The task a deleted comment belonged to.
+	TaskID int64 `xorm:"bigint null INDEX" json:"task_id"`
+
+	DeletedByID int64 `xorm:"bigint not null INDEX" json:"-"`
+
+	// A timestamp when this item was moved to the trash.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this item will be removed permanently.
+	PurgeAt time.Time `xorm:"-" json:"purge_at"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for trash items
+func (*TrashItem) TableName() string {
+	return "trash"
+}
+
+func isTrashEnabled() bool {
+	return config.ServiceTrashRetentionDays.GetInt() > 0
+}
+
+func getTrashRetention() time.Duration {
+	return time.Duration(config.ServiceTrashRetentionDays.GetInt()) * 24 * time.Hour
+}
+
+func getTrashItemByID(s *xorm.Session, id int64) (item *TrashItem, err error) {
+	item = &TrashItem{}
+	exists, err := s.Where("id = ?", id).Get(item)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTrashItemDoesNotExist{ID: id}
+	}
+
+	item.PurgeAt = item.Created.Add(getTrashRetention())
+	return
+}
+
+func createTrashItem(s *xorm.Session, a web.Auth, item *TrashItem) (err error) {
+	item.DeletedByID = getUserOrLinkShareID(a)
+	_, err = s.Insert(item)
+	return
+}
+
+// markAsTrashed sets the deleted timestamp and trash id of all rows matching cond which are not in the trash yet.
+// The values to set are taken from bean.
+func markAsTrashed(s *xorm.Session, bean interface{}, cond builder.Cond) (err error) {
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		Where(cond).
+		And("deleted IS NULL").
+		Cols("deleted", "trash_id").
+		Update(bean)
+	return
+}
+
+// unmarkAsTrashed restores all rows which were moved to the trash with the given trash item.
+func unmarkAsTrashed(s *xorm.Session, bean interface{}, trashID int64) (err error) {
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		Where("trash_id = ?", trashID).
+		Cols("trash_id").
+		SetExpr("deleted", "NULL").
+		Update(bean)
+	return
+}
+
+func moveTaskToTrash(s *xorm.Session, a web.Auth, task *Task) (err error) {
+	item := &TrashItem{
+		Kind:      TrashItemKindTask,
+		EntityID:  task.ID,
+		Title:     task.Title,
+		ProjectID: task.ProjectID,
+	}
+	err = createTrashItem(s, a, item)
+	if err != nil {
+		return
+	}
+
+	return markAsTrashed(s, &Task{Deleted: time.Now(), TrashID: item.ID}, builder.Eq{"id": task.ID})
+}
+
+// moveProjectToTrash moves a project, together with the given child projects and all of their tasks, to the trash.
+func moveProjectToTrash(s *xorm.Session, a web.Auth, project *Project, projectIDs []int64) (err error) {
+	item := &TrashItem{
+		Kind:      TrashItemKindProject,
+		EntityID:  project.ID,
+		Title:     project.Title,
+		ProjectID: project.ParentProjectID,
+	}
+	err = createTrashItem(s, a, item)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	err = markAsTrashed(s, &Project{Deleted: now, TrashID: item.ID}, builder.In("id", projectIDs))
+	if err != nil {
+		return
+	}
+
+	return markAsTrashed(s, &Task{Deleted: now, TrashID: item.ID}, builder.In("project_id", projectIDs))
+}
+
+func moveTaskCommentToTrash(s *xorm.Session, a web.Auth, comment *TaskComment, task *Task) (err error) {
+	item := &TrashItem{
+		Kind:      TrashItemKindTaskComment,
+		EntityID:  comment.ID,
+		Title:     comment.Comment,
+		ProjectID: task.ProjectID,
+		TaskID:    task.ID,
+	}
+	err = createTrashItem(s, a, item)
+	if err != nil {
+		return
+	}
+
+	return markAsTrashed(s, &TaskComment{Deleted: time.Now(), TrashID: item.ID}, builder.Eq{"id": comment.ID})
+}
+
+// parentExists checks if the project or task the trashed entity belonged to still exists outside the trash.
+func (t *TrashItem) parentExists(s *xorm.Session) (bool, error) {
+	switch t.Kind {
+	case TrashItemKindTask:
+		return s.Where("id = ?", t.ProjectID).Exist(&Project{})
+	case TrashItemKindProject:
+		if t.ProjectID == 0 {
+			return true, nil
+		}
+		return s.Where("id = ?", t.ProjectID).Exist(&Project{})
+	case TrashItemKindTaskComment:
+		return s.Where("id = ?", t.TaskID).Exist(&Task{})
+	}
+
+	return false, nil
+}
+
+// canWriteParent checks if the user has write access to the project or task the trashed entity belonged to.
+func (t *TrashItem) canWriteParent(s *xorm.Session, a web.Auth) (bool, error) {
+	exists, err := t.parentExists(s)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, &ErrCannotRestoreTrashItem{ID: t.ID}
+	}
+
+	switch t.Kind {
+	case TrashItemKindTask:
+		return (&Project{ID: t.ProjectID}).CanWrite(s, a)
+	case TrashItemKindProject:
+		if t.ProjectID == 0 {
+			return true, nil
+		}
+		return (&Project{ID: t.ProjectID}).CanWrite(s, a)
+	case TrashItemKindTaskComment:
+		return (&Task{ID: t.TaskID}).CanWrite(s, a)
+	}
+
+	return false, nil
+}
+
+// relinkRestoredTasks puts restored tasks back into the buckets and positions of all views of their project
+// and removes everything pointing to buckets, views or tasks which were deleted in the meantime.
+func relinkRestoredTasks(s *xorm.Session, a web.Auth, tasks []*Task) (err error) {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
+
+	_, err = s.
+		In("task_id", taskIDs).
+		NotIn("bucket_id", builder.Select("id").From("buckets")).
+		Delete(&TaskBucket{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		In("task_id", taskIDs).
+		NotIn("project_view_id", builder.Select("id").From("project_views")).
+		Delete(&TaskPosition{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where(builder.Or(
+			builder.In("task_id", taskIDs),
+			builder.In("other_task_id", taskIDs),
+		)).
+		And(builder.Or(
+			builder.NotIn("task_id", builder.Select("id").From("tasks")),
+			builder.NotIn("other_task_id", builder.Select("id").From("tasks")),
+		)).
+		Delete(&TaskRelation{})
+	if err != nil {
+		return
+	}
+
+	existingBuckets := []*TaskBucket{}
+	err = s.In("task_id", taskIDs).Find(&existingBuckets)
+	if err != nil {
+		return
+	}
+	hasBucket := make(map[int64]map[int64]bool, len(tasks))
+	for _, tb := range existingBuckets {
+		if hasBucket[tb.TaskID] == nil {
+			hasBucket[tb.TaskID] = make(map[int64]bool)
+		}
+		hasBucket[tb.TaskID][tb.ProjectViewID] = true
+	}
+
+	existingPositions := []*TaskPosition{}
+	err = s.In("task_id", taskIDs).Find(&existingPositions)
+	if err != nil {
+		return
+	}
+	hasPosition := make(map[int64]map[int64]bool, len(tasks))
+	for _, tp := range existingPositions {
+		if hasPosition[tp.TaskID] == nil {
+			hasPosition[tp.TaskID] = make(map[int64]bool)
+		}
+		hasPosition[tp.TaskID][tp.ProjectViewID] = true
+	}
+
+	for _, t := range tasks {
+		positions, taskBuckets, err := setTaskInBucketInViews(s, t, a, true, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, tb := range taskBuckets {
+			if hasBucket[t.ID][tb.ProjectViewID] {
+				continue
+			}
+			_, err = s.Insert(tb)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, tp := range positions {
+			if hasPosition[t.ID][tp.ProjectViewID] {
+				continue
+			}
+			_, err = s.Insert(tp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func restoreTrashedTasks(s *xorm.Session, a web.Auth, trashID int64) (tasks []*Task, err error) {
+	tasks = []*Task{}
+	err = s.Unscoped().Where("trash_id = ?", trashID).Find(&tasks)
+	if err != nil {
+		return
+	}
+
+	err = unmarkAsTrashed(s, &Task{}, trashID)
+	if err != nil {
+		return
+	}
+
+	err = relinkRestoredTasks(s, a, tasks)
+	if err != nil {
+		return
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	for _, t := range tasks {
+		err = events.Dispatch(&TaskRestoredEvent{
+			Task: t,
+			Doer: doer,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func restoreTrashedProjects(s *xorm.Session, a web.Auth, trashID int64) (err error) {
+	projects := []*Project{}
+	err = s.Unscoped().Where("trash_id = ?", trashID).Find(&projects)
+	if err != nil {
+		return
+	}
+
+	err = unmarkAsTrashed(s, &Project{}, trashID)
+	if err != nil {
+		return
+	}
+
+	for _, p := range projects {
+		err = events.Dispatch(&ProjectRestoredEvent{
+			Project: p,
+			Doer:    a,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// purgeTrashItem permanently deletes a trash item and everything it holds.
+func purgeTrashItem(s *xorm.Session, item *TrashItem) (err error) {
+	switch item.Kind {
+	case TrashItemKindTask:
+		err = deleteTaskPermanently(s, item.EntityID)
+	case TrashItemKindProject:
+		err = deleteProjectPermanently(s, item.EntityID)
+		if IsErrProjectDoesNotExist(err) {
+			err = nil
+		}
+	case TrashItemKindTaskComment:
+		_, err = s.Unscoped().Where("id = ?", item.EntityID).Delete(&TaskComment{})
+	}
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("id = ?", item.ID).Delete(&TrashItem{})
+	return
+}
+
+// ReadAll returns all items the current user moved to the trash
+// @Summary Get all items in the trash
+// @Description Returns all tasks, projects and task comments the current user has deleted and which were not purged yet. Items are purged automatically once their retention period is over.
+// @tags trash
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search items by their title."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.TrashItem "The items in the trash."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash [get]
+func (t *TrashItem) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	where := builder.And(builder.Eq{"deleted_by_id": getUserOrLinkShareID(a)})
+	if search != "" {
+		where = builder.And(where, db.ILIKE("title", search))
+	}
+
+	items := []*TrashItem{}
+	query := s.
+		Where(where).
+		OrderBy("created desc, id desc")
+	limit, start := getLimitFromPageIndex(page, perPage)
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&items)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	retention := getTrashRetention()
+	for _, item := range items {
+		item.PurgeAt = item.Created.Add(retention)
+	}
+
+	numberOfTotalItems, err = s.
+		Where(where).
+		Count(&TrashItem{})
+	return items, len(items), numberOfTotalItems, err
+}
+
+// Delete permanently removes an item from the trash
+// @Summary Purge an item from the trash
+// @Description Permanently deletes an item in the trash together with everything it holds. This cannot be undone.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param trashitem path int true "Trash item ID"
+// @Success 200 {object} models.Message "The item was successfully purged."
+// @Failure 403 {object} web.HTTPError "The item was not deleted by the current user."
+// @Failure 404 {object} web.HTTPError "The item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash/{trashitem} [delete]
+func (t *TrashItem) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	item, err := getTrashItemByID(s, t.ID)
+	if err != nil {
+		return err
+	}
+
+	return purgeTrashItem(s, item)
+}
+
+// TrashItemRestore restores an item from the trash
+type TrashItemRestore struct {
+	TrashItemID int64 `json:"-" param:"trashitem"`
+
+	// The kind of the restored entity.
+	Kind TrashItemKind `json:"kind"`
+	// The restored task, if the item was a task.
+	Task *Task `json:"task,omitempty"`
+	// The restored project, if the item was a project.
+	Project *Project `json:"project,omitempty"`
+	// The restored comment, if the item was a task comment.
+	Comment *TaskComment `json:"comment,omitempty"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+// Update restores an item from the trash
+// @Summary Restore an item from the trash
+// @Description Restores a task, project or task comment from the trash. Restored tasks are put back into the buckets and positions of all views of their project. Restoring a project also restores all child projects and tasks which were deleted with it.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param trashitem path int true "Trash item ID"
+// @Success 200 {object} models.TrashItemRestore "The restored entity."
+// @Failure 403 {object} web.HTTPError "The item was not deleted by the current user or the user does not have write access to the project or task it belonged to."
+// @Failure 404 {object} web.HTTPError "The item does not exist."
+// @Failure 412 {object} web.HTTPError "The project or task the item belonged to does not exist anymore."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash/{trashitem}/restore [post]
+func (r *TrashItemRestore) Update(s *xorm.Session, a web.Auth) (err error) {
+	item, err := getTrashItemByID(s, r.TrashItemID)
+	if err != nil {
+		return err
+	}
+
+	r.Kind = item.Kind
+
+	switch item.Kind {
+	case TrashItemKindTask:
+		_, err = restoreTrashedTasks(s, a, item.ID)
+		if err != nil {
+			return err
+		}
+		r.Task = &Task{ID: item.EntityID}
+		err = r.Task.ReadOne(s, a)
+	case TrashItemKindProject:
+		err = restoreTrashedProjects(s, a, item.ID)
+		if err != nil {
+			return err
+		}
+		_, err = restoreTrashedTasks(s, a, item.ID)
+		if err != nil {
+			return err
+		}
+		r.Project = &Project{ID: item.EntityID}
+		err = r.Project.ReadOne(s, a)
+	case TrashItemKindTaskComment:
+		err = unmarkAsTrashed(s, &TaskComment{}, item.ID)
+		if err != nil {
+			return err
+		}
+		r.Comment = &TaskComment{ID: item.EntityID, TaskID: item.TaskID}
+		err = r.Comment.ReadOne(s, a)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ?", item.ID).Delete(&TrashItem{})
+	return
+}
+
+// RegisterTrashPurgeCron registers a cron function which permanently deletes all items in the trash
+// which are older than the configured retention period.
+func RegisterTrashPurgeCron() {
+	if !isTrashEnabled() {
+		return
+	}
+
+	const logPrefix = "[Trash Purge Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		items := []*TrashItem{}
+		err := s.Where("created < ?", time.Now().Add(-getTrashRetention())).Find(&items)
+		if err != nil {
+			log.Errorf(logPrefix+"Could not get expired trash items: %s", err)
+			return
+		}
+
+		if len(items) == 0 {
+			return
+		}
+
+		log.Debugf(logPrefix+"Purging %d expired trash items...", len(items))
+
+		for _, item := range items {
+			err = purgeTrashItem(s, item)
+			if err != nil {
+				log.Errorf(logPrefix+"Could not purge trash item %d: %s", item.ID, err)
+				_ = s.Rollback()
+				return
+			}
+		}
+
+		err = s.Commit()
+		if err != nil {
+			log.Errorf(logPrefix+"Could not commit purged trash items: %s", err)
+			return
+		}
+
+		log.Debugf(logPrefix+"Purged %d expired trash items", len(items))
+	})
+	if err != nil {
+		log.Fatalf("Could not register trash purge cron: %s", err)
+	}
+}
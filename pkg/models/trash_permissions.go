@@ -0,0 +1,48 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanDelete checks if the user can purge a trash item. Only the user who deleted the entity can do that.
+func (t *TrashItem) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	item, err := getTrashItemByID(s, t.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return item.DeletedByID == getUserOrLinkShareID(a), nil
+}
+
+// CanUpdate checks if the user can restore a trash item. Only the user who deleted the entity can do that,
+// and only if they still have write access to the project or task it belonged to.
+func (r *TrashItemRestore) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	item, err := getTrashItemByID(s, r.TrashItemID)
+	if err != nil {
+		return false, err
+	}
+
+	if item.DeletedByID != getUserOrLinkShareID(a) {
+		return false, nil
+	}
+
+	return item.canWriteParent(s, a)
+}
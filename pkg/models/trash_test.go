@@ -0,0 +1,285 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
+)
+
+func getTrashItemForEntity(t *testing.T, kind TrashItemKind, entityID int64) *TrashItem {
+	s := db.NewSession()
+	defer s.Close()
+
+	item := &TrashItem{}
+	has, err := s.Where("kind = ? AND entity_id = ?", kind, entityID).Get(item)
+	require.NoError(t, err)
+	require.True(t, has)
+	return item
+}
+
+func TestTrashItem_ReadAll(t *testing.T) {
+	t.Run("only own items", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		err := (&Task{ID: 1}).Delete(s, u)
+		require.NoError(t, err)
+
+		items, _, total, err := (&TrashItem{}).ReadAll(s, u, "", 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		trash := items.([]*TrashItem)
+		require.Len(t, trash, 2)
+		assert.Equal(t, TrashItemKindTask, trash[0].Kind)
+		assert.Equal(t, int64(1), trash[0].EntityID)
+		assert.Equal(t, "task #1", trash[0].Title)
+		assert.Equal(t, trash[0].Created.Add(getTrashRetention()), trash[0].PurgeAt)
+		assert.Equal(t, int64(1), trash[1].ID)
+	})
+	t.Run("search", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		items, _, _, err := (&TrashItem{}).ReadAll(s, &user.User{ID: 2}, "another", 0, 50)
+		require.NoError(t, err)
+		trash := items.([]*TrashItem)
+		require.Len(t, trash, 1)
+		assert.Equal(t, int64(2), trash[0].ID)
+	})
+}
+
+func TestTrashItemRestore(t *testing.T) {
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		err := (&Task{ID: 1}).Delete(s, u)
+		require.NoError(t, err)
+
+		err = (&Task{ID: 1}).ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+
+		// The bucket the task was in is gone in the meantime
+		_, err = s.Where("task_id = ?", 1).Delete(&TaskBucket{})
+		require.NoError(t, err)
+
+		item := getTrashItemForEntity(t, TrashItemKindTask, 1)
+		restore := &TrashItemRestore{TrashItemID: item.ID}
+		can, err := restore.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = restore.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Equal(t, TrashItemKindTask, restore.Kind)
+		require.NotNil(t, restore.Task)
+		assert.Equal(t, "task #1", restore.Task.Title)
+		db.AssertCount(t, "tasks", builder.Eq{"id": 1}.And(builder.IsNull{"deleted"}), 1)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":         1,
+			"project_view_id": 4,
+		}, false)
+		db.AssertMissing(t, "trash", map[string]interface{}{
+			"id": item.ID,
+		})
+	})
+	t.Run("project with children", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 6}
+		err := (&Project{ID: 12}).Delete(s, u)
+		require.NoError(t, err)
+
+		item := getTrashItemForEntity(t, TrashItemKindProject, 12)
+		restore := &TrashItemRestore{TrashItemID: item.ID}
+		can, err := restore.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = restore.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		require.NotNil(t, restore.Project)
+		assert.Equal(t, int64(12), restore.Project.ID)
+		for _, id := range []int64{12, 25, 26} {
+			db.AssertCount(t, "projects", builder.Eq{"id": id}.And(builder.IsNull{"deleted"}), 1)
+		}
+	})
+	t.Run("task comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		err := (&TaskComment{ID: 1, TaskID: 1}).Delete(s, u)
+		require.NoError(t, err)
+
+		item := getTrashItemForEntity(t, TrashItemKindTaskComment, 1)
+		restore := &TrashItemRestore{TrashItemID: item.ID}
+		err = restore.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		require.NotNil(t, restore.Comment)
+		assert.Equal(t, "Lorem Ipsum Dolor Sit Amet", restore.Comment.Comment)
+		db.AssertCount(t, "task_comments", builder.Eq{"id": 1}.And(builder.IsNull{"deleted"}), 1)
+	})
+	t.Run("comment of a task in the trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		err := (&TaskComment{ID: 1, TaskID: 1}).Delete(s, u)
+		require.NoError(t, err)
+		err = (&Task{ID: 1}).Delete(s, u)
+		require.NoError(t, err)
+
+		item := getTrashItemForEntity(t, TrashItemKindTaskComment, 1)
+		_, err = (&TrashItemRestore{TrashItemID: item.ID}).CanUpdate(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrCannotRestoreTrashItem(err))
+	})
+	t.Run("deleted project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := (&TrashItemRestore{TrashItemID: 1}).CanUpdate(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrCannotRestoreTrashItem(err))
+	})
+	t.Run("deleted by another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, err := (&TrashItemRestore{TrashItemID: 2}).CanUpdate(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := (&TrashItemRestore{TrashItemID: 9999}).CanUpdate(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrTrashItemDoesNotExist(err))
+	})
+}
+
+func TestTrashItem_Delete(t *testing.T) {
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		err := (&Task{ID: 1}).Delete(s, u)
+		require.NoError(t, err)
+
+		item := getTrashItemForEntity(t, TrashItemKindTask, 1)
+		can, err := item.CanDelete(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = item.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+		})
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"task_id": 1,
+		})
+		db.AssertMissing(t, "trash", map[string]interface{}{
+			"id": item.ID,
+		})
+	})
+	t.Run("project with children", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 6}
+		err := (&Project{ID: 12}).Delete(s, u)
+		require.NoError(t, err)
+
+		item := getTrashItemForEntity(t, TrashItemKindProject, 12)
+		err = item.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		for _, id := range []int64{12, 25, 26} {
+			db.AssertMissing(t, "projects", map[string]interface{}{
+				"id": id,
+			})
+			db.AssertMissing(t, "tasks", map[string]interface{}{
+				"project_id": id,
+			})
+		}
+	})
+	t.Run("entity already gone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TrashItem{ID: 1}
+		err := item.Delete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "trash", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("deleted by another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, err := (&TrashItem{ID: 2}).CanDelete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
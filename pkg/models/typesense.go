@@ -120,6 +120,18 @@ func CreateTypesenseCollections() error {
 				Name: "percent_done",
 				Type: "float",
 			},
+			{
+				Name: "time_spent",
+				Type: "int64",
+			},
+			{
+				Name: "story_points",
+				Type: "float",
+			},
+			{
+				Name: "estimate",
+				Type: "int64",
+			},
 			{
 				Name: "identifier",
 				Type: "string",
@@ -192,6 +204,17 @@ func CreateTypesenseCollections() error {
 				Name: "buckets",
 				Type: "int64[]",
 			},
+			{
+				Name:     "custom_fields",
+				Type:     "object",
+				Optional: pointer.True(),
+			},
+			{
+				Name:     "custom_fields.field_.*",
+				Type:     "auto",
+				Optional: pointer.True(),
+				Sort:     pointer.True(),
+			},
 		},
 	}
 
@@ -452,6 +475,9 @@ type typesenseTask struct {
 	EndDate                *int64      `json:"end_date"`
 	HexColor               string      `json:"hex_color"`
 	PercentDone            float64     `json:"percent_done"`
+	TimeSpent              int64       `json:"time_spent"`
+	StoryPoints            float64     `json:"story_points"`
+	Estimate               int64       `json:"estimate"`
 	Identifier             string      `json:"identifier"`
 	Index                  int64       `json:"index"`
 	UID                    string      `json:"uid"`
@@ -467,6 +493,17 @@ type typesenseTask struct {
 	Comments    interface{}        `json:"comments"`
 	Positions   map[string]float64 `json:"positions"`
 	Buckets     []int64            `json:"buckets"`
+	// Custom field values keyed by "field_<id>", dates are converted to unix timestamps.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+func getTypesenseCustomFieldKey(fieldID int64) string {
+	return "field_" + strconv.FormatInt(fieldID, 10)
+}
+
+// getTypesenseCustomFieldName returns the name of the nested typesense field holding the value of a custom field.
+func getTypesenseCustomFieldName(fieldID int64) string {
+	return "custom_fields." + getTypesenseCustomFieldKey(fieldID)
 }
 
 func convertTaskToTypesenseTask(task *Task, positions []*TaskPositionWithView, buckets []*TaskBucket) *typesenseTask {
@@ -486,6 +523,9 @@ func convertTaskToTypesenseTask(task *Task, positions []*TaskPositionWithView, b
 		EndDate:                pointer.Int64(task.EndDate.UTC().Unix()),
 		HexColor:               task.HexColor,
 		PercentDone:            task.PercentDone,
+		TimeSpent:              task.TimeSpent,
+		StoryPoints:            task.StoryPoints,
+		Estimate:               task.Estimate,
 		Identifier:             task.Identifier,
 		Index:                  task.Index,
 		UID:                    task.UID,
@@ -515,6 +555,16 @@ func convertTaskToTypesenseTask(task *Task, positions []*TaskPositionWithView, b
 		tt.EndDate = nil
 	}
 
+	if len(task.CustomFields) > 0 {
+		tt.CustomFields = make(map[string]interface{}, len(task.CustomFields))
+		for fieldID, value := range task.CustomFields {
+			if date, is := value.(time.Time); is {
+				value = date.UTC().Unix()
+			}
+			tt.CustomFields[getTypesenseCustomFieldKey(fieldID)] = value
+		}
+	}
+
 	for _, position := range positions {
 		pos := position.TaskPosition.Position
 		if pos == 0 {
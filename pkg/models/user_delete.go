@@ -141,7 +141,7 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 			// Child projects are deleted by p.Delete
 			continue
 		}
-		err = p.Delete(s, u)
+		err = p.delete(s, u, true)
 		// If the user is the owner of the default project it will be deleted, if they are not the owner
 		// we can ignore the error as the project was shared in that case.
 		if err != nil && !IsErrCannotDeleteDefaultProject(err) {
@@ -149,6 +149,28 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 		}
 	}
 
+	// Purge everything the user moved to the trash and their own projects someone else moved there
+	trashItems := []*TrashItem{}
+	err = s.
+		Where(builder.Or(
+			builder.Eq{"deleted_by_id": u.ID},
+			builder.And(
+				builder.Eq{"kind": TrashItemKindProject},
+				builder.In("entity_id", builder.Select("id").From("projects").Where(builder.Eq{"owner_id": u.ID})),
+			),
+		)).
+		Find(&trashItems)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range trashItems {
+		err = purgeTrashItem(s, item)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Delete all related entities
 	relatedEntities := []struct {
 		column string
@@ -161,6 +183,14 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 		{"user_id", &Reaction{}},
 		{"user_id", &Favorite{}},
 		{"owner_id", &APIToken{}},
+		{"user_id", &TaskTimeEntry{}},
+		{"owner_id", &Template{}},
+		{"user_id", &WIPLimit{}},
+		{"owner_id", &CalendarFeed{}},
+		{"user_id", &user.NotificationPreference{}},
+		{"notifiable_id", &notifications.DigestItem{}},
+		{"notifiable_id", &notifications.PushSubscription{}},
+		{"notifiable_id", &notifications.ChatTarget{}},
 	}
 
 	for _, entity := range relatedEntities {
@@ -170,6 +200,23 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 		}
 	}
 
+	// Checklist items stay with their task, they only lose their assignee
+	_, err = s.Where("assignee_id = ?", u.ID).Cols("assignee_id").NoAutoTime().Update(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+
+	err = deleteWebhooks(s, builder.Eq{"user_id": u.ID})
+	if err != nil {
+		return err
+	}
+
+	// Automation rules run as the user who created them
+	err = deleteAutomationRules(s, builder.Eq{"created_by_id": u.ID})
+	if err != nil {
+		return err
+	}
+
 	_, err = s.Where("id = ?", u.ID).Delete(&user.User{})
 	if err != nil {
 		return err
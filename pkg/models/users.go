@@ -40,6 +40,14 @@ func GetUserOrLinkShareUser(s *xorm.Session, a web.Auth) (uu *user.User, err err
 	return
 }
 
+// getUserOrLinkShareID returns the id of a user or the negative id of a link share, the same way they are stored as creator of an entity.
+func getUserOrLinkShareID(a web.Auth) int64 {
+	if ls, is := a.(*LinkSharing); is {
+		return ls.getUserID()
+	}
+	return a.GetID()
+}
+
 // Returns all users or pseudo link shares from a slice of ids. ids < 0 are considered to be a link share in that case.
 func getUsersOrLinkSharesFromIDs(s *xorm.Session, ids []int64) (users map[int64]*user.User, err error) {
 	users = make(map[int64]*user.User)
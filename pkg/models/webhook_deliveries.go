@@ -0,0 +1,407 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// How much of a webhook response body is saved with a delivery.
+const webhookResponseSnippetLength = 4096
+
+// WebhookDelivery is a single request made to a webhook target.
+type WebhookDelivery struct {
+	// The unique, numeric id of this delivery.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"delivery"`
+	// The webhook this delivery was made for.
+	WebhookID int64 `xorm:"bigint not null index" json:"webhook_id" param:"webhook"`
+	ProjectID int64 `xorm:"-" json:"-" param:"project"`
+	TeamID    int64 `xorm:"-" json:"-" param:"team"`
+
+	// The event which triggered this delivery.
+	EventName string `xorm:"varchar(250) not null" json:"event_name"`
+	// The url the request was sent to.
+	RequestURL string `xorm:"text not null" json:"request_url"`
+	// All headers sent with the request.
+	RequestHeaders map[string]string `xorm:"json null" json:"request_headers"`
+	// The request body.
+	RequestBody string `xorm:"longtext null" json:"request_body"`
+
+	// The http status code of the response. 0 if no response was received.
+	ResponseStatus int `xorm:"null" json:"response_status"`
+	// The beginning of the response body.
+	ResponseBody string `xorm:"text null" json:"response_body"`
+	// The error which occurred while sending the request, for example a timeout.
+	Error string `xorm:"text null" json:"error"`
+	// How long it took to get a response, in milliseconds.
+	DurationMs int64 `xorm:"bigint not null default 0" json:"duration_ms"`
+	// Whether the request was successful, meaning it got a 2xx response.
+	Success bool `xorm:"not null default false" json:"success"`
+
+	// 1 for the first attempt to deliver an event, increased with every automatic retry.
+	Attempt int64 `xorm:"bigint not null default 1" json:"attempt"`
+	// If this delivery was triggered manually, the id of the delivery it repeated.
+	RedeliveryOf int64 `xorm:"bigint null" json:"redelivery_of"`
+	// Whether this delivery was a test event with sample data.
+	IsTest bool `xorm:"not null default false" json:"is_test"`
+	// When the next automatic retry of this delivery will happen. Empty if there is none.
+	NextAttempt time.Time `xorm:"DATETIME null index" json:"next_attempt"`
+
+	// A timestamp when this delivery was made.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhook deliveries
+func (*WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ReadAll returns all deliveries of a webhook
+// @Summary Get all deliveries of a webhook
+// @Description Returns the log of all requests made to a webhook target, newest first. Deliveries are kept for the configured retention period.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
+// @Param webhookID path int true "Webhook ID"
+// @Success 200 {array} models.WebhookDelivery "The deliveries"
+// @Failure 403 {object} web.HTTPError "The user cannot manage the webhook."
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID}/deliveries [get]
+// @Router /teams/{team}/webhooks/{webhookID}/deliveries [get]
+// @Router /user/webhooks/{webhookID}/deliveries [get]
+func (d *WebhookDelivery) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, err := canDoExistingWebhook(s, a, d.WebhookID, d.ProjectID, d.TeamID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	deliveries := []*WebhookDelivery{}
+	err = s.
+		Where("webhook_id = ?", d.WebhookID).
+		OrderBy("id desc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&deliveries)
+	if err != nil {
+		return
+	}
+
+	total, err := s.Where("webhook_id = ?", d.WebhookID).Count(&WebhookDelivery{})
+	return deliveries, len(deliveries), total, err
+}
+
+// WebhookRedelivery sends the request of an earlier delivery again
+type WebhookRedelivery struct {
+	ProjectID  int64 `json:"-" param:"project"`
+	TeamID     int64 `json:"-" param:"team"`
+	WebhookID  int64 `json:"-" param:"webhook"`
+	DeliveryID int64 `json:"-" param:"delivery"`
+
+	// The new delivery.
+	Delivery *WebhookDelivery `json:"delivery"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+// Update sends the request of an earlier delivery again
+// @Summary Redeliver a webhook request
+// @Description Sends the request of an earlier delivery to the webhook target again and records it as a new delivery. Failed redeliveries are not retried automatically.
+// @tags webhooks
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
+// @Param webhookID path int true "Webhook ID"
+// @Param deliveryID path int true "Delivery ID"
+// @Success 200 {object} models.WebhookRedelivery "The new delivery."
+// @Failure 403 {object} web.HTTPError "The user cannot manage the webhook."
+// @Failure 404 {object} web.HTTPError "The webhook or delivery does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver [post]
+// @Router /teams/{team}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver [post]
+// @Router /user/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver [post]
+func (r *WebhookRedelivery) Update(s *xorm.Session, _ web.Auth) (err error) {
+	original, err := getWebhookDeliveryByID(s, r.DeliveryID)
+	if err != nil {
+		return err
+	}
+	if original.WebhookID != r.WebhookID {
+		return &ErrWebhookDeliveryDoesNotExist{ID: r.DeliveryID}
+	}
+
+	w, err := getWebhookByID(s, r.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	r.Delivery, err = w.deliver(s, original.EventName, []byte(original.RequestBody), 1, original.ID)
+	return
+}
+
+func getWebhookDeliveryByID(s *xorm.Session, id int64) (d *WebhookDelivery, err error) {
+	d = &WebhookDelivery{}
+	exists, err := s.Where("id = ?", id).Get(d)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrWebhookDeliveryDoesNotExist{ID: id}
+	}
+	return d, nil
+}
+
+// send makes the request to the webhook target. Failed requests are not returned as an error but
+// recorded in the delivery.
+func (w *Webhook) send(delivery *WebhookDelivery, payload []byte) {
+	req, err := w.newRequest(payload)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+
+	delivery.RequestHeaders = make(map[string]string, len(req.Header))
+	for key := range req.Header {
+		delivery.RequestHeaders[key] = req.Header.Get(key)
+	}
+	// Credentials are not stored in the log. Custom headers often carry them as well, we can't know which do.
+	redacted := []string{"Authorization"}
+	for key := range w.Headers {
+		redacted = append(redacted, http.CanonicalHeaderKey(key))
+	}
+	for _, key := range redacted {
+		if _, has := delivery.RequestHeaders[key]; has {
+			delivery.RequestHeaders[key] = "[redacted]"
+		}
+	}
+
+	start := time.Now()
+	res, err := getWebhookHTTPClient().Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer res.Body.Close()
+
+	delivery.ResponseStatus = res.StatusCode
+	responseBody, err := io.ReadAll(io.LimitReader(res.Body, webhookResponseSnippetLength))
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	delivery.ResponseBody = strings.ToValidUTF8(string(responseBody), "")
+	delivery.Success = err == nil && res.StatusCode >= 200 && res.StatusCode < 300
+}
+
+// getWebhookRetryBackoff returns how long to wait before retrying a delivery, doubling with every attempt.
+func getWebhookRetryBackoff(attempt int64) time.Duration {
+	return time.Minute * time.Duration(int64(1)<<(attempt-1))
+}
+
+// deliver sends a payload to the webhook target and records the delivery. If the request failed, a retry
+// is scheduled and the webhook is disabled after too many failures in a row.
+func (w *Webhook) deliver(s *xorm.Session, eventName string, payload []byte, attempt int64, redeliveryOf int64) (delivery *WebhookDelivery, err error) {
+	delivery = &WebhookDelivery{
+		WebhookID:    w.ID,
+		EventName:    eventName,
+		RequestURL:   w.TargetURL,
+		RequestBody:  string(payload),
+		Attempt:      attempt,
+		RedeliveryOf: redeliveryOf,
+	}
+
+	w.send(delivery, payload)
+
+	if delivery.Success {
+		log.Debugf("Sent webhook payload for webhook %d for event %s", w.ID, eventName)
+	} else {
+		log.Errorf("Could not deliver event %s to webhook %d (attempt %d, status %d): %s %s", eventName, w.ID, attempt, delivery.ResponseStatus, delivery.Error, delivery.ResponseBody)
+
+		if redeliveryOf == 0 && attempt <= config.WebhooksMaxRetries.GetInt64() {
+			delivery.NextAttempt = time.Now().Add(getWebhookRetryBackoff(attempt))
+		}
+	}
+
+	_, err = s.Insert(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.updateConsecutiveFailures(s, delivery.Success)
+	return
+}
+
+func (w *Webhook) updateConsecutiveFailures(s *xorm.Session, success bool) (err error) {
+	if success {
+		if w.ConsecutiveFailures == 0 {
+			return nil
+		}
+		w.ConsecutiveFailures = 0
+		_, err = s.Where("id = ?", w.ID).
+			NoAutoTime().
+			Cols("consecutive_failures").
+			Update(w)
+		return
+	}
+
+	_, err = s.Where("id = ?", w.ID).
+		NoAutoTime().
+		Incr("consecutive_failures").
+		Update(&Webhook{})
+	if err != nil {
+		return err
+	}
+
+	current := &Webhook{}
+	_, err = s.Where("id = ?", w.ID).
+		Cols("consecutive_failures", "is_disabled").
+		Get(current)
+	if err != nil {
+		return err
+	}
+	w.ConsecutiveFailures = current.ConsecutiveFailures
+	w.IsDisabled = current.IsDisabled
+
+	maxFailures := config.WebhooksDisableAfterFailures.GetInt64()
+	if maxFailures <= 0 || w.IsDisabled || w.ConsecutiveFailures < maxFailures {
+		return nil
+	}
+
+	w.IsDisabled = true
+	disabled, err := s.Where("id = ? AND is_disabled = ?", w.ID, false).
+		Cols("is_disabled").
+		Update(w)
+	if err != nil || disabled == 0 {
+		return err
+	}
+
+	log.Infof("Disabled webhook %d after %d failed requests in a row", w.ID, w.ConsecutiveFailures)
+
+	webhook := *w
+	webhook.Secret = ""
+	return events.Dispatch(&WebhookDisabledEvent{
+		Webhook: &webhook,
+		Creator: &user.User{ID: w.CreatedByID},
+	})
+}
+
+// RegisterWebhookDeliveryCron registers cron functions which retry failed webhook deliveries and remove
+// deliveries older than the configured retention period.
+func RegisterWebhookDeliveryCron() {
+	if !config.WebhooksEnabled.GetBool() {
+		return
+	}
+
+	err := cron.Schedule("* * * * *", retryWebhookDeliveries)
+	if err != nil {
+		log.Errorf("Could not register webhook retry cron: %s", err.Error())
+	}
+
+	err = cron.Schedule("0 * * * *", cleanupWebhookDeliveries)
+	if err != nil {
+		log.Errorf("Could not register webhook delivery cleanup cron: %s", err.Error())
+	}
+}
+
+func retryWebhookDeliveries() {
+	const logPrefix = "[Webhook Retry Cron] "
+
+	s := db.NewSession()
+	defer s.Close()
+
+	due := []*WebhookDelivery{}
+	err := s.
+		Where("next_attempt IS NOT NULL AND next_attempt <= ?", time.Now().In(config.GetTimeZone()).Format(dbTimeFormat)).
+		OrderBy("id asc").
+		Limit(100).
+		Find(&due)
+	if err != nil {
+		log.Errorf(logPrefix+"Could not get webhook deliveries to retry: %s", err)
+		return
+	}
+
+	for _, d := range due {
+		// Only retry deliveries no other instance has picked up already
+		claimed, err := s.
+			Where("id = ? AND next_attempt IS NOT NULL", d.ID).
+			Cols("next_attempt").
+			Update(&WebhookDelivery{})
+		if err != nil {
+			log.Errorf(logPrefix+"Could not claim webhook delivery %d: %s", d.ID, err)
+			continue
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		w, err := getWebhookByID(s, d.WebhookID)
+		if err != nil {
+			if !IsErrWebhookDoesNotExist(err) {
+				log.Errorf(logPrefix+"Could not get webhook %d: %s", d.WebhookID, err)
+			}
+			continue
+		}
+		if w.IsDisabled {
+			continue
+		}
+
+		_, err = w.deliver(s, d.EventName, []byte(d.RequestBody), d.Attempt+1, 0)
+		if err != nil {
+			log.Errorf(logPrefix+"Could not retry webhook delivery %d: %s", d.ID, err)
+		}
+	}
+}
+
+func cleanupWebhookDeliveries() {
+	s := db.NewSession()
+	defer s.Close()
+
+	retention := time.Duration(config.WebhooksDeliveryRetentionDays.GetInt64()) * 24 * time.Hour
+	deleted, err := s.
+		Where("created < ?", time.Now().Add(-retention).In(config.GetTimeZone()).Format(dbTimeFormat)).
+		Delete(&WebhookDelivery{})
+	if err != nil {
+		log.Errorf("[Webhook Delivery Cleanup Cron] Could not remove old webhook deliveries: %s", err)
+		return
+	}
+
+	log.Debugf("[Webhook Delivery Cleanup Cron] Removed %d old webhook deliveries", deleted)
+}
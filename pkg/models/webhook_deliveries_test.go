@@ -0,0 +1,313 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookTestReceiver struct {
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func newWebhookTestServer(t *testing.T, receiver *webhookTestReceiver) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		receiver.requests = append(receiver.requests, r)
+		receiver.bodies = append(receiver.bodies, string(body))
+		w.WriteHeader(receiver.status)
+		_, _ = w.Write([]byte("response"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getWebhookForTest(t *testing.T, id int64, targetURL string) *Webhook {
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err := s.Where("id = ?", id).Cols("target_url").Update(&Webhook{TargetURL: targetURL})
+	require.NoError(t, err)
+
+	w, err := getWebhookByID(s, id)
+	require.NoError(t, err)
+	return w
+}
+
+func TestWebhookDelivery_ReadAll(t *testing.T) {
+	t.Run("newest first", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		d := &WebhookDelivery{WebhookID: 1, ProjectID: 1}
+		result, count, total, err := d.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(2), total)
+		deliveries := result.([]*WebhookDelivery)
+		assert.Equal(t, int64(2), deliveries[0].ID)
+		assert.Equal(t, int64(1), deliveries[1].ID)
+	})
+	t.Run("webhook of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		d := &WebhookDelivery{WebhookID: 2, ProjectID: 1}
+		_, _, _, err := d.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		d := &WebhookDelivery{WebhookID: 2, ProjectID: 3}
+		_, _, _, err := d.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrGenericForbidden{})
+	})
+}
+
+func TestWebhook_deliver(t *testing.T) {
+	t.Run("successful", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusOK}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		delivery, err := w.deliver(s, "task.created", []byte(`{"foo":"bar"}`), 1, 0)
+		require.NoError(t, err)
+		assert.True(t, delivery.Success)
+		assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+		assert.Equal(t, "response", delivery.ResponseBody)
+		assert.True(t, delivery.NextAttempt.IsZero())
+		assert.NotEmpty(t, delivery.RequestHeaders["X-Vikunja-Signature"])
+
+		require.Len(t, receiver.requests, 1)
+		assert.Equal(t, `{"foo":"bar"}`, receiver.bodies[0])
+		assert.Equal(t, delivery.RequestHeaders["X-Vikunja-Signature"], receiver.requests[0].Header.Get("X-Vikunja-Signature"))
+
+		db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+			"id":              delivery.ID,
+			"webhook_id":      1,
+			"event_name":      "task.created",
+			"request_body":    `{"foo":"bar"}`,
+			"response_status": 200,
+			"success":         true,
+		}, false)
+	})
+	t.Run("failed request schedules a retry", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusInternalServerError}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		before := time.Now()
+		delivery, err := w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+		require.NoError(t, err)
+		assert.False(t, delivery.Success)
+		assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+		assert.WithinDuration(t, before.Add(time.Minute), delivery.NextAttempt, 5*time.Second)
+
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":                   1,
+			"consecutive_failures": 1,
+			"is_disabled":          false,
+		}, false)
+	})
+	t.Run("no retry after the last attempt", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusBadGateway}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		delivery, err := w.deliver(s, "task.created", []byte(`{}`), config.WebhooksMaxRetries.GetInt64()+1, 0)
+		require.NoError(t, err)
+		assert.False(t, delivery.Success)
+		assert.True(t, delivery.NextAttempt.IsZero())
+	})
+	t.Run("unreachable target", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := getWebhookForTest(t, 1, "http://127.0.0.1:1/webhook")
+
+		s := db.NewSession()
+		defer s.Close()
+
+		delivery, err := w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+		require.NoError(t, err)
+		assert.False(t, delivery.Success)
+		assert.Equal(t, 0, delivery.ResponseStatus)
+		assert.NotEmpty(t, delivery.Error)
+	})
+	t.Run("success resets the failure count", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusInternalServerError}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+		require.NoError(t, err)
+		receiver.status = http.StatusNoContent
+		_, err = w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+		require.NoError(t, err)
+
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":                   1,
+			"consecutive_failures": 0,
+		}, false)
+	})
+	t.Run("disable after too many failures", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.ClearDispatchedEvents()
+		receiver := &webhookTestReceiver{status: http.StatusInternalServerError}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		for i := int64(0); i < config.WebhooksDisableAfterFailures.GetInt64(); i++ {
+			_, err := w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+			require.NoError(t, err)
+		}
+
+		assert.True(t, w.IsDisabled)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":          1,
+			"is_disabled": true,
+		}, false)
+		assert.Equal(t, 1, events.CountDispatchedEvents((&WebhookDisabledEvent{}).Name()))
+	})
+}
+
+func TestWebhookRedelivery_Update(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusOK}
+		server := newWebhookTestServer(t, receiver)
+		getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &WebhookRedelivery{ProjectID: 1, WebhookID: 1, DeliveryID: 2}
+		can, err := r.CanUpdate(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		err = r.Update(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		require.NotNil(t, r.Delivery)
+		assert.True(t, r.Delivery.Success)
+		assert.Equal(t, int64(2), r.Delivery.RedeliveryOf)
+
+		require.Len(t, receiver.bodies, 1)
+		assert.JSONEq(t, `{"event_name":"task.created","data":{"task":{"id":2}}}`, receiver.bodies[0])
+	})
+	t.Run("failed redeliveries are not retried", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusInternalServerError}
+		server := newWebhookTestServer(t, receiver)
+		getWebhookForTest(t, 1, server.URL)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &WebhookRedelivery{ProjectID: 1, WebhookID: 1, DeliveryID: 2}
+		err := r.Update(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, r.Delivery.Success)
+		assert.True(t, r.Delivery.NextAttempt.IsZero())
+	})
+	t.Run("delivery of another webhook", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &WebhookRedelivery{ProjectID: 1, WebhookID: 1, DeliveryID: 3}
+		err := r.Update(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrWebhookDeliveryDoesNotExist(err))
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &WebhookRedelivery{ProjectID: 3, WebhookID: 2, DeliveryID: 3}
+		can, err := r.CanUpdate(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestRetryWebhookDeliveries(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	receiver := &webhookTestReceiver{status: http.StatusOK}
+	server := newWebhookTestServer(t, receiver)
+	getWebhookForTest(t, 1, server.URL)
+
+	s := db.NewSession()
+	_, err := s.Where("id = ?", 2).
+		Cols("next_attempt").
+		Update(&WebhookDelivery{NextAttempt: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	s.Close()
+
+	retryWebhookDeliveries()
+
+	require.Len(t, receiver.bodies, 1)
+	db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+		"webhook_id": 1,
+		"attempt":    2,
+		"success":    true,
+	}, false)
+
+	// The retry must only happen once
+	retryWebhookDeliveries()
+	assert.Len(t, receiver.bodies, 1)
+}
@@ -0,0 +1,346 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/version"
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// WebhookSignatureScheme defines how webhook requests are signed
+type WebhookSignatureScheme string
+
+const (
+	WebhookSignatureSchemeBody        WebhookSignatureScheme = "body"
+	WebhookSignatureSchemeTimestamped WebhookSignatureScheme = "timestamped"
+)
+
+// WebhookAuthType defines how webhook requests authenticate against the target
+type WebhookAuthType string
+
+const (
+	WebhookAuthTypeNone   WebhookAuthType = ""
+	WebhookAuthTypeBasic  WebhookAuthType = "basic"
+	WebhookAuthTypeBearer WebhookAuthType = "bearer"
+)
+
+// Headers which are set by Vikunja or the http client and cannot be overridden by custom headers.
+var reservedWebhookHeaders = map[string]bool{
+	"Host":                true,
+	"Content-Length":      true,
+	"Transfer-Encoding":   true,
+	"Connection":          true,
+	"X-Vikunja-Signature": true,
+	"X-Vikunja-Timestamp": true,
+}
+
+var webhookHeaderNameRegex = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+func parseWebhookPayloadTemplate(tpl string) (*template.Template, error) {
+	return template.New("payload").
+		Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				encoded, err := json.Marshal(v)
+				return string(encoded), err
+			},
+		}).
+		Parse(tpl)
+}
+
+// validateDeliveryOptions checks the payload template, custom headers, authentication and signature scheme of a webhook.
+func (w *Webhook) validateDeliveryOptions() error {
+	switch w.SignatureScheme {
+	case "", WebhookSignatureSchemeBody, WebhookSignatureSchemeTimestamped:
+	default:
+		return InvalidFieldErrorWithMessage([]string{"signature_scheme"}, "The signature scheme must be either body or timestamped.")
+	}
+
+	switch w.AuthType {
+	case WebhookAuthTypeNone, WebhookAuthTypeBearer:
+	case WebhookAuthTypeBasic:
+		if w.AuthUsername == "" {
+			return InvalidFieldErrorWithMessage([]string{"auth_username"}, "Basic auth needs a username.")
+		}
+	default:
+		return InvalidFieldErrorWithMessage([]string{"auth_type"}, "The auth type must be either empty, basic or bearer.")
+	}
+
+	for name, value := range w.Headers {
+		canonical := http.CanonicalHeaderKey(name)
+		if !webhookHeaderNameRegex.MatchString(name) ||
+			strings.ContainsAny(value, "\r\n") ||
+			reservedWebhookHeaders[canonical] ||
+			(canonical == "Authorization" && w.AuthType != WebhookAuthTypeNone) {
+			return InvalidFieldErrorWithMessage([]string{"headers"}, "The header "+name+" is invalid or cannot be set.")
+		}
+	}
+
+	if w.PayloadTemplate != "" {
+		_, err := parseWebhookPayloadTemplate(w.PayloadTemplate)
+		if err != nil {
+			return InvalidFieldErrorWithMessage([]string{"payload_template"}, "The payload template is invalid: "+err.Error())
+		}
+	}
+
+	return nil
+}
+
+// renderPayload returns the request body for a payload. Without a template this is the payload as json.
+// Templates see the event data the same way it is sent as json, so fields are accessed by their json names.
+func (w *Webhook) renderPayload(p *WebhookPayload) ([]byte, error) {
+	if w.PayloadTemplate == "" {
+		return json.Marshal(p)
+	}
+
+	tmpl, err := parseWebhookPayloadTemplate(w.PayloadTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(p.Data)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	err = json.Unmarshal(encoded, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, &WebhookPayload{
+		EventName: p.EventName,
+		Time:      p.Time,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (w *Webhook) newRequest(payload []byte) (req *http.Request, err error) {
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, w.TargetURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "Vikunja/"+version.Version)
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range w.Headers {
+		req.Header.Set(name, value)
+	}
+
+	switch w.AuthType {
+	case WebhookAuthTypeBasic:
+		req.SetBasicAuth(w.AuthUsername, w.AuthPassword)
+	case WebhookAuthTypeBearer:
+		req.Header.Set("Authorization", "Bearer "+w.AuthPassword)
+	}
+
+	w.sign(req, payload)
+
+	return req, nil
+}
+
+func (w *Webhook) sign(req *http.Request, payload []byte) {
+	if len(w.Secret) == 0 {
+		return
+	}
+
+	sig256 := hmac.New(sha256.New, []byte(w.Secret))
+
+	if w.SignatureScheme == WebhookSignatureSchemeTimestamped {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		_, err := sig256.Write([]byte(timestamp + "."))
+		if err != nil {
+			log.Errorf("Could not generate webhook signature for Webhook %d: %s", w.ID, err)
+		}
+		_, err = sig256.Write(payload)
+		if err != nil {
+			log.Errorf("Could not generate webhook signature for Webhook %d: %s", w.ID, err)
+		}
+		req.Header.Set("X-Vikunja-Timestamp", timestamp)
+		req.Header.Set("X-Vikunja-Signature", "v1="+hex.EncodeToString(sig256.Sum(nil)))
+		return
+	}
+
+	_, err := sig256.Write(payload)
+	if err != nil {
+		log.Errorf("Could not generate webhook signature for Webhook %d: %s", w.ID, err)
+	}
+	req.Header.Set("X-Vikunja-Signature", hex.EncodeToString(sig256.Sum(nil)))
+}
+
+// getSampleWebhookEventData returns example data shaped like the payload of an event.
+func getSampleWebhookEventData(eventName string, doer *user.User) map[string]interface{} {
+	now := time.Now()
+	project := &Project{
+		ID:          1,
+		Title:       "Example project",
+		Description: "This is an example project.",
+		Identifier:  "EXAMPLE",
+		Owner:       doer,
+		Created:     now,
+		Updated:     now,
+	}
+	task := &Task{
+		ID:          1,
+		Title:       "Example task",
+		Description: "This is an example task.",
+		ProjectID:   project.ID,
+		Identifier:  "EXAMPLE-1",
+		Index:       1,
+		CreatedBy:   doer,
+		Created:     now,
+		Updated:     now,
+	}
+
+	data := map[string]interface{}{
+		"doer": doer,
+	}
+
+	switch {
+	case strings.HasPrefix(eventName, "task.comment."):
+		data["task"] = task
+		data["project"] = project
+		data["comment"] = &TaskComment{
+			ID:      1,
+			Comment: "This is an example comment.",
+			Author:  doer,
+			TaskID:  task.ID,
+			Created: now,
+			Updated: now,
+		}
+	case strings.HasPrefix(eventName, "task.assignee."):
+		data["task"] = task
+		data["project"] = project
+		data["assignee"] = doer
+	case strings.HasPrefix(eventName, "task."):
+		data["task"] = task
+		data["project"] = project
+	case strings.HasPrefix(eventName, "project."):
+		data["project"] = project
+	case strings.HasPrefix(eventName, "team."):
+		data["team"] = &Team{
+			ID:          1,
+			Name:        "Example team",
+			Description: "This is an example team.",
+			CreatedBy:   doer,
+			Created:     now,
+			Updated:     now,
+		}
+		data["member"] = doer
+	}
+
+	return data
+}
+
+// WebhookTest sends an example event to a webhook
+type WebhookTest struct {
+	ProjectID int64 `json:"-" param:"project"`
+	TeamID    int64 `json:"-" param:"team"`
+	WebhookID int64 `json:"-" param:"webhook"`
+
+	// The event to send example data for. Defaults to the first event of the webhook.
+	EventName string `json:"event_name"`
+
+	// The delivery of the test event.
+	Delivery *WebhookDelivery `json:"delivery"`
+
+	web.CRUDable    `json:"-"`
+	web.Permissions `json:"-"`
+}
+
+// Update sends a test event to a webhook
+// @Summary Send a test event to a webhook
+// @Description Renders the payload of the webhook with example data for an event and sends it to the webhook target. Test deliveries show up in the delivery log, but are not retried and don't count towards disabling the webhook.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
+// @Param webhookID path int true "Webhook ID"
+// @Param test body models.WebhookTest true "The event to send."
+// @Success 200 {object} models.WebhookTest "The test delivery."
+// @Failure 400 {object} web.HTTPError "The event does not exist or the payload template could not be rendered."
+// @Failure 403 {object} web.HTTPError "The user cannot manage the webhook."
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID}/test [post]
+// @Router /teams/{team}/webhooks/{webhookID}/test [post]
+// @Router /user/webhooks/{webhookID}/test [post]
+func (wt *WebhookTest) Update(s *xorm.Session, a web.Auth) (err error) {
+	w, err := getWebhookByID(s, wt.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	if wt.EventName == "" && len(w.Events) > 0 {
+		wt.EventName = w.Events[0]
+	}
+	if _, has := availableWebhookEvents[wt.EventName]; !has {
+		return InvalidFieldError([]string{"event_name"})
+	}
+
+	doer, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return err
+	}
+
+	payload, err := w.renderPayload(&WebhookPayload{
+		EventName: wt.EventName,
+		Time:      time.Now(),
+		Data:      getSampleWebhookEventData(wt.EventName, doer),
+	})
+	if err != nil {
+		return InvalidFieldErrorWithMessage([]string{"payload_template"}, "The payload template could not be rendered: "+err.Error())
+	}
+
+	wt.Delivery = &WebhookDelivery{
+		WebhookID:   w.ID,
+		EventName:   wt.EventName,
+		RequestURL:  w.TargetURL,
+		RequestBody: string(payload),
+		Attempt:     1,
+		IsTest:      true,
+	}
+	w.send(wt.Delivery, payload)
+
+	_, err = s.Insert(wt.Delivery)
+	return
+}
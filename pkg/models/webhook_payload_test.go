@@ -0,0 +1,259 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_validateDeliveryOptions(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		w := &Webhook{
+			SignatureScheme: WebhookSignatureSchemeTimestamped,
+			PayloadTemplate: `{"text": {{ json .Data.task.title }}}`,
+			Headers:         map[string]string{"X-Custom": "value"},
+			AuthType:        WebhookAuthTypeBasic,
+			AuthUsername:    "user",
+		}
+		require.NoError(t, w.validateDeliveryOptions())
+	})
+	t.Run("invalid template", func(t *testing.T) {
+		w := &Webhook{PayloadTemplate: `{{ .Data.task.title `}
+		err := w.validateDeliveryOptions()
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("reserved header", func(t *testing.T) {
+		w := &Webhook{Headers: map[string]string{"x-vikunja-signature": "forged"}}
+		err := w.validateDeliveryOptions()
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("authorization header with auth type", func(t *testing.T) {
+		w := &Webhook{
+			Headers:  map[string]string{"Authorization": "Bearer foo"},
+			AuthType: WebhookAuthTypeBearer,
+		}
+		err := w.validateDeliveryOptions()
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("header value with newline", func(t *testing.T) {
+		w := &Webhook{Headers: map[string]string{"X-Custom": "foo\r\nX-Other: bar"}}
+		err := w.validateDeliveryOptions()
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("basic auth without username", func(t *testing.T) {
+		w := &Webhook{AuthType: WebhookAuthTypeBasic}
+		err := w.validateDeliveryOptions()
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("unknown signature scheme", func(t *testing.T) {
+		w := &Webhook{SignatureScheme: "md5"}
+		err := w.validateDeliveryOptions()
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
+
+func TestWebhook_renderPayload(t *testing.T) {
+	p := &WebhookPayload{
+		EventName: "task.created",
+		Time:      time.Now(),
+		Data: map[string]interface{}{
+			"task": &Task{ID: 42, Title: `Say "hello"`},
+		},
+	}
+
+	t.Run("without template", func(t *testing.T) {
+		w := &Webhook{}
+		payload, err := w.renderPayload(p)
+		require.NoError(t, err)
+		assert.Contains(t, string(payload), `"event_name":"task.created"`)
+	})
+	t.Run("with template", func(t *testing.T) {
+		w := &Webhook{PayloadTemplate: `{"event": "{{ .EventName }}", "id": {{ .Data.task.id }}, "text": {{ json .Data.task.title }}}`}
+		payload, err := w.renderPayload(p)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"event": "task.created", "id": 42, "text": "Say \"hello\""}`, string(payload))
+	})
+	t.Run("template error", func(t *testing.T) {
+		w := &Webhook{PayloadTemplate: `{{ index .Data.task.labels 3 }}`}
+		_, err := w.renderPayload(p)
+		require.Error(t, err)
+	})
+}
+
+func TestWebhook_newRequest(t *testing.T) {
+	t.Run("custom headers and basic auth", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusOK}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+		w.Headers = map[string]string{"X-Custom": "value", "x-api-key": "secret"}
+		w.AuthType = WebhookAuthTypeBasic
+		w.AuthUsername = "user"
+		w.AuthPassword = "password"
+
+		s := db.NewSession()
+		defer s.Close()
+
+		delivery, err := w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+		require.NoError(t, err)
+		require.Len(t, receiver.requests, 1)
+		assert.Equal(t, "value", receiver.requests[0].Header.Get("X-Custom"))
+		username, password, ok := receiver.requests[0].BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "password", password)
+		assert.NotContains(t, delivery.RequestHeaders["Authorization"], "Basic")
+		assert.Equal(t, "secret", receiver.requests[0].Header.Get("X-Api-Key"))
+		assert.Equal(t, "[redacted]", delivery.RequestHeaders["X-Custom"])
+		assert.Equal(t, "[redacted]", delivery.RequestHeaders["X-Api-Key"])
+	})
+	t.Run("bearer auth", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusOK}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+		w.AuthType = WebhookAuthTypeBearer
+		w.AuthPassword = "token"
+
+		s := db.NewSession()
+		defer s.Close()
+
+		delivery, err := w.deliver(s, "task.created", []byte(`{}`), 1, 0)
+		require.NoError(t, err)
+		require.Len(t, receiver.requests, 1)
+		assert.Equal(t, "Bearer token", receiver.requests[0].Header.Get("Authorization"))
+		assert.NotContains(t, delivery.RequestHeaders["Authorization"], "token")
+	})
+	t.Run("timestamped signature", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusOK}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+		w.SignatureScheme = WebhookSignatureSchemeTimestamped
+
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := w.deliver(s, "task.created", []byte(`{"foo":"bar"}`), 1, 0)
+		require.NoError(t, err)
+		require.Len(t, receiver.requests, 1)
+
+		timestamp := receiver.requests[0].Header.Get("X-Vikunja-Timestamp")
+		require.NotEmpty(t, timestamp)
+		mac := hmac.New(sha256.New, []byte("s3cr3t"))
+		_, _ = mac.Write([]byte(timestamp + "." + `{"foo":"bar"}`))
+		assert.Equal(t, "v1="+hex.EncodeToString(mac.Sum(nil)), receiver.requests[0].Header.Get("X-Vikunja-Signature"))
+	})
+}
+
+func TestWebhook_sendWebhookPayload(t *testing.T) {
+	t.Run("render error records a failed delivery", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusOK}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+		w.PayloadTemplate = `{{ index .Data.task.labels 3 }}`
+
+		s := db.NewSession()
+		defer s.Close()
+
+		err := w.sendWebhookPayload(s, &WebhookPayload{
+			EventName: "task.created",
+			Time:      time.Now(),
+			Data:      map[string]interface{}{"task": &Task{ID: 1}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, receiver.requests)
+		db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id": 1,
+			"event_name": "task.created",
+			"success":    false,
+		}, false)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":                   1,
+			"consecutive_failures": 1,
+		}, false)
+	})
+}
+
+func TestWebhookTest_Update(t *testing.T) {
+	RegisterEventForWebhook(&TaskCreatedEvent{})
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		receiver := &webhookTestReceiver{status: http.StatusInternalServerError}
+		server := newWebhookTestServer(t, receiver)
+		w := getWebhookForTest(t, 1, server.URL)
+		_, err := db.NewSession().Where("id = ?", w.ID).Cols("payload_template").Update(&Webhook{PayloadTemplate: `{"text": {{ json .Data.task.title }}}`})
+		require.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		wt := &WebhookTest{ProjectID: 1, WebhookID: 1}
+		err = wt.Update(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "task.created", wt.EventName)
+		require.Len(t, receiver.bodies, 1)
+		assert.JSONEq(t, `{"text": "Example task"}`, receiver.bodies[0])
+
+		assert.True(t, wt.Delivery.IsTest)
+		assert.False(t, wt.Delivery.Success)
+		assert.True(t, wt.Delivery.NextAttempt.IsZero())
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":                   1,
+			"consecutive_failures": 0,
+		}, false)
+	})
+	t.Run("unknown event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		wt := &WebhookTest{ProjectID: 1, WebhookID: 1, EventName: "does.not.exist"}
+		err := wt.Update(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+	t.Run("permission", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		wt := &WebhookTest{ProjectID: 1, WebhookID: 1}
+		can, err := wt.CanUpdate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
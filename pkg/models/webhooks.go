@@ -17,29 +17,26 @@
 package models
 
 import (
-	"bytes"
-	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
-	"encoding/hex"
-	"encoding/json"
-	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/version"
 	"code.vikunja.io/api/pkg/web"
 
+	"xorm.io/builder"
 	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
 )
 
 var webhookClient *http.Client
@@ -51,10 +48,41 @@ type Webhook struct {
 	TargetURL string `xorm:"not null" valid:"required,url" json:"target_url"`
 	// The webhook events which should fire this webhook target
 	Events []string `xorm:"JSON not null" valid:"required" json:"events"`
-	// The project ID of the project this webhook target belongs to
+	// The project ID of the project this webhook target belongs to. 0 if the webhook belongs to a team or user.
 	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// The team ID of the team this webhook target belongs to. Team webhooks receive events of the team and of all projects
+	// shared with the team. 0 if the webhook belongs to a project or user.
+	TeamID int64 `xorm:"bigint not null default 0 index" json:"team_id" param:"team"`
+	// The user ID of the user this webhook target belongs to. User webhooks receive events of all projects and teams
+	// the user has access to. 0 if the webhook belongs to a project or team. You cannot change this value.
+	UserID int64 `xorm:"bigint not null default 0 index" json:"user_id"`
 	// If provided, webhook requests will be signed using HMAC. Check out the docs about how to use this: https://vikunja.io/docs/webhooks/#signing
 	Secret string `xorm:"null" json:"secret"`
+	// How requests are signed when a secret is provided. `body` (the default) signs only the request body and sends the
+	// signature in the `X-Vikunja-Signature` header. `timestamped` signs `<timestamp>.<body>`, sends the signature as
+	// `v1=<signature>` in the `X-Vikunja-Signature` header and the unix timestamp in the `X-Vikunja-Timestamp` header,
+	// which allows receivers to reject replayed requests.
+	SignatureScheme WebhookSignatureScheme `xorm:"varchar(20) null" json:"signature_scheme"`
+
+	// A Go text/template used to render the request body instead of the default json payload. The template is executed
+	// with the webhook payload, for example `{{ .EventName }}` or `{{ .Data.task.title }}`. Use `{{ json .Data.task.title }}`
+	// to produce a json encoded value.
+	PayloadTemplate string `xorm:"text null" json:"payload_template"`
+	// Additional headers sent with every request. A `Content-Type` header here replaces the default `application/json`.
+	// Their values are not stored in the delivery log.
+	Headers map[string]string `xorm:"json null" json:"headers"`
+	// How to authenticate against the webhook target. Either empty for no authentication, `basic` or `bearer`.
+	AuthType WebhookAuthType `xorm:"varchar(10) null" json:"auth_type"`
+	// The username used for basic auth.
+	AuthUsername string `xorm:"null" json:"auth_username"`
+	// The password used for basic auth or the token used for bearer auth. It is never returned. Leave it empty when
+	// updating a webhook to keep the current one.
+	AuthPassword string `xorm:"null" json:"auth_password"`
+	// Whether the webhook is disabled. Disabled webhooks don't receive any events. Webhooks are disabled automatically
+	// after too many failed requests in a row. Set this to false to enable a disabled webhook again.
+	IsDisabled bool `xorm:"not null default false" json:"is_disabled"`
+	// How many requests to this webhook failed in a row. You cannot change this value.
+	ConsecutiveFailures int64 `xorm:"bigint not null default 0" json:"consecutive_failures"`
 
 	// The user who initially created the webhook target.
 	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
@@ -104,31 +132,50 @@ func GetAvailableWebhookEvents() []string {
 
 // Create creates a webhook target
 // @Summary Create a webhook target
-// @Description Create a webhook target which receives POST requests about specified events from a project.
+// @Description Create a webhook target which receives POST requests about specified events from a project, a team or everything the current user has access to.
 // @tags webhooks
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
 // @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
 // @Param webhook body models.Webhook true "The webhook target object with required fields"
 // @Success 200 {object} models.Webhook "The created webhook target."
 // @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{id}/webhooks [put]
+// @Router /teams/{team}/webhooks [put]
+// @Router /user/webhooks [put]
 func (w *Webhook) Create(s *xorm.Session, a web.Auth) (err error) {
 
 	if !strings.HasPrefix(w.TargetURL, "http") {
 		return InvalidFieldError([]string{"target_url"})
 	}
 
+	if w.ProjectID != 0 && w.TeamID != 0 {
+		return InvalidFieldError([]string{"project_id", "team_id"})
+	}
+
+	w.UserID = 0
+	if w.ProjectID == 0 && w.TeamID == 0 {
+		w.UserID = a.GetID()
+	}
+
 	for _, event := range w.Events {
 		if _, has := availableWebhookEvents[event]; !has {
 			return InvalidFieldError([]string{"events"})
 		}
 	}
 
+	err = w.validateDeliveryOptions()
+	if err != nil {
+		return err
+	}
+
 	w.CreatedByID = a.GetID()
 	w.ID = 0
+	w.IsDisabled = false
+	w.ConsecutiveFailures = 0
 	_, err = s.Insert(w)
 	if err != nil {
 		return err
@@ -138,9 +185,9 @@ func (w *Webhook) Create(s *xorm.Session, a web.Auth) (err error) {
 	return
 }
 
-// ReadAll returns all webhook targets for a project
-// @Summary Get all api webhook targets for the specified project
-// @Description Get all api webhook targets for the specified project.
+// ReadAll returns all webhook targets for a project, team or the current user
+// @Summary Get all api webhook targets for the specified project, team or the current user
+// @Description Get all api webhook targets for the specified project, team or the current user. Only team admins can see the webhooks of a team.
 // @tags webhooks
 // @Accept json
 // @Produce json
@@ -148,12 +195,28 @@ func (w *Webhook) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per bucket per page. This parameter is limited by the configured maximum of items per page."
 // @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
 // @Success 200 {array} models.Webhook "The list of all webhook targets"
 // @Failure 500 {object} models.Message "Internal server error"
 // @Router /projects/{id}/webhooks [get]
+// @Router /teams/{team}/webhooks [get]
+// @Router /user/webhooks [get]
 func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
-	p := &Project{ID: w.ProjectID}
-	can, _, err := p.CanRead(s, a)
+	var can bool
+	var cond builder.Cond
+	switch {
+	case w.ProjectID != 0:
+		p := &Project{ID: w.ProjectID}
+		can, _, err = p.CanRead(s, a)
+		cond = builder.Eq{"project_id": w.ProjectID}
+	case w.TeamID != 0:
+		can, err = w.canDoWebhook(s, a)
+		cond = builder.Eq{"team_id": w.TeamID}
+	default:
+		_, isShareAuth := a.(*LinkSharing)
+		can = !isShareAuth
+		cond = builder.Eq{"user_id": a.GetID()}
+	}
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -162,14 +225,14 @@ func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPa
 	}
 
 	ws := []*Webhook{}
-	err = s.Where("project_id = ?", w.ProjectID).
+	err = s.Where(cond).
 		Limit(getLimitFromPageIndex(page, perPage)).
 		Find(&ws)
 	if err != nil {
 		return
 	}
 
-	total, err := s.Where("project_id = ?", w.ProjectID).
+	total, err := s.Where(cond).
 		Count(&Webhook{})
 	if err != nil {
 		return
@@ -187,6 +250,7 @@ func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPa
 
 	for _, webhook := range ws {
 		webhook.Secret = ""
+		webhook.AuthPassword = ""
 		if createdBy, has := users[webhook.CreatedByID]; has {
 			webhook.CreatedBy = createdBy
 		}
@@ -196,18 +260,21 @@ func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPa
 }
 
 // Update updates a webhook target
-// @Summary Change a webhook target's events.
-// @Description Change a webhook target's events. You cannot change other values of a webhook.
+// @Summary Change a webhook target's events and how requests are made.
+// @Description Change a webhook target's events, payload template, headers, authentication and signature scheme or enable a disabled webhook again. You cannot change the target url or secret of a webhook.
 // @tags webhooks
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
 // @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
 // @Param webhookID path int true "Webhook ID"
 // @Success 200 {object} models.Webhook "Updated webhook target"
 // @Failure 404 {object} web.HTTPError "The webhok target does not exist"
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{id}/webhooks/{webhookID} [post]
+// @Router /teams/{team}/webhooks/{webhookID} [post]
+// @Router /user/webhooks/{webhookID} [post]
 func (w *Webhook) Update(s *xorm.Session, _ web.Auth) (err error) {
 	for _, event := range w.Events {
 		if _, has := availableWebhookEvents[event]; !has {
@@ -215,30 +282,145 @@ func (w *Webhook) Update(s *xorm.Session, _ web.Auth) (err error) {
 		}
 	}
 
+	err = w.validateDeliveryOptions()
+	if err != nil {
+		return err
+	}
+
+	cols := []string{
+		"events",
+		"is_disabled",
+		"signature_scheme",
+		"payload_template",
+		"headers",
+		"auth_type",
+		"auth_username",
+	}
+	if w.AuthPassword != "" || w.AuthType == WebhookAuthTypeNone {
+		cols = append(cols, "auth_password")
+	}
+	if !w.IsDisabled {
+		w.ConsecutiveFailures = 0
+		cols = append(cols, "consecutive_failures")
+	}
+
 	_, err = s.Where("id = ?", w.ID).
-		Cols("events").
+		Cols(cols...).
 		Update(w)
 	return
 }
 
 // Delete deletes a webhook target
 // @Summary Deletes an existing webhook target
-// @Description Delete any of the project's webhook targets.
+// @Description Delete any of the project's, team's or the current user's webhook targets.
 // @tags webhooks
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
 // @Param id path int true "Project ID"
+// @Param team path int true "Team ID"
 // @Param webhookID path int true "Webhook ID"
 // @Success 200 {object} models.Message "Successfully deleted."
 // @Failure 404 {object} web.HTTPError "The webhok target does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{id}/webhooks/{webhookID} [delete]
+// @Router /teams/{team}/webhooks/{webhookID} [delete]
+// @Router /user/webhooks/{webhookID} [delete]
 func (w *Webhook) Delete(s *xorm.Session, _ web.Auth) (err error) {
-	_, err = s.Where("id = ?", w.ID).Delete(&Webhook{})
+	return deleteWebhooks(s, builder.Eq{"id": w.ID})
+}
+
+// deleteWebhooks removes all webhooks matching the condition together with their deliveries.
+func deleteWebhooks(s *xorm.Session, cond builder.Cond) (err error) {
+	_, err = s.Where(builder.In("webhook_id", builder.Select("id").From("webhooks").Where(cond))).
+		Delete(&WebhookDelivery{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where(cond).Delete(&Webhook{})
 	return
 }
 
+func getWebhookByID(s *xorm.Session, id int64) (w *Webhook, err error) {
+	w = &Webhook{}
+	exists, err := s.Where("id = ?", id).Get(w)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrWebhookDoesNotExist{ID: id}
+	}
+	return w, nil
+}
+
+// getWebhooksForEvent returns all enabled webhooks which are subscribed to an event about a project and/or team.
+// These are the webhooks of the project and its parents, the webhooks of teams with access to the project or
+// the team itself and the webhooks of users who can read the project or are a member of the team.
+func getWebhooksForEvent(s *xorm.Session, eventName string, projectID, teamID int64) (webhooks []*Webhook, err error) {
+	receivers := []builder.Cond{}
+
+	if projectID != 0 {
+		parents, err := GetAllParentProjects(s, projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		projectIDs := make([]int64, 0, len(parents)+1)
+		projectIDs = append(projectIDs, projectID)
+		for _, p := range parents {
+			projectIDs = append(projectIDs, p.ID)
+		}
+
+		// Access to a parent project means access to all of its child projects
+		teamsWithAccess := builder.Select("team_id").From("team_projects").Where(builder.In("project_id", projectIDs))
+		receivers = append(receivers,
+			builder.In("project_id", projectIDs),
+			builder.In("team_id", teamsWithAccess),
+			builder.In("user_id", builder.Select("owner_id").From("projects").Where(builder.In("id", projectIDs))),
+			builder.In("user_id", builder.Select("user_id").From("users_projects").Where(builder.In("project_id", projectIDs))),
+			builder.In("user_id", builder.Select("user_id").From("team_members").Where(builder.In("team_id", teamsWithAccess))),
+		)
+	}
+
+	if teamID != 0 {
+		receivers = append(receivers,
+			builder.Eq{"team_id": teamID},
+			builder.In("user_id", builder.Select("user_id").From("team_members").Where(builder.Eq{"team_id": teamID})),
+		)
+	}
+
+	if len(receivers) == 0 {
+		return []*Webhook{}, nil
+	}
+
+	// The events are stored as a json array, this narrows them down to the webhooks which probably subscribed to
+	// the event. The exact check happens below.
+	eventsColumn := "events"
+	if db.Type() == schemas.POSTGRES {
+		eventsColumn = "events::text"
+	}
+
+	ws := []*Webhook{}
+	err = s.
+		Where(builder.Or(receivers...)).
+		And(builder.Like{eventsColumn, "%\"" + eventName + "\"%"}).
+		And("is_disabled = ?", false).
+		Find(&ws)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks = make([]*Webhook, 0, len(ws))
+	for _, w := range ws {
+		if slices.Contains(w.Events, eventName) {
+			webhooks = append(webhooks, w)
+		}
+	}
+
+	return webhooks, nil
+}
+
 func getWebhookHTTPClient() (client *http.Client) {
 
 	if webhookClient != nil {
@@ -268,47 +450,24 @@ func getWebhookHTTPClient() (client *http.Client) {
 	return
 }
 
-func (w *Webhook) sendWebhookPayload(p *WebhookPayload) (err error) {
-	payload, err := json.Marshal(p)
+func (w *Webhook) sendWebhookPayload(s *xorm.Session, p *WebhookPayload) (err error) {
+	payload, err := w.renderPayload(p)
 	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.TargetURL, bytes.NewReader(payload))
-	if err != nil {
-		return err
-	}
-
-	if len(w.Secret) > 0 {
-		sig256 := hmac.New(sha256.New, []byte(w.Secret))
-		_, err = sig256.Write(payload)
-		if err != nil {
-			log.Errorf("Could not generate webhook signature for Webhook %d: %s", w.ID, err)
-		}
-		signature := hex.EncodeToString(sig256.Sum(nil))
-		req.Header.Add("X-Vikunja-Signature", signature)
-	}
-
-	req.Header.Add("User-Agent", "Vikunja/"+version.Version)
-	req.Header.Add("Content-Type", "application/json")
-
-	client := getWebhookHTTPClient()
-	res, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer res.Body.Close()
-
-	if res.StatusCode > 399 {
-		responseBody, err := io.ReadAll(res.Body)
+		// Retrying won't help if the template can't be rendered, so this is only recorded as a failed delivery.
+		log.Errorf("Could not render payload template of webhook %d for event %s: %s", w.ID, p.EventName, err)
+		_, err = s.Insert(&WebhookDelivery{
+			WebhookID:  w.ID,
+			EventName:  p.EventName,
+			RequestURL: w.TargetURL,
+			Error:      "Could not render the payload template: " + err.Error(),
+			Attempt:    1,
+		})
 		if err != nil {
 			return err
 		}
-
-		log.Errorf("Got response with status %d from webhook %d: %s", res.StatusCode, w.ID, responseBody)
+		return w.updateConsecutiveFailures(s, false)
 	}
 
-	log.Debugf("Sent webhook payload for webhook %d for event %s", w.ID, p.EventName)
+	_, err = w.deliver(s, p.EventName, payload, 1, 0)
 	return
 }
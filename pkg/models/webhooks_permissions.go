@@ -27,23 +27,66 @@ func (w *Webhook) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
 }
 
 func (w *Webhook) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
-	return w.canDoWebhook(s, a)
+	return canDoExistingWebhook(s, a, w.ID, w.ProjectID, w.TeamID)
 }
 
 func (w *Webhook) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
-	return w.canDoWebhook(s, a)
+	return canDoExistingWebhook(s, a, w.ID, w.ProjectID, w.TeamID)
 }
 
 func (w *Webhook) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// User webhooks always belong to the current user
+	w.UserID = 0
 	return w.canDoWebhook(s, a)
 }
 
+// canDoWebhook checks if the user can manage webhooks of the project, team or user the webhook belongs to.
+// Project webhooks need write access to the project, team webhooks need team admin permissions and user webhooks
+// can only be managed by the user themselves.
 func (w *Webhook) canDoWebhook(s *xorm.Session, a web.Auth) (bool, error) {
 	_, isShareAuth := a.(*LinkSharing)
 	if isShareAuth {
 		return false, nil
 	}
 
-	p := &Project{ID: w.ProjectID}
-	return p.CanUpdate(s, a)
+	switch {
+	case w.ProjectID != 0:
+		p := &Project{ID: w.ProjectID}
+		return p.CanUpdate(s, a)
+	case w.TeamID != 0:
+		t := &Team{ID: w.TeamID}
+		return t.IsAdmin(s, a)
+	case w.UserID != 0:
+		return w.UserID == a.GetID(), nil
+	default:
+		// A new user webhook
+		return true, nil
+	}
+}
+
+// canDoExistingWebhook checks if the webhook exists, belongs to the project or team from the request and the user
+// can manage it. If neither a project nor a team is given, the webhook must be one of the user's own webhooks.
+func canDoExistingWebhook(s *xorm.Session, a web.Auth, webhookID, projectID, teamID int64) (bool, error) {
+	w, err := getWebhookByID(s, webhookID)
+	if err != nil {
+		return false, err
+	}
+	if w.ProjectID != projectID || w.TeamID != teamID {
+		return false, &ErrWebhookDoesNotExist{ID: webhookID}
+	}
+
+	return w.canDoWebhook(s, a)
+}
+
+func (d *WebhookDelivery) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	can, err := canDoExistingWebhook(s, a, d.WebhookID, d.ProjectID, d.TeamID)
+	return can, int(PermissionWrite), err
+}
+
+func (r *WebhookRedelivery) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return canDoExistingWebhook(s, a, r.WebhookID, r.ProjectID, r.TeamID)
+}
+
+func (wt *WebhookTest) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return canDoExistingWebhook(s, a, wt.WebhookID, wt.ProjectID, wt.TeamID)
 }
@@ -0,0 +1,257 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getWebhookIDs(webhooks []*Webhook) []int64 {
+	ids := make([]int64, 0, len(webhooks))
+	for _, w := range webhooks {
+		ids = append(ids, w.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestWebhook_Create(t *testing.T) {
+	RegisterEventForWebhook(&TaskCreatedEvent{})
+	RegisterEventForWebhook(&TeamMemberAddedEvent{})
+
+	t.Run("user webhook", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 2}
+		w := &Webhook{
+			TargetURL: "https://example.com/hook",
+			Events:    []string{"task.created"},
+			UserID:    1,
+		}
+		can, err := w.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = w.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), w.UserID)
+
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":         w.ID,
+			"user_id":    2,
+			"project_id": 0,
+			"team_id":    0,
+		}, false)
+	})
+	t.Run("team webhook", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		w := &Webhook{
+			TargetURL: "https://example.com/hook",
+			Events:    []string{"team.member.added"},
+			TeamID:    1,
+		}
+		can, err := w.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = w.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), w.UserID)
+	})
+	t.Run("team webhook without admin permissions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{TeamID: 1}
+		can, err := w.CanCreate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("project and team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/hook",
+			Events:    []string{"task.created"},
+			ProjectID: 1,
+			TeamID:    1,
+		}
+		err := w.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.IsType(t, ValidationHTTPError{}, err)
+	})
+}
+
+func TestWebhook_ReadAll(t *testing.T) {
+	t.Run("user webhooks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, total, err := (&Webhook{}).ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []int64{3, 7}, getWebhookIDs(result.([]*Webhook)))
+	})
+	t.Run("team webhooks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, _, err := (&Webhook{TeamID: 1}).ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{5}, getWebhookIDs(result.([]*Webhook)))
+	})
+	t.Run("team webhooks without admin permissions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, _, _, err := (&Webhook{TeamID: 1}).ReadAll(s, &user.User{ID: 2}, "", 1, 50)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrGenericForbidden{})
+	})
+}
+
+func TestWebhook_CanUpdate(t *testing.T) {
+	t.Run("own user webhook", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, err := (&Webhook{ID: 3}).CanUpdate(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("user webhook of someone else", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, err := (&Webhook{ID: 4}).CanUpdate(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("project webhook through the user route", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := (&Webhook{ID: 1}).CanUpdate(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+	t.Run("webhook of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := (&Webhook{ID: 2, ProjectID: 1}).CanDelete(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+	t.Run("team webhook without admin permissions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, err := (&Webhook{ID: 5, TeamID: 1}).CanUpdate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestGetWebhooksForEvent(t *testing.T) {
+	t.Run("project event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "task.created", 3, 0)
+		require.NoError(t, err)
+		// Project webhook, user webhooks of users who can read the project and the webhook of the team
+		// the project is shared with
+		assert.Equal(t, []int64{2, 3, 4, 5}, getWebhookIDs(webhooks))
+	})
+	t.Run("project event the other users can't read", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "task.created", 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 3}, getWebhookIDs(webhooks))
+	})
+	t.Run("only webhooks subscribed to the event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "team.member.added", 3, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 5}, getWebhookIDs(webhooks))
+
+		webhooks, err = getWebhooksForEvent(s, "task", 3, 0)
+		require.NoError(t, err)
+		assert.Empty(t, webhooks)
+	})
+	t.Run("team event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "team.member.added", 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 5}, getWebhookIDs(webhooks))
+	})
+	t.Run("team event of a team the user is not a member of", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "team.member.added", 0, 9)
+		require.NoError(t, err)
+		assert.Empty(t, webhooks)
+	})
+}
+
+func TestWebhook_DeleteWithOwner(t *testing.T) {
+	t.Run("team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Team{ID: 1}).Delete(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		db.AssertMissing(t, "webhooks", map[string]interface{}{"id": 5})
+		db.AssertExists(t, "webhooks", map[string]interface{}{"id": 6}, false)
+	})
+}
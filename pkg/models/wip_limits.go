@@ -0,0 +1,253 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// WIPLimit limits how many tasks a user can be assigned to in buckets with a certain title, across all projects.
+type WIPLimit struct {
+	// The unique, numeric id of this limit.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"wiplimit"`
+	// The user this limit applies to. Always the user who created it.
+	UserID int64 `xorm:"bigint not null index" json:"-"`
+	// The title of the buckets this limit applies to, for example `In progress`. Matched case-insensitively against buckets in all projects.
+	BucketTitle string `xorm:"varchar(250) not null" json:"bucket_title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// How many open tasks the user can be assigned to in these buckets at the same time.
+	Limit int64 `xorm:"bigint not null" json:"limit" valid:"range(1|9223372036854775807)" minimum:"1"`
+	// If true, exceeding the limit is allowed and only returns a warning on the task. Otherwise, the change is rejected.
+	Soft bool `xorm:"not null default false" json:"soft"`
+
+	// A timestamp when this limit was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this limit was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable    `xorm:"-" json:"-"`
+	web.Permissions `xorm:"-" json:"-"`
+}
+
+// WIPLimitWarning is returned on a task when a change exceeded a soft work-in-progress limit.
+type WIPLimitWarning struct {
+	UserID      int64  `json:"user_id"`
+	BucketTitle string `json:"bucket_title"`
+	Limit       int64  `json:"limit"`
+	// The number of open tasks the user is assigned to in these buckets, including this one.
+	Count int64 `json:"count"`
+}
+
+// TableName returns the table name for work-in-progress limits
+func (*WIPLimit) TableName() string {
+	return "wip_limits"
+}
+
+func getWIPLimitByID(s *xorm.Session, id int64) (limit *WIPLimit, err error) {
+	limit = &WIPLimit{}
+	exists, err := s.Where("id = ?", id).Get(limit)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrWIPLimitDoesNotExist{ID: id}
+	}
+	return
+}
+
+// Create creates a new work-in-progress limit
+// @Summary Create a work-in-progress limit
+// @Description Creates a limit for how many open tasks the current user can be assigned to in buckets with a certain title, across all projects.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param limit body models.WIPLimit true "The limit"
+// @Success 200 {object} models.WIPLimit "The created limit."
+// @Failure 400 {object} web.HTTPError "Invalid limit object provided."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/wip-limits [put]
+func (l *WIPLimit) Create(s *xorm.Session, a web.Auth) (err error) {
+	l.ID = 0
+	l.UserID = a.GetID()
+	l.BucketTitle = strings.TrimSpace(l.BucketTitle)
+	_, err = s.Insert(l)
+	return
+}
+
+// ReadAll returns all work-in-progress limits of the current user
+// @Summary Get all work-in-progress limits
+// @Description Returns all work-in-progress limits of the current user.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} models.WIPLimit "The limits"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/wip-limits [get]
+func (l *WIPLimit) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limits := []*WIPLimit{}
+	query := s.Where("user_id = ?", a.GetID()).OrderBy("id asc")
+	limit, start := getLimitFromPageIndex(page, perPage)
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&limits)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	total, err := s.Where("user_id = ?", a.GetID()).Count(&WIPLimit{})
+	return limits, len(limits), total, err
+}
+
+// Update updates a work-in-progress limit
+// @Summary Update a work-in-progress limit
+// @Description Updates a work-in-progress limit of the current user.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param wiplimit path int true "Limit ID"
+// @Param limit body models.WIPLimit true "The limit"
+// @Success 200 {object} models.WIPLimit "The updated limit."
+// @Failure 400 {object} web.HTTPError "Invalid limit object provided."
+// @Failure 404 {object} web.HTTPError "The limit does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/wip-limits/{wiplimit} [post]
+func (l *WIPLimit) Update(s *xorm.Session, _ web.Auth) (err error) {
+	l.BucketTitle = strings.TrimSpace(l.BucketTitle)
+	_, err = s.
+		ID(l.ID).
+		Cols("bucket_title", "limit", "soft").
+		Update(l)
+	return
+}
+
+// Delete removes a work-in-progress limit
+// @Summary Delete a work-in-progress limit
+// @Description Deletes a work-in-progress limit of the current user.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param wiplimit path int true "Limit ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The limit does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/wip-limits/{wiplimit} [delete]
+func (l *WIPLimit) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", l.ID).Delete(&WIPLimit{})
+	return
+}
+
+// checkWIPLimits checks the work-in-progress limits of the given users for a task which would end up in the
+// given buckets. Exceeding a hard limit returns an error, exceeding a soft limit returns a warning.
+func checkWIPLimits(s *xorm.Session, taskID int64, buckets []*Bucket, userIDs []int64) (warnings []*WIPLimitWarning, err error) {
+	if len(buckets) == 0 || len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	titles := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		titles = append(titles, strings.ToLower(strings.TrimSpace(b.Title)))
+	}
+
+	limits := []*WIPLimit{}
+	err = s.
+		In("user_id", userIDs).
+		And(builder.In("LOWER(bucket_title)", titles)).
+		OrderBy("id asc").
+		Find(&limits)
+	if err != nil || len(limits) == 0 {
+		return nil, err
+	}
+
+	for _, limit := range limits {
+		taskIDs := []int64{}
+		err = s.
+			Table("tasks").
+			Select("DISTINCT tasks.id").
+			Join("INNER", "task_assignees", "task_assignees.task_id = tasks.id").
+			Join("INNER", "task_buckets", "task_buckets.task_id = tasks.id").
+			Join("INNER", "buckets", "buckets.id = task_buckets.bucket_id").
+			Where("task_assignees.user_id = ?", limit.UserID).
+			And("LOWER(buckets.title) = ?", strings.ToLower(limit.BucketTitle)).
+			And("tasks.done = ?", false).
+			And("tasks.deleted IS NULL").
+			And("tasks.id != ?", taskID).
+			Find(&taskIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		count := int64(len(taskIDs))
+		if count < limit.Limit {
+			continue
+		}
+
+		if !limit.Soft {
+			return nil, &ErrWIPLimitExceeded{
+				TaskID:      taskID,
+				UserID:      limit.UserID,
+				BucketTitle: limit.BucketTitle,
+				Limit:       limit.Limit,
+			}
+		}
+
+		warnings = append(warnings, &WIPLimitWarning{
+			UserID:      limit.UserID,
+			BucketTitle: limit.BucketTitle,
+			Limit:       limit.Limit,
+			Count:       count + 1,
+		})
+	}
+
+	return
+}
+
+// checkWIPLimitsForNewAssignee checks the limits of a user who is about to be assigned to a task, for all
+// buckets the task currently is in.
+func checkWIPLimitsForNewAssignee(s *xorm.Session, taskID int64, userID int64) (warnings []*WIPLimitWarning, err error) {
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Done {
+		return nil, nil
+	}
+
+	buckets := []*Bucket{}
+	err = s.
+		Table("buckets").
+		Select("buckets.*").
+		Join("INNER", "task_buckets", "task_buckets.bucket_id = buckets.id").
+		Where("task_buckets.task_id = ?", taskID).
+		Find(&buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkWIPLimits(s, taskID, buckets, []int64{userID})
+}
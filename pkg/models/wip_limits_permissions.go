@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/web"
+
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can create a work-in-progress limit. Link shares cannot have limits.
+func (l *WIPLimit) CanCreate(_ *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+	return true, nil
+}
+
+// CanUpdate checks if a user can update a work-in-progress limit
+func (l *WIPLimit) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return l.isOwner(s, a)
+}
+
+// CanDelete checks if a user can delete a work-in-progress limit
+func (l *WIPLimit) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return l.isOwner(s, a)
+}
+
+func (l *WIPLimit) isOwner(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	existing, err := getWIPLimitByID(s, l.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return existing.UserID == a.GetID(), nil
+}
@@ -0,0 +1,210 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+// renameBucketToInProgress turns bucket 2 (holding the unassigned tasks 3, 4 and 5) into an unlimited "in progress" bucket.
+func renameBucketToInProgress(t *testing.T, s *xorm.Session) {
+	_, err := s.ID(2).Cols("title", "limit").Update(&Bucket{Title: "in progress", Limit: 0})
+	require.NoError(t, err)
+}
+
+func TestWIPLimit_Create(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 3}
+		l := &WIPLimit{BucketTitle: " Doing ", Limit: 2, UserID: 1}
+		can, err := l.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = l.Create(s, u)
+		require.NoError(t, err)
+
+		db.AssertExists(t, "wip_limits", map[string]interface{}{
+			"id":           l.ID,
+			"user_id":      3,
+			"bucket_title": "Doing",
+			"limit":        2,
+		}, false)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		l := &WIPLimit{BucketTitle: "Doing", Limit: 2}
+		can, err := l.CanCreate(s, &LinkSharing{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestWIPLimit_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	l := &WIPLimit{}
+	result, _, total, err := l.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.NoError(t, err)
+	limits := result.([]*WIPLimit)
+	require.Len(t, limits, 1)
+	assert.Equal(t, int64(1), limits[0].ID)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestWIPLimit_Permissions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	l := &WIPLimit{ID: 1}
+	can, err := l.CanUpdate(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	assert.True(t, can)
+
+	can, err = l.CanDelete(s, &user.User{ID: 2})
+	require.NoError(t, err)
+	assert.False(t, can)
+
+	l = &WIPLimit{ID: 9999}
+	_, err = l.CanUpdate(s, &user.User{ID: 1})
+	require.Error(t, err)
+	assert.True(t, IsErrWIPLimitDoesNotExist(err))
+}
+
+func TestWIPLimit_Enforcement(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("hard limit when assigning", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		renameBucketToInProgress(t, s)
+
+		err := (&TaskAssginee{TaskID: 3, UserID: 1}).Create(s, u)
+		require.NoError(t, err)
+
+		err = (&TaskAssginee{TaskID: 4, UserID: 1}).Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrWIPLimitExceeded(err))
+	})
+	t.Run("hard limit when moving into a bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		renameBucketToInProgress(t, s)
+		err := (&TaskAssginee{TaskID: 3, UserID: 1}).Create(s, u)
+		require.NoError(t, err)
+
+		// Task 30 is assigned to user 1 and 2
+		tb := &TaskBucket{TaskID: 30, BucketID: 2, ProjectViewID: 4, ProjectID: 1}
+		err = tb.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrWIPLimitExceeded(err))
+		wipErr := err.(*ErrWIPLimitExceeded)
+		assert.Equal(t, int64(1), wipErr.UserID)
+	})
+	t.Run("soft limit warns on the task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		renameBucketToInProgress(t, s)
+		_, err := s.Insert(&ProjectUser{UserID: 2, ProjectID: 1, Permission: PermissionWrite})
+		require.NoError(t, err)
+
+		task := &Task{ID: 3, Title: "task #3 high prio", Assignees: []*user.User{{ID: 2}}}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+		assert.Empty(t, task.WIPLimitWarnings)
+
+		task = &Task{ID: 4, Title: "task #4 low prio", Assignees: []*user.User{{ID: 2}}}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+		require.Len(t, task.WIPLimitWarnings, 1)
+		assert.Equal(t, int64(2), task.WIPLimitWarnings[0].UserID)
+		assert.Equal(t, int64(2), task.WIPLimitWarnings[0].Count)
+
+		db.AssertExists(t, "task_assignees", map[string]interface{}{
+			"task_id": 4,
+			"user_id": 2,
+		}, false)
+	})
+	t.Run("done tasks do not count", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		renameBucketToInProgress(t, s)
+		err := (&TaskAssginee{TaskID: 3, UserID: 1}).Create(s, u)
+		require.NoError(t, err)
+		_, err = s.ID(3).Cols("done").Update(&Task{Done: true})
+		require.NoError(t, err)
+
+		err = (&TaskAssginee{TaskID: 4, UserID: 1}).Create(s, u)
+		require.NoError(t, err)
+	})
+	t.Run("tasks in the trash do not count", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		renameBucketToInProgress(t, s)
+		err := (&TaskAssginee{TaskID: 3, UserID: 1}).Create(s, u)
+		require.NoError(t, err)
+		err = moveTaskToTrash(s, u, &Task{ID: 3, ProjectID: 1})
+		require.NoError(t, err)
+
+		err = (&TaskAssginee{TaskID: 4, UserID: 1}).Create(s, u)
+		require.NoError(t, err)
+	})
+	t.Run("soft limit warns when marking a task undone moves it out of the done bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Bucket 1 is the default bucket of the view
+		_, err := s.ID(1).Cols("title").Update(&Bucket{Title: "in progress"})
+		require.NoError(t, err)
+		_, err = s.Insert(&ProjectUser{UserID: 2, ProjectID: 1, Permission: PermissionWrite})
+		require.NoError(t, err)
+		_, err = s.Insert(&TaskAssginee{TaskID: 1, UserID: 2})
+		require.NoError(t, err)
+
+		task := &Task{ID: 2, Title: "task #2 done", Done: false, Assignees: []*user.User{{ID: 2}}}
+		err = task.Update(s, u)
+		require.NoError(t, err)
+		require.Len(t, task.WIPLimitWarnings, 1)
+		assert.Equal(t, int64(2), task.WIPLimitWarnings[0].UserID)
+	})
+}
@@ -0,0 +1,359 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/api/pkg/version"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"xorm.io/xorm"
+)
+
+// ChatTargetKind is the kind of service a chat target sends messages to.
+type ChatTargetKind string
+
+const (
+	// ChatTargetSlack is a Slack-compatible incoming webhook. Mattermost, Rocket.Chat and others accept the same payload.
+	ChatTargetSlack ChatTargetKind = "slack"
+	// ChatTargetMatrix is a room on a Matrix homeserver, messages are sent through the client-server api.
+	ChatTargetMatrix ChatTargetKind = "matrix"
+	// ChatTargetNtfy is a topic on an ntfy server.
+	ChatTargetNtfy ChatTargetKind = "ntfy"
+	// ChatTargetGotify is an application on a Gotify server.
+	ChatTargetGotify ChatTargetKind = "gotify"
+)
+
+// ChatTargetKinds returns all kinds of chat targets a notifiable can add.
+func ChatTargetKinds() []ChatTargetKind {
+	return []ChatTargetKind{ChatTargetSlack, ChatTargetMatrix, ChatTargetNtfy, ChatTargetGotify}
+}
+
+// ChatTarget is a chat a notifiable gets notifications in.
+type ChatTarget struct {
+	// The unique, numeric id of this chat target.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"target"`
+
+	// The ID of the notifiable this target belongs to.
+	NotifiableID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The kind of service messages are sent to. Can be `slack`, `matrix`, `ntfy` or `gotify`.
+	Kind ChatTargetKind `xorm:"varchar(20) not null" json:"kind" valid:"required"`
+	// A human-readable name for this target.
+	Title string `xorm:"varchar(250) null" json:"title"`
+	// Where messages are sent to. For slack the url of the incoming webhook, for matrix and gotify the url of the
+	// server and for ntfy the url of the topic, like `https://ntfy.sh/my-topic`.
+	URL string `xorm:"text not null" json:"url" valid:"required,url"`
+	// The id of the matrix room messages are sent to. Only used for matrix.
+	Room string `xorm:"varchar(250) null" json:"room"`
+	// The access token for matrix and ntfy or the application token for gotify. Only visible when creating or
+	// updating the target. When updating a target without a token, the old one is kept.
+	Token string `xorm:"text null" json:"token,omitempty"`
+
+	// A timestamp when this target was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this target was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+}
+
+// TableName returns the table name for chat targets
+func (*ChatTarget) TableName() string {
+	return "chat_notification_targets"
+}
+
+// GetChatTargetsForNotifiable returns all chat targets of a notifiable.
+func GetChatTargetsForNotifiable(s *xorm.Session, notifiableID int64) (targets []*ChatTarget, err error) {
+	targets = []*ChatTarget{}
+	err = s.
+		Where("notifiable_id = ?", notifiableID).
+		OrderBy("id asc").
+		Find(&targets)
+	return
+}
+
+// GetChatTargetByID returns a chat target by its id.
+func GetChatTargetByID(s *xorm.Session, id int64) (target *ChatTarget, exists bool, err error) {
+	target = &ChatTarget{}
+	exists, err = s.Where("id = ?", id).Get(target)
+	return
+}
+
+// IsChatEnabled checks if notifiables can get notifications in chats.
+func IsChatEnabled() bool {
+	return config.ChatNotificationsEnabled.GetBool()
+}
+
+// ChatMessage is a notification rendered for a chat. It is derived from the mail of the notification so every
+// notification with a mail can be sent to a chat.
+type ChatMessage struct {
+	Subject string
+	// The content of the message as markdown.
+	Lines      []string
+	ActionText string
+	ActionURL  string
+}
+
+// Chattable is implemented by notifications whose chat message is not derived from their mail.
+type Chattable interface {
+	ToChat(lang string) *ChatMessage
+}
+
+// NewChatMessage derives a chat message from a mail. The greeting and footer of the mail are left out, they make no
+// sense in a chat.
+func NewChatMessage(m *Mail) *ChatMessage {
+	msg := &ChatMessage{
+		Subject:    m.subject,
+		ActionText: m.actionText,
+		ActionURL:  m.actionURL,
+	}
+
+	for _, line := range append(m.introLines, m.outroLines...) {
+		text := line.Text
+		if line.isHTML {
+			text = PlainText(text)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		msg.Lines = append(msg.Lines, text)
+	}
+
+	return msg
+}
+
+// Markdown returns the content of the message without the subject as markdown.
+func (c *ChatMessage) Markdown() string {
+	parts := append([]string{}, c.Lines...)
+	if c.ActionURL != "" {
+		parts = append(parts, "["+c.ActionText+"]("+c.ActionURL+")")
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// HTML returns the whole message, including the subject, as sanitized html.
+func (c *ChatMessage) HTML() (string, error) {
+	var buf bytes.Buffer
+	err := goldmark.Convert([]byte("**"+c.Subject+"**\n\n"+c.Markdown()), &buf)
+	if err != nil {
+		return "", err
+	}
+	return bluemonday.UGCPolicy().Sanitize(buf.String()), nil
+}
+
+// slackText returns the whole message in the markdown dialect of Slack.
+func (c *ChatMessage) slackText() string {
+	parts := []string{"*" + c.Subject + "*"}
+	for _, line := range c.Lines {
+		parts = append(parts, strings.ReplaceAll(line, "**", "*"))
+	}
+	if c.ActionURL != "" {
+		parts = append(parts, "<"+c.ActionURL+"|"+c.ActionText+">")
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func newJSONRequest(method, url string, payload interface{}) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func newSlackRequest(t *ChatTarget, msg *ChatMessage) (*http.Request, error) {
+	return newJSONRequest(http.MethodPost, t.URL, map[string]string{
+		"text": msg.slackText(),
+	})
+}
+
+func newMatrixRequest(t *ChatTarget, msg *ChatMessage) (*http.Request, error) {
+	formatted, err := msg.HTML()
+	if err != nil {
+		return nil, err
+	}
+
+	txnID, err := utils.CryptoRandomString(20)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(t.URL, "/") +
+		"/_matrix/client/v3/rooms/" + url.PathEscape(t.Room) +
+		"/send/m.room.message/" + txnID
+
+	req, err := newJSONRequest(http.MethodPut, endpoint, map[string]string{
+		"msgtype":        "m.notice",
+		"body":           msg.Subject + "\n\n" + msg.Markdown(),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.Token)
+	return req, nil
+}
+
+func newNtfyRequest(t *ChatTarget, msg *ChatMessage) (*http.Request, error) {
+	// The json api of ntfy is published to at the root of the server, with the topic in the payload. Unlike the
+	// headers of the plain api, it handles titles which are not ascii.
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Trim(u.Path, "/")
+	topic := path[strings.LastIndex(path, "/")+1:]
+	u.Path = strings.TrimSuffix("/"+path, topic)
+
+	payload := map[string]interface{}{
+		"topic":    topic,
+		"title":    msg.Subject,
+		"message":  msg.Markdown(),
+		"markdown": true,
+	}
+	if msg.ActionURL != "" {
+		payload["click"] = msg.ActionURL
+	}
+
+	req, err := newJSONRequest(http.MethodPost, u.String(), payload)
+	if err != nil {
+		return nil, err
+	}
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+	return req, nil
+}
+
+func newGotifyRequest(t *ChatTarget, msg *ChatMessage) (*http.Request, error) {
+	extras := map[string]interface{}{
+		"client::display": map[string]string{"contentType": "text/markdown"},
+	}
+	if msg.ActionURL != "" {
+		extras["client::notification"] = map[string]interface{}{
+			"click": map[string]string{"url": msg.ActionURL},
+		}
+	}
+
+	req, err := newJSONRequest(http.MethodPost, strings.TrimSuffix(t.URL, "/")+"/message", map[string]interface{}{
+		"title":    msg.Subject,
+		"message":  msg.Markdown(),
+		"priority": 5,
+		"extras":   extras,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Gotify-Key", t.Token)
+	return req, nil
+}
+
+var chatRequestBuilders = map[ChatTargetKind]func(t *ChatTarget, msg *ChatMessage) (*http.Request, error){
+	ChatTargetSlack:  newSlackRequest,
+	ChatTargetMatrix: newMatrixRequest,
+	ChatTargetNtfy:   newNtfyRequest,
+	ChatTargetGotify: newGotifyRequest,
+}
+
+// IsValidChatTargetKind checks if a kind is one of the kinds returned by ChatTargetKinds.
+func IsValidChatTargetKind(kind ChatTargetKind) bool {
+	_, exists := chatRequestBuilders[kind]
+	return exists
+}
+
+// SendChatMessage sends a message to a chat target.
+func SendChatMessage(t *ChatTarget, msg *ChatMessage) error {
+	buildRequest, exists := chatRequestBuilders[t.Kind]
+	if !exists {
+		return fmt.Errorf("unknown chat target kind %s", t.Kind)
+	}
+
+	req, err := buildRequest(t, msg)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Vikunja/"+version.Version)
+
+	res, err := getOutgoingHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s returned status %d", t.Kind, res.StatusCode)
+	}
+
+	return nil
+}
+
+func notifyChat(notifiable Notifiable, notification Notification) (err error) {
+	if !IsChatEnabled() {
+		return nil
+	}
+
+	// Only notifications about what happens in projects are sent to chats. Everything else, like password reset
+	// links, has no place in a chat room other people might read.
+	if !IsConfigurableNotification(notification.Name()) {
+		return nil
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	targets, err := GetChatTargetsForNotifiable(s, notifiable.RouteForDB())
+	if err != nil || len(targets) == 0 {
+		return err
+	}
+
+	var msg *ChatMessage
+	if chattable, is := notification.(Chattable); is {
+		msg = chattable.ToChat(notifiable.Lang())
+	} else if mail := notification.ToMail(notifiable.Lang()); mail != nil {
+		msg = NewChatMessage(mail)
+	}
+	if msg == nil {
+		return nil
+	}
+
+	for _, target := range targets {
+		err = SendChatMessage(target, msg)
+		if err != nil {
+			// A single broken target should not keep the others from getting the message
+			log.Errorf("Could not send notification %s to chat target %d: %s", notification.Name(), target.ID, err)
+		}
+	}
+
+	return nil
+}
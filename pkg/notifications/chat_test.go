@@ -0,0 +1,202 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testChatNotification struct {
+	testNotification
+}
+
+func (n *testChatNotification) ToMail(_ string) *Mail {
+	return NewMail().
+		Subject("Task was updated").
+		Greeting("Hi Frederick,").
+		Line("**Frederick** changed the task.").
+		HTML("<p>The new description<br>with two lines.</p>").
+		Action("View Task", "https://vikunja.example.com/tasks/1").
+		Line("Have a nice day!")
+}
+
+func (n *testChatNotification) Name() string {
+	return "test.chat.notification"
+}
+
+type chatRequest struct {
+	Method  string
+	Path    string
+	Header  http.Header
+	Payload map[string]interface{}
+}
+
+func newChatTestServer(t *testing.T) (*httptest.Server, *[]chatRequest) {
+	received := []chatRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		payload := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		received = append(received, chatRequest{
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Header:  r.Header,
+			Payload: payload,
+		})
+	}))
+	return server, &received
+}
+
+func TestNewChatMessage(t *testing.T) {
+	msg := NewChatMessage((&testChatNotification{}).ToMail("en"))
+
+	assert.Equal(t, "Task was updated", msg.Subject)
+	assert.Equal(t, []string{
+		"**Frederick** changed the task.",
+		"The new description\nwith two lines.",
+		"Have a nice day!",
+	}, msg.Lines)
+	assert.Equal(t, "**Frederick** changed the task.\n\nThe new description\nwith two lines.\n\nHave a nice day!\n\n[View Task](https://vikunja.example.com/tasks/1)", msg.Markdown())
+	assert.Equal(t, "*Task was updated*\n\n*Frederick* changed the task.\n\nThe new description\nwith two lines.\n\nHave a nice day!\n\n<https://vikunja.example.com/tasks/1|View Task>", msg.slackText())
+
+	formatted, err := msg.HTML()
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "<strong>Task was updated</strong>")
+	assert.Contains(t, formatted, `<a href="https://vikunja.example.com/tasks/1" rel="nofollow">View Task</a>`)
+}
+
+func TestSendChatMessage(t *testing.T) {
+	msg := NewChatMessage((&testChatNotification{}).ToMail("en"))
+
+	t.Run("slack", func(t *testing.T) {
+		server, received := newChatTestServer(t)
+		defer server.Close()
+
+		err := SendChatMessage(&ChatTarget{Kind: ChatTargetSlack, URL: server.URL + "/hooks/abc"}, msg)
+		require.NoError(t, err)
+		require.Len(t, *received, 1)
+		req := (*received)[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/hooks/abc", req.Path)
+		assert.Equal(t, msg.slackText(), req.Payload["text"])
+	})
+	t.Run("matrix", func(t *testing.T) {
+		server, received := newChatTestServer(t)
+		defer server.Close()
+
+		err := SendChatMessage(&ChatTarget{Kind: ChatTargetMatrix, URL: server.URL + "/", Room: "!room:example.com", Token: "secret"}, msg)
+		require.NoError(t, err)
+		require.Len(t, *received, 1)
+		req := (*received)[0]
+		assert.Equal(t, http.MethodPut, req.Method)
+		assert.Regexp(t, `^/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/[a-zA-Z0-9]{20}$`, req.Path)
+		assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+		assert.Equal(t, "m.notice", req.Payload["msgtype"])
+		assert.Equal(t, "org.matrix.custom.html", req.Payload["format"])
+		assert.Contains(t, req.Payload["formatted_body"], "<strong>Task was updated</strong>")
+	})
+	t.Run("ntfy", func(t *testing.T) {
+		server, received := newChatTestServer(t)
+		defer server.Close()
+
+		err := SendChatMessage(&ChatTarget{Kind: ChatTargetNtfy, URL: server.URL + "/my-topic", Token: "tk_secret"}, msg)
+		require.NoError(t, err)
+		require.Len(t, *received, 1)
+		req := (*received)[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/", req.Path)
+		assert.Equal(t, "Bearer tk_secret", req.Header.Get("Authorization"))
+		assert.Equal(t, "my-topic", req.Payload["topic"])
+		assert.Equal(t, "Task was updated", req.Payload["title"])
+		assert.Equal(t, msg.Markdown(), req.Payload["message"])
+		assert.Equal(t, true, req.Payload["markdown"])
+		assert.Equal(t, "https://vikunja.example.com/tasks/1", req.Payload["click"])
+	})
+	t.Run("gotify", func(t *testing.T) {
+		server, received := newChatTestServer(t)
+		defer server.Close()
+
+		err := SendChatMessage(&ChatTarget{Kind: ChatTargetGotify, URL: server.URL, Token: "app-token"}, msg)
+		require.NoError(t, err)
+		require.Len(t, *received, 1)
+		req := (*received)[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/message", req.Path)
+		assert.Equal(t, "app-token", req.Header.Get("X-Gotify-Key"))
+		assert.Equal(t, "Task was updated", req.Payload["title"])
+		assert.Equal(t, msg.Markdown(), req.Payload["message"])
+	})
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		err := SendChatMessage(&ChatTarget{Kind: ChatTargetSlack, URL: server.URL}, msg)
+		require.Error(t, err)
+	})
+}
+
+func TestNotifyChat(t *testing.T) {
+	RegisterConfigurableNotification("test.chat.notification")
+
+	server, received := newChatTestServer(t)
+	defer server.Close()
+
+	s := db.NewSession()
+	defer s.Close()
+	_, err := s.Exec("delete from chat_notification_targets")
+	require.NoError(t, err)
+	_, err = s.Insert(&ChatTarget{NotifiableID: 42, Kind: ChatTargetSlack, URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, s.Commit())
+
+	tnf := &testNotifiable{ShouldSendNotification: true, Language: "en"}
+
+	t.Run("configurable notification", func(t *testing.T) {
+		err := Notify(tnf, &testChatNotification{})
+		require.NoError(t, err)
+		require.Len(t, *received, 1)
+		assert.Contains(t, (*received)[0].Payload["text"], "*Task was updated*")
+	})
+	t.Run("not configurable notification", func(t *testing.T) {
+		*received = []chatRequest{}
+		err := Notify(tnf, &testNotification{Test: "somethingsomething"})
+		require.NoError(t, err)
+		assert.Empty(t, *received)
+	})
+	t.Run("disabled for the channel", func(t *testing.T) {
+		*received = []chatRequest{}
+		tnfp := &testNotifiableWithPreferences{
+			testNotifiable:   *tnf,
+			DisabledChannels: []Channel{ChannelChat},
+		}
+		err := Notify(tnfp, &testChatNotification{})
+		require.NoError(t, err)
+		assert.Empty(t, *received)
+	})
+}
@@ -0,0 +1,232 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/i18n"
+
+	"xorm.io/xorm"
+)
+
+// DigestEntry describes where a notification belongs in a digest mail.
+type DigestEntry struct {
+	ProjectID    int64
+	ProjectTitle string
+	// The title and url of the thing in the project the notification is about, usually a task.
+	Title string
+	URL   string
+	// An optional line shown before the content of the mail of the notification.
+	Summary string
+}
+
+// Digestable is implemented by notifications which can be batched into a digest mail instead of being sent one by one.
+type Digestable interface {
+	ToDigest(lang string) *DigestEntry
+}
+
+// NotifiableWithDigest is a notifiable which can choose to get the mails of digestable notifications batched into
+// a digest.
+type NotifiableWithDigest interface {
+	Notifiable
+	// WantsDigest returns true if the mails of digestable notifications should be queued for the next digest
+	// instead of being sent right away.
+	WantsDigest() (bool, error)
+}
+
+// DigestItem is the mail of a notification queued for the next digest of a notifiable.
+type DigestItem struct {
+	ID           int64  `xorm:"bigint autoincr not null unique pk"`
+	NotifiableID int64  `xorm:"bigint not null INDEX"`
+	Name         string `xorm:"varchar(250) not null"`
+
+	ProjectID    int64  `xorm:"bigint not null default 0"`
+	ProjectTitle string `xorm:"varchar(250) null"`
+	// Items with the same thread id are listed together in a digest.
+	ThreadID string        `xorm:"varchar(250) null"`
+	Title    string        `xorm:"text null"`
+	URL      string        `xorm:"text null"`
+	Summary  string        `xorm:"text null"`
+	Lines    []*DigestLine `xorm:"json null"`
+
+	Created time.Time `xorm:"created not null"`
+}
+
+// DigestLine is a line of the mail of a queued notification.
+type DigestLine struct {
+	Text   string `json:"text"`
+	IsHTML bool   `json:"is_html"`
+}
+
+// TableName returns the table name for queued digest items
+func (*DigestItem) TableName() string {
+	return "notification_digest_items"
+}
+
+// queueForDigest saves the mail of a notification for the next digest of the notifiable if it wants one.
+func queueForDigest(notifiable Notifiable, notification Notification, mail *Mail) (queued bool, err error) {
+	digestable, is := notification.(Digestable)
+	if !is {
+		return false, nil
+	}
+	n, is := notifiable.(NotifiableWithDigest)
+	if !is {
+		return false, nil
+	}
+
+	wants, err := n.WantsDigest()
+	if err != nil || !wants {
+		return false, err
+	}
+
+	entry := digestable.ToDigest(notifiable.Lang())
+	item := &DigestItem{
+		NotifiableID: notifiable.RouteForDB(),
+		Name:         notification.Name(),
+		ProjectID:    entry.ProjectID,
+		ProjectTitle: entry.ProjectTitle,
+		Title:        entry.Title,
+		URL:          entry.URL,
+		Summary:      entry.Summary,
+	}
+	if threadID, is := notification.(ThreadID); is {
+		item.ThreadID = threadID.ThreadID()
+	}
+	for _, line := range append(mail.introLines, mail.outroLines...) {
+		item.Lines = append(item.Lines, &DigestLine{Text: line.Text, IsHTML: line.isHTML})
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err = s.Insert(item)
+	if err != nil {
+		_ = s.Rollback()
+		return false, err
+	}
+
+	return true, s.Commit()
+}
+
+// GetNotifiableIDsWithPendingDigest returns the ids of all notifiables which have items queued for their next digest.
+func GetNotifiableIDsWithPendingDigest(s *xorm.Session) (ids []int64, err error) {
+	ids = []int64{}
+	err = s.
+		Table("notification_digest_items").
+		Distinct("notifiable_id").
+		Find(&ids)
+	return
+}
+
+// GetDigestItems returns all items queued for the next digest of a notifiable, oldest first.
+func GetDigestItems(s *xorm.Session, notifiableID int64) (items []*DigestItem, err error) {
+	items = []*DigestItem{}
+	err = s.
+		Where("notifiable_id = ?", notifiableID).
+		OrderBy("id asc").
+		Find(&items)
+	return
+}
+
+// DeleteDigestItems removes items after they were sent in a digest.
+func DeleteDigestItems(s *xorm.Session, items []*DigestItem) (err error) {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	_, err = s.In("id", ids).Delete(&DigestItem{})
+	return
+}
+
+// NewDigestMail summarizes queued items in one mail, grouped by project and the thing in the project they are
+// about. If all items are about the same thing, the mail is part of its thread.
+func NewDigestMail(lang string, items []*DigestItem) *Mail {
+	type thread struct {
+		title string
+		url   string
+		items []*DigestItem
+	}
+	type project struct {
+		title   string
+		threads []*thread
+		byID    map[string]*thread
+	}
+
+	projects := []*project{}
+	projectsByID := make(map[int64]*project)
+	threadIDs := make(map[string]bool)
+	for _, item := range items {
+		p, exists := projectsByID[item.ProjectID]
+		if !exists {
+			p = &project{title: item.ProjectTitle, byID: make(map[string]*thread)}
+			projectsByID[item.ProjectID] = p
+			projects = append(projects, p)
+		}
+
+		// Items without a thread are listed on their own
+		key := item.ThreadID
+		if key == "" {
+			key = item.Name + item.Title + item.Created.String()
+		}
+		t, exists := p.byID[key]
+		if !exists {
+			t = &thread{title: item.Title, url: item.URL}
+			p.byID[key] = t
+			p.threads = append(p.threads, t)
+		}
+		t.items = append(t.items, item)
+		threadIDs[item.ThreadID] = true
+	}
+
+	mail := NewMail().
+		Subject(i18n.T(lang, "notifications.digest.subject", len(items))).
+		Line(i18n.T(lang, "notifications.digest.message"))
+
+	for _, p := range projects {
+		if p.title != "" {
+			mail.Line("## " + p.title)
+		}
+		for _, t := range p.threads {
+			if t.url != "" {
+				mail.Line("**[" + t.title + "](" + t.url + ")**")
+			} else if t.title != "" {
+				mail.Line("**" + t.title + "**")
+			}
+			for _, item := range t.items {
+				if item.Summary != "" {
+					mail.Line(item.Summary)
+				}
+				for _, line := range item.Lines {
+					mail.appendLine(line.Text, line.IsHTML)
+				}
+			}
+		}
+	}
+
+	if len(threadIDs) == 1 && len(items) > 0 && items[0].ThreadID != "" {
+		mail.ThreadID(items[0].ThreadID)
+	}
+
+	return mail
+}
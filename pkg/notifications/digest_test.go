@@ -0,0 +1,63 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDigestMail(t *testing.T) {
+	t.Run("grouped by project and thread", func(t *testing.T) {
+		items := []*DigestItem{
+			{ID: 1, ProjectID: 1, ProjectTitle: "Inbox", ThreadID: "<task-1>", Title: "Task 1", URL: "https://example.com/tasks/1", Lines: []*DigestLine{{Text: "first"}}},
+			{ID: 2, ProjectID: 2, ProjectTitle: "Work", ThreadID: "<task-2>", Title: "Task 2", URL: "https://example.com/tasks/2", Lines: []*DigestLine{{Text: "second"}}},
+			{ID: 3, ProjectID: 1, ProjectTitle: "Inbox", ThreadID: "<task-1>", Title: "Task 1", URL: "https://example.com/tasks/1", Summary: "summary", Lines: []*DigestLine{{Text: "third"}}},
+		}
+
+		mail := NewDigestMail("en", items)
+
+		lines := []string{}
+		for _, line := range mail.introLines {
+			lines = append(lines, line.Text)
+		}
+		require.Len(t, lines, 9)
+		assert.Equal(t, []string{
+			"## Inbox",
+			"**[Task 1](https://example.com/tasks/1)**",
+			"first",
+			"summary",
+			"third",
+			"## Work",
+			"**[Task 2](https://example.com/tasks/2)**",
+			"second",
+		}, lines[1:])
+		assert.Contains(t, mail.subject, "3")
+		assert.Empty(t, mail.threadID)
+	})
+	t.Run("single thread", func(t *testing.T) {
+		items := []*DigestItem{
+			{ID: 1, ProjectID: 1, ThreadID: "<task-1>", Title: "Task 1"},
+			{ID: 2, ProjectID: 1, ThreadID: "<task-1>", Title: "Task 1"},
+		}
+
+		mail := NewDigestMail("en", items)
+		assert.Equal(t, "<task-1>", mail.threadID)
+	})
+}
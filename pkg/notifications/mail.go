@@ -36,6 +36,7 @@ type Mail struct {
 	outroLines  []*mailLine
 	footerLines []*mailLine
 	threadID    string
+	replyTo     string
 }
 
 type mailLine struct {
@@ -107,6 +108,12 @@ func (m *Mail) ThreadID(threadID string) *Mail {
 	return m
 }
 
+// ReplyTo sets the address replies to the mail message are sent to
+func (m *Mail) ReplyTo(address string) *Mail {
+	m.replyTo = address
+	return m
+}
+
 func (m *Mail) appendLine(line string, isHTML bool) *Mail {
 	if m.actionURL == "" {
 		m.introLines = append(m.introLines, &mailLine{
@@ -202,6 +202,7 @@ func RenderMail(m *Mail, lang string) (mailOpts *mail.Opts, err error) {
 		HTMLMessage: htmlContent.String(),
 		Boundary:    boundary,
 		ThreadID:    m.threadID,
+		ReplyTo:     m.replyTo,
 		EmbedFS: map[string]*embed.FS{
 			"logo.png": &logo,
 		},
@@ -422,6 +422,18 @@ This is a footer line
 		assert.Equal(t, mail.to, mailopts.To)
 		assert.Equal(t, "<task-123@vikunja>", mailopts.ThreadID)
 	})
+	t.Run("with reply to address", func(t *testing.T) {
+		mail := NewMail().
+			From("test@example.com").
+			To("test@otherdomain.com").
+			Subject("Testmail").
+			Line("This is a line").
+			ReplyTo("reply+1-123-abc@vikunja.example.com")
+
+		mailopts, err := RenderMail(mail, "en")
+		require.NoError(t, err)
+		assert.Equal(t, "reply+1-123-abc@vikunja.example.com", mailopts.ReplyTo)
+	})
 	t.Run("with special characters in task title", func(t *testing.T) {
 		mail := NewMail().
 			From("test@example.com").
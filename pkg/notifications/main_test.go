@@ -35,7 +35,7 @@ func SetupTests() {
 		log.Fatal(err)
 	}
 
-	err = x.Sync2(&DatabaseNotification{})
+	err = x.Sync2(GetTables()...)
 	if err != nil {
 		log.Fatal(err)
 	}
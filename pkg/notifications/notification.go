@@ -19,11 +19,14 @@ package notifications
 import (
 	"encoding/json"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
 )
 
 // Notification is a notification which can be sent via mail or db.
+// Notifications can additionally implement Pushable to be sent as push messages. Configurable notifications are
+// also sent to the chats of a notifiable, rendered from their mail unless they implement Chattable.
 type Notification interface {
 	ToMail(lang string) *Mail
 	ToDB() interface{}
@@ -43,6 +46,12 @@ type ThreadID interface {
 	ThreadID() string
 }
 
+// ReplyTo is an optional interface for notifications the notifiable can reply to per mail. It returns the address
+// replies are sent to, or an empty string if the notifiable cannot reply.
+type ReplyTo interface {
+	ReplyTo(notifiable Notifiable) string
+}
+
 // Notifiable is an entity which can be notified. Usually a user.
 type Notifiable interface {
 	// RouteForMail should return the email address this notifiable has.
@@ -69,20 +78,62 @@ func Notify(notifiable Notifiable, notification Notification) (err error) {
 		return err
 	}
 
-	err = notifyMail(notifiable, notification)
+	wantsMail, err := wantsNotification(notifiable, notification, ChannelMail)
 	if err != nil {
-		return
+		return err
+	}
+	if wantsMail {
+		err = notifyMail(notifiable, notification)
+		if err != nil {
+			return
+		}
 	}
 
-	return notifyDB(notifiable, notification)
+	wantsInApp, err := wantsNotification(notifiable, notification, ChannelInApp)
+	if err != nil {
+		return err
+	}
+	if wantsInApp {
+		err = notifyDB(notifiable, notification)
+		if err != nil {
+			return
+		}
+	}
+
+	wantsPush, err := wantsNotification(notifiable, notification, ChannelPush)
+	if err != nil {
+		return err
+	}
+	if wantsPush {
+		err = notifyPush(notifiable, notification)
+		if err != nil {
+			return
+		}
+	}
+
+	wantsChat, err := wantsNotification(notifiable, notification, ChannelChat)
+	if err != nil || !wantsChat {
+		return err
+	}
+
+	return notifyChat(notifiable, notification)
 }
 
 func notifyMail(notifiable Notifiable, notification Notification) error {
+	if !config.MailerEnabled.GetBool() {
+		return nil
+	}
+
 	mail := notification.ToMail(notifiable.Lang())
 	if mail == nil {
 		return nil
 	}
 
+	queued, err := queueForDigest(notifiable, notification, mail)
+	if err != nil || queued {
+		return err
+	}
+
 	to, err := notifiable.RouteForMail()
 	if err != nil {
 		return err
@@ -93,6 +144,10 @@ func notifyMail(notifiable Notifiable, notification Notification) error {
 		mail.ThreadID(threadID.ThreadID())
 	}
 
+	if replyTo, is := notification.(ReplyTo); is {
+		mail.ReplyTo(replyTo.ReplyTo(notifiable))
+	}
+
 	return SendMail(mail, notifiable.Lang())
 }
 
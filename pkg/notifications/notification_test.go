@@ -19,8 +19,10 @@ package notifications
 import (
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"xorm.io/xorm/schemas"
 )
@@ -73,6 +75,59 @@ func (t *testNotifiable) Lang() string {
 	return t.Language
 }
 
+type testNotifiableWithPreferences struct {
+	testNotifiable
+	DisabledChannels []Channel
+	askedForProject  int64
+}
+
+func (t *testNotifiableWithPreferences) WantsNotification(channel Channel, _ string, projectID int64) (bool, error) {
+	t.askedForProject = projectID
+	for _, c := range t.DisabledChannels {
+		if c == channel {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type testProjectNotification struct {
+	testNotification
+}
+
+func (n *testProjectNotification) Name() string {
+	return "test.project.notification"
+}
+
+func (n *testProjectNotification) ProjectID() int64 {
+	return 7
+}
+
+type testNotifiableWithDigest struct {
+	testNotifiable
+}
+
+func (t *testNotifiableWithDigest) WantsDigest() (bool, error) {
+	return true, nil
+}
+
+type testDigestableNotification struct {
+	testNotification
+}
+
+func (n *testDigestableNotification) ToDigest(_ string) *DigestEntry {
+	return &DigestEntry{
+		ProjectID:    7,
+		ProjectTitle: "Project",
+		Title:        "Task",
+		URL:          "https://example.com/tasks/1",
+	}
+}
+
+func (n *testDigestableNotification) ThreadID() string {
+	return "<task-1@vikunja>"
+}
+
 func TestNotify(t *testing.T) {
 	t.Run("normal", func(t *testing.T) {
 
@@ -131,4 +186,67 @@ func TestNotify(t *testing.T) {
 			"notifiable_id": 42,
 		})
 	})
+	t.Run("in-app disabled by preferences", func(t *testing.T) {
+		RegisterConfigurableNotification("test.project.notification")
+
+		s := db.NewSession()
+		defer s.Close()
+		_, err := s.Exec("delete from notifications")
+		require.NoError(t, err)
+
+		tnf := &testNotifiableWithPreferences{
+			testNotifiable:   testNotifiable{ShouldSendNotification: true, Language: "en"},
+			DisabledChannels: []Channel{ChannelInApp},
+		}
+
+		err = Notify(tnf, &testProjectNotification{testNotification{Test: "something"}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), tnf.askedForProject)
+		db.AssertMissing(t, "notifications", map[string]interface{}{
+			"notifiable_id": 42,
+		})
+	})
+	t.Run("preferences are ignored for notifications which cannot be configured", func(t *testing.T) {
+		s := db.NewSession()
+		defer s.Close()
+		_, err := s.Exec("delete from notifications")
+		require.NoError(t, err)
+
+		tnf := &testNotifiableWithPreferences{
+			testNotifiable:   testNotifiable{ShouldSendNotification: true, Language: "en"},
+			DisabledChannels: []Channel{ChannelInApp},
+		}
+
+		err = Notify(tnf, &testNotification{Test: "something"})
+		require.NoError(t, err)
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"notifiable_id": 42,
+			"name":          "test.notification",
+		}, false)
+	})
+	t.Run("queued for digest", func(t *testing.T) {
+		config.MailerEnabled.Set(true)
+		defer config.MailerEnabled.Set(false)
+
+		s := db.NewSession()
+		defer s.Close()
+		_, err := s.Exec("delete from notification_digest_items")
+		require.NoError(t, err)
+
+		tnf := &testNotifiableWithDigest{
+			testNotifiable: testNotifiable{ShouldSendNotification: true, Language: "en"},
+		}
+
+		err = Notify(tnf, &testDigestableNotification{testNotification{Test: "somethingsomething"}})
+		require.NoError(t, err)
+
+		items, err := GetDigestItems(s, 42)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "test.notification", items[0].Name)
+		assert.Equal(t, int64(7), items[0].ProjectID)
+		assert.Equal(t, "<task-1@vikunja>", items[0].ThreadID)
+		require.Len(t, items[0].Lines, 1)
+		assert.Equal(t, "somethingsomething", items[0].Lines[0].Text)
+	})
 }
@@ -0,0 +1,111 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"sort"
+	"sync"
+)
+
+// Channel is a way a notification is delivered to a notifiable.
+type Channel string
+
+const (
+	ChannelMail  Channel = "mail"
+	ChannelInApp Channel = "in_app"
+	ChannelPush  Channel = "push"
+	ChannelChat  Channel = "chat"
+)
+
+// Channels returns all channels a notifiable can choose from in its notification preferences.
+func Channels() []Channel {
+	return []Channel{ChannelMail, ChannelInApp, ChannelPush, ChannelChat}
+}
+
+// IsValidChannel checks if a channel is one of the channels returned by Channels.
+func IsValidChannel(channel Channel) bool {
+	for _, c := range Channels() {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectID is implemented by notifications about something in a project.
+// Notifiables can use it to have different preferences per project.
+type ProjectID interface {
+	ProjectID() int64
+}
+
+// NotifiableWithPreferences is a notifiable which can decide per notification and channel if it wants to be
+// notified. It is only asked for notifications which were registered with RegisterConfigurableNotification.
+type NotifiableWithPreferences interface {
+	Notifiable
+	// WantsNotification is called before sending a notification through a channel. projectID is 0 if the
+	// notification is not about anything in a project.
+	WantsNotification(channel Channel, name string, projectID int64) (bool, error)
+}
+
+var (
+	configurableNotifications     = make(map[string]bool)
+	configurableNotificationsLock sync.RWMutex
+)
+
+// RegisterConfigurableNotification allows notifiables to choose the channels the notifications with these names
+// are sent through. All other notifications, for example security related ones, are always sent.
+func RegisterConfigurableNotification(names ...string) {
+	configurableNotificationsLock.Lock()
+	defer configurableNotificationsLock.Unlock()
+	for _, name := range names {
+		configurableNotifications[name] = true
+	}
+}
+
+// IsConfigurableNotification checks if a notification was registered with RegisterConfigurableNotification.
+func IsConfigurableNotification(name string) bool {
+	configurableNotificationsLock.RLock()
+	defer configurableNotificationsLock.RUnlock()
+	return configurableNotifications[name]
+}
+
+// ConfigurableNotifications returns the sorted names of all notifications registered with RegisterConfigurableNotification.
+func ConfigurableNotifications() []string {
+	configurableNotificationsLock.RLock()
+	defer configurableNotificationsLock.RUnlock()
+	names := make([]string, 0, len(configurableNotifications))
+	for name := range configurableNotifications {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wantsNotification checks if a notifiable wants to get a notification through a channel.
+func wantsNotification(notifiable Notifiable, notification Notification, channel Channel) (bool, error) {
+	n, has := notifiable.(NotifiableWithPreferences)
+	if !has || !IsConfigurableNotification(notification.Name()) {
+		return true, nil
+	}
+
+	var projectID int64
+	if p, is := notification.(ProjectID); is {
+		projectID = p.ProjectID()
+	}
+
+	return n.WantsNotification(channel, notification.Name(), projectID)
+}
@@ -0,0 +1,203 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/microcosm-cc/bluemonday"
+	"xorm.io/xorm"
+)
+
+// The maximum number of characters of the body of a push message. Devices only show the first few lines anyway.
+const pushMaxBodyLength = 1000
+
+// Push is the content of a push message shown by the browser or device of a notifiable.
+type Push struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// The url the browser should open when the message is clicked.
+	URL string `json:"url,omitempty"`
+	// Messages with the same tag replace each other on the device.
+	Tag string `json:"tag,omitempty"`
+}
+
+// Pushable is implemented by notifications which can be sent as a push message.
+type Pushable interface {
+	ToPush(lang string) *Push
+}
+
+// PlainText turns html, like the content of a comment, into text which can be shown in a push message.
+func PlainText(content string) string {
+	// Keep paragraphs apart, the strict policy drops all tags
+	content = strings.NewReplacer("</p>", "</p>\n", "<br>", "\n", "<br/>", "\n", "<br />", "\n").Replace(content)
+	text := bluemonday.StrictPolicy().Sanitize(content)
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+// PushSubscriptionKeys holds the keys a browser created to encrypt push messages for one subscription.
+type PushSubscriptionKeys struct {
+	// The public key of the browser, base64url encoded.
+	P256dh string `xorm:"varchar(250) not null" json:"p256dh" valid:"required"`
+	// The authentication secret of the browser, base64url encoded.
+	Auth string `xorm:"varchar(250) not null" json:"auth" valid:"required"`
+}
+
+// PushSubscription is a browser or device which receives push messages for a notifiable.
+type PushSubscription struct {
+	// The unique, numeric id of this subscription.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"subscription"`
+
+	// The ID of the notifiable this subscription belongs to.
+	NotifiableID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The url of the push service messages for this subscription are sent to.
+	Endpoint string `xorm:"text not null" json:"endpoint" valid:"required,url"`
+	// The keys of the subscription, exactly as returned by the browser.
+	Keys PushSubscriptionKeys `xorm:"extends" json:"keys"`
+	// A human-readable name for the browser or device, to tell subscriptions apart.
+	Title string `xorm:"varchar(250) null" json:"title"`
+	// When the browser will end this subscription on its own. Null if the browser did not say.
+	ExpiresAt time.Time `xorm:"datetime null" json:"expires_at"`
+
+	// A timestamp when this subscription was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName returns the table name for push subscriptions
+func (*PushSubscription) TableName() string {
+	return "push_subscriptions"
+}
+
+func (p *PushSubscription) isExpired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(now)
+}
+
+// GetPushSubscriptionsForNotifiable returns all push subscriptions of a notifiable.
+func GetPushSubscriptionsForNotifiable(s *xorm.Session, notifiableID int64) (subscriptions []*PushSubscription, err error) {
+	subscriptions = []*PushSubscription{}
+	err = s.
+		Where("notifiable_id = ?", notifiableID).
+		OrderBy("id asc").
+		Find(&subscriptions)
+	return
+}
+
+// GetPushSubscriptionByID returns a push subscription by its id.
+func GetPushSubscriptionByID(s *xorm.Session, id int64) (subscription *PushSubscription, exists bool, err error) {
+	subscription = &PushSubscription{}
+	exists, err = s.Where("id = ?", id).Get(subscription)
+	return
+}
+
+// AddPushSubscription saves a new subscription for a notifiable. A browser only ever has one subscription per
+// endpoint, if the notifiable subscribed with it before, the old subscription is replaced.
+// Subscriptions of other notifiables are never touched, even if they use the same endpoint.
+func AddPushSubscription(s *xorm.Session, subscription *PushSubscription) (err error) {
+	_, err = s.
+		Where("endpoint = ? AND notifiable_id = ?", subscription.Endpoint, subscription.NotifiableID).
+		Delete(&PushSubscription{})
+	if err != nil {
+		return err
+	}
+
+	subscription.ID = 0
+	_, err = s.Insert(subscription)
+	return
+}
+
+// DeletePushSubscription removes a subscription of a notifiable.
+func DeletePushSubscription(s *xorm.Session, id, notifiableID int64) (err error) {
+	_, err = s.Where("id = ? AND notifiable_id = ?", id, notifiableID).Delete(&PushSubscription{})
+	return
+}
+
+func notifyPush(notifiable Notifiable, notification Notification) (err error) {
+	if !IsWebPushEnabled() {
+		return nil
+	}
+
+	pushable, is := notification.(Pushable)
+	if !is {
+		return nil
+	}
+	push := pushable.ToPush(notifiable.Lang())
+	if push == nil {
+		return nil
+	}
+
+	if utf8.RuneCountInString(push.Body) > pushMaxBodyLength {
+		push.Body = string([]rune(push.Body)[:pushMaxBodyLength-1]) + "…"
+	}
+
+	payload, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	subscriptions, err := GetPushSubscriptionsForNotifiable(s, notifiable.RouteForDB())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	gone := []int64{}
+	for _, subscription := range subscriptions {
+		if subscription.isExpired(now) {
+			gone = append(gone, subscription.ID)
+			continue
+		}
+
+		err = sendPush(subscription, payload)
+		if IsErrPushSubscriptionGone(err) {
+			log.Debugf("Push subscription %d of notifiable %d is gone, removing it", subscription.ID, subscription.NotifiableID)
+			gone = append(gone, subscription.ID)
+			continue
+		}
+		if err != nil {
+			// A single broken device should not keep the others from getting the message
+			log.Errorf("Could not send push message to subscription %d: %s", subscription.ID, err)
+		}
+	}
+
+	if len(gone) == 0 {
+		return nil
+	}
+
+	_, err = s.In("id", gone).Delete(&PushSubscription{})
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	return s.Commit()
+}
+
+// IsWebPushEnabled checks if web push is enabled and configured.
+func IsWebPushEnabled() bool {
+	return config.WebPushEnabled.GetBool() && config.WebPushVAPIDPrivateKey.GetString() != ""
+}
@@ -0,0 +1,296 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/version"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// The size of the only record of an encrypted push message. Push services accept at most 4096 bytes.
+	pushRecordSize = 4096
+	// The salt, record size, key id length and key id in front of the encrypted record.
+	pushHeaderSize = 16 + 4 + 1 + 65
+	// The padding delimiter and authentication tag added to the payload when encrypting it.
+	pushRecordOverhead = 1 + 16
+	// The maximum size of a payload which still fits into a push message.
+	pushMaxPayloadSize = pushRecordSize - pushHeaderSize - pushRecordOverhead
+)
+
+var (
+	outgoingClient     *http.Client
+	outgoingClientOnce sync.Once
+)
+
+// getOutgoingHTTPClient returns the client to send requests to urls users can choose freely, like push endpoints
+// and chat targets. Just like webhooks, they are sent through the webhook proxy if one is configured.
+func getOutgoingHTTPClient() *http.Client {
+	outgoingClientOnce.Do(func() {
+		outgoingClient = &http.Client{
+			Timeout: time.Duration(config.WebhooksTimeoutSeconds.GetInt()) * time.Second,
+		}
+
+		if config.WebhooksProxyURL.GetString() == "" || config.WebhooksProxyPassword.GetString() == "" {
+			return
+		}
+
+		proxyURL, _ := url.Parse(config.WebhooksProxyURL.GetString())
+		outgoingClient.Transport = &http.Transport{
+			Proxy: http.ProxyURL(proxyURL),
+			ProxyConnectHeader: http.Header{
+				"Proxy-Authorization": []string{"Basic " + base64.StdEncoding.EncodeToString([]byte("vikunja:"+config.WebhooksProxyPassword.GetString()))},
+				"User-Agent":          []string{"Vikunja/" + version.Version},
+			},
+		}
+	})
+
+	return outgoingClient
+}
+
+// ErrPushSubscriptionGone is returned when a push service does not know a subscription (anymore),
+// usually because the user revoked the permission or the browser was uninstalled.
+type ErrPushSubscriptionGone struct {
+	StatusCode int
+}
+
+func (err *ErrPushSubscriptionGone) Error() string {
+	return fmt.Sprintf("push subscription is gone [StatusCode: %d]", err.StatusCode)
+}
+
+// IsErrPushSubscriptionGone checks if an error is ErrPushSubscriptionGone.
+func IsErrPushSubscriptionGone(err error) bool {
+	_, ok := err.(*ErrPushSubscriptionGone)
+	return ok
+}
+
+// decodeBase64URL decodes base64url values as browsers return them. Some add padding, some do not.
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// GenerateVAPIDKeys creates a new key pair to sign push messages with, both encoded as unpadded base64url.
+func GenerateVAPIDKeys() (privateKey, publicKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(key.Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		nil
+}
+
+func getVAPIDKey() (*ecdh.PrivateKey, error) {
+	raw, err := decodeBase64URL(config.WebPushVAPIDPrivateKey.GetString())
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the vapid private key: %w", err)
+	}
+
+	return ecdh.P256().NewPrivateKey(raw)
+}
+
+// GetVAPIDPublicKey returns the public key browsers need to subscribe to push messages of this instance.
+func GetVAPIDPublicKey() (string, error) {
+	key, err := getVAPIDKey()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// vapidAuthorization returns the Authorization header for a push service as described in RFC 8292.
+func vapidAuthorization(endpoint string, now time.Time) (string, error) {
+	key, err := getVAPIDKey()
+	if err != nil {
+		return "", err
+	}
+
+	// The jwt library can only sign with ecdsa keys
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return "", err
+	}
+	signingKey, is := parsed.(*ecdsa.PrivateKey)
+	if !is {
+		return "", fmt.Errorf("vapid private key is not an ecdsa key")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	subject := config.WebPushSubject.GetString()
+	if subject == "" {
+		subject = "mailto:" + config.MailerFromEmail.GetString()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	return "vapid t=" + signed + ", k=" + base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// encryptPushPayload encrypts a payload for a subscription as described in RFC 8291.
+func encryptPushPayload(payload []byte, keys PushSubscriptionKeys) ([]byte, error) {
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptPushPayloadWithKey(payload, keys, serverKey, salt)
+}
+
+func encryptPushPayloadWithKey(payload []byte, keys PushSubscriptionKeys, serverKey *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(payload) > pushMaxPayloadSize {
+		return nil, fmt.Errorf("push payload is %d bytes, but can be at most %d bytes", len(payload), pushMaxPayloadSize)
+	}
+
+	rawClientKey, err := decodeBase64URL(keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the public key of the subscription: %w", err)
+	}
+	authSecret, err := decodeBase64URL(keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the auth secret of the subscription: %w", err)
+	}
+
+	clientKey, err := ecdh.P256().NewPublicKey(rawClientKey)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := serverKey.ECDH(clientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serverPublicKey := serverKey.PublicKey().Bytes()
+
+	keyInfo := "WebPush: info\x00" + string(rawClientKey) + string(serverPublicKey)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything fits into one record, which is marked as the last one with the 0x02 delimiter.
+	record := make([]byte, 0, len(payload)+1)
+	record = append(record, payload...)
+	record = append(record, 0x02)
+
+	body := bytes.NewBuffer(make([]byte, 0, pushHeaderSize+len(record)+gcm.Overhead()))
+	body.Write(salt)
+	_ = binary.Write(body, binary.BigEndian, uint32(pushRecordSize))
+	body.WriteByte(byte(len(serverPublicKey)))
+	body.Write(serverPublicKey)
+	body.Write(gcm.Seal(nil, nonce, record, nil))
+
+	return body.Bytes(), nil
+}
+
+// sendPush encrypts a payload and sends it to the push service of a subscription.
+func sendPush(subscription *PushSubscription, payload []byte) error {
+	body, err := encryptPushPayload(payload, subscription.Keys)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := vapidAuthorization(subscription.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(config.WebPushTTL.GetInt()))
+
+	res, err := getOutgoingHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+		return &ErrPushSubscriptionGone{StatusCode: res.StatusCode}
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("push service returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeBase64URL(t *testing.T, value string) []byte {
+	raw, err := decodeBase64URL(value)
+	require.NoError(t, err)
+	return raw
+}
+
+// decryptPushPayload does what a browser does with a push message.
+func decryptPushPayload(t *testing.T, body []byte, clientKey *ecdh.PrivateKey, authSecret []byte) []byte {
+	salt := body[:16]
+	assert.Equal(t, uint32(pushRecordSize), binary.BigEndian.Uint32(body[16:20]))
+	keyIDLength := int(body[20])
+	serverKey, err := ecdh.P256().NewPublicKey(body[21 : 21+keyIDLength])
+	require.NoError(t, err)
+
+	sharedSecret, err := clientKey.ECDH(serverKey)
+	require.NoError(t, err)
+	keyInfo := "WebPush: info\x00" + string(clientKey.PublicKey().Bytes()) + string(serverKey.Bytes())
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	require.NoError(t, err)
+	contentKey, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	require.NoError(t, err)
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(contentKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	record, err := gcm.Open(nil, nonce, body[21+keyIDLength:], nil)
+	require.NoError(t, err)
+
+	require.Equal(t, byte(0x02), record[len(record)-1])
+	return record[:len(record)-1]
+}
+
+func TestEncryptPushPayload(t *testing.T) {
+	t.Run("RFC 8291 example", func(t *testing.T) {
+		serverKey, err := ecdh.P256().NewPrivateKey(mustDecodeBase64URL(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+		require.NoError(t, err)
+		keys := PushSubscriptionKeys{
+			P256dh: "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4",
+			Auth:   "BTBZMqHH6r4Tts7J_aSIgg",
+		}
+
+		body, err := encryptPushPayloadWithKey(
+			[]byte("When I grow up, I want to be a watermelon"),
+			keys,
+			serverKey,
+			mustDecodeBase64URL(t, "DGv6ra1nlYgDCS1FRnbzlw"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t,
+			"DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN",
+			base64.RawURLEncoding.EncodeToString(body),
+		)
+	})
+	t.Run("can be decrypted by the browser", func(t *testing.T) {
+		clientKey, err := ecdh.P256().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		authSecret := []byte("0123456789abcdef")
+		keys := PushSubscriptionKeys{
+			// Browsers are not consistent about padding
+			P256dh: base64.URLEncoding.EncodeToString(clientKey.PublicKey().Bytes()),
+			Auth:   base64.RawURLEncoding.EncodeToString(authSecret),
+		}
+
+		body, err := encryptPushPayload([]byte(`{"title":"Test"}`), keys)
+		require.NoError(t, err)
+		assert.Equal(t, `{"title":"Test"}`, string(decryptPushPayload(t, body, clientKey, authSecret)))
+	})
+	t.Run("payload too large", func(t *testing.T) {
+		clientKey, err := ecdh.P256().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		keys := PushSubscriptionKeys{
+			P256dh: base64.RawURLEncoding.EncodeToString(clientKey.PublicKey().Bytes()),
+			Auth:   "BTBZMqHH6r4Tts7J_aSIgg",
+		}
+
+		_, err = encryptPushPayload([]byte(strings.Repeat("a", pushMaxPayloadSize+1)), keys)
+		require.Error(t, err)
+	})
+}
+
+type testPushNotification struct {
+	testNotification
+}
+
+func (n *testPushNotification) ToPush(_ string) *Push {
+	return &Push{Title: "Test", Body: n.Test}
+}
+
+func TestNotifyPush(t *testing.T) {
+	privateKey, _, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+	config.WebPushEnabled.Set(true)
+	config.WebPushVAPIDPrivateKey.Set(privateKey)
+	defer config.WebPushEnabled.Set(false)
+
+	clientKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authSecret := []byte("0123456789abcdef")
+
+	received := [][]byte{}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		assert.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "86400", r.Header.Get("TTL"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received = append(received, body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	s := db.NewSession()
+	defer s.Close()
+	_, err = s.Exec("delete from push_subscriptions")
+	require.NoError(t, err)
+
+	keys := PushSubscriptionKeys{
+		P256dh: base64.RawURLEncoding.EncodeToString(clientKey.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(authSecret),
+	}
+	for _, path := range []string{"/active", "/gone"} {
+		err = AddPushSubscription(s, &PushSubscription{NotifiableID: 42, Endpoint: server.URL + path, Keys: keys})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Commit())
+
+	tnf := &testNotifiable{ShouldSendNotification: true, Language: "en"}
+	err = Notify(tnf, &testPushNotification{testNotification{Test: "somethingsomething"}})
+	require.NoError(t, err)
+
+	require.Len(t, received, 1)
+	assert.JSONEq(t, `{"title":"Test","body":"somethingsomething"}`, string(decryptPushPayload(t, received[0], clientKey, authSecret)))
+
+	publicKey, err := GetVAPIDPublicKey()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(authorization, "vapid t="))
+	assert.True(t, strings.HasSuffix(authorization, ", k="+publicKey))
+	token := strings.TrimSuffix(strings.TrimPrefix(authorization, "vapid t="), ", k="+publicKey)
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL, claims["aud"])
+
+	db.AssertExists(t, "push_subscriptions", map[string]interface{}{"endpoint": server.URL + "/active"}, false)
+	db.AssertMissing(t, "push_subscriptions", map[string]interface{}{"endpoint": server.URL + "/gone"})
+}
+
+func TestAddPushSubscription(t *testing.T) {
+	s := db.NewSession()
+	defer s.Close()
+	_, err := s.Exec("delete from push_subscriptions")
+	require.NoError(t, err)
+
+	keys := PushSubscriptionKeys{P256dh: "key", Auth: "auth"}
+	endpoint := "https://push.example.com/shared"
+	require.NoError(t, AddPushSubscription(s, &PushSubscription{NotifiableID: 1, Endpoint: endpoint, Keys: keys}))
+	require.NoError(t, AddPushSubscription(s, &PushSubscription{NotifiableID: 2, Endpoint: endpoint, Keys: keys}))
+	require.NoError(t, AddPushSubscription(s, &PushSubscription{NotifiableID: 2, Endpoint: endpoint, Keys: keys}))
+
+	subscriptions, err := GetPushSubscriptionsForNotifiable(s, 1)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 1, "another notifiable must not remove the subscription")
+	subscriptions, err = GetPushSubscriptionsForNotifiable(s, 2)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 1, "subscribing again must replace the old subscription")
+}
@@ -0,0 +1,38 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+	"github.com/labstack/echo/v4"
+)
+
+// GetAvailableAutomationEvents returns a list of all events which can trigger an automation rule
+// @Summary Get all possible automation rule events
+// @Description Get all events which can be used as the trigger of an automation rule.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} string "The list of all possible automation rule events"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /automations/events [get]
+func GetAvailableAutomationEvents(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.GetAvailableAutomationEvents())
+}
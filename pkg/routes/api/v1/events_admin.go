@@ -0,0 +1,160 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/web/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CheckAdminToken only lets requests through which provide the configured admin token
+// as `Authorization: Bearer <token>`.
+func CheckAdminToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+		expected := config.ServiceAdminToken.GetString()
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			return echo.ErrForbidden
+		}
+		return next(c)
+	}
+}
+
+func getPoisonedMessageID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("message"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid message id.").SetInternal(err)
+	}
+	return id, nil
+}
+
+// GetPoisonedEvents lists all events which could not be handled
+// @Summary Get all poisoned events
+// @Description Returns all events which failed to be handled after all retries, newest first. Requires the admin token as `Authorization: Bearer <token>`.
+// @tags admin
+// @Produce json
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} events.PoisonedMessage "The poisoned events."
+// @Failure 403 {object} web.HTTPError "The admin token is missing or wrong."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /admin/events/poison [get]
+func GetPoisonedEvents(c echo.Context) error {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(c.QueryParam("per_page"))
+	if err != nil || perPage < 1 || perPage > config.ServiceMaxItemsPerPage.GetInt() {
+		perPage = config.ServiceMaxItemsPerPage.GetInt()
+	}
+
+	messages, total, err := events.GetPoisonedMessages(page, perPage)
+	if err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	c.Response().Header().Set("x-pagination-total-pages", strconv.FormatFloat(math.Ceil(float64(total)/float64(perPage)), 'f', 0, 64))
+	c.Response().Header().Set("x-pagination-result-count", strconv.Itoa(len(messages)))
+	c.Response().Header().Set("Access-Control-Expose-Headers", "x-pagination-total-pages, x-pagination-result-count")
+
+	return c.JSON(http.StatusOK, messages)
+}
+
+// GetPoisonedEvent returns a single poisoned event
+// @Summary Get one poisoned event
+// @Description Requires the admin token as `Authorization: Bearer <token>`.
+// @tags admin
+// @Produce json
+// @Param message path int true "The poisoned event id"
+// @Success 200 {object} events.PoisonedMessage "The poisoned event."
+// @Failure 403 {object} web.HTTPError "The admin token is missing or wrong."
+// @Failure 404 {object} web.HTTPError "The poisoned event does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /admin/events/poison/{message} [get]
+func GetPoisonedEvent(c echo.Context) error {
+	id, err := getPoisonedMessageID(c)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.GetPoisonedMessage(id)
+	if err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, msg)
+}
+
+// RequeuePoisonedEvent sends a poisoned event to the handler which failed on it again
+// @Summary Requeue a poisoned event
+// @Description Publishes the event again so that only the handler which failed on it retries it. The event is removed from the poison queue. Requires the admin token as `Authorization: Bearer <token>`.
+// @tags admin
+// @Produce json
+// @Param message path int true "The poisoned event id"
+// @Success 200 {object} models.Message "The event was requeued."
+// @Failure 403 {object} web.HTTPError "The admin token is missing or wrong."
+// @Failure 404 {object} web.HTTPError "The poisoned event does not exist."
+// @Failure 503 {object} web.HTTPError "The event system is not running."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /admin/events/poison/{message}/requeue [post]
+func RequeuePoisonedEvent(c echo.Context) error {
+	id, err := getPoisonedMessageID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := events.RequeuePoisonedMessage(id); err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "The event was requeued."})
+}
+
+// DeletePoisonedEvent removes a poisoned event without handling it again
+// @Summary Delete a poisoned event
+// @Description Requires the admin token as `Authorization: Bearer <token>`.
+// @tags admin
+// @Produce json
+// @Param message path int true "The poisoned event id"
+// @Success 200 {object} models.Message "The event was deleted."
+// @Failure 403 {object} web.HTTPError "The admin token is missing or wrong."
+// @Failure 404 {object} web.HTTPError "The poisoned event does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /admin/events/poison/{message} [delete]
+func DeletePoisonedEvent(c echo.Context) error {
+	id, err := getPoisonedMessageID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := events.DeletePoisonedMessage(id); err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "The event was deleted."})
+}
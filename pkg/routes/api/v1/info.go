@@ -27,31 +27,34 @@ import (
 	"code.vikunja.io/api/pkg/modules/migration/todoist"
 	"code.vikunja.io/api/pkg/modules/migration/trello"
 	vikunja_file "code.vikunja.io/api/pkg/modules/migration/vikunja-file"
+	"code.vikunja.io/api/pkg/notifications"
 	"code.vikunja.io/api/pkg/version"
 
 	"github.com/labstack/echo/v4"
 )
 
 type vikunjaInfos struct {
-	Version                    string    `json:"version"`
-	FrontendURL                string    `json:"frontend_url"`
-	Motd                       string    `json:"motd"`
-	LinkSharingEnabled         bool      `json:"link_sharing_enabled"`
-	MaxFileSize                string    `json:"max_file_size"`
-	MaxItemsPerPage            int       `json:"max_items_per_page"`
-	AvailableMigrators         []string  `json:"available_migrators"`
-	TaskAttachmentsEnabled     bool      `json:"task_attachments_enabled"`
-	EnabledBackgroundProviders []string  `json:"enabled_background_providers"`
-	TotpEnabled                bool      `json:"totp_enabled"`
-	Legal                      legalInfo `json:"legal"`
-	CaldavEnabled              bool      `json:"caldav_enabled"`
-	AuthInfo                   authInfo  `json:"auth"`
-	EmailRemindersEnabled      bool      `json:"email_reminders_enabled"`
-	UserDeletionEnabled        bool      `json:"user_deletion_enabled"`
-	TaskCommentsEnabled        bool      `json:"task_comments_enabled"`
-	DemoModeEnabled            bool      `json:"demo_mode_enabled"`
-	WebhooksEnabled            bool      `json:"webhooks_enabled"`
-	PublicTeamsEnabled         bool      `json:"public_teams_enabled"`
+	Version                    string      `json:"version"`
+	FrontendURL                string      `json:"frontend_url"`
+	Motd                       string      `json:"motd"`
+	LinkSharingEnabled         bool        `json:"link_sharing_enabled"`
+	MaxFileSize                string      `json:"max_file_size"`
+	MaxItemsPerPage            int         `json:"max_items_per_page"`
+	AvailableMigrators         []string    `json:"available_migrators"`
+	TaskAttachmentsEnabled     bool        `json:"task_attachments_enabled"`
+	EnabledBackgroundProviders []string    `json:"enabled_background_providers"`
+	TotpEnabled                bool        `json:"totp_enabled"`
+	Legal                      legalInfo   `json:"legal"`
+	CaldavEnabled              bool        `json:"caldav_enabled"`
+	AuthInfo                   authInfo    `json:"auth"`
+	EmailRemindersEnabled      bool        `json:"email_reminders_enabled"`
+	UserDeletionEnabled        bool        `json:"user_deletion_enabled"`
+	TaskCommentsEnabled        bool        `json:"task_comments_enabled"`
+	DemoModeEnabled            bool        `json:"demo_mode_enabled"`
+	WebhooksEnabled            bool        `json:"webhooks_enabled"`
+	PublicTeamsEnabled         bool        `json:"public_teams_enabled"`
+	WebPush                    webPushInfo `json:"web_push"`
+	ChatNotificationsEnabled   bool        `json:"chat_notifications_enabled"`
 }
 
 type authInfo struct {
@@ -74,6 +77,12 @@ type openIDAuthInfo struct {
 	Providers []*openid.Provider `json:"providers"`
 }
 
+type webPushInfo struct {
+	Enabled bool `json:"enabled"`
+	// The key browsers need to subscribe to push messages of this instance.
+	VAPIDPublicKey string `json:"vapid_public_key"`
+}
+
 type legalInfo struct {
 	ImprintURL       string `json:"imprint_url"`
 	PrivacyPolicyURL string `json:"privacy_policy_url"`
@@ -88,21 +97,22 @@ type legalInfo struct {
 // @Router /info [get]
 func Info(c echo.Context) error {
 	info := vikunjaInfos{
-		Version:                version.Version,
-		FrontendURL:            config.ServicePublicURL.GetString(),
-		Motd:                   config.ServiceMotd.GetString(),
-		LinkSharingEnabled:     config.ServiceEnableLinkSharing.GetBool(),
-		MaxFileSize:            config.FilesMaxSize.GetString(),
-		MaxItemsPerPage:        config.ServiceMaxItemsPerPage.GetInt(),
-		TaskAttachmentsEnabled: config.ServiceEnableTaskAttachments.GetBool(),
-		TotpEnabled:            config.ServiceEnableTotp.GetBool(),
-		CaldavEnabled:          config.ServiceEnableCaldav.GetBool(),
-		EmailRemindersEnabled:  config.ServiceEnableEmailReminders.GetBool(),
-		UserDeletionEnabled:    config.ServiceEnableUserDeletion.GetBool(),
-		TaskCommentsEnabled:    config.ServiceEnableTaskComments.GetBool(),
-		DemoModeEnabled:        config.ServiceDemoMode.GetBool(),
-		WebhooksEnabled:        config.WebhooksEnabled.GetBool(),
-		PublicTeamsEnabled:     config.ServiceEnablePublicTeams.GetBool(),
+		Version:                  version.Version,
+		FrontendURL:              config.ServicePublicURL.GetString(),
+		Motd:                     config.ServiceMotd.GetString(),
+		LinkSharingEnabled:       config.ServiceEnableLinkSharing.GetBool(),
+		MaxFileSize:              config.FilesMaxSize.GetString(),
+		MaxItemsPerPage:          config.ServiceMaxItemsPerPage.GetInt(),
+		TaskAttachmentsEnabled:   config.ServiceEnableTaskAttachments.GetBool(),
+		TotpEnabled:              config.ServiceEnableTotp.GetBool(),
+		CaldavEnabled:            config.ServiceEnableCaldav.GetBool(),
+		EmailRemindersEnabled:    config.ServiceEnableEmailReminders.GetBool(),
+		UserDeletionEnabled:      config.ServiceEnableUserDeletion.GetBool(),
+		TaskCommentsEnabled:      config.ServiceEnableTaskComments.GetBool(),
+		DemoModeEnabled:          config.ServiceDemoMode.GetBool(),
+		WebhooksEnabled:          config.WebhooksEnabled.GetBool(),
+		PublicTeamsEnabled:       config.ServiceEnablePublicTeams.GetBool(),
+		ChatNotificationsEnabled: notifications.IsChatEnabled(),
 		AvailableMigrators: []string{
 			(&vikunja_file.FileMigrator{}).Name(),
 			(&ticktick.Migrator{}).Name(),
@@ -133,6 +143,18 @@ func Info(c echo.Context) error {
 
 	info.AuthInfo.OpenIDConnect.Providers = providers
 
+	if notifications.IsWebPushEnabled() {
+		publicKey, err := notifications.GetVAPIDPublicKey()
+		if err != nil {
+			log.Errorf("Error while getting the vapid public key for /info: %s", err)
+		} else {
+			info.WebPush = webPushInfo{
+				Enabled:        true,
+				VAPIDPublicKey: publicKey,
+			}
+		}
+	}
+
 	// Migrators
 	if config.MigrationTodoistEnable.GetBool() {
 		m := &todoist.Migration{}
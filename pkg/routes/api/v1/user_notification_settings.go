@@ -0,0 +1,147 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/notifications"
+	user2 "code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/web/handler"
+
+	"github.com/labstack/echo/v4"
+	"xorm.io/xorm"
+)
+
+// UserNotificationSettings holds the notification preferences of a user
+type UserNotificationSettings struct {
+	// All notifications which can be configured. You cannot change this value.
+	Notifications []string `json:"notifications"`
+	// All channels notifications can be sent through. You cannot change this value.
+	Channels []notifications.Channel `json:"channels"`
+	// The preferences of the user. Every notification on every channel without a preference is sent.
+	// A preference with a project id overrides the one without for notifications about that project.
+	Preferences []*user2.NotificationPreference `json:"preferences"`
+}
+
+func getUserNotificationSettings(s *xorm.Session, u *user2.User) (*UserNotificationSettings, error) {
+	preferences, err := user2.GetNotificationPreferences(s, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserNotificationSettings{
+		Notifications: notifications.ConfigurableNotifications(),
+		Channels:      notifications.Channels(),
+		Preferences:   preferences,
+	}, nil
+}
+
+// GetUserNotificationSettings returns the notification preferences of the current user
+// @Summary Get the notification preferences of the current user
+// @Description Returns which notifications the current user wants to get through which channel, for all projects and per project.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} v1.UserNotificationSettings
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/notifications [get]
+func GetUserNotificationSettings(c echo.Context) error {
+	u, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	settings, err := getUserNotificationSettings(s, u)
+	if err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateUserNotificationSettings replaces the notification preferences of the current user
+// @Summary Change the notification preferences of the current user
+// @Description Replaces all notification preferences of the current user with the ones provided. Preferences for a project need read access to that project.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param settings body v1.UserNotificationSettings true "The new notification preferences. Only the preferences are used."
+// @Success 200 {object} v1.UserNotificationSettings
+// @Failure 400 {object} web.HTTPError "Invalid notification or channel."
+// @Failure 403 {object} web.HTTPError "The user does not have access to a project."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/notifications [post]
+func UpdateUserNotificationSettings(c echo.Context) error {
+	settings := &UserNotificationSettings{}
+	err := c.Bind(settings)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.").SetInternal(err)
+	}
+
+	u, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	checkedProjects := make(map[int64]bool)
+	for _, p := range settings.Preferences {
+		if p.ProjectID == 0 || checkedProjects[p.ProjectID] {
+			continue
+		}
+
+		project := &models.Project{ID: p.ProjectID}
+		can, _, err := project.CanRead(s, u)
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err)
+		}
+		if !can {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(models.ErrGenericForbidden{})
+		}
+		checkedProjects[p.ProjectID] = true
+	}
+
+	err = user2.SetNotificationPreferences(s, u, settings.Preferences)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err)
+	}
+
+	updated, err := getUserNotificationSettings(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
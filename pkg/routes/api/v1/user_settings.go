@@ -60,6 +60,11 @@ type UserSettings struct {
 	Language string `json:"language"`
 	// The user's time zone. Used to send task reminders in the time zone of the user.
 	Timezone string `json:"timezone"`
+	// If set to `hourly` or `daily`, notification mails about comments, assignments, deleted tasks and mentions are
+	// batched into one digest mail instead of being sent one by one. Leave empty to get them right away.
+	NotificationDigest string `json:"notification_digest" valid:"in(hourly|daily)"`
+	// The time when the daily digest will be sent via email, in the user's time zone.
+	NotificationDigestTime string `json:"notification_digest_time" valid:"time"`
 	// Additional settings only used by the frontend
 	FrontendSettings interface{} `json:"frontend_settings"`
 	// Additional settings links as provided by openid
@@ -215,6 +220,8 @@ func UpdateGeneralUserSettings(c echo.Context) error {
 	user.Language = us.Language
 	user.Timezone = us.Timezone
 	user.OverdueTasksRemindersTime = us.OverdueTasksRemindersTime
+	user.NotificationDigest = us.NotificationDigest
+	user.NotificationDigestTime = us.NotificationDigestTime
 	user.FrontendSettings = us.FrontendSettings
 
 	_, err = user2.UpdateUser(s, user, true)
@@ -79,6 +79,8 @@ func UserShow(c echo.Context) error {
 			Language:                     u.Language,
 			Timezone:                     u.Timezone,
 			OverdueTasksRemindersTime:    u.OverdueTasksRemindersTime,
+			NotificationDigest:           u.NotificationDigest,
+			NotificationDigestTime:       u.NotificationDigestTime,
 			FrontendSettings:             u.FrontendSettings,
 			ExtraSettingsLinks:           u.ExtraSettingsLinks,
 		},
@@ -0,0 +1,63 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"net/http"
+	"strings"
+
+	"code.vikunja.io/api/pkg/db"
+
+	caldav2 "code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/labstack/echo/v4"
+)
+
+// FeedHandler returns all tasks of a project view as a read-only iCalendar feed. Instead of credentials,
+// the feed is authenticated by its secret token in the url. Tasks with a date are events in the feed, since
+// most calendar apps which subscribe to feeds ignore todos.
+func FeedHandler(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	s := db.NewSession()
+	defer s.Close()
+
+	feed, err := models.GetCalendarFeedByToken(s, token)
+	if models.IsErrCalendarFeedDoesNotExist(err) {
+		return c.String(http.StatusNotFound, "Feed not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	title, tasks, err := feed.GetTasks(s)
+	if user.IsErrAccountDisabled(err) {
+		return c.String(http.StatusNotFound, "Feed not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	project := &models.ProjectWithTasksAndBuckets{Project: models.Project{Title: title}}
+	projectTasks := make([]*models.TaskWithComments, 0, len(tasks))
+	for _, t := range tasks {
+		projectTasks = append(projectTasks, &models.TaskWithComments{Task: *t})
+	}
+
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(caldav2.GetCaldavFeedForTasks(project, projectTasks)))
+}
@@ -45,6 +45,8 @@ type VikunjaCaldavProjectStorage struct {
 	project *models.ProjectWithTasksAndBuckets
 	// Used when handling a single task, like updating
 	task *models.Task
+	// Set when the single task is a checklist item exposed as subtask
+	checklistItem *models.TaskChecklistItem
 	// The current user
 	user        *user2.User
 	isPrincipal bool
@@ -172,12 +174,29 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByList(rpaths []string) (re
 		_ = s.Rollback()
 		return
 	}
+
+	// Everything which is not a task might be a checklist item
+	for _, uid := range uids {
+		if slices.ContainsFunc(tasks, func(t *models.Task) bool { return t.UID == uid }) {
+			continue
+		}
+		itemTask, _, err := getChecklistItemTaskByUID(s, vcls.user, uid)
+		if err != nil {
+			_ = s.Rollback()
+			return nil, err
+		}
+		if itemTask != nil {
+			tasks = append(tasks, itemTask)
+		}
+	}
+
 	err = s.Commit()
 	if err != nil {
 		return
 	}
 
 	for _, t := range tasks {
+		caldav.LinkChecklistItems(t)
 		rr := VikunjaProjectResourceAdapter{
 			task: t,
 		}
@@ -242,14 +261,24 @@ func (vcls *VikunjaCaldavProjectStorage) GetResource(rpath string) (*data.Resour
 			}
 			return nil, false, err
 		}
+		if len(tasks) < 1 {
+			itemTask, item, err := getChecklistItemTaskByUID(s, vcls.user, vcls.task.UID)
+			if err != nil {
+				return nil, false, err
+			}
+			if itemTask == nil {
+				return nil, false, errs.ResourceNotFoundError
+			}
+			vcls.checklistItem = item
+			tasks = []*models.Task{itemTask}
+		}
 		if err := s.Commit(); err != nil {
 			return nil, false, err
 		}
-
-		if len(tasks) < 1 {
-			return nil, false, errs.ResourceNotFoundError
-		}
 		vcls.task = tasks[0]
+		if vcls.checklistItem == nil {
+			caldav.LinkChecklistItems(vcls.task)
+		}
 
 		if updated.Unix() > 0 {
 			vcls.task.Updated = updated
@@ -306,6 +335,12 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 		return nil, errs.ForbiddenError
 	}
 
+	auditLog, err := models.NewAuditLogRecorder(s, vTask, models.AuditLogActionCreated)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	// Create the task
 	err = vTask.Create(s, vcls.user)
 	if err != nil {
@@ -314,6 +349,13 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 		return nil, err
 	}
 
+	err = auditLog.Record(s, vcls.getAuditLogActor())
+	if err != nil {
+		log.Errorf("[CALDAV] Failed to record audit log in CreateResource: %v", err)
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	vcls.task.ID = vTask.ID
 	err = persistLabels(s, vcls.user, vcls.task, vTask.Labels)
 	if err != nil {
@@ -354,6 +396,10 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 		return nil, err
 	}
 
+	if vcls.checklistItem != nil {
+		return vcls.updateChecklistItemResource(rpath, vTask)
+	}
+
 	// At this point, we already have the right task in vcls.task, so we can use that ID directly
 	vTask.ID = vcls.task.ID
 
@@ -377,6 +423,12 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 		return nil, errs.ForbiddenError
 	}
 
+	auditLog, err := models.NewAuditLogRecorder(s, vTask, models.AuditLogActionUpdated)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	// Update the task
 	err = vTask.Update(s, vcls.user)
 	if err != nil {
@@ -385,6 +437,13 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 		return nil, err
 	}
 
+	err = auditLog.Record(s, vcls.getAuditLogActor())
+	if err != nil {
+		log.Errorf("[CALDAV] Failed to record audit log in UpdateResource: %v", err)
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	err = persistLabels(s, vcls.user, vcls.task, vTask.Labels)
 	if err != nil {
 		log.Errorf("[CALDAV] Failed to persist labels in UpdateResource: %v, labels: %+v", err, vTask.Labels)
@@ -415,6 +474,10 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 
 // DeleteResource deletes a resource
 func (vcls *VikunjaCaldavProjectStorage) DeleteResource(_ string) error {
+	if vcls.checklistItem != nil {
+		return vcls.deleteChecklistItemResource()
+	}
+
 	if vcls.task != nil {
 		s := db.NewSession()
 		defer s.Close()
@@ -429,6 +492,12 @@ func (vcls *VikunjaCaldavProjectStorage) DeleteResource(_ string) error {
 			return errs.ForbiddenError
 		}
 
+		auditLog, err := models.NewAuditLogRecorder(s, vcls.task, models.AuditLogActionDeleted)
+		if err != nil {
+			_ = s.Rollback()
+			return err
+		}
+
 		// Delete it
 		err = vcls.task.Delete(s, vcls.user)
 		if err != nil {
@@ -436,12 +505,156 @@ func (vcls *VikunjaCaldavProjectStorage) DeleteResource(_ string) error {
 			return err
 		}
 
+		err = auditLog.Record(s, vcls.getAuditLogActor())
+		if err != nil {
+			_ = s.Rollback()
+			return err
+		}
+
 		return s.Commit()
 	}
 
 	return nil
 }
 
+// getChecklistItemTaskByUID returns the checklist item a caldav uid refers to as a task. If the uid does not
+// belong to a checklist item the user has access to, it returns nil.
+func getChecklistItemTaskByUID(s *xorm.Session, a web.Auth, uid string) (itemTask *models.Task, item *models.TaskChecklistItem, err error) {
+	itemID, is := caldav.ParseChecklistItemUID(uid)
+	if !is {
+		return nil, nil, nil
+	}
+
+	item, err = models.GetChecklistItemByID(s, itemID)
+	if err != nil {
+		if models.IsErrChecklistItemDoesNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	canRead, _, err := item.CanRead(s, a)
+	if err != nil || !canRead {
+		return nil, nil, err
+	}
+
+	parent, err := models.GetTaskByIDSimple(s, item.TaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itemTask = caldav.GetChecklistItemTask(&parent, item)
+	if itemTask.UID != uid {
+		return nil, nil, nil
+	}
+
+	return itemTask, item, nil
+}
+
+// updateChecklistItemResource updates the title, done state and due date of a checklist item from its subtask.
+func (vcls *VikunjaCaldavProjectStorage) updateChecklistItemResource(rpath string, vTask *models.Task) (*data.Resource, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	item := &models.TaskChecklistItem{
+		ID:         vcls.checklistItem.ID,
+		TaskID:     vcls.checklistItem.TaskID,
+		Title:      vTask.Title,
+		Done:       vTask.Done,
+		DueDate:    vTask.DueDate,
+		AssigneeID: vcls.checklistItem.AssigneeID,
+		Position:   vcls.checklistItem.Position,
+	}
+
+	canUpdate, err := item.CanUpdate(s, vcls.user)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+	if !canUpdate {
+		log.Warningf("[CALDAV] User %s does not have permission to update checklist item %d", vcls.user.Username, item.ID)
+		_ = s.Rollback()
+		return nil, errs.ForbiddenError
+	}
+
+	auditLog, err := models.NewAuditLogRecorder(s, item, models.AuditLogActionUpdated)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	err = item.Update(s, vcls.user)
+	if err != nil {
+		log.Errorf("[CALDAV] Failed to update checklist item in UpdateResource: %v, item: %+v", err, item)
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	err = auditLog.Record(s, vcls.getAuditLogActor())
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	parent, err := models.GetTaskByIDSimple(s, item.TaskID)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	if err := s.Commit(); err != nil {
+		return nil, err
+	}
+
+	rr := VikunjaProjectResourceAdapter{
+		project: vcls.project,
+		task:    caldav.GetChecklistItemTask(&parent, item),
+	}
+	r := data.NewResource(rpath, &rr)
+	return &r, nil
+}
+
+func (vcls *VikunjaCaldavProjectStorage) deleteChecklistItemResource() error {
+	s := db.NewSession()
+	defer s.Close()
+
+	canDelete, err := vcls.checklistItem.CanDelete(s, vcls.user)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+	if !canDelete {
+		return errs.ForbiddenError
+	}
+
+	auditLog, err := models.NewAuditLogRecorder(s, vcls.checklistItem, models.AuditLogActionDeleted)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	err = vcls.checklistItem.Delete(s, vcls.user)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	err = auditLog.Record(s, vcls.getAuditLogActor())
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	return s.Commit()
+}
+
+func (vcls *VikunjaCaldavProjectStorage) getAuditLogActor() *models.AuditLogActor {
+	return &models.AuditLogActor{
+		Auth:     vcls.user,
+		AuthType: models.AuditLogAuthTypeCalDAV,
+	}
+}
+
 func persistLabels(s *xorm.Session, a web.Auth, task *models.Task, labels []*models.Label) (err error) {
 
 	labelTitles := []string{}
@@ -532,6 +745,11 @@ func persistRelations(s *xorm.Session, a web.Auth, task *models.Task, newRelatio
 		// Persist each relation independently:
 		for _, relatedTaskInVTODO := range relatedTasksInVTODO {
 
+			// Checklist items are exposed as subtasks but are no real tasks
+			if caldav.IsChecklistItemUIDOf(task, relatedTaskInVTODO.UID) {
+				continue
+			}
+
 			var relatedTask *models.Task
 			createDummy := false
 
@@ -688,6 +906,7 @@ func (vcls *VikunjaCaldavProjectStorage) getProjectRessource(isCollection bool)
 		for _, t := range tasks {
 			projectTasks = append(projectTasks, &models.TaskWithComments{Task: *t})
 		}
+		projectTasks = caldav.AddChecklistItemsAsSubtasks(projectTasks)
 		vcls.project.Tasks = projectTasks
 	}
 
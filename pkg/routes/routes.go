@@ -209,6 +209,9 @@ func setupSentry(e *echo.Echo) {
 // RegisterRoutes registers all routes for the application
 func RegisterRoutes(e *echo.Echo) {
 
+	// Changes made through the web handler end up in the audit log
+	handler.SetChangeRecorder(models.AuditLogChangeRecorder{})
+
 	if config.ServiceEnableCaldav.GetBool() {
 		// Caldav routes
 		wkg := e.Group("/.well-known")
@@ -310,6 +313,15 @@ func registerAPIRoutes(a *echo.Group) {
 		n.PATCH("/test/:table", apiv1.HandleTesting)
 	}
 
+	// Admin
+	if config.ServiceAdminToken.GetString() != "" {
+		ad := n.Group("/admin", apiv1.CheckAdminToken)
+		ad.GET("/events/poison", apiv1.GetPoisonedEvents)
+		ad.GET("/events/poison/:message", apiv1.GetPoisonedEvent)
+		ad.POST("/events/poison/:message/requeue", apiv1.RequeuePoisonedEvent)
+		ad.DELETE("/events/poison/:message", apiv1.DeletePoisonedEvent)
+	}
+
 	// Info endpoint
 	n.GET("/info", apiv1.Info)
 
@@ -318,6 +330,9 @@ func registerAPIRoutes(a *echo.Group) {
 		ur.POST("/shares/:share/auth", apiv1.AuthenticateLinkShare)
 	}
 
+	// Calendar feeds are authenticated by the secret token in their url
+	ur.GET("/calendar-feeds/:token", caldav.FeedHandler)
+
 	// ===== Routes with Authentication =====
 	a.Use(SetupTokenMiddleware())
 
@@ -346,6 +361,8 @@ func registerAPIRoutes(a *echo.Group) {
 	u.POST("/settings/avatar", apiv1.ChangeUserAvatarProvider)
 	u.PUT("/settings/avatar/upload", apiv1.UploadAvatar)
 	u.POST("/settings/general", apiv1.UpdateGeneralUserSettings)
+	u.GET("/settings/notifications", apiv1.GetUserNotificationSettings)
+	u.POST("/settings/notifications", apiv1.UpdateUserNotificationSettings)
 	u.POST("/export/request", apiv1.RequestUserDataExport)
 	u.POST("/export/download", apiv1.DownloadUserDataExport)
 	u.GET("/export", apiv1.GetUserExportStatus)
@@ -516,6 +533,43 @@ func registerAPIRoutes(a *echo.Group) {
 		a.GET("/tasks/:task/comments/:commentid", taskCommentHandler.ReadOneWeb)
 	}
 
+	taskTimeEntryHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTimeEntry{}
+		},
+	}
+	a.GET("/tasks/:task/time-entries", taskTimeEntryHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/time-entries", taskTimeEntryHandler.CreateWeb)
+	a.GET("/tasks/:task/time-entries/:timeentry", taskTimeEntryHandler.ReadOneWeb)
+	a.POST("/tasks/:task/time-entries/:timeentry", taskTimeEntryHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/time-entries/:timeentry", taskTimeEntryHandler.DeleteWeb)
+
+	taskChecklistItemHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskChecklistItem{}
+		},
+	}
+	a.GET("/tasks/:task/checklist", taskChecklistItemHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/checklist", taskChecklistItemHandler.CreateWeb)
+	a.POST("/tasks/:task/checklist/:checklistitem", taskChecklistItemHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/checklist/:checklistitem", taskChecklistItemHandler.DeleteWeb)
+
+	taskTimerHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTimer{}
+		},
+	}
+	a.GET("/tasks/:task/timer", taskTimerHandler.ReadOneWeb)
+	a.PUT("/tasks/:task/timer", taskTimerHandler.CreateWeb)
+	a.POST("/tasks/:task/timer", taskTimerHandler.UpdateWeb)
+
+	taskHistoryHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskHistory{}
+		},
+	}
+	a.GET("/tasks/:task/history", taskHistoryHandler.ReadAllWeb)
+
 	labelHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Label{}
@@ -557,6 +611,23 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/filters/:filter", savedFiltersHandler.DeleteWeb)
 	a.POST("/filters/:filter", savedFiltersHandler.UpdateWeb)
 
+	templateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Template{}
+		},
+	}
+	a.GET("/templates", templateHandler.ReadAllWeb)
+	a.PUT("/templates", templateHandler.CreateWeb)
+	a.GET("/templates/:template", templateHandler.ReadOneWeb)
+	a.POST("/templates/:template", templateHandler.UpdateWeb)
+	a.DELETE("/templates/:template", templateHandler.DeleteWeb)
+	templateInstanceHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TemplateInstance{}
+		},
+	}
+	a.PUT("/templates/:template/instantiate", templateInstanceHandler.CreateWeb)
+
 	teamHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Team{}
@@ -635,6 +706,57 @@ func registerAPIRoutes(a *echo.Group) {
 	a.PUT("/tokens", apiTokenProvider.CreateWeb)
 	a.DELETE("/tokens/:token", apiTokenProvider.DeleteWeb)
 
+	// Push Subscriptions
+	pushSubscriptionProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.PushSubscription{}
+		},
+	}
+	a.GET("/push-subscriptions", pushSubscriptionProvider.ReadAllWeb)
+	a.PUT("/push-subscriptions", pushSubscriptionProvider.CreateWeb)
+	a.DELETE("/push-subscriptions/:subscription", pushSubscriptionProvider.DeleteWeb)
+
+	// Chat notification targets
+	chatTargetProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ChatTarget{}
+		},
+	}
+	a.GET("/chat-targets", chatTargetProvider.ReadAllWeb)
+	a.PUT("/chat-targets", chatTargetProvider.CreateWeb)
+	a.GET("/chat-targets/:target", chatTargetProvider.ReadOneWeb)
+	a.POST("/chat-targets/:target", chatTargetProvider.UpdateWeb)
+	a.DELETE("/chat-targets/:target", chatTargetProvider.DeleteWeb)
+
+	wipLimitProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.WIPLimit{}
+		},
+	}
+	a.GET("/user/wip-limits", wipLimitProvider.ReadAllWeb)
+	a.PUT("/user/wip-limits", wipLimitProvider.CreateWeb)
+	a.POST("/user/wip-limits/:wiplimit", wipLimitProvider.UpdateWeb)
+	a.DELETE("/user/wip-limits/:wiplimit", wipLimitProvider.DeleteWeb)
+
+	// Automation rules
+	automationRuleProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.AutomationRule{}
+		},
+	}
+	a.GET("/projects/:project/automations", automationRuleProvider.ReadAllWeb)
+	a.PUT("/projects/:project/automations", automationRuleProvider.CreateWeb)
+	a.GET("/projects/:project/automations/:automation", automationRuleProvider.ReadOneWeb)
+	a.POST("/projects/:project/automations/:automation", automationRuleProvider.UpdateWeb)
+	a.DELETE("/projects/:project/automations/:automation", automationRuleProvider.DeleteWeb)
+	automationRuleExecutionProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.AutomationRuleExecution{}
+		},
+	}
+	a.GET("/projects/:project/automations/:automation/executions", automationRuleExecutionProvider.ReadAllWeb)
+	a.GET("/automations/events", apiv1.GetAvailableAutomationEvents)
+
 	// Webhooks
 	if config.WebhooksEnabled.GetBool() {
 		webhookProvider := &handler.WebHandler{
@@ -646,6 +768,38 @@ func registerAPIRoutes(a *echo.Group) {
 		a.PUT("/projects/:project/webhooks", webhookProvider.CreateWeb)
 		a.DELETE("/projects/:project/webhooks/:webhook", webhookProvider.DeleteWeb)
 		a.POST("/projects/:project/webhooks/:webhook", webhookProvider.UpdateWeb)
+		webhookDeliveryProvider := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.WebhookDelivery{}
+			},
+		}
+		a.GET("/projects/:project/webhooks/:webhook/deliveries", webhookDeliveryProvider.ReadAllWeb)
+		webhookRedeliveryProvider := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.WebhookRedelivery{}
+			},
+		}
+		a.POST("/projects/:project/webhooks/:webhook/deliveries/:delivery/redeliver", webhookRedeliveryProvider.UpdateWeb)
+		webhookTestProvider := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.WebhookTest{}
+			},
+		}
+		a.POST("/projects/:project/webhooks/:webhook/test", webhookTestProvider.UpdateWeb)
+		a.GET("/teams/:team/webhooks", webhookProvider.ReadAllWeb)
+		a.PUT("/teams/:team/webhooks", webhookProvider.CreateWeb)
+		a.DELETE("/teams/:team/webhooks/:webhook", webhookProvider.DeleteWeb)
+		a.POST("/teams/:team/webhooks/:webhook", webhookProvider.UpdateWeb)
+		a.GET("/teams/:team/webhooks/:webhook/deliveries", webhookDeliveryProvider.ReadAllWeb)
+		a.POST("/teams/:team/webhooks/:webhook/deliveries/:delivery/redeliver", webhookRedeliveryProvider.UpdateWeb)
+		a.POST("/teams/:team/webhooks/:webhook/test", webhookTestProvider.UpdateWeb)
+		a.GET("/user/webhooks", webhookProvider.ReadAllWeb)
+		a.PUT("/user/webhooks", webhookProvider.CreateWeb)
+		a.DELETE("/user/webhooks/:webhook", webhookProvider.DeleteWeb)
+		a.POST("/user/webhooks/:webhook", webhookProvider.UpdateWeb)
+		a.GET("/user/webhooks/:webhook/deliveries", webhookDeliveryProvider.ReadAllWeb)
+		a.POST("/user/webhooks/:webhook/deliveries/:delivery/redeliver", webhookRedeliveryProvider.UpdateWeb)
+		a.POST("/user/webhooks/:webhook/test", webhookTestProvider.UpdateWeb)
 		a.GET("/webhooks/events", apiv1.GetAvailableWebhookEvents)
 	}
 
@@ -671,6 +825,69 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/projects/:project/views/:view", projectViewProvider.DeleteWeb)
 	a.POST("/projects/:project/views/:view", projectViewProvider.UpdateWeb)
 
+	projectScheduleProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectSchedule{}
+		},
+	}
+	a.GET("/projects/:project/views/:view/schedule", projectScheduleProvider.ReadOneWeb)
+
+	calendarFeedProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.CalendarFeed{}
+		},
+	}
+	a.GET("/projects/:project/views/:view/calendar-feeds", calendarFeedProvider.ReadAllWeb)
+	a.PUT("/projects/:project/views/:view/calendar-feeds", calendarFeedProvider.CreateWeb)
+	a.DELETE("/projects/:project/views/:view/calendar-feeds/:feed", calendarFeedProvider.DeleteWeb)
+
+	// Project custom fields
+	projectCustomFieldProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectCustomField{}
+		},
+	}
+	a.GET("/projects/:project/custom-fields", projectCustomFieldProvider.ReadAllWeb)
+	a.GET("/projects/:project/custom-fields/:customfield", projectCustomFieldProvider.ReadOneWeb)
+	a.PUT("/projects/:project/custom-fields", projectCustomFieldProvider.CreateWeb)
+	a.DELETE("/projects/:project/custom-fields/:customfield", projectCustomFieldProvider.DeleteWeb)
+	a.POST("/projects/:project/custom-fields/:customfield", projectCustomFieldProvider.UpdateWeb)
+
+	// Sprints
+	sprintProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Sprint{}
+		},
+	}
+	a.GET("/projects/:project/sprints", sprintProvider.ReadAllWeb)
+	a.GET("/projects/:project/sprints/:sprint", sprintProvider.ReadOneWeb)
+	a.PUT("/projects/:project/sprints", sprintProvider.CreateWeb)
+	a.POST("/projects/:project/sprints/:sprint", sprintProvider.UpdateWeb)
+	a.DELETE("/projects/:project/sprints/:sprint", sprintProvider.DeleteWeb)
+
+	sprintTaskProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.SprintTask{}
+		},
+	}
+	a.PUT("/projects/:project/sprints/:sprint/tasks", sprintTaskProvider.CreateWeb)
+	a.DELETE("/projects/:project/sprints/:sprint/tasks/:task", sprintTaskProvider.DeleteWeb)
+
+	sprintBurndownProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.SprintBurndown{}
+		},
+	}
+	a.GET("/projects/:project/sprints/:sprint/burndown", sprintBurndownProvider.ReadOneWeb)
+
+	// Project history
+	projectHistoryProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectHistory{}
+		},
+	}
+	a.GET("/projects/:project/history", projectHistoryProvider.ReadAllWeb)
+
 	// Kanban Task Bucket Relation
 	taskBucketProvider := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
@@ -679,6 +896,22 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.POST("/projects/:project/views/:view/buckets/:bucket/tasks", taskBucketProvider.UpdateWeb)
 
+	// Trash
+	trashProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TrashItem{}
+		},
+	}
+	a.GET("/trash", trashProvider.ReadAllWeb)
+	a.DELETE("/trash/:trashitem", trashProvider.DeleteWeb)
+
+	trashRestoreProvider := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TrashItemRestore{}
+		},
+	}
+	a.POST("/trash/:trashitem/restore", trashRestoreProvider.UpdateWeb)
+
 	// Plugin routes
 	if config.PluginsEnabled.GetBool() {
 		// Authenticated plugin routes
@@ -714,3 +714,32 @@ func (err ErrTokenUserMismatch) HTTPError() web.HTTPError {
 		Message:  "This deletion token does not belong to your account.",
 	}
 }
+
+// ErrInvalidNotificationPreference represents an error where a notification preference is for an unknown
+// notification or channel.
+type ErrInvalidNotificationPreference struct {
+	Notification string
+	Channel      string
+}
+
+// IsErrInvalidNotificationPreference checks if an error is a ErrInvalidNotificationPreference.
+func IsErrInvalidNotificationPreference(err error) bool {
+	_, ok := err.(*ErrInvalidNotificationPreference)
+	return ok
+}
+
+func (err *ErrInvalidNotificationPreference) Error() string {
+	return fmt.Sprintf("Invalid notification preference [Notification: %s, Channel: %s]", err.Notification, err.Channel)
+}
+
+// ErrorCodeInvalidNotificationPreference holds the unique world-error code of this error
+const ErrorCodeInvalidNotificationPreference = 1030
+
+// HTTPError holds the http error description
+func (err *ErrInvalidNotificationPreference) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrorCodeInvalidNotificationPreference,
+		Message:  fmt.Sprintf("The notification '%s' cannot be configured for the channel '%s'.", err.Notification, err.Channel),
+	}
+}
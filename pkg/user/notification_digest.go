@@ -0,0 +1,179 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/notifications"
+
+	"xorm.io/xorm"
+)
+
+const (
+	NotificationDigestHourly = "hourly"
+	NotificationDigestDaily  = "daily"
+)
+
+// WantsDigest checks if the user wants to get their notification mails batched into a digest.
+func (u *User) WantsDigest() (bool, error) {
+	if !config.MailerEnabled.GetBool() {
+		return false, nil
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+	user, err := getUser(s, &User{ID: u.ID}, true)
+	if err != nil {
+		return false, err
+	}
+
+	return user.NotificationDigest == NotificationDigestHourly || user.NotificationDigest == NotificationDigestDaily, nil
+}
+
+// lastDigestTime returns the last time before now when the user should have gotten a digest, in their time zone.
+func (u *User) lastDigestTime(now time.Time) (time.Time, error) {
+	tz := u.Timezone
+	if tz == "" {
+		tz = config.GetTimeZone().String()
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now = now.In(loc)
+
+	if u.NotificationDigest == NotificationDigestHourly {
+		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, loc), nil
+	}
+
+	if u.NotificationDigest == NotificationDigestDaily {
+		digestTime := u.NotificationDigestTime
+		if digestTime == "" {
+			digestTime = "09:00"
+		}
+		tm, err := time.Parse("15:04", digestTime)
+		if err != nil {
+			return time.Time{}, err
+		}
+		last := time.Date(now.Year(), now.Month(), now.Day(), tm.Hour(), tm.Minute(), 0, 0, loc)
+		if last.After(now) {
+			last = last.AddDate(0, 0, -1)
+		}
+		return last, nil
+	}
+
+	// The user does not want a digest (anymore), everything still queued is sent right away.
+	return now, nil
+}
+
+// sendDueDigests sends a digest to every user whose digest time passed since the oldest item in their queue.
+func sendDueDigests(s *xorm.Session, now time.Time) error {
+	userIDs, err := notifications.GetNotifiableIDsWithPendingDigest(s)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	users, err := GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	// A failure for one user must not keep everyone else from getting their digest
+	for _, userID := range userIDs {
+		items, err := notifications.GetDigestItems(s, userID)
+		if err != nil {
+			log.Errorf("[Notification Digest] Could not get the pending notifications of user %d: %s", userID, err)
+			continue
+		}
+
+		u, exists := users[userID]
+		if !exists {
+			// The user was deleted in the meantime
+			err = notifications.DeleteDigestItems(s, items)
+			if err != nil {
+				log.Errorf("[Notification Digest] Could not delete the pending notifications of deleted user %d: %s", userID, err)
+			}
+			continue
+		}
+
+		last, err := u.lastDigestTime(now)
+		if err != nil {
+			log.Errorf("[Notification Digest] Could not get the digest time of user %d: %s", u.ID, err)
+			continue
+		}
+		if len(items) == 0 || items[0].Created.After(last) {
+			continue
+		}
+
+		err = notifications.Notify(u, &NotificationDigestNotification{
+			User:  u,
+			Items: items,
+		})
+		if err != nil {
+			// The notifications stay pending, sending them is tried again the next time
+			log.Errorf("[Notification Digest] Could not send the digest to user %d: %s", u.ID, err)
+			continue
+		}
+
+		err = notifications.DeleteDigestItems(s, items)
+		if err != nil {
+			log.Errorf("[Notification Digest] Could not delete the sent notifications of user %d: %s", u.ID, err)
+			continue
+		}
+
+		log.Debugf("[Notification Digest] Sent a digest with %d notifications to user %d", len(items), u.ID)
+	}
+
+	return nil
+}
+
+// RegisterNotificationDigestCron registers a cron function which sends the queued notification mails of users
+// who want a digest at the time they chose.
+func RegisterNotificationDigestCron() {
+	if !config.MailerEnabled.GetBool() {
+		log.Info("Mailer is disabled, not sending notification digests")
+		return
+	}
+
+	err := cron.Schedule("* * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		err := sendDueDigests(s, time.Now())
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("[Notification Digest] Could not send digests: %s", err)
+			return
+		}
+
+		err = s.Commit()
+		if err != nil {
+			log.Errorf("[Notification Digest] Could not commit: %s", err)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register notification digest cron: %s", err)
+	}
+}
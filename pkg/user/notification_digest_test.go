@@ -0,0 +1,130 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/notifications"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_lastDigestTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC)
+
+	t.Run("hourly", func(t *testing.T) {
+		u := &User{NotificationDigest: NotificationDigestHourly, Timezone: "Asia/Kolkata"}
+		last, err := u.lastDigestTime(now)
+		require.NoError(t, err)
+		// 14:00 in India, which is half an hour off UTC
+		assert.Equal(t, time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC).Unix(), last.Unix())
+	})
+	t.Run("daily later today", func(t *testing.T) {
+		u := &User{NotificationDigest: NotificationDigestDaily, Timezone: "Europe/Berlin"}
+		last, err := u.lastDigestTime(now)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC).Unix(), last.Unix())
+	})
+	t.Run("daily yesterday", func(t *testing.T) {
+		u := &User{NotificationDigest: NotificationDigestDaily, NotificationDigestTime: "18:00", Timezone: "Europe/Berlin"}
+		last, err := u.lastDigestTime(now)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 10, 17, 16, 0, 0, 0, time.UTC).Unix(), last.Unix())
+	})
+	t.Run("digest turned off", func(t *testing.T) {
+		u := &User{}
+		last, err := u.lastDigestTime(now)
+		require.NoError(t, err)
+		assert.Equal(t, now.Unix(), last.Unix())
+	})
+}
+
+func TestSendDueDigests(t *testing.T) {
+	now := time.Now()
+
+	insertItem := func(t *testing.T, userID int64, created time.Time) {
+		s := db.NewSession()
+		defer s.Close()
+		item := &notifications.DigestItem{
+			NotifiableID: userID,
+			Name:         "task.comment",
+			Title:        "Task",
+			Lines:        []*notifications.DigestLine{{Text: "Lorem Ipsum"}},
+		}
+		_, err := s.Insert(item)
+		require.NoError(t, err)
+		_, err = s.Table("notification_digest_items").
+			Where("id = ?", item.ID).
+			Update(map[string]interface{}{"created": created})
+		require.NoError(t, err)
+	}
+	setDigest := func(t *testing.T, userID int64, digest, digestTime string) {
+		s := db.NewSession()
+		defer s.Close()
+		_, err := s.ID(userID).
+			Cols("notification_digest", "notification_digest_time", "timezone").
+			Update(&User{NotificationDigest: digest, NotificationDigestTime: digestTime, Timezone: "GMT"})
+		require.NoError(t, err)
+	}
+
+	t.Run("due", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setDigest(t, 1, NotificationDigestHourly, "")
+		insertItem(t, 1, now.Add(-2*time.Hour))
+		insertItem(t, 1, now)
+
+		s := db.NewSession()
+		defer s.Close()
+		err := sendDueDigests(s, now)
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertMissing(t, "notification_digest_items", map[string]interface{}{"notifiable_id": 1})
+	})
+	t.Run("not yet due", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		digestTime := now.In(time.UTC).Add(time.Hour)
+		setDigest(t, 1, NotificationDigestDaily, digestTime.Format("15:04"))
+		insertItem(t, 1, now.Add(-time.Minute))
+
+		s := db.NewSession()
+		defer s.Close()
+		err := sendDueDigests(s, now)
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertExists(t, "notification_digest_items", map[string]interface{}{"notifiable_id": 1}, false)
+		_, err = db.NewSession().Where("1 = 1").Delete(&notifications.DigestItem{})
+		require.NoError(t, err)
+	})
+	t.Run("digest turned off", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		insertItem(t, 1, now)
+
+		s := db.NewSession()
+		defer s.Close()
+		err := sendDueDigests(s, now)
+		require.NoError(t, err)
+		require.NoError(t, s.Commit())
+
+		db.AssertMissing(t, "notification_digest_items", map[string]interface{}{"notifiable_id": 1})
+	})
+}
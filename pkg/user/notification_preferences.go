@@ -0,0 +1,135 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/notifications"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// NotificationPreference decides if a user gets a notification through a channel.
+// Every notification on every channel without a preference is sent.
+type NotificationPreference struct {
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	UserID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The project this preference applies to. A preference with a project overrides the one without for
+	// notifications about that project. 0 applies to all projects.
+	ProjectID int64 `xorm:"bigint not null default 0 INDEX" json:"project_id"`
+	// The name of the notification, for example `task.comment`.
+	Notification string `xorm:"varchar(250) not null" json:"notification"`
+	// The channel the notification is sent through. Can be `mail`, `in_app` or `push`.
+	Channel notifications.Channel `xorm:"varchar(50) not null" json:"channel"`
+	// Whether the notification should be sent through this channel.
+	Enabled bool `xorm:"not null default false" json:"enabled"`
+
+	// A timestamp when this preference was last changed. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+}
+
+// TableName returns the table name for notification preferences
+func (*NotificationPreference) TableName() string {
+	return "user_notification_preferences"
+}
+
+// GetNotificationPreferences returns all notification preferences of a user.
+func GetNotificationPreferences(s *xorm.Session, u *User) (preferences []*NotificationPreference, err error) {
+	preferences = []*NotificationPreference{}
+	err = s.
+		Where("user_id = ?", u.ID).
+		OrderBy("project_id asc, notification asc, channel asc").
+		Find(&preferences)
+	return
+}
+
+// SetNotificationPreferences replaces all notification preferences of a user. If a preference is passed more
+// than once, the last one wins.
+func SetNotificationPreferences(s *xorm.Session, u *User, preferences []*NotificationPreference) (err error) {
+	type preferenceKey struct {
+		projectID    int64
+		notification string
+		channel      notifications.Channel
+	}
+
+	unique := make(map[preferenceKey]*NotificationPreference, len(preferences))
+	keys := make([]preferenceKey, 0, len(preferences))
+	for _, p := range preferences {
+		if !notifications.IsConfigurableNotification(p.Notification) || !notifications.IsValidChannel(p.Channel) {
+			return &ErrInvalidNotificationPreference{Notification: p.Notification, Channel: string(p.Channel)}
+		}
+
+		key := preferenceKey{projectID: p.ProjectID, notification: p.Notification, channel: p.Channel}
+		if _, exists := unique[key]; !exists {
+			keys = append(keys, key)
+		}
+		unique[key] = p
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&NotificationPreference{})
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		p := unique[key]
+		p.ID = 0
+		p.UserID = u.ID
+		_, err = s.Insert(p)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// DeleteNotificationPreferencesForProject removes the preferences all users have for a project.
+func DeleteNotificationPreferencesForProject(s *xorm.Session, projectID int64) (err error) {
+	_, err = s.Where("project_id = ?", projectID).Delete(&NotificationPreference{})
+	return
+}
+
+// WantsNotification checks the notification preferences of the user for a notification. A preference for the
+// project of the notification wins over the one for all projects.
+func (u *User) WantsNotification(channel notifications.Channel, name string, projectID int64) (bool, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	preferences := []*NotificationPreference{}
+	err := s.
+		Where(builder.And(
+			builder.Eq{"user_id": u.ID},
+			builder.Eq{"notification": name},
+			builder.Eq{"channel": channel},
+			builder.In("project_id", 0, projectID),
+		)).
+		OrderBy("project_id desc").
+		Find(&preferences)
+	if err != nil {
+		return false, err
+	}
+
+	if len(preferences) == 0 {
+		return true, nil
+	}
+
+	return preferences[0].Enabled, nil
+}
@@ -0,0 +1,114 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/notifications"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_WantsNotification(t *testing.T) {
+	notifications.RegisterConfigurableNotification("task.comment", "task.assigned")
+
+	t.Run("disabled for all projects", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		wants, err := (&User{ID: 1}).WantsNotification(notifications.ChannelMail, "task.comment", 2)
+		require.NoError(t, err)
+		assert.False(t, wants)
+	})
+	t.Run("enabled for one project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		wants, err := (&User{ID: 1}).WantsNotification(notifications.ChannelMail, "task.comment", 1)
+		require.NoError(t, err)
+		assert.True(t, wants)
+	})
+	t.Run("other channel", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		wants, err := (&User{ID: 1}).WantsNotification(notifications.ChannelInApp, "task.comment", 2)
+		require.NoError(t, err)
+		assert.True(t, wants)
+	})
+	t.Run("no preferences", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		wants, err := (&User{ID: 2}).WantsNotification(notifications.ChannelMail, "task.comment", 0)
+		require.NoError(t, err)
+		assert.True(t, wants)
+	})
+}
+
+func TestSetNotificationPreferences(t *testing.T) {
+	notifications.RegisterConfigurableNotification("task.comment", "task.assigned")
+
+	t.Run("replaces all preferences", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &User{ID: 1}
+		err := SetNotificationPreferences(s, u, []*NotificationPreference{
+			{Notification: "task.assigned", Channel: notifications.ChannelMail, Enabled: true},
+			{Notification: "task.assigned", Channel: notifications.ChannelPush, Enabled: false},
+			{Notification: "task.assigned", Channel: notifications.ChannelPush, Enabled: true},
+		})
+		require.NoError(t, err)
+
+		preferences, err := GetNotificationPreferences(s, u)
+		require.NoError(t, err)
+		require.Len(t, preferences, 2)
+		assert.Equal(t, notifications.ChannelMail, preferences[0].Channel)
+		assert.Equal(t, notifications.ChannelPush, preferences[1].Channel)
+		assert.True(t, preferences[1].Enabled)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "user_notification_preferences", map[string]interface{}{
+			"user_id":      1,
+			"notification": "task.comment",
+		})
+	})
+	t.Run("unknown notification", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := SetNotificationPreferences(s, &User{ID: 1}, []*NotificationPreference{
+			{Notification: "user.deletion.confirm", Channel: notifications.ChannelMail},
+		})
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidNotificationPreference(err))
+	})
+	t.Run("unknown channel", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := SetNotificationPreferences(s, &User{ID: 1}, []*NotificationPreference{
+			{Notification: "task.comment", Channel: "pigeon"},
+		})
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidNotificationPreference(err))
+	})
+}
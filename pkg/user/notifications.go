@@ -285,3 +285,26 @@ func (n *AccountDeletedNotification) ToDB() interface{} {
 func (n *AccountDeletedNotification) Name() string {
 	return "user.deleted"
 }
+
+// NotificationDigestNotification represents a NotificationDigestNotification notification
+type NotificationDigestNotification struct {
+	User  *User
+	Items []*notifications.DigestItem
+}
+
+// ToMail returns the mail notification for NotificationDigestNotification
+func (n *NotificationDigestNotification) ToMail(lang string) *notifications.Mail {
+	return notifications.NewDigestMail(lang, n.Items).
+		IncludeLinkToSettings(lang).
+		Greeting(i18n.T(lang, "notifications.greeting", n.User.GetName()))
+}
+
+// ToDB returns the NotificationDigestNotification notification in a format which can be saved in the db
+func (n *NotificationDigestNotification) ToDB() interface{} {
+	return nil
+}
+
+// Name returns the name of the notification
+func (n *NotificationDigestNotification) Name() string {
+	return "notification.digest"
+}
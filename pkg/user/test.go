@@ -23,6 +23,7 @@ import (
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/mail"
 	"code.vikunja.io/api/pkg/modules/keyvalue"
+	"code.vikunja.io/api/pkg/notifications"
 )
 
 // InitTests handles the actual bootstrapping of the test env
@@ -32,12 +33,14 @@ func InitTests() {
 		log.Fatal(err)
 	}
 
-	err = x.Sync2(GetTables()...)
+	tables := GetTables()
+	tables = append(tables, notifications.GetTables()...)
+	err = x.Sync2(tables...)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = db.InitTestFixtures("users", "user_tokens")
+	err = db.InitTestFixtures("users", "user_tokens", "user_notification_preferences")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -102,6 +102,8 @@ type User struct {
 	WeekStart                    int    `xorm:"null" json:"-"`
 	Language                     string `xorm:"varchar(50) null" json:"-" valid:"language"`
 	Timezone                     string `xorm:"varchar(255) null" json:"-"`
+	NotificationDigest           string `xorm:"varchar(10) not null default ''" json:"-"`
+	NotificationDigestTime       string `xorm:"varchar(5) not null default ''" json:"-"`
 
 	DeletionScheduledAt      time.Time `xorm:"datetime null" json:"-"`
 	DeletionLastReminderSent time.Time `xorm:"datetime null" json:"-"`
@@ -613,6 +615,8 @@ func UpdateUser(s *xorm.Session, user *User, forceOverride bool) (updatedUser *U
 			"language",
 			"timezone",
 			"overdue_tasks_reminders_time",
+			"notification_digest",
+			"notification_digest_time",
 			"frontend_settings",
 			"extra_settings_links",
 		).
@@ -24,6 +24,7 @@ import (
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/web"
 
 	"github.com/labstack/echo/v4"
 )
@@ -75,6 +76,12 @@ func (c *WebHandler) CreateWeb(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden)
 	}
 
+	afterChange, err := beforeChange(s, ctx, currentAuth, currentStruct, web.ChangeKindCreated)
+	if err != nil {
+		_ = s.Rollback()
+		return HandleHTTPError(err)
+	}
+
 	// Create
 	err = currentStruct.Create(s, currentAuth)
 	if err != nil {
@@ -82,6 +89,12 @@ func (c *WebHandler) CreateWeb(ctx echo.Context) error {
 		return HandleHTTPError(err)
 	}
 
+	err = afterChange(s)
+	if err != nil {
+		_ = s.Rollback()
+		return HandleHTTPError(err)
+	}
+
 	err = s.Commit()
 	if err != nil {
 		return HandleHTTPError(err)
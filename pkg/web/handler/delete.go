@@ -24,6 +24,7 @@ import (
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/web"
 
 	"github.com/labstack/echo/v4"
 )
@@ -74,12 +75,24 @@ func (c *WebHandler) DeleteWeb(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden)
 	}
 
+	afterChange, err := beforeChange(s, ctx, currentAuth, currentStruct, web.ChangeKindDeleted)
+	if err != nil {
+		_ = s.Rollback()
+		return HandleHTTPError(err)
+	}
+
 	err = currentStruct.Delete(s, currentAuth)
 	if err != nil {
 		_ = s.Rollback()
 		return HandleHTTPError(err)
 	}
 
+	err = afterChange(s)
+	if err != nil {
+		_ = s.Rollback()
+		return HandleHTTPError(err)
+	}
+
 	err = s.Commit()
 	if err != nil {
 		return HandleHTTPError(err)
@@ -23,6 +23,7 @@ import (
 	"code.vikunja.io/api/pkg/web"
 
 	"github.com/labstack/echo/v4"
+	"xorm.io/xorm"
 )
 
 // WebHandler defines the webhandler object
@@ -42,7 +43,35 @@ func HandleHTTPError(err error) *echo.HTTPError {
 	log.Error(err.Error())
 	if a, has := err.(web.HTTPErrorProcessor); has {
 		errDetails := a.HTTPError()
+		if d, hasDetails := err.(web.HTTPErrorDetailsProcessor); hasDetails {
+			return echo.NewHTTPError(errDetails.HTTPCode, web.HTTPErrorWithDetails{
+				HTTPError: errDetails,
+				Details:   d.HTTPErrorDetails(),
+			}).SetInternal(err)
+		}
 		return echo.NewHTTPError(errDetails.HTTPCode, errDetails).SetInternal(err)
 	}
 	return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 }
+
+var changeRecorder web.ChangeRecorder
+
+// SetChangeRecorder sets the recorder all changes made through the web handler are passed to.
+func SetChangeRecorder(r web.ChangeRecorder) {
+	changeRecorder = r
+}
+
+// beforeChange passes a change to the change recorder, if there is one. The returned function
+// must be called once the change was made.
+func beforeChange(s *xorm.Session, ctx echo.Context, a web.Auth, subject interface{}, kind web.ChangeKind) (after func(*xorm.Session) error, err error) {
+	if changeRecorder != nil {
+		after, err = changeRecorder.BeforeChange(s, ctx, a, subject, kind)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if after == nil {
+		after = func(*xorm.Session) error { return nil }
+	}
+	return after, nil
+}
@@ -50,11 +50,32 @@ type HTTPError struct {
 	Message  string `json:"message"`
 }
 
+// HTTPErrorDetailsProcessor can be implemented by errors which carry structured details in addition to their message
+type HTTPErrorDetailsProcessor interface {
+	HTTPErrorDetails() interface{}
+}
+
 type HTTPErrorWithDetails struct {
 	HTTPError
 	Details interface{} `json:"details"`
 }
 
+// ChangeKind is the kind of change made through the standard web handlers
+type ChangeKind string
+
+const (
+	ChangeKindCreated ChangeKind = "created"
+	ChangeKindUpdated ChangeKind = "updated"
+	ChangeKindDeleted ChangeKind = "deleted"
+)
+
+// ChangeRecorder is implemented by the application to record changes made through the standard web handlers,
+// for example in an audit log. BeforeChange is called before the change is made, the function it returns
+// after it was made, both in the same transaction. It returns a nil function if there is nothing to record.
+type ChangeRecorder interface {
+	BeforeChange(s *xorm.Session, c echo.Context, a Auth, subject interface{}, kind ChangeKind) (after func(*xorm.Session) error, err error)
+}
+
 // Auth defines the interface used to retrieve authentication information
 type Auth interface {
 	// Most of the time, we need an ID from the auth object only. Having this method saves the need to cast it.